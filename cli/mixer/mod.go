@@ -0,0 +1,136 @@
+// Package main implements a standalone mixer. A mixer shuffles the ballots of
+// the forms it is authorized for without being part of the roster. It only
+// needs access to the proxy of one of the nodes.
+//
+// Unix example:
+//
+//	# Create the key of the mixer and print its public key, which must be
+//	# given as one of the mixers when the form is created.
+//	mixer keygen --key /tmp/mixer.key
+//
+//	# Once the form is closed, shuffle its ballots.
+//	mixer shuffle --key /tmp/mixer.key --proxy http://localhost:9080 \
+//	  --form <formID>
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/c4dt/d-voting/services/shuffle/neff"
+	"github.com/urfave/cli/v2"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/crypto/loader"
+	"golang.org/x/xerrors"
+)
+
+func main() {
+	err := run(os.Args, os.Stdout)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	keyFlag := &cli.StringFlag{
+		Name:     "key",
+		Usage:    "path to the private key of the mixer",
+		Required: true,
+	}
+
+	app := &cli.App{
+		Name:  "mixer",
+		Usage: "shuffle the ballots of forms without being part of the roster",
+		Commands: []*cli.Command{
+			{
+				Name:  "keygen",
+				Usage: "create the private key if it doesn't exist and print the public key",
+				Flags: []cli.Flag{keyFlag},
+				Action: func(c *cli.Context) error {
+					return keygen(c.String("key"), out)
+				},
+			},
+			{
+				Name:  "shuffle",
+				Usage: "shuffle the ballots of a closed form",
+				Flags: []cli.Flag{
+					keyFlag,
+					&cli.StringFlag{
+						Name:  "proxy",
+						Usage: "base address of the proxy of a node",
+						Value: "http://localhost:9080",
+					},
+					&cli.StringFlag{
+						Name:     "form",
+						Usage:    "hex-encoded ID of the form",
+						Required: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return shuffle(c.String("key"), c.String("proxy"), c.String("form"))
+				},
+			},
+		},
+	}
+
+	return app.Run(args)
+}
+
+func keygen(keyPath string, out io.Writer) error {
+	signerData, err := loader.NewFileLoader(keyPath).LoadOrCreate(generator{})
+	if err != nil {
+		return xerrors.Errorf("failed to load key: %v", err)
+	}
+
+	signer, err := bls.NewSignerFromBytes(signerData)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal signer: %v", err)
+	}
+
+	publicKey, err := signer.GetPublicKey().MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("failed to marshal public key: %v", err)
+	}
+
+	fmt.Fprintln(out, hex.EncodeToString(publicKey))
+
+	return nil
+}
+
+func shuffle(keyPath, proxyAddr, formID string) error {
+	signerData, err := loader.NewFileLoader(keyPath).Load()
+	if err != nil {
+		return xerrors.Errorf("failed to load key: %v", err)
+	}
+
+	signer, err := bls.NewSignerFromBytes(signerData)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal signer: %v", err)
+	}
+
+	err = neff.NewMixer(proxyAddr, signer).Shuffle(formID)
+	if err != nil {
+		return xerrors.Errorf("failed to shuffle: %v", err)
+	}
+
+	return nil
+}
+
+// generator creates the private key of a mixer.
+//
+// - implements loader.Generator
+type generator struct{}
+
+// Generate implements loader.Generator. It returns the marshaled data of a new
+// BLS signer.
+func (generator) Generate() ([]byte, error) {
+	data, err := bls.NewSigner().MarshalBinary()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal signer: %v", err)
+	}
+
+	return data, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	evoting "github.com/c4dt/d-voting/contracts/evoting/controller"
 	prom "github.com/c4dt/d-voting/metrics/controller"
+	eproxy "github.com/c4dt/d-voting/proxy"
 	dkg "github.com/c4dt/d-voting/services/dkg/pedersen/controller"
 	neff "github.com/c4dt/d-voting/services/shuffle/neff/controller"
 	"go.dedis.ch/dela"
@@ -18,7 +19,7 @@ import (
 )
 
 var defaultRetry = 10
-var proxyFac func(string) proxy.Proxy = http.NewHTTP
+var proxyFac = newProxy
 
 const defaultProxyAddr = "127.0.0.1:0"
 const defaultPromAddr = "127.0.0.1:0"
@@ -35,7 +36,7 @@ type controller struct{}
 
 // Build implements node.Initializer.
 func (m controller) SetCommands(builder node.Builder) {
-	builder.SetStartFlags(
+	flags := []cli.Flag{
 		cli.StringFlag{
 			Name:     "proxyaddr",
 			Usage:    "the proxy address",
@@ -58,7 +59,25 @@ func (m controller) SetCommands(builder node.Builder) {
 			Usage:    "the frontend public key that signs requests, hex encoded",
 			Required: false,
 		},
-	)
+		cli.StringFlag{
+			Name:     "proxykeys",
+			Usage:    "the file of the trusted frontend keys and their scopes, replaces proxykey",
+			Required: false,
+		},
+		cli.StringFlag{
+			Name:     "proxyformat",
+			Usage:    "the serialization format of the new forms, JSON or PROTOBUF",
+			Required: false,
+			Value:    "JSON",
+		},
+	}
+
+	flags = append(flags, eproxy.LimitFlags...)
+	flags = append(flags, eproxy.CORSFlags...)
+	flags = append(flags, eproxy.HealthFlags...)
+	flags = append(flags, eproxy.TLSFlags...)
+
+	builder.SetStartFlags(flags...)
 }
 
 // OnStart implements node.Initializer. It creates and registers a pedersen DKG.
@@ -94,7 +113,10 @@ func (m controller) OnStart(ctx cli.Flags, inj node.Injector) error {
 
 	proxyAddr := ctx.String("proxyaddr")
 
-	proxyhttp := proxyFac(proxyAddr)
+	proxyhttp, err := proxyFac(proxyAddr, eproxy.NewTLSConfigFromFlags(ctx))
+	if err != nil {
+		return xerrors.Errorf("failed to create proxy server: %v", err)
+	}
 
 	inj.Inject(proxyhttp)
 
@@ -120,8 +142,16 @@ func (m controller) OnStart(ctx cli.Flags, inj node.Injector) error {
 	err = eregister.Execute(node.Context{
 		Injector: inj,
 		Flags: node.FlagSet{
-			"signer":   filepath.Join(ctx.Path("config"), "private.key"),
-			"proxykey": ctx.String("proxykey"),
+			"signer":      filepath.Join(ctx.Path("config"), "private.key"),
+			"proxykey":    ctx.String("proxykey"),
+			"proxykeys":   ctx.String("proxykeys"),
+			"proxyformat": ctx.String("proxyformat"),
+
+			"proxyiprate":  ctx.Int("proxyiprate"),
+			"proxykeyrate": ctx.Int("proxykeyrate"),
+			"proxymaxbody": ctx.Int("proxymaxbody"),
+			"proxymaxpool": ctx.Int("proxymaxpool"),
+			"proxyorigins": ctx.String("proxyorigins"),
 		},
 		Out: os.Stdout,
 	})
@@ -177,6 +207,21 @@ func (m controller) OnStart(ctx cli.Flags, inj node.Injector) error {
 	return nil
 }
 
+// newProxy returns the proxy server, served with TLS if the configuration sets
+// a certificate.
+func newProxy(addr string, config eproxy.TLSConfig) (proxy.Proxy, error) {
+	if !config.Enabled() {
+		return http.NewHTTP(addr), nil
+	}
+
+	server, err := eproxy.NewTLSServer(addr, config)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create TLS server: %v", err)
+	}
+
+	return server, nil
+}
+
 // OnStop implements node.Initializer.
 func (controller) OnStop(inj node.Injector) error {
 	return nil
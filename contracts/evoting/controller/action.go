@@ -1,25 +1,23 @@
 package controller
 
 import (
-	"bytes"
-	"crypto/sha256"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
-	"go.dedis.ch/kyber/v3"
-	"go.dedis.ch/kyber/v3/sign/schnorr"
 	"go.dedis.ch/kyber/v3/suites"
 
 	"github.com/c4dt/d-voting/contracts/evoting/types"
 	"github.com/c4dt/d-voting/internal/testing/fake"
 	eproxy "github.com/c4dt/d-voting/proxy"
+	"github.com/c4dt/d-voting/proxy/auditlog"
+	"github.com/c4dt/d-voting/proxy/client"
+	"github.com/c4dt/d-voting/proxy/openapi"
 	"github.com/c4dt/d-voting/proxy/txnmanager"
 	ptypes "github.com/c4dt/d-voting/proxy/types"
 	"github.com/c4dt/d-voting/services/dkg"
@@ -30,6 +28,7 @@ import (
 	"go.dedis.ch/dela/core/ordering"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
+	"go.dedis.ch/dela/core/store/kv"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/pool"
 	"go.dedis.ch/dela/core/txn/signed"
@@ -45,8 +44,7 @@ import (
 )
 
 const (
-	contentType = "application/json"
-	formPath    = "/evoting/forms"
+	formPath = "/evoting/forms"
 	// FormPathSlash is the path to the form with a trailing slash
 	FormPathSlash    = formPath + "/"
 	formIDPath       = FormPathSlash + "{formID}"
@@ -54,13 +52,23 @@ const (
 
 	evotingPathSlash = "/evoting/"
 
-	transactionPath        = transactionSlash + "{token}"
-	unexpectedStatus       = "unexpected status: %s, body: %s"
-	failRetrieveDecryption = "failed to retrieve decryption key: %v"
-	selectString           = "select:"
-	getFormErr             = "failed to get form: %v"
-	castFailed             = "failed to cast vote: %v"
-	responseBody           = "response body: "
+	healthPath = "/healthz"
+	readyPath  = "/readyz"
+
+	transactionPath = transactionSlash + "{token}"
+	selectString    = "select:"
+	getFormErr      = "failed to get form: %v"
+	castFailed      = "failed to cast vote: %v"
+
+	// scenarioUserID is the user that creates and manages the form of the
+	// scenario test
+	scenarioUserID = "UserID"
+	// scenarioTimeout bounds the time the scenario test waits for the
+	// transactions and the shuffle
+	scenarioTimeout = 5 * time.Minute
+	// migrateTimeout bounds the time the migration of a form waits for its
+	// transaction
+	migrateTimeout = time.Minute
 )
 
 var suite = suites.MustFind("ed25519")
@@ -149,6 +157,12 @@ func (a *RegisterAction) Execute(ctx node.Context) error {
 		return xerrors.Errorf("failed to resolve proxy: %v", err)
 	}
 
+	var db kv.DB
+	err = ctx.Injector.Resolve(&db)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve db: %v", err)
+	}
+
 	var rosterFac authority.Factory
 	err = ctx.Injector.Resolve(&rosterFac)
 	if err != nil {
@@ -158,25 +172,48 @@ func (a *RegisterAction) Execute(ctx node.Context) error {
 	formFac := types.NewFormFactory(types.CiphervoteFactory{}, rosterFac)
 	mngr := getManager(signer, client)
 
-	proxykeyHex := ctx.Flags.String("proxykey")
+	keys, err := eproxy.NewKeyRingFromFlags(ctx.Flags)
+	if err != nil {
+		return xerrors.Errorf("failed to get proxy keys: %v", err)
+	}
+
+	limiter, err := eproxy.ResolveLimiter(ctx.Injector, ctx.Flags)
+	if err != nil {
+		return xerrors.Errorf("failed to get proxy limiter: %v", err)
+	}
+
+	// The transactions of the proxy, and so the forms they create, are
+	// serialized in the configured format. The values of any format are read.
+	format := serde.Format(ctx.Flags.String("proxyformat"))
+	if format == "" {
+		format = serde.FormatJSON
+	}
 
-	proxykeyBuf, err := hex.DecodeString(proxykeyHex)
+	formCtx, err := types.NewContext(format)
 	if err != nil {
-		return xerrors.Errorf("failed to decode proxykeyHex: %v", err)
+		return xerrors.Errorf("failed to get the proxy context: %v", err)
 	}
 
-	proxykey := suite.Point()
+	transactionManager := txnmanager.NewTransactionManager(mngr, p, ordering, sjson.NewContext(), blocks, signer, validation)
+
+	ep := eproxy.NewForm(ordering, p, formCtx, formFac, keys, limiter,
+		transactionManager)
 
-	err = proxykey.UnmarshalBinary(proxykeyBuf)
+	auditLog, err := auditlog.NewLog(context.Background(), db, blocks, orderingSvc)
 	if err != nil {
-		return xerrors.Errorf("failed to unmarshal proxy key: %v", err)
+		return xerrors.Errorf("failed to get audit log: %v", err)
 	}
 
-	transactionManager := txnmanager.NewTransactionManager(mngr, p, sjson.NewContext(), proxykey, blocks, signer, validation)
+	audit := eproxy.NewAudit(auditLog, orderingSvc, formCtx, keys, limiter)
 
-	ep := eproxy.NewForm(ordering, p, sjson.NewContext(), formFac, proxykey, transactionManager)
+	health := eproxy.NewHealthFromFlags(context.Background(), ctx.Flags, orderingSvc,
+		blocks, p, m.GetAddress(), dkg, shuffleActor)
 
 	router := mux.NewRouter()
+	router.Use(eproxy.MetricsMiddleware)
+	router.Use(eproxy.TracingMiddleware)
+	router.Use(eproxy.NewCORSFromFlags(ctx.Flags).Middleware)
+	router.Use(limiter.Middleware)
 
 	router.HandleFunc(evotingPathSlash+"addadmin", ep.AddAdmin).Methods("POST")
 	router.HandleFunc(evotingPathSlash+"removeadmin", ep.RemoveAdmin).Methods("POST")
@@ -190,6 +227,7 @@ func (a *RegisterAction) Execute(ctx node.Context) error {
 	router.HandleFunc(formIDPath+"/removeowner", ep.RemoveOwnerToForm).Methods("POST")
 	router.HandleFunc(formIDPath+"/addvoter", ep.AddVoterToForm).Methods("POST")
 	router.HandleFunc(formIDPath+"/removevoter", ep.RemoveVoterToForm).Methods("POST")
+	router.HandleFunc(formIDPath+"/credentials", ep.AddCredential).Methods("POST")
 	router.HandleFunc(formPath, ep.NewForm).Methods("POST")
 	router.HandleFunc(formPath, ep.Forms).Methods("GET")
 	router.HandleFunc(formPath, eproxy.AllowCORS).Methods("OPTIONS")
@@ -198,7 +236,19 @@ func (a *RegisterAction) Execute(ctx node.Context) error {
 	router.HandleFunc(formIDPath, eproxy.AllowCORS).Methods("OPTIONS")
 	router.HandleFunc(formIDPath, ep.DeleteForm).Methods("DELETE")
 	router.HandleFunc(formIDPath+"/vote", ep.NewFormVote).Methods("POST")
+	router.HandleFunc(formIDPath+"/vote/signed", ep.NewSignedFormVote).Methods("POST")
+	router.HandleFunc(formIDPath+"/vote/anonymous", ep.NewAnonymousFormVote).Methods("POST")
+	router.HandleFunc(formIDPath+"/vote/audit", ep.NewBallotAudit).Methods("POST")
+	router.HandleFunc(formIDPath+"/ballots/{receiptHash}", ep.Ballot).Methods("GET")
+	router.HandleFunc(formIDPath+"/ballots/{voterID}/proof", ep.BallotProof).Methods("GET")
 	router.HandleFunc(transactionPath, transactionManager.StatusHandlerGet).Methods("GET")
+	router.HandleFunc(evotingPathSlash+"audit", audit.AuditLog).Methods("POST")
+	router.HandleFunc(openapi.Path, openapi.Handler).Methods("GET")
+	router.HandleFunc(openapi.Path, eproxy.AllowCORS).Methods("OPTIONS")
+	router.HandleFunc(healthPath, health.HealthZ).Methods("GET")
+	router.HandleFunc(readyPath, health.ReadyZ).Methods("GET")
+
+	openapi.WarnUndocumented(router)
 
 	router.NotFoundHandler = http.HandlerFunc(eproxy.NotFoundHandler)
 	router.MethodNotAllowedHandler = http.HandlerFunc(eproxy.NotAllowedHandler)
@@ -207,6 +257,8 @@ func (a *RegisterAction) Execute(ctx node.Context) error {
 	proxy.RegisterHandler(formPath, router.ServeHTTP)
 	proxy.RegisterHandler(FormPathSlash, router.ServeHTTP)
 	proxy.RegisterHandler(transactionSlash, router.ServeHTTP)
+	proxy.RegisterHandler(healthPath, router.ServeHTTP)
+	proxy.RegisterHandler(readyPath, router.ServeHTTP)
 
 	dela.Logger.Info().Msg("d-voting proxy handlers registered")
 
@@ -274,10 +326,21 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 		return xerrors.Errorf("failed to resolve service: %v", err)
 	}
 
+	bg, cancel := context.WithTimeout(context.Background(), scenarioTimeout)
+	defer cancel()
+
+	clients := []*client.Client{
+		client.NewClient(proxyAddr1, secret, nil),
+		client.NewClient(proxyAddr2, secret, nil),
+		client.NewClient(proxyAddr3, secret, nil),
+	}
+
+	proxy1 := clients[0]
+
 	// ###################################### CREATE SIMPLE FORM ######
 
-	formID, form, formIDBuf, err := setupSimpleForm(ctx, secret,
-		proxyAddr1, serdecontext, formFac, service)
+	formID, form, err := setupSimpleForm(bg, ctx, proxy1, serdecontext,
+		formFac, service)
 
 	if err != nil {
 		return xerrors.Errorf("failed to simple form: %v", err)
@@ -287,21 +350,19 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "Init DKG")
 
-	proxys := []string{proxyAddr1, proxyAddr2, proxyAddr3}
-
 	// Initializing the DKG for the nodes.
-	for i := 1; i <= 3; i++ {
-		fmt.Fprintf(ctx.Out, "Node %d ", i)
-		err = initDKG(secret, proxys[i], formID)
+	for i, c := range clients {
+		fmt.Fprintf(ctx.Out, "Node %d ", i+1)
+
+		err = c.InitDKG(bg, formID)
 		if err != nil {
-			return xerrors.Errorf("failed to init dkg %d: %v", i, err)
+			return xerrors.Errorf("failed to init dkg %d: %v", i+1, err)
 		}
-
 	}
 
 	fmt.Fprintf(ctx.Out, "Setup DKG on node 1")
 
-	_, err = updateDKG(secret, proxyAddr1, formID, "setup")
+	err = proxy1.UpdateDKG(bg, formID, "setup")
 	if err != nil {
 		return xerrors.Errorf("failed to setup dkg on node 1: %v", err)
 	}
@@ -310,7 +371,7 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintf(ctx.Out, "Open form")
 
-	_, err = updateForm(secret, proxyAddr1, formID, "open")
+	err = updateForm(bg, proxy1, formID, "open")
 	if err != nil {
 		return xerrors.Errorf("failed to open form: %v", err)
 	}
@@ -319,21 +380,29 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "Get form")
 
+	formInfo, err := proxy1.Form(bg, formID)
+	if err != nil {
+		return xerrors.Errorf(getFormErr, err)
+	}
+
 	form, err = types.FormFromStore(serdecontext, formFac, formID, service.GetStore())
 	if err != nil {
 		return xerrors.Errorf(getFormErr, err)
 	}
 
 	logFormStatus(form)
-	dela.Logger.Info().Msgf("Pubkey of the form : %x", form.Pubkey)
+	dela.Logger.Info().Msgf("Pubkey of the form : %s", formInfo.Pubkey)
 
 	// ############################# ATTEMPT TO CLOSE FORM #################
 
 	fmt.Fprintln(ctx.Out, "Close form")
 
-	status, err := updateForm(secret, proxyAddr1, formID, "close")
-	if status != http.StatusInternalServerError {
-		return xerrors.Errorf("unexpected error: %d: %v", status, err)
+	// the form can't be closed before at least two ballots are cast
+	err = updateForm(bg, proxy1, formID, "close")
+
+	var rejected *client.RejectedError
+	if !errors.As(err, &rejected) {
+		return xerrors.Errorf("expected the closing to be rejected, got: %v", err)
 	}
 
 	// ##################################### CAST BALLOTS ######################
@@ -341,101 +410,73 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 	fmt.Fprintln(ctx.Out, "cast ballots")
 
 	// Create the ballots
-	b1 := string(selectString + encodeID("bb") + ":0,0,1,0\n" +
-		"text:" + encodeID("ee") + ":eWVz\n\n") //encoding of "yes"
-
-	b2 := string(selectString + encodeID("bb") + ":1,1,0,0\n" +
-		"text:" + encodeID("ee") + ":amE=\n\n") //encoding of "ja
-
-	b3 := string(selectString + encodeID("bb") + ":0,0,0,1\n" +
-		"text:" + encodeID("ee") + ":b3Vp\n\n") //encoding of "oui"
-
-	var dkg dkg.DKG
-	err = ctx.Injector.Resolve(&dkg)
-	if err != nil {
-		return xerrors.Errorf("failed to resolve DKG: %v", err)
-	}
-
-	dkgActor, exists := dkg.GetActor(formIDBuf)
-	if !exists {
-		return xerrors.Errorf("failed to get actor: %v", err)
-	}
-
-	// Ballot 1
-	ballot1, err := marshallBallot(b1, dkgActor, form.ChunksPerBallot())
-	if err != nil {
-		return xerrors.Errorf("failed to marshall ballot : %v", err)
+	ballots := map[string]string{
+		"user1": string(selectString + encodeID("bb") + ":0,0,1,0\n" +
+			"text:" + encodeID("ee") + ":eWVz\n\n"), //encoding of "yes"
+		"user2": string(selectString + encodeID("bb") + ":1,1,0,0\n" +
+			"text:" + encodeID("ee") + ":amE=\n\n"), //encoding of "ja
+		"user3": string(selectString + encodeID("bb") + ":0,0,0,1\n" +
+			"text:" + encodeID("ee") + ":b3Vp\n\n"), //encoding of "oui"
 	}
 
-	castVoteRequest := ptypes.CastVoteRequest{
-		VoterID: "user1",
-		Ballot:  ballot1,
-	}
+	voterIDs := []string{"user1", "user2", "user3"}
 
-	signed, err := createSignedRequest(secret, castVoteRequest)
-	if err != nil {
-		return createSignedErr(err)
+	plaintexts := make([]string, len(voterIDs))
+	for i, voterID := range voterIDs {
+		plaintexts[i] = ballots[voterID]
 	}
 
-	fmt.Fprintln(ctx.Out, "cast first ballot")
+	// the ephemeral keys of all the chunks are computed before encrypting
+	numChunks := len(voterIDs) * formInfo.ChunksPerBallot
 
-	respBody, err := castVote(formID, signed, proxyAddr1)
+	encrypter, err := client.NewEncrypter(formInfo.Pubkey, numChunks)
 	if err != nil {
-		return xerrors.Errorf(castFailed, err)
+		return xerrors.Errorf("failed to create encrypter: %v", err)
 	}
 
-	dela.Logger.Info().Msg(responseBody + respBody)
-
-	// Ballot 2
-	ballot2, err := marshallBallot(b2, dkgActor, form.ChunksPerBallot())
+	err = encrypter.Precompute(numChunks)
 	if err != nil {
-		return xerrors.Errorf("failed to marshall ballot : %v", err)
-	}
-
-	castVoteRequest = ptypes.CastVoteRequest{
-		VoterID: "user2",
-		Ballot:  ballot2,
+		return xerrors.Errorf("failed to precompute ephemeral keys: %v", err)
 	}
 
-	signed, err = createSignedRequest(secret, castVoteRequest)
+	encrypted, err := encrypter.EncryptBallots(plaintexts, formInfo.ChunksPerBallot)
 	if err != nil {
-		return createSignedErr(err)
+		return xerrors.Errorf("failed to encrypt ballots: %v", err)
 	}
 
-	fmt.Fprintln(ctx.Out, "cast second ballot")
-
-	respBody, err = castVote(formID, signed, proxyAddr1)
-	if err != nil {
-		return xerrors.Errorf(castFailed, err)
-	}
+	for i, voterID := range voterIDs {
+		castVoteRequest := ptypes.CastVoteRequest{
+			VoterID: voterID,
+			Ballot:  encrypted[i],
+		}
 
-	dela.Logger.Info().Msg(responseBody + respBody)
+		fmt.Fprintln(ctx.Out, "cast ballot of", voterID)
 
-	// Ballot 3
-	ballot3, err := marshallBallot(b3, dkgActor, form.ChunksPerBallot())
-	if err != nil {
-		return xerrors.Errorf("failed to marshall ballot: %v", err)
-	}
+		info, err := proxy1.CastVote(bg, formID, castVoteRequest)
+		if err != nil {
+			return xerrors.Errorf(castFailed, err)
+		}
 
-	castVoteRequest = ptypes.CastVoteRequest{
-		VoterID: "user3",
-		Ballot:  ballot3,
-	}
+		err = proxy1.WaitTransaction(bg, info.Token)
+		if err != nil {
+			return xerrors.Errorf(castFailed, err)
+		}
 
-	signed, err = createSignedRequest(secret, castVoteRequest)
-	if err != nil {
-		return createSignedErr(err)
-	}
+		// the receipt lets the voter check that the ballot is counted
+		if info.Receipt == nil {
+			return xerrors.Errorf("no receipt for the ballot of %s", voterID)
+		}
 
-	fmt.Fprintln(ctx.Out, "cast third ballot")
+		stored, err := proxy1.Ballot(bg, formID, info.Receipt.Hash)
+		if err != nil {
+			return xerrors.Errorf("failed to get ballot: %w", err)
+		}
 
-	respBody, err = castVote(formID, signed, proxyAddr1)
-	if err != nil {
-		return xerrors.Errorf(castFailed, err)
+		if !stored.Effective {
+			return xerrors.Errorf("the ballot of %s is not counted", voterID)
+		}
 	}
 
-	dela.Logger.Info().Msg(responseBody + respBody)
-
 	form, err = types.FormFromStore(serdecontext, formFac, formID, service.GetStore())
 	if err != nil {
 		return xerrors.Errorf(getFormErr, err)
@@ -455,7 +496,7 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "Close form (for real)")
 
-	_, err = updateForm(secret, proxyAddr1, formID, "close")
+	err = updateForm(bg, proxy1, formID, "close")
 	if err != nil {
 		return xerrors.Errorf("failed to close form: %v", err)
 	}
@@ -472,32 +513,16 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "shuffle ballots")
 
-	shuffleRequest := ptypes.UpdateShuffle{
-		Action: "shuffle",
-	}
-
-	signed, err = createSignedRequest(secret, shuffleRequest)
+	err = proxy1.Shuffle(bg, formID, scenarioUserID)
 	if err != nil {
-		return createSignedErr(err)
+		return xerrors.Errorf("failed to start the shuffle: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPut, proxyAddr1+"/evoting/services/shuffle/"+formID, bytes.NewBuffer(signed))
+	err = proxy1.WaitShuffle(bg, formID)
 	if err != nil {
-		return xerrors.Errorf("failed to create shuffle request: %v", err)
+		return xerrors.Errorf("failed to wait for shuffle: %v", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return xerrors.Errorf("failed to execute the shuffle query: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return xerrors.Errorf("unexpected shuffle status: %s - %s", resp.Status, buf)
-	}
-
-	// time.Sleep(20 * time.Second)
-
 	form, err = types.FormFromStore(serdecontext, formFac, formID, service.GetStore())
 	if err != nil {
 		return xerrors.Errorf(getFormErr, err)
@@ -515,7 +540,7 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "request public shares")
 
-	_, err = updateDKG(secret, proxyAddr1, formID, "computePubshares")
+	err = proxy1.UpdateDKG(bg, formID, "computePubshares")
 	if err != nil {
 		return xerrors.Errorf("failed to compute pubshares: %v", err)
 	}
@@ -527,7 +552,7 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 		return xerrors.Errorf(getFormErr, err)
 	}
 
-	validSubmissions := len(form.PubsharesUnits.Pubshares)
+	validSubmissions := len(form.PubsharesUnits.IDs)
 
 	logFormStatus(form)
 	dela.Logger.Info().Msg("Number of Pubshare units submitted: " + strconv.Itoa(validSubmissions))
@@ -536,7 +561,7 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "decrypt ballots")
 
-	_, err = updateForm(secret, proxyAddr1, formID, "combineShares")
+	err = updateForm(bg, proxy1, formID, "combineShares")
 	if err != nil {
 		return xerrors.Errorf("failed to combine shares: %v", err)
 	}
@@ -546,9 +571,6 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 		return xerrors.Errorf(getFormErr, err)
 	}
 
-	// dela.Logger.Info().Msg("----------------------- Form : " +
-	// string(proof.GetValue()))
-
 	logFormStatus(form)
 	dela.Logger.Info().Msg("Number of decrypted ballots : " + strconv.Itoa(len(form.DecryptedBallots)))
 
@@ -556,51 +578,36 @@ func (a *scenarioTestAction) Execute(ctx node.Context) error {
 
 	fmt.Fprintln(ctx.Out, "Get form result")
 
-	form, err = types.FormFromStore(serdecontext, formFac, formID, service.GetStore())
+	formInfo, err = proxy1.Form(bg, formID)
 	if err != nil {
 		return xerrors.Errorf(getFormErr, err)
 	}
 
-	logFormStatus(form)
-	dela.Logger.Info().Msg("Number of decrypted ballots : " + strconv.Itoa(len(form.DecryptedBallots)))
+	dela.Logger.Info().Msg("Number of decrypted ballots : " + strconv.Itoa(len(formInfo.Result)))
 
-	if len(form.DecryptedBallots) != 3 {
-		return xerrors.Errorf("unexpected number of decrypted ballot: %d != 3", len(form.DecryptedBallots))
+	if len(formInfo.Result) != 3 {
+		return xerrors.Errorf("unexpected number of decrypted ballot: %d != 3", len(formInfo.Result))
 	}
 
-	// dela.Logger.Info().Msg(form.DecryptedBallots[0].Vote)
-	// dela.Logger.Info().Msg(form.DecryptedBallots[1].Vote)
-	// dela.Logger.Info().Msg(form.DecryptedBallots[2].Vote)
-
 	// ###################################### GET ALL FORM ##############
 
-	resp, err = http.Get(proxyAddr1 + formPath)
-
-	if err != nil {
-		return xerrors.Errorf("failed to get all forms")
-	}
-
-	var allForms ptypes.GetFormsResponse
-
-	decoder := json.NewDecoder(resp.Body)
-
-	err = decoder.Decode(&allForms)
+	allForms, err := proxy1.Forms(bg)
 	if err != nil {
-		return xerrors.Errorf("failed to decode getAllForms: %v", err)
+		return xerrors.Errorf("failed to get all forms: %v", err)
 	}
 
 	dela.Logger.Info().Msgf("All forms: %v", allForms)
 
-	if len(allForms.Forms) != 1 && allForms.Forms[0].FormID != formID {
+	if len(allForms.Forms) != 1 || allForms.Forms[0].FormID != formID {
 		return xerrors.Errorf("unexpected allForms: %v", allForms)
 	}
 
 	return nil
 }
 
-func setupSimpleForm(ctx node.Context, secret kyber.Scalar, proxyAddr1 string,
+func setupSimpleForm(bg context.Context, ctx node.Context, proxy1 *client.Client,
 	serdecontext serde.Context, formFac types.FormFactory,
-	service ordering.Service) (string, types.Form, []byte, error) {
+	service ordering.Service) (string, types.Form, error) {
 
 	fmt.Fprintln(ctx.Out, "Create form")
 
@@ -609,68 +616,58 @@ func setupSimpleForm(ctx node.Context, secret kyber.Scalar, proxyAddr1 string,
 
 	createSimpleFormRequest := ptypes.CreateFormRequest{
 		Configuration: configuration,
-		UserID:        "UserID",
+		UserID:        scenarioUserID,
 	}
 
-	signed, err := createSignedRequest(secret, createSimpleFormRequest)
+	formResponse, err := proxy1.CreateForm(bg, createSimpleFormRequest)
 	if err != nil {
-		return "", types.Form{}, nil, createSignedErr(err)
+		return "", types.Form{}, xerrors.Errorf("failed to create form: %v", err)
 	}
 
-	fmt.Fprintln(ctx.Out, "create form js:", signed)
-
-	url := proxyAddr1 + formPath
-	fmt.Fprintln(ctx.Out, "POST", url)
-
-	resp, err := http.Post(url, contentType, bytes.NewBuffer(signed))
-	if err != nil {
-		return "", types.Form{}, nil, xerrors.Errorf(failRetrieveDecryption, err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return "", types.Form{}, nil, xerrors.Errorf(unexpectedStatus, resp.Status, buf)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", types.Form{}, nil, xerrors.Errorf("failed to read the body of the response: %v", err)
-	}
-
-	fmt.Fprintln(ctx.Out, "response body:", string(body))
-
-	resp.Body.Close()
+	fmt.Fprintln(ctx.Out, "response:", formResponse)
 
-	var formResponse ptypes.CreateFormResponse
-
-	err = json.Unmarshal(body, &formResponse)
+	err = proxy1.WaitTransaction(bg, formResponse.Token)
 	if err != nil {
-		return "", types.Form{}, nil, xerrors.Errorf("failed to unmarshal create form response: %v - %s", err, body)
+		return "", types.Form{}, xerrors.Errorf("failed to create form: %v", err)
 	}
 
 	formID := formResponse.FormID
 
-	formIDBuf, err := hex.DecodeString(formID)
-	if err != nil {
-		return "", types.Form{}, nil, xerrors.Errorf("failed to decode formID '%s': %v", formID, err)
-	}
-
 	form, err := types.FormFromStore(serdecontext, formFac, formID, service.GetStore())
 	if err != nil {
-		return "", types.Form{}, nil, xerrors.Errorf(getFormErr, err)
+		return "", types.Form{}, xerrors.Errorf(getFormErr, err)
 	}
 
 	// sanity check, the formID returned and the one stored in the form
 	// type must be the same.
 	if form.FormID != formID {
-		return "", types.Form{}, nil, xerrors.Errorf("formID mismatch: %s != %s", form.FormID, formID)
+		return "", types.Form{}, xerrors.Errorf("formID mismatch: %s != %s", form.FormID, formID)
 	}
 
 	fmt.Fprintf(ctx.Out, "Title of the form: %s", form.Configuration.Title.En)
 	fmt.Fprintf(ctx.Out, "ID of the form: %s", form.FormID)
 	fmt.Fprintf(ctx.Out, "Status of the form: %d", form.Status)
 
-	return formID, form, formIDBuf, nil
+	return formID, form, nil
+}
+
+// updateForm updates the form with the action and waits for the transaction
+// to be included.
+func updateForm(bg context.Context, proxy *client.Client, formID, action string) error {
+	info, err := proxy.UpdateForm(bg, formID, ptypes.UpdateFormRequest{
+		Action: action,
+		UserID: scenarioUserID,
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to update form: %w", err)
+	}
+
+	err = proxy.WaitTransaction(bg, info.Token)
+	if err != nil {
+		return xerrors.Errorf("failed to update form: %w", err)
+	}
+
+	return nil
 }
 
 func logFormStatus(form types.Form) {
@@ -683,181 +680,84 @@ func encodeID(ID string) types.ID {
 	return types.ID(base64.StdEncoding.EncodeToString([]byte(ID)))
 }
 
-func marshallBallot(voteStr string, actor dkg.Actor, chunks int) (ptypes.CiphervoteJSON, error) {
-
-	var ballot = make(ptypes.CiphervoteJSON, chunks)
-	vote := strings.NewReader(voteStr)
-
-	buf := make([]byte, 29)
-
-	for i := 0; i < chunks; i++ {
-		var K, C kyber.Point
-		var err error
-
-		n, err := vote.Read(buf)
-		if err != nil {
-			return nil, xerrors.Errorf("failed to read: %v", err)
-		}
-
-		K, C, _, err = actor.Encrypt(buf[:n])
-
-		if err != nil {
-			return ptypes.CiphervoteJSON{}, xerrors.Errorf("failed to encrypt the plaintext: %v", err)
-		}
-
-		kbuff, err := K.MarshalBinary()
-		if err != nil {
-			return ptypes.CiphervoteJSON{}, xerrors.Errorf("failed to marshal K: %v", err)
-		}
-
-		cbuff, err := C.MarshalBinary()
-		if err != nil {
-			return ptypes.CiphervoteJSON{}, xerrors.Errorf("failed to marshal C: %v", err)
-		}
-
-		ballot[i] = ptypes.EGPairJSON{
-			K: kbuff,
-			C: cbuff,
-		}
-	}
-
-	return ballot, nil
+// migrateAction is an action to store the forms and their ballots in another
+// serialization format, through the proxy of a node.
+//
+// - implements node.ActionTemplate
+type migrateAction struct {
 }
 
-// formID is hex-encoded
-func castVote(formID string, signed []byte, proxyAddr string) (string, error) {
-	resp, err := http.Post(proxyAddr+FormPathSlash+formID+"/vote", contentType, bytes.NewBuffer(signed))
-
+// Execute implements node.ActionTemplate. It migrates the given form, or all
+// the forms, one after the other.
+func (a *migrateAction) Execute(ctx node.Context) error {
+	secretkeyBuf, err := hex.DecodeString(ctx.Flags.String("secretkey"))
 	if err != nil {
-		return "", xerrors.Errorf(failRetrieveDecryption, err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return "", xerrors.Errorf(unexpectedStatus, resp.Status, buf)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", xerrors.Errorf("failed to read the body of the response: %v", err)
+		return xerrors.Errorf("failed to decode secretkeyHex: %v", err)
 	}
 
-	resp.Body.Close()
-
-	return string(body), nil
-}
-
-func updateForm(secret kyber.Scalar, proxyAddr, formIDHex, action string) (int, error) {
-	msg := ptypes.UpdateFormRequest{
-		Action: action,
-	}
+	secret := suite.Scalar()
 
-	signed, err := createSignedRequest(secret, msg)
+	err = secret.UnmarshalBinary(secretkeyBuf)
 	if err != nil {
-		return 0, createSignedErr(err)
+		return xerrors.Errorf("failed to unmarshal secret key: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPut, proxyAddr+FormPathSlash+formIDHex, bytes.NewBuffer(signed))
+	format := ctx.Flags.String("format")
 
+	_, err = types.NewContext(serde.Format(format))
 	if err != nil {
-		return 0, xerrors.Errorf("failed to create request: %v", err)
+		return xerrors.Errorf("invalid format: %v", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, xerrors.Errorf(failRetrieveDecryption, err)
-	}
+	proxy := client.NewClient(ctx.Flags.String("proxy-addr"), secret, nil)
 
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return resp.StatusCode, xerrors.Errorf(unexpectedStatus, resp.Status, buf)
-	}
+	formIDs := []string{ctx.Flags.String("form")}
 
-	return 0, nil
-}
+	if formIDs[0] == "" {
+		forms, err := proxy.Forms(context.Background())
+		if err != nil {
+			return xerrors.Errorf("failed to get the forms: %v", err)
+		}
 
-func initDKG(secret kyber.Scalar, proxyAddr, formIDHex string) error {
-	setupDKG := ptypes.NewDKGRequest{
-		FormID: formIDHex,
+		formIDs = make([]string, len(forms.Forms))
+		for i, form := range forms.Forms {
+			formIDs[i] = form.FormID
+		}
 	}
 
-	signed, err := createSignedRequest(secret, setupDKG)
-	if err != nil {
-		return createSignedErr(err)
+	req := ptypes.UpdateFormRequest{
+		Action: "migrate",
+		UserID: ctx.Flags.String("userid"),
+		Format: format,
 	}
 
-	resp, err := http.Post(proxyAddr+"/evoting/services/dkg/actors", contentType, bytes.NewBuffer(signed))
-	if err != nil {
-		return xerrors.Errorf("failed to post request: %v", err)
-	}
+	for _, formID := range formIDs {
+		err = migrateForm(proxy, formID, req)
+		if err != nil {
+			return xerrors.Errorf("failed to migrate form %s: %v", formID, err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return xerrors.Errorf(unexpectedStatus, resp.Status, buf)
+		fmt.Fprintf(ctx.Out, "Form %s stored in %s\n", formID, format)
 	}
 
 	return nil
 }
 
-func updateDKG(secret kyber.Scalar, proxyAddr, formIDHex, action string) (int, error) {
-	msg := ptypes.UpdateDKG{
-		Action: action,
-	}
-
-	signed, err := createSignedRequest(secret, msg)
-	if err != nil {
-		return 0, createSignedErr(err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, proxyAddr+"/evoting/services/dkg/actors/"+formIDHex, bytes.NewBuffer(signed))
-	if err != nil {
-		return 0, xerrors.Errorf("failed to create request: %v", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, xerrors.Errorf("failed to execute the query: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		return resp.StatusCode, xerrors.Errorf(unexpectedStatus, resp.Status, buf)
-	}
-
-	return 0, nil
-}
-
-func createSignedErr(err error) error {
-	return xerrors.Errorf("failed to create signed request: %v", err)
-}
+// migrateForm submits the migration of the form and waits for its
+// transaction.
+func migrateForm(proxy *client.Client, formID string, req ptypes.UpdateFormRequest) error {
+	bg, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
 
-func createSignedRequest(secret kyber.Scalar, msg interface{}) ([]byte, error) {
-	jsonMsg, err := json.Marshal(msg)
+	info, err := proxy.UpdateForm(bg, formID, req)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to marshal json: %v", err)
+		return xerrors.Errorf("failed to update form: %v", err)
 	}
 
-	payload := base64.URLEncoding.EncodeToString(jsonMsg)
-
-	hash := sha256.New()
-
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
-
-	signature, err := schnorr.Sign(suite, secret, md)
+	err = proxy.WaitTransaction(bg, info.Token)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to sign: %v", err)
+		return xerrors.Errorf("failed to wait for the transaction: %v", err)
 	}
 
-	signed := ptypes.SignedRequest{
-		Payload:   payload,
-		Signature: hex.EncodeToString(signature),
-	}
-
-	signedJSON, err := json.Marshal(signed)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to create json signed: %v", err)
-	}
-
-	return signedJSON, nil
+	return nil
 }
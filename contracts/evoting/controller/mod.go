@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	eproxy "github.com/c4dt/d-voting/proxy"
 	"go.dedis.ch/dela/cli"
 	"go.dedis.ch/dela/cli/node"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/ordering"
 	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/serde"
 )
 
 // NewController returns a new controller initializer
@@ -29,13 +32,23 @@ func (m controller) SetCommands(builder node.Builder) {
 	//   registerHandlers --signer private.key
 	sub := cmd.SetSubCommand("registerHandlers")
 	sub.SetDescription("register the e-voting handlers on the default proxy")
-	sub.SetFlags(
+	flags := []cli.Flag{
 		cli.StringFlag{
 			Name:     "signer",
 			Usage:    "Path to signer's private key",
 			Required: true,
 		},
-	)
+		cli.StringFlag{
+			Name: "proxyformat",
+			Usage: "serialization format of the transactions of the proxy, " +
+				"and so of the new forms: JSON or PROTOBUF",
+			Value: string(serde.FormatJSON),
+		},
+	}
+	flags = append(flags, eproxy.LimitFlags...)
+	flags = append(flags, eproxy.CORSFlags...)
+	flags = append(flags, eproxy.HealthFlags...)
+	sub.SetFlags(flags...)
 	sub.SetAction(builder.MakeAction(&RegisterAction{}))
 
 	// dvoting --config /tmp/node1 e-voting scenarioTest
@@ -64,6 +77,38 @@ func (m controller) SetCommands(builder node.Builder) {
 		},
 	)
 	sub.SetAction(builder.MakeAction(&scenarioTestAction{}))
+
+	// dvoting --config /tmp/node1 e-voting migrate --secretkey <hex> \
+	//   --userid <SCIPER> --format PROTOBUF
+	sub = cmd.SetSubCommand("migrate")
+	sub.SetDescription("store the forms and their ballots in another format")
+	sub.SetFlags(
+		cli.StringFlag{
+			Name:     "secretkey",
+			Usage:    "the proxy secret key to sign requests, hex encoded",
+			Required: true,
+		},
+		cli.StringFlag{
+			Name:  "proxy-addr",
+			Usage: "base address of the proxy of the node",
+			Value: "http://localhost:9080",
+		},
+		cli.StringFlag{
+			Name:     "userid",
+			Usage:    "the SCIPER of an admin, or of the owner of the forms",
+			Required: true,
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "the new format of the forms, JSON or PROTOBUF",
+			Value: string(types.FormatProtobuf),
+		},
+		cli.StringFlag{
+			Name:  "form",
+			Usage: "the hex-encoded ID of the form to migrate, all the forms by default",
+		},
+	)
+	sub.SetAction(builder.MakeAction(&migrateAction{}))
 }
 
 // OnStart implements node.Initializer. It creates and registers a pedersen DKG.
@@ -16,6 +16,8 @@ import (
 	"go.dedis.ch/kyber/v3/share"
 
 	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/services/dkg"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.dedis.ch/dela/core/execution"
 	"go.dedis.ch/dela/core/execution/native"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
@@ -23,9 +25,11 @@ import (
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/cosi/threshold"
 	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/kyber/v3/proof"
 	"go.dedis.ch/kyber/v3/shuffle"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
 	"golang.org/x/xerrors"
 )
 
@@ -108,10 +112,16 @@ func (e evotingCommand) createForm(snap store.Snapshot, step execution.Step) err
 		return xerrors.Errorf("configuration of form is incoherent or has duplicated IDs")
 	}
 
+	err = checkMixers(tx.Mixers, tx.MixerThreshold)
+	if err != nil {
+		return xerrors.Errorf("invalid mixers: %v", err)
+	}
+
 	units := types.PubsharesUnits{
-		Pubshares: make([]types.PubsharesUnit, 0),
-		PubKeys:   make([][]byte, 0),
-		Indexes:   make([]int, 0),
+		IDs:     make([][]byte, 0),
+		Hashes:  make([][]byte, 0),
+		PubKeys: make([][]byte, 0),
+		Indexes: make([]int, 0),
 	}
 
 	// Initial owner is the creator
@@ -131,14 +141,20 @@ func (e evotingCommand) createForm(snap store.Snapshot, step execution.Step) err
 		// Pubkey is set by the opening command
 		BallotSize:       tx.Configuration.MaxBallotSize(),
 		PubsharesUnits:   units,
-		ShuffleInstances: []types.ShuffleInstance{},
+		ShuffleInstances: []types.ShuffleRef{},
 		DecryptedBallots: []types.Ballot{},
 		// We set the participant in the e-voting once for all. If it happens
 		// that 1/3 of the participants go away, the form will never end.
 		Roster:           roster,
 		ShuffleThreshold: threshold.ByzantineThreshold(roster.Len()),
+		Mixers:           tx.Mixers,
+		MixerThreshold:   tx.MixerThreshold,
 		Owners:           owners,
 		Voters:           make([]int, 0),
+		Anonymous:        tx.Anonymous,
+		// The form is stored in the format of the transaction that creates
+		// it, which is the format chosen by the proxy.
+		Format: types.FormatOf(step.Current.GetArg(FormArg)),
 	}
 
 	PromFormStatus.WithLabelValues(form.FormID).Set(float64(form.Status))
@@ -233,6 +249,10 @@ func (e evotingCommand) openForm(snap store.Snapshot, step execution.Step) error
 		return xerrors.Errorf("the form was opened before, current status: %d", form.Status)
 	}
 
+	if form.Anonymous && form.ElectoralRoll == nil {
+		return xerrors.Errorf("the electoral roll of the anonymous form is empty")
+	}
+
 	form.Status = types.Open
 	PromFormStatus.WithLabelValues(form.FormID).Set(float64(form.Status))
 
@@ -240,9 +260,38 @@ func (e evotingCommand) openForm(snap store.Snapshot, step execution.Step) error
 		return xerrors.Errorf("pubkey is already set: %s", form.Pubkey)
 	}
 
-	dkgActor, exists := e.pedersen.GetActor(formID)
-	if !exists {
-		return xerrors.Errorf("failed to get actor for form %q", form.FormID)
+	var dkgActor dkg.Actor
+	var exists bool
+
+	if tx.CeremonyID != "" {
+		ceremonyID, err := hex.DecodeString(tx.CeremonyID)
+		if err != nil {
+			return xerrors.Errorf("failed to decode ceremonyID: %v", err)
+		}
+
+		dkgActor, exists = e.pedersen.GetCeremony(ceremonyID)
+		if !exists {
+			return xerrors.Errorf("failed to get key ceremony %q", tx.CeremonyID)
+		}
+
+		// the nodes of the form must hold the shares of the key, otherwise
+		// they can't decrypt the ballots.
+		participants, err := dkgActor.GetParticipants()
+		if err != nil {
+			return xerrors.Errorf("failed to get participants of key ceremony: %v", err)
+		}
+
+		if !sameMembers(form.Roster, participants) {
+			return xerrors.Errorf("the participants of key ceremony %q are not "+
+				"the roster of the form", tx.CeremonyID)
+		}
+
+		form.CeremonyID = tx.CeremonyID
+	} else {
+		dkgActor, exists = e.pedersen.GetActor(formID)
+		if !exists {
+			return xerrors.Errorf("failed to get actor for form %q", form.FormID)
+		}
 	}
 
 	pubkey, err := dkgActor.GetPublicKey()
@@ -265,6 +314,33 @@ func (e evotingCommand) openForm(snap store.Snapshot, step execution.Step) error
 	return nil
 }
 
+// sameMembers returns true if the addresses are the ones of the roster, in any
+// order.
+func sameMembers(roster authority.Authority, addrs []mino.Address) bool {
+	if roster == nil || roster.Len() != len(addrs) {
+		return false
+	}
+
+	iter := roster.AddressIterator()
+	for iter.HasNext() {
+		addr := iter.GetNext()
+
+		found := false
+		for _, other := range addrs {
+			if addr.Equal(other) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // castVote implements commands. It performs the CAST_VOTE command
 func (e evotingCommand) castVote(snap store.Snapshot, step execution.Step) error {
 
@@ -287,13 +363,29 @@ func (e evotingCommand) castVote(snap store.Snapshot, step execution.Step) error
 		return xerrors.Errorf("the form is not open, current status: %d", form.Status)
 	}
 
-	isOwner, err := e.isRole(form, tx.VoterID, Voters)
-	if err != nil {
-		return xerrors.Errorf(errIsRole, err)
-	}
+	voterID := tx.VoterID
+
+	if form.Anonymous {
+		// the ballot is stored with the tag of the credential, which is the
+		// same if the voter votes again, but doesn't reveal who the voter is.
+		voterID, err = form.VerifyCredential(snap, tx)
+		if err != nil {
+			return xerrors.Errorf("failed to verify credential: %v", err)
+		}
+	} else {
+		isOwner, err := e.isRole(form, tx.VoterID, Voters)
+		if err != nil {
+			return xerrors.Errorf(errIsRole, err)
+		}
 
-	if !isOwner {
-		return xerrors.Errorf(errNoVoterPerms, tx.VoterID)
+		if !isOwner {
+			return xerrors.Errorf(errNoVoterPerms, tx.VoterID)
+		}
+
+		err = checkVoterSignature(form, tx)
+		if err != nil {
+			return xerrors.Errorf("failed to check voter signature: %v", err)
+		}
 	}
 
 	if len(tx.Ballot) != form.ChunksPerBallot() {
@@ -301,7 +393,32 @@ func (e evotingCommand) castVote(snap store.Snapshot, step execution.Step) error
 			len(tx.Ballot), form.ChunksPerBallot())
 	}
 
-	err = form.CastVote(e.context, snap, tx.VoterID, tx.Ballot)
+	hash, err := tx.Ballot.Hash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash ballot: %v", err)
+	}
+
+	audited, err := form.IsAudited(snap, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to check audit: %v", err)
+	}
+
+	// the randomness of an audited ballot is public, it can't be counted
+	if audited {
+		return xerrors.Errorf("the ballot has been audited")
+	}
+
+	// a signed vote must be more recent than the previous vote of the voter,
+	// so that an earlier vote can't be replayed. The anonymous votes are
+	// signed by the credential, whose tag is the voter.
+	if len(tx.Signature) != 0 || form.Anonymous {
+		err = form.UpdateSequence(snap, voterID, tx.Sequence)
+		if err != nil {
+			return xerrors.Errorf("failed to update sequence: %v", err)
+		}
+	}
+
+	err = form.CastVote(e.context, snap, voterID, tx.Ballot)
 	if err != nil {
 		return xerrors.Errorf("couldn't cast vote: %v", err)
 	}
@@ -349,13 +466,29 @@ func (e evotingCommand) shuffleBallots(snap store.Snapshot, step execution.Step)
 			form.Status, types.Closed)
 	}
 
-	canEditForm, err := e.canEditForm(snap, form, tx.UserID)
-	if err != nil {
-		return xerrors.Errorf(errIsRole, err)
-	}
+	shufflerPublicKey := tx.PublicKey
 
-	if !canEditForm {
-		return xerrors.Errorf(errNoOwnerPerms, tx.UserID)
+	if len(form.Mixers) == 0 {
+		canEditForm, err := e.canEditForm(snap, form, tx.UserID)
+		if err != nil {
+			return xerrors.Errorf(errIsRole, err)
+		}
+
+		if !canEditForm {
+			return xerrors.Errorf(errNoOwnerPerms, tx.UserID)
+		}
+
+		err = isMemberOf(form.Roster, shufflerPublicKey)
+		if err != nil {
+			return xerrors.Errorf("could not verify identity of shuffler : %v", err)
+		}
+	} else {
+		// mixers are authorized by the owner when the form is created and
+		// don't act on behalf of a user.
+		err = isMixerOf(form, shufflerPublicKey)
+		if err != nil {
+			return xerrors.Errorf("could not verify identity of mixer : %v", err)
+		}
 	}
 
 	// Round starts at 0
@@ -366,13 +499,6 @@ func (e evotingCommand) shuffleBallots(snap store.Snapshot, step execution.Step)
 			"transaction is for round '%d'", expectedRound, tx.Round)
 	}
 
-	shufflerPublicKey := tx.PublicKey
-
-	err = isMemberOf(form.Roster, shufflerPublicKey)
-	if err != nil {
-		return xerrors.Errorf("could not verify identity of shuffler : %v", err)
-	}
-
 	// Check the node who submitted the shuffle did not already submit an
 	// accepted shuffle
 	for i, shuffleInstance := range form.ShuffleInstances {
@@ -451,14 +577,35 @@ func (e evotingCommand) shuffleBallots(snap store.Snapshot, step execution.Step)
 		ciphervotes = suff.Ciphervotes
 	} else {
 		// get the form's last shuffled ballots
-		lastIndex := len(form.ShuffleInstances) - 1
-		ciphervotes = form.ShuffleInstances[lastIndex].ShuffledBallots
+		lastShuffle, err := form.LastShuffle(e.context, snap)
+		if err != nil {
+			return xerrors.Errorf("couldn't get last shuffle: %v", err)
+		}
+		ciphervotes = lastShuffle.ShuffledBallots
 	}
 
 	if len(ciphervotes) < 2 {
 		return xerrors.Errorf("not enough votes: %d < 2", len(ciphervotes))
 	}
 
+	roundSize := len(ciphervotes)
+
+	blocks, err := GetBlockCount(snap)
+	if err != nil {
+		return xerrors.Errorf("failed to get block count: %v", err)
+	}
+
+	if tx.BatchSize != 0 {
+		dropStalledShuffle(&form, tx, blocks)
+
+		ciphervotes, err = checkShuffleBatch(form, tx, ciphervotes)
+		if err != nil {
+			return xerrors.Errorf("invalid shuffle batch: %v", err)
+		}
+	} else if form.PendingShuffle != nil {
+		return xerrors.Errorf("round %d is being shuffled in batches", tx.Round)
+	}
+
 	X, Y := types.CiphervotesToPairs(ciphervotes)
 
 	XXUp, YYUp, XXDown, YYDown := shuffle.GetSequenceVerifiable(suite, X, Y, XX,
@@ -466,24 +613,36 @@ func (e evotingCommand) shuffleBallots(snap store.Snapshot, step execution.Step)
 
 	verifier := shuffle.Verifier(suite, nil, form.Pubkey, XXUp, YYUp, XXDown, YYDown)
 
+	timer := prometheus.NewTimer(PromShuffleProofVerification)
+
 	err = e.prover(suite, shufflingProtocolName, verifier, tx.Proof)
+	timer.ObserveDuration()
+
 	if err != nil {
 		return xerrors.Errorf("proof verification failed: %v", err)
 	}
 
-	// append the new shuffled ballots and the proof to the lists
-	currentShuffleInstance := types.ShuffleInstance{
-		ShuffledBallots:   tx.ShuffledBallots,
-		ShuffleProofs:     tx.Proof,
-		ShufflerPublicKey: shufflerPublicKey,
+	if tx.BatchSize == 0 {
+		// store the new shuffled ballots and the proof
+		currentShuffleInstance := types.ShuffleInstance{
+			ShuffledBallots:   tx.ShuffledBallots,
+			ShuffleProofs:     tx.Proof,
+			ShufflerPublicKey: shufflerPublicKey,
+		}
+
+		err = form.StoreShuffle(e.context, snap, currentShuffleInstance, true)
+	} else {
+		err = e.addShuffleBatch(snap, &form, tx, roundSize, blocks)
 	}
 
-	form.ShuffleInstances = append(form.ShuffleInstances, currentShuffleInstance)
+	if err != nil {
+		return xerrors.Errorf("failed to store shuffle: %v", err)
+	}
 
 	PromFormShufflingInstances.WithLabelValues(form.FormID).Set(float64(len(form.ShuffleInstances)))
 
 	// in case we have enough shuffled ballots, we update the status
-	if len(form.ShuffleInstances) >= form.ShuffleThreshold {
+	if len(form.ShuffleInstances) >= form.ShuffleRounds() {
 		form.Status = types.ShuffledBallots
 		PromFormStatus.WithLabelValues(form.FormID).Set(float64(form.Status))
 	}
@@ -501,6 +660,150 @@ func (e evotingCommand) shuffleBallots(snap store.Snapshot, step execution.Step)
 	return nil
 }
 
+// dropStalledShuffle drops the pending shuffle of the form if it has no new
+// batch for ShuffleTakeoverBlocks blocks and another node submits the first
+// batch of the round, so that a node that stops shuffling can't block the
+// round forever. The other node then starts the round over.
+func dropStalledShuffle(form *types.Form, tx types.ShuffleBallots, blocks uint64) {
+	pending := form.PendingShuffle
+
+	if pending == nil || tx.BatchIndex != 0 ||
+		bytes.Equal(pending.ShufflerPublicKey, tx.PublicKey) {
+		return
+	}
+
+	if blocks < pending.LastBlock+ShuffleTakeoverBlocks {
+		return
+	}
+
+	dela.Logger.Warn().Msgf("round %d of form %s is stalled since block %d, "+
+		"it is started over", tx.Round, form.FormID, pending.LastBlock)
+
+	form.PendingShuffle = nil
+}
+
+// checkShuffleBatch checks that a batch of shuffled ballots is the expected
+// next batch of the round and returns the ballots of the round that are part
+// of it.
+func checkShuffleBatch(form types.Form, tx types.ShuffleBallots,
+	ciphervotes []types.Ciphervote) ([]types.Ciphervote, error) {
+
+	if tx.BatchSize < types.MinShuffleBatchSize {
+		return nil, xerrors.Errorf("batch size is too small: %d < %d",
+			tx.BatchSize, types.MinShuffleBatchSize)
+	}
+
+	expectedIndex := 0
+
+	pending := form.PendingShuffle
+	if pending != nil {
+		if !bytes.Equal(pending.ShufflerPublicKey, tx.PublicKey) {
+			return nil, xerrors.Errorf("round %d is being shuffled by another node",
+				tx.Round)
+		}
+
+		if pending.BatchSize != tx.BatchSize {
+			return nil, xerrors.Errorf("wrong batch size: expected %d, got %d",
+				pending.BatchSize, tx.BatchSize)
+		}
+
+		expectedIndex = pending.Batches
+	}
+
+	if tx.BatchIndex != expectedIndex {
+		return nil, xerrors.Errorf("wrong batch index: expected %d, got %d",
+			expectedIndex, tx.BatchIndex)
+	}
+
+	indexes := types.ShuffleBatch(tx.Round, len(ciphervotes), tx.BatchSize, tx.BatchIndex)
+	batch := types.SelectCiphervotes(ciphervotes, indexes)
+
+	if len(tx.ShuffledBallots) != len(batch) {
+		return nil, xerrors.Errorf("unexpected number of shuffled ballots: %d != %d",
+			len(tx.ShuffledBallots), len(batch))
+	}
+
+	return batch, nil
+}
+
+// addShuffleBatch adds a verified batch to the pending shuffle of the form. The
+// pending shuffle becomes a new shuffle instance once all the batches of the
+// round have been added. It records the block count at which the batch is
+// added, see dropStalledShuffle.
+func (e evotingCommand) addShuffleBatch(snap store.Snapshot, form *types.Form,
+	tx types.ShuffleBallots, roundSize int, blocks uint64) error {
+
+	pending, err := form.Pending(e.context, snap)
+	if err != nil {
+		return xerrors.Errorf("failed to get pending shuffle: %v", err)
+	}
+
+	if form.PendingShuffle == nil {
+		pending.ShufflerPublicKey = tx.PublicKey
+		pending.BatchSize = tx.BatchSize
+	}
+
+	pending.ShuffledBallots = append(pending.ShuffledBallots, tx.ShuffledBallots...)
+	pending.BatchProofs = append(pending.BatchProofs, tx.Proof)
+
+	complete := len(pending.BatchProofs) == types.ShuffleBatchCount(roundSize, tx.BatchSize)
+
+	err = form.StoreShuffle(e.context, snap, pending, complete)
+	if err != nil {
+		return xerrors.Errorf("failed to store pending shuffle: %v", err)
+	}
+
+	if !complete {
+		form.PendingShuffle.LastBlock = blocks
+	}
+
+	return nil
+}
+
+// countBlock increments the block count, see BlockCountId, if the transaction
+// is the first one of the contract in its block.
+func countBlock(snap store.Snapshot, step execution.Step) error {
+	for _, tx := range step.Previous {
+		if string(tx.GetArg(native.ContractArg)) == ContractName {
+			return nil
+		}
+	}
+
+	count, err := GetBlockCount(snap)
+	if err != nil {
+		return xerrors.Errorf("failed to get block count: %v", err)
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, count+1)
+
+	key := sha256.Sum256([]byte(BlockCountId))
+
+	err = snap.Set(key[:], buf)
+	if err != nil {
+		return xerrors.Errorf("failed to set block count: %v", err)
+	}
+
+	return nil
+}
+
+// GetBlockCount returns the number of blocks with a transaction of the
+// contract.
+func GetBlockCount(rd store.Readable) (uint64, error) {
+	key := sha256.Sum256([]byte(BlockCountId))
+
+	buf, err := rd.Get(key[:])
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get value: %v", err)
+	}
+
+	if len(buf) != 8 {
+		return 0, nil
+	}
+
+	return binary.BigEndian.Uint64(buf), nil
+}
+
 // checkPreviousTransactions checks if a ShuffleBallotsTransaction has already
 // been accepted and executed for a specific round.
 func (e evotingCommand) checkPreviousTransactions(step execution.Step, round int) error {
@@ -640,9 +943,13 @@ func (e evotingCommand) registerPubshares(snap store.Snapshot, step execution.St
 		return xerrors.Errorf("signature does not match the PubsharesUnit: %v ", err)
 	}
 
+	lastShuffle, err := form.LastShuffle(e.context, snap)
+	if err != nil {
+		return xerrors.Errorf("couldn't get last shuffle: %v", err)
+	}
+
 	// coherence check on the length of the shares submitted
-	shuffledBallots := form.ShuffleInstances[len(form.ShuffleInstances)-1].
-		ShuffledBallots
+	shuffledBallots := lastShuffle.ShuffledBallots
 	if len(tx.Pubshares) != len(shuffledBallots) {
 		return xerrors.Errorf("unexpected size of pubshares submission: %d != %d",
 			len(tx.Pubshares), len(shuffledBallots))
@@ -671,11 +978,12 @@ func (e evotingCommand) registerPubshares(snap store.Snapshot, step execution.St
 	}
 
 	// Add the pubshares to the form
-	units.Pubshares = append(units.Pubshares, tx.Pubshares)
-	units.PubKeys = append(units.PubKeys, tx.PublicKey)
-	units.Indexes = append(units.Indexes, tx.Index)
+	err = form.AddPubshares(e.context, snap, tx.Pubshares, tx.PublicKey, tx.Index)
+	if err != nil {
+		return xerrors.Errorf("failed to add pubShares: %v", err)
+	}
 
-	nbrSubmissions := len(units.Pubshares)
+	nbrSubmissions := len(units.IDs)
 
 	PromFormPubShares.WithLabelValues(form.FormID).Set(float64(nbrSubmissions))
 
@@ -729,12 +1037,18 @@ func (e evotingCommand) combineShares(snap store.Snapshot, step execution.Step)
 		return xerrors.Errorf(errNoOwnerPerms, tx.UserID)
 	}
 
-	allPubShares := form.PubsharesUnits.Pubshares
+	allPubShares, err := form.Pubshares(e.context, snap)
+	if err != nil {
+		return xerrors.Errorf("failed to get pubShares: %v", err)
+	}
 
-	shufflesSize := len(form.ShuffleInstances)
+	lastShuffle, err := form.LastShuffle(e.context, snap)
+	if err != nil {
+		return xerrors.Errorf("couldn't get last shuffle: %v", err)
+	}
 
-	shuffledBallotsSize := len(form.ShuffleInstances[shufflesSize-1].ShuffledBallots)
-	ballotSize := len(form.ShuffleInstances[shufflesSize-1].ShuffledBallots[0])
+	shuffledBallotsSize := len(lastShuffle.ShuffledBallots)
+	ballotSize := len(lastShuffle.ShuffledBallots[0])
 
 	decryptedBallots := make([]types.Ballot, shuffledBallotsSize)
 
@@ -822,6 +1136,112 @@ func (e evotingCommand) cancelForm(snap store.Snapshot, step execution.Step) err
 	return nil
 }
 
+// migrateForm implements commands. It performs the MIGRATE_FORM command
+func (e evotingCommand) migrateForm(snap store.Snapshot, step execution.Step) error {
+
+	msg, err := e.getTransaction(step.Current)
+	if err != nil {
+		return xerrors.Errorf(errGetTransaction, err)
+	}
+
+	tx, ok := msg.(types.MigrateForm)
+	if !ok {
+		return xerrors.Errorf(errWrongTx, msg)
+	}
+
+	form, formID, err := e.getForm(tx.FormID, snap)
+	if err != nil {
+		return xerrors.Errorf(errGetForm, err)
+	}
+
+	canEditForm, err := e.canEditForm(snap, form, tx.UserID)
+	if err != nil {
+		return xerrors.Errorf(errIsRole, err)
+	}
+
+	if !canEditForm {
+		return xerrors.Errorf(errNoOwnerPerms, tx.UserID)
+	}
+
+	err = form.Migrate(snap, serde.Format(tx.Format))
+	if err != nil {
+		return xerrors.Errorf("failed to migrate ballots: %v", err)
+	}
+
+	formBuf, err := form.Serialize(e.context)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal Form : %v", err)
+	}
+
+	err = snap.Set(formID, formBuf)
+	if err != nil {
+		return xerrors.Errorf("failed to set value: %v", err)
+	}
+
+	return nil
+}
+
+// auditBallot implements commands. It performs the AUDIT_BALLOT command
+func (e evotingCommand) auditBallot(snap store.Snapshot, step execution.Step) error {
+
+	msg, err := e.getTransaction(step.Current)
+	if err != nil {
+		return xerrors.Errorf(errGetTransaction, err)
+	}
+
+	tx, ok := msg.(types.AuditBallot)
+	if !ok {
+		return xerrors.Errorf(errWrongTx, msg)
+	}
+
+	form, _, err := e.getForm(tx.FormID, snap)
+	if err != nil {
+		return xerrors.Errorf(errGetForm, err)
+	}
+
+	if form.Status != types.Open {
+		return xerrors.Errorf("the form is not open, current status: %d", form.Status)
+	}
+
+	// only the voter who encrypted the ballot knows its randomness, so that
+	// nobody else can prevent a ballot from being cast.
+	_, err = tx.Ballot.Audit(form.Pubkey, tx.Randomness)
+	if err != nil {
+		return xerrors.Errorf("failed to audit ballot: %v", err)
+	}
+
+	hash, err := tx.Ballot.Hash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash ballot: %v", err)
+	}
+
+	audited, err := form.IsAudited(snap, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to check audit: %v", err)
+	}
+
+	if audited {
+		return xerrors.Errorf("the ballot has already been audited")
+	}
+
+	_, found, err := form.FindBallot(snap, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to find ballot: %v", err)
+	}
+
+	if found {
+		return xerrors.Errorf("the ballot has already been cast")
+	}
+
+	// the audit is stored apart from the form, which is left unchanged
+	err = form.SetAudited(snap, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to set audit: %v", err)
+	}
+
+	return nil
+}
+
 // deleteForm implements commands. It performs the DELETE_FORM command
 func (e evotingCommand) deleteForm(snap store.Snapshot, step execution.Step) error {
 
@@ -1111,7 +1531,8 @@ func initializeAdminList(snap store.Snapshot, initialAdmin int, formId string, c
 }
 
 // manageVotersForm implements commands.
-// It performs the ADD or REMOVE VOTERS/OWNERS command
+// It performs the ADD or REMOVE VOTERS/OWNERS command, and the ADD CREDENTIAL
+// command
 func (e evotingCommand) manageOwnersVotersForm(snap store.Snapshot, step execution.Step) error {
 	msg, err := e.getTransaction(step.Current)
 	if err != nil {
@@ -1125,6 +1546,7 @@ func (e evotingCommand) manageOwnersVotersForm(snap store.Snapshot, step executi
 	txRemoveVoter, okRemoveVoter := msg.(types.RemoveVoter)
 	txAddOwner, okAddOwner := msg.(types.AddOwner)
 	txRemoveOwner, okRemoveOwner := msg.(types.RemoveOwner)
+	txAddCredential, okAddCredential := msg.(types.AddCredential)
 
 	if okAddVoter {
 		form, formID, err = e.getForm(txAddVoter.FormID, snap)
@@ -1145,6 +1567,13 @@ func (e evotingCommand) manageOwnersVotersForm(snap store.Snapshot, step executi
 		if err != nil {
 			return xerrors.Errorf("couldn't add voter: %v", err)
 		}
+
+		if len(txAddVoter.PublicKey) != 0 {
+			err = form.SetVoterKey(txAddVoter.TargetUserID, txAddVoter.PublicKey)
+			if err != nil {
+				return xerrors.Errorf("couldn't register voter key: %v", err)
+			}
+		}
 	} else if okRemoveVoter {
 		form, formID, err = e.getForm(txRemoveVoter.FormID, snap)
 		if err != nil {
@@ -1202,6 +1631,25 @@ func (e evotingCommand) manageOwnersVotersForm(snap store.Snapshot, step executi
 		if err != nil {
 			return xerrors.Errorf("couldn't remove owner: %v", err)
 		}
+	} else if okAddCredential {
+		form, formID, err = e.getForm(txAddCredential.FormID, snap)
+		if err != nil {
+			return xerrors.Errorf(errGetForm, err)
+		}
+
+		canEditForm, err := e.canEditForm(snap, form, txAddCredential.PerformingUserID)
+		if err != nil {
+			return xerrors.Errorf(errIsRole, err)
+		}
+
+		if !canEditForm {
+			return xerrors.Errorf(errNoOwnerPerms, txAddCredential.PerformingUserID)
+		}
+
+		err = form.AddCredential(snap, txAddCredential.PublicKey)
+		if err != nil {
+			return xerrors.Errorf("couldn't add credential: %v", err)
+		}
 	} else {
 		return xerrors.Errorf(errWrongTx, msg)
 	}
@@ -1219,6 +1667,47 @@ func (e evotingCommand) manageOwnersVotersForm(snap store.Snapshot, step executi
 	return nil
 }
 
+// checkVoterSignature checks that the vote is signed with the key registered
+// by the voter. Votes of voters without a key must not be signed, as they are
+// authenticated by the trusted frontend.
+func checkVoterSignature(form types.Form, tx types.CastVote) error {
+	publicKey, err := form.GetVoterKey(tx.VoterID)
+	if err != nil {
+		return xerrors.Errorf("failed to get voter key: %v", err)
+	}
+
+	if publicKey == nil {
+		if len(tx.Signature) != 0 {
+			return xerrors.Errorf("voter %s has no registered key", tx.VoterID)
+		}
+
+		return nil
+	}
+
+	if len(tx.Signature) == 0 {
+		return xerrors.Errorf("the vote of voter %s must be signed", tx.VoterID)
+	}
+
+	voterKey := suite.Point()
+
+	err = voterKey.UnmarshalBinary(publicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal voter key: %v", err)
+	}
+
+	hash, err := tx.Hash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash vote: %v", err)
+	}
+
+	err = schnorr.Verify(suite, voterKey, hash, tx.Signature)
+	if err != nil {
+		return xerrors.Errorf("signature does not match the vote: %v", err)
+	}
+
+	return nil
+}
+
 // isMemberOf is a utility function to verify if a public key is associated to a
 // member of the roster or not. Returns nil if it's the case.
 func isMemberOf(roster authority.Authority, publicKey []byte) error {
@@ -1243,6 +1732,51 @@ func isMemberOf(roster authority.Authority, publicKey []byte) error {
 	return nil
 }
 
+// isMixerOf returns an error if the public key is not one of the mixers of the
+// form.
+func isMixerOf(form types.Form, publicKey []byte) error {
+	for _, mixer := range form.Mixers {
+		if bytes.Equal(mixer, publicKey) {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("public key not associated to a mixer of the form: %x",
+		publicKey)
+}
+
+// checkMixers checks that the mixers are distinct valid public keys and that
+// the threshold can be reached.
+func checkMixers(mixers [][]byte, mixerThreshold int) error {
+	if len(mixers) == 0 {
+		if mixerThreshold != 0 {
+			return xerrors.Errorf("threshold set without mixers: %d", mixerThreshold)
+		}
+
+		return nil
+	}
+
+	if mixerThreshold < 1 || mixerThreshold > len(mixers) {
+		return xerrors.Errorf("threshold must be between 1 and %d: %d",
+			len(mixers), mixerThreshold)
+	}
+
+	for i, mixer := range mixers {
+		_, err := bls.NewPublicKey(mixer)
+		if err != nil {
+			return xerrors.Errorf("failed to decode public key of mixer %d: %v", i, err)
+		}
+
+		for _, other := range mixers[:i] {
+			if bytes.Equal(mixer, other) {
+				return xerrors.Errorf("duplicated mixer: %x", mixer)
+			}
+		}
+	}
+
+	return nil
+}
+
 // SemiRandomStream implements cipher.Stream
 type SemiRandomStream struct {
 	// Seed is the seed on which should be based our random number generation
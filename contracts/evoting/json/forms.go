@@ -2,7 +2,6 @@ package json
 
 import (
 	"encoding/hex"
-	"encoding/json"
 
 	"github.com/c4dt/d-voting/contracts/evoting/types"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
@@ -46,9 +45,16 @@ func (formFormat) Encode(ctx serde.Context, message serde.Message) ([]byte, erro
 			suffragiaHashes[i] = hex.EncodeToString(sufH)
 		}
 
-		shuffleInstances, err := encodeShuffleInstances(ctx, m.ShuffleInstances)
-		if err != nil {
-			return nil, xerrors.Errorf("failed to encode shuffle instances: %v", err)
+		shuffleInstances := make([]ShuffleRefJSON, len(m.ShuffleInstances))
+		for i, ref := range m.ShuffleInstances {
+			shuffleInstances[i] = ShuffleRefJSON(ref)
+		}
+
+		var pendingShuffle *ShuffleRefJSON
+
+		if m.PendingShuffle != nil {
+			pending := ShuffleRefJSON(*m.PendingShuffle)
+			pendingShuffle = &pending
 		}
 
 		rosterBuf, err := m.Roster.Serialize(ctx)
@@ -56,10 +62,11 @@ func (formFormat) Encode(ctx serde.Context, message serde.Message) ([]byte, erro
 			return nil, xerrors.Errorf("failed to serialize roster: %v", err)
 		}
 
-		pubsharesUnits, err := encodePubsharesUnits(m.PubsharesUnits)
-		if err != nil {
-			return nil, xerrors.Errorf("failed to encode submissions of pubShares: %v",
-				err)
+		var electoralRoll *ElectoralRollRefJSON
+
+		if m.ElectoralRoll != nil {
+			ref := ElectoralRollRefJSON(*m.ElectoralRoll)
+			electoralRoll = &ref
 		}
 
 		formJSON := FormJSON{
@@ -67,17 +74,24 @@ func (formFormat) Encode(ctx serde.Context, message serde.Message) ([]byte, erro
 			FormID:           m.FormID,
 			Status:           uint16(m.Status),
 			Pubkey:           pubkey,
+			CeremonyID:       m.CeremonyID,
 			BallotSize:       m.BallotSize,
 			Suffragias:       suffragias,
 			SuffragiaHashes:  suffragiaHashes,
 			BallotCount:      m.BallotCount,
 			ShuffleInstances: shuffleInstances,
+			PendingShuffle:   pendingShuffle,
 			ShuffleThreshold: m.ShuffleThreshold,
-			PubsharesUnits:   pubsharesUnits,
+			Mixers:           m.Mixers,
+			MixerThreshold:   m.MixerThreshold,
+			PubsharesUnits:   PubsharesUnitsJSON(m.PubsharesUnits),
 			DecryptedBallots: m.DecryptedBallots,
 			RosterBuf:        rosterBuf,
 			Owners:           m.Owners,
 			Voters:           m.Voters,
+			VoterKeys:        m.VoterKeys,
+			Anonymous:        m.Anonymous,
+			ElectoralRoll:    electoralRoll,
 		}
 
 		buff, err := ctx.Marshal(&formJSON)
@@ -126,9 +140,23 @@ func (formFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 		}
 	}
 
-	shuffleInstances, err := decodeShuffleInstances(ctx, formJSON.ShuffleInstances)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to decode shuffle instances: %v", err)
+	shuffleInstances := make([]types.ShuffleRef, len(formJSON.ShuffleInstances))
+	for i, ref := range formJSON.ShuffleInstances {
+		shuffleInstances[i] = types.ShuffleRef(ref)
+	}
+
+	var pendingShuffle *types.ShuffleRef
+
+	if formJSON.PendingShuffle != nil {
+		pending := types.ShuffleRef(*formJSON.PendingShuffle)
+		pendingShuffle = &pending
+	}
+
+	var electoralRoll *types.ElectoralRollRef
+
+	if formJSON.ElectoralRoll != nil {
+		ref := types.ElectoralRollRef(*formJSON.ElectoralRoll)
+		electoralRoll = &ref
 	}
 
 	fac := ctx.GetFactory(ctypes.RosterKey{})
@@ -142,27 +170,29 @@ func (formFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 		return nil, xerrors.Errorf("failed to decode roster: %v", err)
 	}
 
-	pubSharesSubmissions, err := decodePubSharesUnits(formJSON.PubsharesUnits)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to decode pubShares submissions: %v", err)
-	}
-
 	return types.Form{
 		Configuration:    formJSON.Configuration,
 		FormID:           formJSON.FormID,
 		Status:           types.Status(formJSON.Status),
 		Pubkey:           pubKey,
+		CeremonyID:       formJSON.CeremonyID,
 		BallotSize:       formJSON.BallotSize,
 		SuffragiaIDs:     suffragias,
 		SuffragiaHashes:  suffragiaHashes,
 		BallotCount:      formJSON.BallotCount,
 		ShuffleInstances: shuffleInstances,
+		PendingShuffle:   pendingShuffle,
 		ShuffleThreshold: formJSON.ShuffleThreshold,
-		PubsharesUnits:   pubSharesSubmissions,
+		Mixers:           formJSON.Mixers,
+		MixerThreshold:   formJSON.MixerThreshold,
+		PubsharesUnits:   types.PubsharesUnits(formJSON.PubsharesUnits),
 		DecryptedBallots: formJSON.DecryptedBallots,
 		Roster:           roster,
 		Owners:           formJSON.Owners,
 		Voters:           formJSON.Voters,
+		VoterKeys:        formJSON.VoterKeys,
+		Anonymous:        formJSON.Anonymous,
+		ElectoralRoll:    electoralRoll,
 	}, nil
 }
 
@@ -178,6 +208,10 @@ type FormJSON struct {
 	Status  uint16
 	Pubkey  []byte `json:"Pubkey,omitempty"`
 
+	// CeremonyID is the hex-encoded ID of the DKG key ceremony the form is
+	// bound to, if any.
+	CeremonyID string `json:",omitempty"`
+
 	// BallotSize represents the total size in bytes of one ballot. It is used
 	// to pad smaller ballots such that all  ballots cast have the same size
 	BallotSize int
@@ -192,14 +226,25 @@ type FormJSON struct {
 	// in every Suffragia.
 	SuffragiaHashes []string
 
-	// ShuffleInstances is all the shuffles, along with their proof and identity
-	// of shuffler.
-	ShuffleInstances []ShuffleInstanceJSON
+	// ShuffleInstances reference the shuffles of each round.
+	ShuffleInstances []ShuffleRefJSON
+
+	// PendingShuffle references the shuffle of the current round while it is
+	// made in batches.
+	PendingShuffle *ShuffleRefJSON `json:",omitempty"`
 
 	// ShuffleThreshold is set based on the roster. We save it so we do not have
 	// to compute it based on the roster each time we need it.
 	ShuffleThreshold int
 
+	// Mixers are the public keys of the nodes authorized to shuffle the
+	// ballots in place of the roster.
+	Mixers [][]byte `json:",omitempty"`
+
+	// MixerThreshold is the number of shuffles needed when the form has
+	// mixers.
+	MixerThreshold int `json:",omitempty"`
+
 	PubsharesUnits PubsharesUnitsJSON
 
 	DecryptedBallots []types.Ballot
@@ -216,183 +261,42 @@ type FormJSON struct {
 
 	// Store the list of SCIPER of user that are Voters on the form.
 	Voters []int
-}
 
-// ShuffleInstanceJSON defines the JSON representation of a shuffle instance
-type ShuffleInstanceJSON struct {
-	// ShuffledBallots contains the list of shuffled ciphertext for this round
-	ShuffledBallots []json.RawMessage
+	// VoterKeys are the public keys registered by the voters, by SCIPER.
+	VoterKeys map[int][]byte `json:",omitempty"`
 
-	// ShuffleProofs is the proof of the shuffle for this round
-	ShuffleProofs []byte
+	// Anonymous is true if the voters prove their eligibility with a
+	// credential of the electoral roll.
+	Anonymous bool `json:",omitempty"`
 
-	// ShufflerPublicKey is the key of the node who made the given shuffle.
-	ShufflerPublicKey []byte
+	// ElectoralRoll references the public keys of the credentials of the
+	// voters of an anonymous form.
+	ElectoralRoll *ElectoralRollRefJSON `json:",omitempty"`
 }
 
-func encodeShuffleInstances(ctx serde.Context,
-	shuffleInstances []types.ShuffleInstance) ([]ShuffleInstanceJSON, error) {
-
-	res := make([]ShuffleInstanceJSON, len(shuffleInstances))
-
-	for i, shuffleInstance := range shuffleInstances {
-		shuffleInstanceJSON, err := encodeShuffleInstance(ctx, shuffleInstance)
-		if err != nil {
-			return nil, xerrors.Errorf("failed to encode shuffle instance: %v", err)
-		}
-
-		res[i] = shuffleInstanceJSON
-	}
-
-	return res, nil
-}
-
-func encodeShuffleInstance(ctx serde.Context,
-	shuffleInstance types.ShuffleInstance) (ShuffleInstanceJSON, error) {
-
-	var res ShuffleInstanceJSON
-	shuffledBallots := make([]json.RawMessage, len(shuffleInstance.ShuffledBallots))
-
-	for i, shuffledBallot := range shuffleInstance.ShuffledBallots {
-		buff, err := shuffledBallot.Serialize(ctx)
-		if err != nil {
-			return res, xerrors.Errorf("failed to serialize ciphervote: %v", err)
-		}
-
-		shuffledBallots[i] = buff
-	}
-
-	res = ShuffleInstanceJSON{
-		ShuffledBallots:   shuffledBallots,
-		ShuffleProofs:     shuffleInstance.ShuffleProofs,
-		ShufflerPublicKey: shuffleInstance.ShufflerPublicKey,
-	}
-
-	return res, nil
-}
-
-func decodeShuffleInstances(ctx serde.Context,
-	shuffleInstancesJSON []ShuffleInstanceJSON) ([]types.ShuffleInstance, error) {
-
-	res := make([]types.ShuffleInstance, len(shuffleInstancesJSON))
-
-	for i, shuffleInstanceJSON := range shuffleInstancesJSON {
-		shuffleInstance, err := decodeShuffleInstance(ctx, shuffleInstanceJSON)
-		if err != nil {
-			return nil, xerrors.Errorf("failed to decode shuffle instance: %v", err)
-		}
-
-		res[i] = shuffleInstance
-	}
-
-	return res, nil
+// ShuffleRefJSON defines the JSON representation of the reference to a
+// shuffle
+type ShuffleRefJSON struct {
+	ID                []byte
+	Hash              []byte
+	ShufflerPublicKey []byte
+	BallotCount       int
+	BatchSize         int    `json:",omitempty"`
+	Batches           int    `json:",omitempty"`
+	LastBlock         uint64 `json:",omitempty"`
 }
 
-func decodeShuffleInstance(ctx serde.Context,
-	shuffleInstanceJSON ShuffleInstanceJSON) (types.ShuffleInstance, error) {
-
-	var res types.ShuffleInstance
-	fac := ctx.GetFactory(types.CiphervoteKey{})
-
-	factory, ok := fac.(types.CiphervoteFactory)
-	if !ok {
-		return res, xerrors.Errorf("invalid ciphervote factory: '%T'", fac)
-	}
-
-	shuffledBallots := make([]types.Ciphervote, len(shuffleInstanceJSON.ShuffledBallots))
-
-	for i, ciphervoteJSON := range shuffleInstanceJSON.ShuffledBallots {
-		msg, err := factory.Deserialize(ctx, ciphervoteJSON)
-		if err != nil {
-			return res, xerrors.Errorf("failed to deserialize shuffle instance json: %v", err)
-		}
-
-		ciphervote, ok := msg.(types.Ciphervote)
-		if !ok {
-			return res, xerrors.Errorf("wrong type: '%T'", msg)
-		}
-
-		shuffledBallots[i] = ciphervote
-	}
-
-	res = types.ShuffleInstance{
-		ShuffledBallots:   shuffledBallots,
-		ShuffleProofs:     shuffleInstanceJSON.ShuffleProofs,
-		ShufflerPublicKey: shuffleInstanceJSON.ShufflerPublicKey,
-	}
-
-	return res, nil
+// ElectoralRollRefJSON defines the JSON representation of the reference to
+// the electoral roll
+type ElectoralRollRefJSON struct {
+	Size int
 }
 
-// PubsharesUnitJSON is the JSON representation of a submission of pubShares by
-// one node.The first dimension is the pubshares marshalled into bytes.
-type PubsharesUnitJSON [][][]byte
-
 // PubsharesUnitsJSON defines the JSON representation of the
 // types.PubsharesUnits as used in the form.
 type PubsharesUnitsJSON struct {
-	// PubsharesJSON contains all the pubShares submitted.
-	PubsharesJSON []PubsharesUnitJSON
-	PubKeys       [][]byte
-	Indexes       []int
-}
-
-func encodePubsharesUnits(units types.PubsharesUnits) (
-	PubsharesUnitsJSON, error) {
-	var unitsJSON PubsharesUnitsJSON
-
-	submissionsJSON := make([]PubsharesUnitJSON, len(units.Pubshares))
-
-	for i, submission := range units.Pubshares {
-		submissionsJSON[i] = make([][][]byte, len(submission))
-
-		for i2, ballotShares := range submission {
-			submissionsJSON[i][i2] = make([][]byte, len(ballotShares))
-
-			for i3, pubShare := range ballotShares {
-				pubShareMarshaled, err := pubShare.MarshalBinary()
-				if err != nil {
-					return unitsJSON, xerrors.Errorf("could not marshal public share: %v", err)
-				}
-
-				submissionsJSON[i][i2][i3] = pubShareMarshaled
-			}
-		}
-	}
-
-	unitsJSON.Indexes = units.Indexes
-	unitsJSON.PubKeys = units.PubKeys
-	unitsJSON.PubsharesJSON = submissionsJSON
-
-	return unitsJSON, nil
-}
-
-func decodePubSharesUnits(unitsJSON PubsharesUnitsJSON) (types.PubsharesUnits, error) {
-	var units types.PubsharesUnits
-
-	submissions := make([]types.PubsharesUnit, len(unitsJSON.PubsharesJSON))
-
-	for i, submissionJSON := range unitsJSON.PubsharesJSON {
-		submissions[i] = make([][]types.Pubshare, len(submissionJSON))
-
-		for i2, ballotSharesJSON := range submissionJSON {
-			submissions[i][i2] = make([]types.Pubshare, len(ballotSharesJSON))
-
-			for i3, pubShareJSON := range ballotSharesJSON {
-				pubShare := suite.Point()
-				err := pubShare.UnmarshalBinary(pubShareJSON)
-				if err != nil {
-					return units, xerrors.Errorf("could not unmarshal public share: %v", err)
-				}
-
-				submissions[i][i2][i3] = pubShare
-			}
-		}
-	}
-
-	units.Indexes = unitsJSON.Indexes
-	units.PubKeys = unitsJSON.PubKeys
-	units.Pubshares = submissions
-
-	return units, nil
+	IDs     [][]byte
+	Hashes  [][]byte
+	PubKeys [][]byte
+	Indexes []int
 }
@@ -5,11 +5,14 @@ import (
 	"go.dedis.ch/dela/serde"
 )
 
-// Register the JSON formats for the form, ciphervote, and transaction
+// Register the JSON formats for the form, its entries, ciphervote, and
+// transaction
 
 func init() {
 	types.RegisterFormFormat(serde.FormatJSON, formFormat{})
 	types.RegisterSuffragiaFormat(serde.FormatJSON, suffragiaFormat{})
+	types.RegisterShuffleFormat(serde.FormatJSON, shuffleFormat{})
+	types.RegisterPubsharesFormat(serde.FormatJSON, pubsharesFormat{})
 	types.RegisterCiphervoteFormat(serde.FormatJSON, ciphervoteFormat{})
 	types.RegisterTransactionFormat(serde.FormatJSON, transactionFormat{})
 	types.RegisterAdminListFormat(serde.FormatJSON, adminListFormat{})
@@ -0,0 +1,84 @@
+package json
+
+import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+type pubsharesFormat struct{}
+
+func (pubsharesFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	switch m := msg.(type) {
+	case types.PubsharesUnit:
+		unitJSON, err := encodePubsharesUnit(m)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't encode pubShares: %v", err)
+		}
+
+		buff, err := ctx.Marshal(&unitJSON)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal pubShares: %v", err)
+		}
+
+		return buff, nil
+	default:
+		return nil, xerrors.Errorf("Unknown format: %T", msg)
+	}
+}
+
+func (pubsharesFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var unitJSON PubsharesUnitJSON
+
+	err := ctx.Unmarshal(data, &unitJSON)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal pubShares: %v", err)
+	}
+
+	return decodePubsharesUnit(unitJSON)
+}
+
+// PubsharesUnitJSON is the JSON representation of a submission of pubShares by
+// one node. The first dimension is the ballots, the second the pubShares of a
+// ballot marshalled into bytes.
+type PubsharesUnitJSON [][][]byte
+
+func encodePubsharesUnit(unit types.PubsharesUnit) (PubsharesUnitJSON, error) {
+	unitJSON := make(PubsharesUnitJSON, len(unit))
+
+	for i, ballotShares := range unit {
+		unitJSON[i] = make([][]byte, len(ballotShares))
+
+		for j, pubShare := range ballotShares {
+			pubShareMarshaled, err := pubShare.MarshalBinary()
+			if err != nil {
+				return nil, xerrors.Errorf("could not marshal public share: %v", err)
+			}
+
+			unitJSON[i][j] = pubShareMarshaled
+		}
+	}
+
+	return unitJSON, nil
+}
+
+func decodePubsharesUnit(unitJSON PubsharesUnitJSON) (types.PubsharesUnit, error) {
+	unit := make(types.PubsharesUnit, len(unitJSON))
+
+	for i, ballotSharesJSON := range unitJSON {
+		unit[i] = make([]types.Pubshare, len(ballotSharesJSON))
+
+		for j, pubShareJSON := range ballotSharesJSON {
+			pubShare := suite.Point()
+
+			err := pubShare.UnmarshalBinary(pubShareJSON)
+			if err != nil {
+				return nil, xerrors.Errorf("could not unmarshal public share: %v", err)
+			}
+
+			unit[i][j] = pubShare
+		}
+	}
+
+	return unit, nil
+}
@@ -0,0 +1,123 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+type shuffleFormat struct{}
+
+func (shuffleFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	switch m := msg.(type) {
+	case types.ShuffleInstance:
+		shuffleJSON, err := encodeShuffleInstance(ctx, m)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't encode shuffle: %v", err)
+		}
+
+		buff, err := ctx.Marshal(&shuffleJSON)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal shuffle: %v", err)
+		}
+
+		return buff, nil
+	default:
+		return nil, xerrors.Errorf("Unknown format: %T", msg)
+	}
+}
+
+func (shuffleFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var shuffleJSON ShuffleInstanceJSON
+
+	err := ctx.Unmarshal(data, &shuffleJSON)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal shuffle: %v", err)
+	}
+
+	return decodeShuffleInstance(ctx, shuffleJSON)
+}
+
+// ShuffleInstanceJSON defines the JSON representation of a shuffle instance
+type ShuffleInstanceJSON struct {
+	// ShuffledBallots contains the list of shuffled ciphertext for this round
+	ShuffledBallots []json.RawMessage
+
+	// ShuffleProofs is the proof of the shuffle for this round
+	ShuffleProofs []byte
+
+	// ShufflerPublicKey is the key of the node who made the given shuffle.
+	ShufflerPublicKey []byte
+
+	// BatchSize is the maximum number of ballots shuffled together, or 0.
+	BatchSize int `json:",omitempty"`
+
+	// BatchProofs are the proofs of each batch when BatchSize is not 0.
+	BatchProofs [][]byte `json:",omitempty"`
+}
+
+func encodeShuffleInstance(ctx serde.Context,
+	shuffleInstance types.ShuffleInstance) (ShuffleInstanceJSON, error) {
+
+	var res ShuffleInstanceJSON
+	shuffledBallots := make([]json.RawMessage, len(shuffleInstance.ShuffledBallots))
+
+	for i, shuffledBallot := range shuffleInstance.ShuffledBallots {
+		buff, err := shuffledBallot.Serialize(ctx)
+		if err != nil {
+			return res, xerrors.Errorf("failed to serialize ciphervote: %v", err)
+		}
+
+		shuffledBallots[i] = buff
+	}
+
+	res = ShuffleInstanceJSON{
+		ShuffledBallots:   shuffledBallots,
+		ShuffleProofs:     shuffleInstance.ShuffleProofs,
+		ShufflerPublicKey: shuffleInstance.ShufflerPublicKey,
+		BatchSize:         shuffleInstance.BatchSize,
+		BatchProofs:       shuffleInstance.BatchProofs,
+	}
+
+	return res, nil
+}
+
+func decodeShuffleInstance(ctx serde.Context,
+	shuffleInstanceJSON ShuffleInstanceJSON) (types.ShuffleInstance, error) {
+
+	var res types.ShuffleInstance
+	fac := ctx.GetFactory(types.CiphervoteKey{})
+
+	factory, ok := fac.(types.CiphervoteFactory)
+	if !ok {
+		return res, xerrors.Errorf("invalid ciphervote factory: '%T'", fac)
+	}
+
+	shuffledBallots := make([]types.Ciphervote, len(shuffleInstanceJSON.ShuffledBallots))
+
+	for i, ciphervoteJSON := range shuffleInstanceJSON.ShuffledBallots {
+		msg, err := factory.Deserialize(ctx, ciphervoteJSON)
+		if err != nil {
+			return res, xerrors.Errorf("failed to deserialize shuffle instance json: %v", err)
+		}
+
+		ciphervote, ok := msg.(types.Ciphervote)
+		if !ok {
+			return res, xerrors.Errorf("wrong type: '%T'", msg)
+		}
+
+		shuffledBallots[i] = ciphervote
+	}
+
+	res = types.ShuffleInstance{
+		ShuffledBallots:   shuffledBallots,
+		ShuffleProofs:     shuffleInstanceJSON.ShuffleProofs,
+		ShufflerPublicKey: shuffleInstanceJSON.ShufflerPublicKey,
+		BatchSize:         shuffleInstanceJSON.BatchSize,
+		BatchProofs:       shuffleInstanceJSON.BatchProofs,
+	}
+
+	return res, nil
+}
@@ -20,15 +20,19 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 	switch t := msg.(type) {
 	case types.CreateForm:
 		ce := CreateFormJSON{
-			Configuration: t.Configuration,
-			UserID:        t.UserID,
+			Configuration:  t.Configuration,
+			UserID:         t.UserID,
+			Anonymous:      t.Anonymous,
+			Mixers:         t.Mixers,
+			MixerThreshold: t.MixerThreshold,
 		}
 
 		m = TransactionJSON{CreateForm: &ce}
 	case types.OpenForm:
 		oe := OpenFormJSON{
-			FormID: t.FormID,
-			UserID: t.UserID,
+			FormID:     t.FormID,
+			UserID:     t.UserID,
+			CeremonyID: t.CeremonyID,
 		}
 
 		m = TransactionJSON{OpenForm: &oe}
@@ -42,6 +46,10 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 			FormID:     t.FormID,
 			VoterID:    t.VoterID,
 			Ciphervote: ballot,
+			Signature:  t.Signature,
+			Credential: t.Credential,
+			Sequence:   t.Sequence,
+			Ring:       t.Ring,
 		}
 
 		m = TransactionJSON{CastVote: &cv}
@@ -73,6 +81,8 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 			Signature:    t.Signature,
 			PublicKey:    t.PublicKey,
 			UserID:       t.UserID,
+			BatchSize:    t.BatchSize,
+			BatchIndex:   t.BatchIndex,
 		}
 
 		m = TransactionJSON{ShuffleBallots: &sb}
@@ -121,6 +131,14 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 		}
 
 		m = TransactionJSON{DeleteForm: &de}
+	case types.MigrateForm:
+		mf := MigrateFormJSON{
+			FormID: t.FormID,
+			UserID: t.UserID,
+			Format: t.Format,
+		}
+
+		m = TransactionJSON{MigrateForm: &mf}
 	case types.AddAdmin:
 		aa := AddAdminJSON{
 			PerformingUserID: t.PerformingUserID,
@@ -170,6 +188,7 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 			FormID:           t.FormID,
 			TargetUserID:     t.TargetUserID,
 			PerformingUserID: t.PerformingUserID,
+			PublicKey:        t.PublicKey,
 		}
 
 		m = TransactionJSON{AddVoter: &addVoter}
@@ -181,6 +200,27 @@ func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, e
 		}
 
 		m = TransactionJSON{RemoveVoter: &removeVoter}
+	case types.AddCredential:
+		addCredential := AddCredentialJSON{
+			FormID:           t.FormID,
+			PublicKey:        t.PublicKey,
+			PerformingUserID: t.PerformingUserID,
+		}
+
+		m = TransactionJSON{AddCredential: &addCredential}
+	case types.AuditBallot:
+		ballot, err := t.Ballot.Serialize(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to serialize ballot: %v", err)
+		}
+
+		auditBallot := AuditBallotJSON{
+			FormID:     t.FormID,
+			Ciphervote: ballot,
+			Randomness: t.Randomness,
+		}
+
+		m = TransactionJSON{AuditBallot: &auditBallot}
 	default:
 		return nil, xerrors.Errorf("unknown type: '%T", msg)
 	}
@@ -205,13 +245,17 @@ func (transactionFormat) Decode(ctx serde.Context, data []byte) (serde.Message,
 	switch {
 	case m.CreateForm != nil:
 		return types.CreateForm{
-			Configuration: m.CreateForm.Configuration,
-			UserID:        m.CreateForm.UserID,
+			Configuration:  m.CreateForm.Configuration,
+			UserID:         m.CreateForm.UserID,
+			Anonymous:      m.CreateForm.Anonymous,
+			Mixers:         m.CreateForm.Mixers,
+			MixerThreshold: m.CreateForm.MixerThreshold,
 		}, nil
 	case m.OpenForm != nil:
 		return types.OpenForm{
-			FormID: m.OpenForm.FormID,
-			UserID: m.OpenForm.UserID,
+			FormID:     m.OpenForm.FormID,
+			UserID:     m.OpenForm.UserID,
+			CeremonyID: m.OpenForm.CeremonyID,
 		}, nil
 	case m.CastVote != nil:
 		msg, err := decodeCastVote(ctx, *m.CastVote)
@@ -254,6 +298,12 @@ func (transactionFormat) Decode(ctx serde.Context, data []byte) (serde.Message,
 			FormID: m.DeleteForm.FormID,
 			UserID: m.DeleteForm.UserID,
 		}, nil
+	case m.MigrateForm != nil:
+		return types.MigrateForm{
+			FormID: m.MigrateForm.FormID,
+			UserID: m.MigrateForm.UserID,
+			Format: m.MigrateForm.Format,
+		}, nil
 	case m.AddAdmin != nil:
 		return types.AddAdmin{
 			TargetUserID:     m.AddAdmin.TargetUserID,
@@ -291,6 +341,7 @@ func (transactionFormat) Decode(ctx serde.Context, data []byte) (serde.Message,
 			FormID:           m.AddVoter.FormID,
 			TargetUserID:     m.AddVoter.TargetUserID,
 			PerformingUserID: m.AddVoter.PerformingUserID,
+			PublicKey:        m.AddVoter.PublicKey,
 		}, nil
 	case m.RemoveVoter != nil:
 		return types.RemoveVoter{
@@ -298,6 +349,19 @@ func (transactionFormat) Decode(ctx serde.Context, data []byte) (serde.Message,
 			TargetUserID:     m.RemoveVoter.TargetUserID,
 			PerformingUserID: m.RemoveVoter.PerformingUserID,
 		}, nil
+	case m.AddCredential != nil:
+		return types.AddCredential{
+			FormID:           m.AddCredential.FormID,
+			PublicKey:        m.AddCredential.PublicKey,
+			PerformingUserID: m.AddCredential.PerformingUserID,
+		}, nil
+	case m.AuditBallot != nil:
+		msg, err := decodeAuditBallot(ctx, *m.AuditBallot)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode audit ballot: %v", err)
+		}
+
+		return msg, nil
 	}
 
 	return nil, xerrors.Errorf("empty type: %s", data)
@@ -315,6 +379,7 @@ type TransactionJSON struct {
 	CombineShares     *CombineSharesJSON     `json:",omitempty"`
 	CancelForm        *CancelFormJSON        `json:",omitempty"`
 	DeleteForm        *DeleteFormJSON        `json:",omitempty"`
+	MigrateForm       *MigrateFormJSON       `json:",omitempty"`
 	AddAdmin          *AddAdminJSON          `json:",omitempty"`
 	RemoveAdmin       *RemoveAdminJSON       `json:",omitempty"`
 	AddOperator       *AddOperatorJSON       `json:",omitempty"`
@@ -323,18 +388,24 @@ type TransactionJSON struct {
 	RemoveOwner       *RemoveOwnerJSON       `json:",omitempty"`
 	AddVoter          *AddVoterJSON          `json:",omitempty"`
 	RemoveVoter       *RemoveVoterJSON       `json:",omitempty"`
+	AddCredential     *AddCredentialJSON     `json:",omitempty"`
+	AuditBallot       *AuditBallotJSON       `json:",omitempty"`
 }
 
 // CreateFormJSON is the JSON representation of a CreateForm transaction
 type CreateFormJSON struct {
-	Configuration types.Configuration
-	UserID        string
+	Configuration  types.Configuration
+	UserID         string
+	Anonymous      bool     `json:",omitempty"`
+	Mixers         [][]byte `json:",omitempty"`
+	MixerThreshold int      `json:",omitempty"`
 }
 
 // OpenFormJSON is the JSON representation of a OpenForm transaction
 type OpenFormJSON struct {
-	FormID string
-	UserID string
+	FormID     string
+	UserID     string
+	CeremonyID string `json:",omitempty"`
 }
 
 // CastVoteJSON is the JSON representation of a CastVote transaction
@@ -342,6 +413,17 @@ type CastVoteJSON struct {
 	FormID     string
 	VoterID    string
 	Ciphervote json.RawMessage
+	Signature  []byte `json:",omitempty"`
+	Credential []byte `json:",omitempty"`
+	Sequence   uint64 `json:",omitempty"`
+	Ring       int    `json:",omitempty"`
+}
+
+// AuditBallotJSON is the JSON representation of a AuditBallot transaction
+type AuditBallotJSON struct {
+	FormID     string
+	Ciphervote json.RawMessage
+	Randomness [][]byte
 }
 
 // CloseFormJSON is the JSON representation of a CloseForm transaction
@@ -360,6 +442,8 @@ type ShuffleBallotsJSON struct {
 	Signature    []byte
 	PublicKey    []byte
 	UserID       string
+	BatchSize    int `json:",omitempty"`
+	BatchIndex   int `json:",omitempty"`
 }
 
 type RegisterPubSharesJSON struct {
@@ -388,6 +472,13 @@ type DeleteFormJSON struct {
 	UserID string
 }
 
+// MigrateFormJSON is the JSON representation of a MigrateForm transaction
+type MigrateFormJSON struct {
+	FormID string
+	UserID string
+	Format string
+}
+
 // AdminList
 
 // AddAdminJSON is the JSON representation of a AddAdmin transaction
@@ -439,6 +530,14 @@ type AddVoterJSON struct {
 	FormID           string
 	TargetUserID     string
 	PerformingUserID string
+	PublicKey        []byte `json:",omitempty"`
+}
+
+// AddCredentialJSON is the JSON representation of a AddCredential transaction
+type AddCredentialJSON struct {
+	FormID           string
+	PublicKey        []byte
+	PerformingUserID string
 }
 
 // RemoveVoterJSON is the JSON representation of a RemoveVoter transaction
@@ -465,9 +564,36 @@ func decodeCastVote(ctx serde.Context, m CastVoteJSON) (serde.Message, error) {
 	}
 
 	return types.CastVote{
-		FormID:  m.FormID,
-		VoterID: m.VoterID,
-		Ballot:  ciphervote,
+		FormID:     m.FormID,
+		VoterID:    m.VoterID,
+		Ballot:     ciphervote,
+		Signature:  m.Signature,
+		Credential: m.Credential,
+		Sequence:   m.Sequence,
+		Ring:       m.Ring,
+	}, nil
+}
+
+func decodeAuditBallot(ctx serde.Context, m AuditBallotJSON) (serde.Message, error) {
+	factory := ctx.GetFactory(types.CiphervoteKey{})
+	if factory == nil {
+		return nil, xerrors.Errorf("missing ciphervote factory")
+	}
+
+	msg, err := factory.Deserialize(ctx, m.Ciphervote)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to deserialize ciphervote: %v", err)
+	}
+
+	ciphervote, ok := msg.(types.Ciphervote)
+	if !ok {
+		return nil, xerrors.Errorf("invalid ciphervote: '%T'", msg)
+	}
+
+	return types.AuditBallot{
+		FormID:     m.FormID,
+		Ballot:     ciphervote,
+		Randomness: m.Randomness,
 	}, nil
 }
 
@@ -502,6 +628,8 @@ func decodeShuffleBallots(ctx serde.Context, m ShuffleBallotsJSON) (serde.Messag
 		Signature:       m.Signature,
 		PublicKey:       m.PublicKey,
 		UserID:          m.UserID,
+		BatchSize:       m.BatchSize,
+		BatchIndex:      m.BatchIndex,
 	}, nil
 }
 
@@ -1,8 +1,12 @@
 package evoting
 
 import (
+	"context"
+	"time"
+
 	dvoting "github.com/c4dt/d-voting"
 	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/tracing"
 	"github.com/c4dt/d-voting/services/dkg"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.dedis.ch/dela/core/access"
@@ -12,13 +16,17 @@ import (
 	"go.dedis.ch/dela/core/store"
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/json"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"go.dedis.ch/kyber/v3/proof"
 	"go.dedis.ch/kyber/v3/suites"
 	"golang.org/x/xerrors"
 
-	// Register the JSON format for the form
+	// Register the JSON and protobuf formats for the form
 	_ "github.com/c4dt/d-voting/contracts/evoting/json"
+	_ "github.com/c4dt/d-voting/contracts/evoting/protobuf"
 )
 
 var (
@@ -56,8 +64,46 @@ var (
 	},
 		[]string{"form"},
 	)
+
+	PromCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dvoting_command_duration_seconds",
+		Help:    "execution time of the commands of the contract",
+		Buckets: prometheus.DefBuckets,
+	},
+		[]string{"command"},
+	)
+
+	PromShuffleProofGeneration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dvoting_shuffle_proof_generation_seconds",
+		Help:    "time to generate the proof of a shuffle",
+		Buckets: longBuckets,
+	})
+
+	PromShuffleProofVerification = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dvoting_shuffle_proof_verification_seconds",
+		Help:    "time to verify the proof of a shuffle",
+		Buckets: longBuckets,
+	})
+
+	PromDkgPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dvoting_dkg_phase_duration_seconds",
+		Help:    "duration of the phases of the distributed key generation",
+		Buckets: longBuckets,
+	},
+		[]string{"phase"},
+	)
+
+	PromPubSharesComputation = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dvoting_pubshares_computation_seconds",
+		Help:    "time to compute the public shares of the shuffled ballots",
+		Buckets: longBuckets,
+	})
 )
 
+// longBuckets are the buckets of the histograms of the operations that take
+// from a few milliseconds to minutes depending on the number of ballots.
+var longBuckets = prometheus.ExponentialBuckets(0.01, 2, 15)
+
 const (
 	// FormsMetadataKey is the key at which form metadata are saved in
 	// the storage.
@@ -77,6 +123,10 @@ const (
 	// run on the contract. Should be one of the Command type.
 	CmdArg = "evoting:command"
 
+	// TraceArg is the argument's name of the optional trace context of the
+	// request that submitted the transaction, in the W3C Trace Context format.
+	TraceArg = "evoting:trace"
+
 	// FormArg is the key at which the form argument is stored in the
 	// transaction. The content is defined by the type of command.
 	FormArg = "evoting:arg"
@@ -87,6 +137,15 @@ const (
 
 	AdminListId    = ContractUID + "AdminList"
 	OperatorListId = ContractUID + "OperatorList"
+
+	// BlockCountId is the ID of the number of blocks with a transaction of
+	// the contract, which the contract uses to measure time.
+	BlockCountId = ContractUID + "BlockCount"
+
+	// ShuffleTakeoverBlocks is the number of blocks, see BlockCountId,
+	// without a new batch after which another node can start over a round
+	// that is shuffled in batches.
+	ShuffleTakeoverBlocks = 10
 )
 
 // commands defines the commands of the evoting contract. Using an interface
@@ -101,6 +160,8 @@ type commands interface {
 	combineShares(snap store.Snapshot, step execution.Step) error
 	cancelForm(snap store.Snapshot, step execution.Step) error
 	deleteForm(snap store.Snapshot, step execution.Step) error
+	migrateForm(snap store.Snapshot, step execution.Step) error
+	auditBallot(snap store.Snapshot, step execution.Step) error
 	manageAdminOperatorList(snap store.Snapshot, step execution.Step) error
 	manageOwnersVotersForm(snap store.Snapshot, step execution.Step) error
 }
@@ -131,6 +192,9 @@ const (
 	// CmdDeleteForm is the command to delete a form
 	CmdDeleteForm Command = "DELETE_FORM"
 
+	// CmdMigrateForm is the command to store a form in another format
+	CmdMigrateForm Command = "MIGRATE_FORM"
+
 	// CmdAddAdmin is the command to add an admin to the system
 	CmdAddAdmin Command = "ADD_ADMIN"
 	// CmdRemoveAdmin is the command to remove an admin to the system
@@ -150,6 +214,14 @@ const (
 	CmdAddVoterForm Command = "ADD_VOTER"
 	// CmdRemoveVoterForm is the command to remove an Voter to a form
 	CmdRemoveVoterForm Command = "REMOVE_VOTER"
+
+	// CmdAddCredential is the command to add a credential to the electoral
+	// roll of an anonymous form
+	CmdAddCredential Command = "ADD_CREDENTIAL"
+
+	// CmdAuditBallot is the command to audit a ballot, which can't be cast
+	// anymore
+	CmdAuditBallot Command = "AUDIT_BALLOT"
 )
 
 // NewCreds creates new credentials for a evoting contract execution. We might
@@ -212,10 +284,10 @@ func NewContract(srvc access.Service,
 }
 
 // Execute implements native.Contract
-func (c Contract) Execute(snap store.Snapshot, step execution.Step) error {
+func (c Contract) Execute(snap store.Snapshot, step execution.Step) (err error) {
 	creds := NewCreds()
 
-	err := c.access.Match(snap, creds, step.Current.GetIdentity())
+	err = c.access.Match(snap, creds, step.Current.GetIdentity())
 	if err != nil {
 		return xerrors.Errorf("identity not authorized: %v (%v)",
 			step.Current.GetIdentity(), err)
@@ -226,6 +298,35 @@ func (c Contract) Execute(snap store.Snapshot, step execution.Step) error {
 		return xerrors.Errorf("%q not found in tx arg", CmdArg)
 	}
 
+	err = countBlock(snap, step)
+	if err != nil {
+		return xerrors.Errorf("failed to count block: %v", err)
+	}
+
+	// the execution continues the trace of the request that submitted the
+	// transaction, if any.
+	ctx := tracing.Extract(context.Background(), step.Current.GetArg(TraceArg))
+
+	_, span := tracing.Tracer().Start(ctx, "contract execution",
+		trace.WithAttributes(attribute.String("dvoting.command", cmd)))
+
+	// the time is only observed for the known commands, so that a transaction
+	// can't add labels to the histogram.
+	start := time.Now()
+	known := true
+
+	defer func() {
+		if known {
+			PromCommandDuration.WithLabelValues(cmd).Observe(time.Since(start).Seconds())
+		}
+
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
 	switch Command(cmd) {
 	case CmdCreateForm:
 		err = c.cmd.createForm(snap, step)
@@ -272,6 +373,11 @@ func (c Contract) Execute(snap store.Snapshot, step execution.Step) error {
 		if err != nil {
 			return xerrors.Errorf("failed to delete form: %v", err)
 		}
+	case CmdMigrateForm:
+		err := c.cmd.migrateForm(snap, step)
+		if err != nil {
+			return xerrors.Errorf("failed to migrate form: %v", err)
+		}
 	case CmdAddAdmin:
 		err := c.cmd.manageAdminOperatorList(snap, step)
 		if err != nil {
@@ -312,7 +418,18 @@ func (c Contract) Execute(snap store.Snapshot, step execution.Step) error {
 		if err != nil {
 			return xerrors.Errorf("failed to remove voter: %v", err)
 		}
+	case CmdAddCredential:
+		err := c.cmd.manageOwnersVotersForm(snap, step)
+		if err != nil {
+			return xerrors.Errorf("failed to add credential: %v", err)
+		}
+	case CmdAuditBallot:
+		err := c.cmd.auditBallot(snap, step)
+		if err != nil {
+			return xerrors.Errorf("failed to audit ballot: %v", err)
+		}
 	default:
+		known = false
 		return xerrors.Errorf("unknown command: %s", cmd)
 	}
 
@@ -331,5 +448,11 @@ func init() {
 		PromFormStatus,
 		PromFormBallots,
 		PromFormShufflingInstances,
-		PromFormPubShares)
+		PromFormPubShares,
+		PromFormDkgStatus,
+		PromCommandDuration,
+		PromShuffleProofGeneration,
+		PromShuffleProofVerification,
+		PromDkgPhaseDuration,
+		PromPubSharesComputation)
 }
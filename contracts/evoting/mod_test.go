@@ -22,10 +22,12 @@ import (
 	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 	sjson "go.dedis.ch/dela/serde/json"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/proof"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
 	"go.dedis.ch/kyber/v3/util/random"
 )
 
@@ -63,6 +65,8 @@ func fakeProver(proof.Suite, string, proof.Verifier, []byte) error {
 }
 
 func TestExecute(t *testing.T) {
+	initMetrics()
+
 	fakeDkg := fakeDKG{
 		actor: fakeDkgActor{},
 		err:   nil,
@@ -101,6 +105,9 @@ func TestExecute(t *testing.T) {
 	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdCancelForm)))
 	require.EqualError(t, err, fake.Err("failed to cancel form"))
 
+	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdMigrateForm)))
+	require.EqualError(t, err, fake.Err("failed to migrate form"))
+
 	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdAddAdmin)))
 	require.EqualError(t, err, fake.Err("failed to add admin"))
 
@@ -113,8 +120,18 @@ func TestExecute(t *testing.T) {
 	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdRemoveOperator)))
 	require.EqualError(t, err, fake.Err("failed to remove operator"))
 
+	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdAddCredential)))
+	require.EqualError(t, err, fake.Err("failed to add credential"))
+
+	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdAuditBallot)))
+	require.EqualError(t, err, fake.Err("failed to audit ballot"))
+
+	// the unknown commands are not observed
+	require.Equal(t, 13, testutil.CollectAndCount(PromCommandDuration))
+
 	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, "fake"))
 	require.EqualError(t, err, "unknown command: fake")
+	require.Equal(t, 13, testutil.CollectAndCount(PromCommandDuration))
 
 	contract.cmd = fakeCmd{}
 	err = contract.Execute(fakeStore{}, makeStep(t, CmdArg, string(CmdCreateForm)))
@@ -228,10 +245,100 @@ func TestCommand_CreateForm(t *testing.T) {
 	require.True(t, ok)
 
 	require.Equal(t, types.Initial, form.Status)
+
+	// the mixers only decide the number of shuffles, the pubShares needed
+	// still depend on the roster
+	mixer, err := bls.NewSigner().GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	createForm = types.CreateForm{
+		UserID:         dummyUserAdminID,
+		Mixers:         [][]byte{mixer},
+		MixerThreshold: 1,
+	}
+	data, err = createForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	step = makeStep(t, FormArg, string(data))
+	err = cmd.createForm(snap, step)
+	require.NoError(t, err)
+
+	h = sha256.New()
+	h.Write(step.Current.GetID())
+	formIDBuff = h.Sum(nil)
+
+	res, err = snap.Get(formIDBuff)
+	require.NoError(t, err)
+
+	message, err = formFac.Deserialize(ctx, res)
+	require.NoError(t, err)
+
+	form, ok = message.(types.Form)
+	require.True(t, ok)
+
+	// the roster of the fake factory is empty
+	require.Equal(t, 0, form.ShuffleThreshold)
+	require.Equal(t, 1, form.MixerThreshold)
+	require.Equal(t, 1, form.ShuffleRounds())
 }
 
 func TestCommand_OpenForm(t *testing.T) {
-	// TODO
+	initMetrics()
+
+	dummyForm, contract := initFormAndContract(123456)
+	cmd := evotingCommand{
+		Contract: &contract,
+	}
+
+	snap := fake.NewSnapshot()
+	setForm(t, snap, dummyForm)
+	initAdminList(t, snap, cmd)
+
+	ceremonyID := hex.EncodeToString([]byte("ceremony"))
+
+	openForm := types.OpenForm{
+		FormID:     fakeFormID,
+		UserID:     dummyUserAdminID,
+		CeremonyID: ceremonyID,
+	}
+
+	data, err := openForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	open := func(ceremony fakeDkgActor) error {
+		contract := NewContract(fakeAccess{}, fakeDKG{actor: ceremony, ceremony: true},
+			fakeAuthorityFactory{})
+
+		cmd := evotingCommand{
+			Contract: &contract,
+		}
+
+		return cmd.openForm(snap, makeStep(t, FormArg, string(data)))
+	}
+
+	pubkey := suite.Point().Pick(suite.RandomStream())
+
+	err = open(fakeDkgActor{err: fake.GetError()})
+	require.EqualError(t, err, "failed to get participants of key ceremony: "+
+		fake.GetError().Error())
+
+	// the roster of the form is empty, so the node of the ceremony holds a
+	// share of the key that the nodes of the form don't have.
+	err = open(fakeDkgActor{
+		publicKey:    pubkey,
+		participants: []mino.Address{fake.NewAddress(0)},
+	})
+	require.EqualError(t, err, fmt.Sprintf("the participants of key ceremony "+
+		"%q are not the roster of the form", ceremonyID))
+
+	err = open(fakeDkgActor{publicKey: pubkey})
+	require.NoError(t, err)
+
+	form, _, err := cmd.getForm(fakeFormID, snap)
+	require.NoError(t, err)
+	require.Equal(t, types.Open, form.Status)
+	require.Equal(t, ceremonyID, form.CeremonyID)
+	require.True(t, pubkey.Equal(form.Pubkey))
 }
 
 /*
@@ -386,6 +493,398 @@ func TestCommand_CastVote(t *testing.T) {
 	require.Equal(t, float64(form.BallotCount), testutil.ToFloat64(PromFormBallots))
 }
 
+func TestCommand_CastVoteSigned(t *testing.T) {
+	initMetrics()
+
+	voterSecret := suite.Scalar().Pick(suite.RandomStream())
+	voterKey, err := suite.Point().Mul(voterSecret, nil).MarshalBinary()
+	require.NoError(t, err)
+
+	dummyForm, contract := initFormAndContract(123456)
+	dummyForm.Status = types.Open
+	dummyForm.BallotSize = 29
+
+	formBuf, err := dummyForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	snap := fake.NewSnapshot()
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	cmd := evotingCommand{
+		Contract: &contract,
+	}
+
+	initAdminList(t, snap, cmd)
+
+	addVoter := types.AddVoter{
+		FormID:           fakeFormID,
+		TargetUserID:     dummyUserAdminID,
+		PerformingUserID: dummyUserAdminID,
+		PublicKey:        voterKey,
+	}
+
+	data, err := addVoter.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.manageOwnersVotersForm(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	// the key can't be replaced once the form is open
+	err = cmd.manageOwnersVotersForm(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "couldn't register voter key: the key of voter "+
+		"123456 can't be changed once the form is open")
+
+	castVote := types.CastVote{
+		FormID:  fakeFormID,
+		VoterID: dummyUserAdminID,
+		Ballot: types.Ciphervote{types.EGPair{
+			K: suite.Point().Pick(suite.RandomStream()),
+			C: suite.Point().Pick(suite.RandomStream()),
+		}},
+		Sequence: 1,
+	}
+
+	data, err = castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "failed to check voter signature: the vote of "+
+		"voter 123456 must be signed")
+
+	hash, err := castVote.Hash()
+	require.NoError(t, err)
+
+	// signed by someone else
+	castVote.Signature, err = schnorr.Sign(suite, suite.Scalar().Pick(suite.RandomStream()), hash)
+	require.NoError(t, err)
+
+	data, err = castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.ErrorContains(t, err, "failed to check voter signature: signature "+
+		"does not match the vote")
+
+	castVote.Signature, err = schnorr.Sign(suite, voterSecret, hash)
+	require.NoError(t, err)
+
+	data, err = castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	res, err := snap.Get(dummyFormIDBuff)
+	require.NoError(t, err)
+
+	message, err := formFac.Deserialize(ctx, res)
+	require.NoError(t, err)
+
+	form := message.(types.Form)
+	require.Equal(t, uint32(1), form.BallotCount)
+	require.Equal(t, voterKey, form.VoterKeys[123456])
+
+	// the ballot is bound to the form
+	castVote.FormID = fakeFormID + "00"
+
+	err = checkVoterSignature(form, castVote)
+	require.ErrorContains(t, err, "signature does not match the vote")
+
+	castVote.FormID = fakeFormID
+
+	// the signed vote can't be replayed after a re-vote
+	newVote := castVote
+	newVote.Sequence = 2
+
+	hash, err = newVote.Hash()
+	require.NoError(t, err)
+
+	newVote.Signature, err = schnorr.Sign(suite, voterSecret, hash)
+	require.NoError(t, err)
+
+	data, err = newVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	data, err = castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "failed to update sequence: the vote is older "+
+		"than the previous vote of the voter: sequence 1 <= 2")
+
+	data, err = newVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "failed to update sequence: the vote is older "+
+		"than the previous vote of the voter: sequence 2 <= 2")
+}
+
+func TestCommand_CastVoteAnonymous(t *testing.T) {
+	initMetrics()
+
+	dummyForm, contract := initFormAndContract(123456)
+	dummyForm.BallotSize = 29
+
+	formBuf, err := dummyForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	snap := fake.NewSnapshot()
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	cmd := evotingCommand{
+		Contract: &contract,
+	}
+
+	initAdminList(t, snap, cmd)
+
+	secrets := make([]kyber.Scalar, 3)
+
+	addCredential := func(i int) error {
+		secrets[i] = suite.Scalar().Pick(suite.RandomStream())
+
+		publicKey, err := suite.Point().Mul(secrets[i], nil).MarshalBinary()
+		require.NoError(t, err)
+
+		tx := types.AddCredential{
+			FormID:           fakeFormID,
+			PublicKey:        publicKey,
+			PerformingUserID: dummyUserAdminID,
+		}
+
+		data, err := tx.Serialize(ctx)
+		require.NoError(t, err)
+
+		return cmd.manageOwnersVotersForm(snap, makeStep(t, FormArg, string(data)))
+	}
+
+	err = addCredential(0)
+	require.EqualError(t, err, "couldn't add credential: the form is not anonymous")
+
+	dummyForm.Anonymous = true
+
+	formBuf, err = dummyForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	for i := range secrets {
+		err = addCredential(i)
+		require.NoError(t, err)
+	}
+
+	err = addCredential(1)
+	require.ErrorContains(t, err, "is already in the roll")
+
+	form, _, err := cmd.getForm(fakeFormID, snap)
+	require.NoError(t, err)
+	require.Equal(t, 3, form.ElectoralRoll.Size)
+
+	roll, err := form.GetElectoralRoll(snap)
+	require.NoError(t, err)
+	require.Len(t, roll, 3)
+
+	form.Status = types.Open
+
+	formBuf, err = form.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	// the roll can't be changed once the form is open
+	err = addCredential(0)
+	require.ErrorContains(t, err, "couldn't add credential: the electoral roll "+
+		"can't be changed once the form is open")
+
+	castVote := types.CastVote{
+		FormID: fakeFormID,
+		Ballot: types.Ciphervote{types.EGPair{
+			K: suite.Point().Pick(suite.RandomStream()),
+			C: suite.Point().Pick(suite.RandomStream()),
+		}},
+		Sequence: 1,
+	}
+
+	castAnonymous := func(castVote types.CastVote) error {
+		data, err := castVote.Serialize(ctx)
+		require.NoError(t, err)
+
+		return cmd.castVote(snap, makeStep(t, FormArg, string(data)))
+	}
+
+	err = castAnonymous(castVote)
+	require.ErrorContains(t, err, "failed to verify credential: invalid credential")
+
+	// a credential that is not in the roll
+	castVote.Credential, err = types.SignCredential(castVote, roll, 1,
+		suite.Scalar().Pick(suite.RandomStream()))
+	require.NoError(t, err)
+
+	err = castAnonymous(castVote)
+	require.ErrorContains(t, err, "failed to verify credential: invalid credential")
+
+	castVote.Credential, err = types.SignCredential(castVote, roll, 1, secrets[1])
+	require.NoError(t, err)
+
+	err = castAnonymous(castVote)
+	require.NoError(t, err)
+
+	firstVote := castVote
+
+	// the voter votes again, which replaces its first ballot
+	castVote.Sequence = 2
+
+	castVote.Credential, err = types.SignCredential(castVote, roll, 1, secrets[1])
+	require.NoError(t, err)
+
+	err = castAnonymous(castVote)
+	require.NoError(t, err)
+
+	// the first vote can't be replayed
+	err = castAnonymous(firstVote)
+	require.EqualError(t, err, "failed to update sequence: the vote is older "+
+		"than the previous vote of the voter: sequence 1 <= 2")
+
+	castVote.Credential, err = types.SignCredential(castVote, roll, 2, secrets[2])
+	require.NoError(t, err)
+
+	err = castAnonymous(castVote)
+	require.NoError(t, err)
+
+	castVote.VoterID = dummyUserAdminID
+
+	err = castAnonymous(castVote)
+	require.EqualError(t, err, "failed to verify credential: an anonymous vote "+
+		"must not have a voter ID")
+
+	form, _, err = cmd.getForm(fakeFormID, snap)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), form.BallotCount)
+
+	suff, err := form.Suffragia(ctx, snap)
+	require.NoError(t, err)
+	require.Len(t, suff.VoterIDs, 2)
+	require.NotEqual(t, suff.VoterIDs[0], suff.VoterIDs[1])
+
+	for _, voterID := range suff.VoterIDs {
+		require.NotContains(t, voterID, dummyUserAdminID)
+	}
+}
+
+func TestCommand_AuditBallot(t *testing.T) {
+	initMetrics()
+
+	secret := suite.Scalar().Pick(suite.RandomStream())
+
+	dummyForm, contract := initFormAndContract(123456)
+	dummyForm.Pubkey = suite.Point().Mul(secret, nil)
+	dummyForm.BallotSize = 29
+	dummyForm.Voters = []int{234567}
+
+	cmd := evotingCommand{
+		Contract: &contract,
+	}
+
+	ballot, randomness := encryptBallot(t, dummyForm.Pubkey, "select:abc")
+
+	auditBallot := types.AuditBallot{
+		FormID:     fakeFormID,
+		Ballot:     ballot,
+		Randomness: randomness,
+	}
+
+	data, err := auditBallot.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.auditBallot(fake.NewSnapshot(), makeStep(t))
+	require.EqualError(t, err, getTransactionErr)
+
+	err = cmd.auditBallot(fake.NewSnapshot(), makeStep(t, FormArg, "dummy"))
+	require.EqualError(t, err, unmarshalTransactionErr)
+
+	snap := fake.NewSnapshot()
+	setForm(t, snap, dummyForm)
+
+	err = cmd.auditBallot(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, fmt.Sprintf("the form is not open, current status: %d", types.Initial))
+
+	dummyForm.Status = types.Open
+	setForm(t, snap, dummyForm)
+
+	// the randomness of another ballot doesn't audit the ballot
+	_, otherRandomness := encryptBallot(t, dummyForm.Pubkey, "select:abc")
+	auditBallot.Randomness = otherRandomness
+
+	badData, err := auditBallot.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.auditBallot(snap, makeStep(t, FormArg, string(badData)))
+	require.EqualError(t, err, "failed to audit ballot: pair 0 is not encrypted with its randomness")
+
+	formBuf, err := snap.Get(dummyFormIDBuff)
+	require.NoError(t, err)
+
+	err = cmd.auditBallot(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	// the audit is stored apart from the form
+	hash, err := ballot.Hash()
+	require.NoError(t, err)
+
+	audited, err := dummyForm.IsAudited(snap, hash)
+	require.NoError(t, err)
+	require.True(t, audited)
+
+	newFormBuf, err := snap.Get(dummyFormIDBuff)
+	require.NoError(t, err)
+	require.Equal(t, formBuf, newFormBuf)
+
+	err = cmd.auditBallot(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "the ballot has already been audited")
+
+	// the audited ballot can't be cast anymore
+	castVote := types.CastVote{
+		FormID:  fakeFormID,
+		VoterID: "234567",
+		Ballot:  ballot,
+	}
+
+	castData, err := castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(castData)))
+	require.EqualError(t, err, "the ballot has been audited")
+
+	// a cast ballot can't be audited anymore
+	ballot, randomness = encryptBallot(t, dummyForm.Pubkey, "select:def")
+	castVote.Ballot = ballot
+
+	castData, err = castVote.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.castVote(snap, makeStep(t, FormArg, string(castData)))
+	require.NoError(t, err)
+
+	auditBallot = types.AuditBallot{
+		FormID:     fakeFormID,
+		Ballot:     ballot,
+		Randomness: randomness,
+	}
+
+	data, err = auditBallot.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.auditBallot(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "the ballot has already been cast")
+}
+
 func TestCommand_CloseForm(t *testing.T) {
 	initMetrics()
 
@@ -487,8 +986,10 @@ func TestCommand_ShuffleBallotsCannotShuffleTwice(t *testing.T) {
 	// Attempts to shuffle twice :
 	shuffleBallots.Round = 1
 
-	form.ShuffleInstances = make([]types.ShuffleInstance, 1)
-	form.ShuffleInstances[0].ShuffledBallots = make([]types.Ciphervote, 3)
+	shuffleInstance := types.ShuffleInstance{
+		ShuffledBallots:   make([]types.Ciphervote, 3),
+		ShufflerPublicKey: shuffleBallots.PublicKey,
+	}
 
 	Ks, Cs, _ := fakeKCPoints(k)
 	for i := 0; i < k; i++ {
@@ -496,10 +997,10 @@ func TestCommand_ShuffleBallotsCannotShuffleTwice(t *testing.T) {
 			K: Ks[i],
 			C: Cs[i],
 		}}
-		form.ShuffleInstances[0].ShuffledBallots[i] = ballot
+		shuffleInstance.ShuffledBallots[i] = ballot
 	}
 
-	form.ShuffleInstances[0].ShufflerPublicKey = shuffleBallots.PublicKey
+	storeShuffles(t, snap, &form, shuffleInstance)
 
 	formBuff, err := form.Serialize(ctx)
 	require.NoError(t, err)
@@ -551,13 +1052,13 @@ func TestCommand_ShuffleBallotsValidScenarios(t *testing.T) {
 
 	// Valid Shuffle is over :
 	shuffleBallots.Round = k
-	form.ShuffleInstances = make([]types.ShuffleInstance, k)
+	shuffleInstances := make([]types.ShuffleInstance, k)
 
 	data, err = shuffleBallots.Serialize(ctx)
 	require.NoError(t, err)
 
 	for i := 1; i <= k-1; i++ {
-		form.ShuffleInstances[i].ShuffledBallots = make([]types.Ciphervote, 3)
+		shuffleInstances[i].ShuffledBallots = make([]types.Ciphervote, 3)
 	}
 
 	Ks, Cs, _ := fakeKCPoints(k)
@@ -566,9 +1067,11 @@ func TestCommand_ShuffleBallotsValidScenarios(t *testing.T) {
 			K: Ks[i],
 			C: Cs[i],
 		}}
-		form.ShuffleInstances[k-1].ShuffledBallots[i] = ballot
+		shuffleInstances[k-1].ShuffledBallots[i] = ballot
 	}
 
+	storeShuffles(t, snap, &form, shuffleInstances...)
+
 	formBuf, err = form.Serialize(ctx)
 	require.NoError(t, err)
 
@@ -583,17 +1086,112 @@ func TestCommand_ShuffleBallotsValidScenarios(t *testing.T) {
 	formTxIDBuff, err := hex.DecodeString(form.FormID)
 	require.NoError(t, err)
 
-	formBuf, err = snap.Get(formTxIDBuff)
+	formBuf, err = snap.Get(formTxIDBuff)
+	require.NoError(t, err)
+
+	message, err := formFac.Deserialize(ctx, formBuf)
+	require.NoError(t, err)
+
+	form, ok := message.(types.Form)
+	require.True(t, ok)
+
+	require.Equal(t, types.ShuffledBallots, form.Status)
+	require.Equal(t, float64(types.ShuffledBallots), testutil.ToFloat64(PromFormStatus))
+}
+
+func TestCommand_ShuffleBallotsMixers(t *testing.T) {
+	initMetrics()
+
+	snap, form, shuffleBallots, contract := initGoodShuffleBallot(t, 3)
+
+	cmd := evotingCommand{
+		Contract: &contract,
+		prover:   fakeProver,
+	}
+
+	otherMixer, err := bls.NewSigner().GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	// mixers don't need to be owners of the form, nor members of the roster
+	form.Mixers = [][]byte{otherMixer}
+
+	formBuf, err := form.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	data, err := shuffleBallots.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.shuffleBallots(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, fmt.Sprintf("could not verify identity of mixer : "+
+		"public key not associated to a mixer of the form: %x", shuffleBallots.PublicKey))
+
+	form.Mixers = [][]byte{otherMixer, shuffleBallots.PublicKey}
+
+	// the mixer threshold is reached before the one of the roster
+	form.ShuffleThreshold = 3
+	form.MixerThreshold = 1
+
+	formBuf, err = form.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	err = cmd.shuffleBallots(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	form = getForm(t, snap)
+	require.Equal(t, types.ShuffledBallots, form.Status)
+	require.Equal(t, 3, form.ShuffleThreshold)
+
+	// the mixer threshold is above the one of the roster
+	snap, form, shuffleBallots, _ = initGoodShuffleBallot(t, 3)
+
+	form.Mixers = [][]byte{otherMixer, shuffleBallots.PublicKey}
+	form.ShuffleThreshold = 1
+	form.MixerThreshold = 2
+
+	formBuf, err = form.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	data, err = shuffleBallots.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.shuffleBallots(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	form = getForm(t, snap)
+	require.Equal(t, types.Closed, form.Status)
+	require.Len(t, form.ShuffleInstances, 1)
+}
+
+func TestCheckMixers(t *testing.T) {
+	mixer1, err := bls.NewSigner().GetPublicKey().MarshalBinary()
 	require.NoError(t, err)
 
-	message, err := formFac.Deserialize(ctx, formBuf)
+	mixer2, err := bls.NewSigner().GetPublicKey().MarshalBinary()
 	require.NoError(t, err)
 
-	form, ok := message.(types.Form)
-	require.True(t, ok)
+	require.NoError(t, checkMixers(nil, 0))
+	require.NoError(t, checkMixers([][]byte{mixer1, mixer2}, 2))
 
-	require.Equal(t, types.ShuffledBallots, form.Status)
-	require.Equal(t, float64(types.ShuffledBallots), testutil.ToFloat64(PromFormStatus))
+	err = checkMixers(nil, 1)
+	require.EqualError(t, err, "threshold set without mixers: 1")
+
+	err = checkMixers([][]byte{mixer1}, 2)
+	require.EqualError(t, err, "threshold must be between 1 and 1: 2")
+
+	err = checkMixers([][]byte{mixer1, mixer1}, 1)
+	require.EqualError(t, err, fmt.Sprintf("duplicated mixer: %x", mixer1))
+
+	err = checkMixers([][]byte{[]byte("bad")}, 1)
+	require.ErrorContains(t, err, "failed to decode public key of mixer 0")
 }
 
 func TestCommand_ShuffleBallotsFormatErrors(t *testing.T) {
@@ -655,7 +1253,7 @@ func TestCommand_ShuffleBallotsFormatErrors(t *testing.T) {
 
 	// Missing public key of shuffler:
 	shuffleBallots.Round = 1
-	form.ShuffleInstances = make([]types.ShuffleInstance, 1)
+	storeShuffles(t, snap, &form, types.ShuffleInstance{})
 	shuffleBallots.PublicKey = []byte("wrong Key")
 
 	data, err = shuffleBallots.Serialize(ctx)
@@ -785,7 +1383,7 @@ func TestCommand_ShuffleBallotsFormatErrors(t *testing.T) {
 
 	form.Pubkey = pubKey
 	shuffleBallots.Round = 0
-	form.ShuffleInstances = make([]types.ShuffleInstance, 0)
+	form.ShuffleInstances = make([]types.ShuffleRef, 0)
 
 	data, err = shuffleBallots.Serialize(ctx)
 	require.NoError(t, err)
@@ -818,6 +1416,161 @@ func TestCommand_ShuffleBallotsFormatErrors(t *testing.T) {
 	require.EqualError(t, err, "not enough votes: 1 < 2")
 }
 
+func TestCommand_ShuffleBallotsBatches(t *testing.T) {
+	k := 10
+	batchSize := types.MinShuffleBatchSize
+
+	Ks, Cs, _ := fakeKCPoints(k)
+
+	ciphervotes := make([]types.Ciphervote, k)
+	for i := 0; i < k; i++ {
+		ciphervotes[i] = types.Ciphervote{types.EGPair{
+			K: Ks[i],
+			C: Cs[i],
+		}}
+	}
+
+	_, contract := initFormAndContract(123456)
+	cmd := evotingCommand{Contract: &contract}
+
+	form := types.Form{FormID: fakeFormID}
+	snap := fake.NewSnapshot()
+
+	shuffleBallots := types.ShuffleBallots{
+		Round:      0,
+		PublicKey:  []byte("shuffler"),
+		BatchSize:  2,
+		BatchIndex: 0,
+	}
+
+	_, err := checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.EqualError(t, err, "batch size is too small: 2 < 4")
+
+	shuffleBallots.BatchSize = batchSize
+	shuffleBallots.BatchIndex = 1
+
+	_, err = checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.EqualError(t, err, "wrong batch index: expected 0, got 1")
+
+	shuffleBallots.BatchIndex = 0
+
+	_, err = checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.EqualError(t, err, "unexpected number of shuffled ballots: 0 != 3")
+
+	count := types.ShuffleBatchCount(k, batchSize)
+
+	for i := 0; i < count; i++ {
+		shuffleBallots.BatchIndex = i
+		shuffleBallots.ShuffledBallots = types.SelectCiphervotes(ciphervotes,
+			types.ShuffleBatch(0, k, batchSize, i))
+
+		batch, err := checkShuffleBatch(form, shuffleBallots, ciphervotes)
+		require.NoError(t, err)
+		require.Equal(t, shuffleBallots.ShuffledBallots, batch)
+
+		err = cmd.addShuffleBatch(snap, &form, shuffleBallots, k, uint64(i))
+		require.NoError(t, err)
+
+		if i < count-1 {
+			require.NotNil(t, form.PendingShuffle)
+			require.Equal(t, i+1, form.PendingShuffle.Batches)
+			require.Equal(t, uint64(i), form.PendingShuffle.LastBlock)
+			require.Len(t, form.ShuffleInstances, 0)
+
+			pending, err := form.Pending(ctx, snap)
+			require.NoError(t, err)
+			require.Len(t, pending.BatchProofs, i+1)
+		}
+	}
+
+	// the round is complete
+	require.Nil(t, form.PendingShuffle)
+	require.Len(t, form.ShuffleInstances, 1)
+	require.Equal(t, k, form.ShuffleInstances[0].BallotCount)
+	require.Equal(t, count, form.ShuffleInstances[0].Batches)
+	require.Equal(t, batchSize, form.ShuffleInstances[0].BatchSize)
+
+	shuffleInstance, err := form.LastShuffle(ctx, snap)
+	require.NoError(t, err)
+	require.Len(t, shuffleInstance.ShuffledBallots, k)
+	require.Len(t, shuffleInstance.BatchProofs, count)
+
+	// only the node that started the round can continue it
+	form.PendingShuffle = &types.ShuffleRef{
+		ShufflerPublicKey: []byte("other"),
+		BatchSize:         batchSize,
+	}
+
+	_, err = checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.EqualError(t, err, "round 0 is being shuffled by another node")
+
+	form.PendingShuffle.ShufflerPublicKey = shuffleBallots.PublicKey
+	form.PendingShuffle.BatchSize = batchSize + 1
+
+	_, err = checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.EqualError(t, err, "wrong batch size: expected 5, got 4")
+
+	// another node can start the round over once the pending shuffle is
+	// stalled
+	form.PendingShuffle = &types.ShuffleRef{
+		ShufflerPublicKey: []byte("other"),
+		BatchSize:         batchSize,
+		Batches:           1,
+		LastBlock:         5,
+	}
+
+	shuffleBallots.BatchIndex = 0
+	shuffleBallots.ShuffledBallots = types.SelectCiphervotes(ciphervotes,
+		types.ShuffleBatch(0, k, batchSize, 0))
+
+	dropStalledShuffle(&form, shuffleBallots, 5+ShuffleTakeoverBlocks-1)
+	require.NotNil(t, form.PendingShuffle)
+
+	shuffleBallots.BatchIndex = 1
+
+	dropStalledShuffle(&form, shuffleBallots, 5+ShuffleTakeoverBlocks)
+	require.NotNil(t, form.PendingShuffle)
+
+	shuffleBallots.BatchIndex = 0
+
+	dropStalledShuffle(&form, shuffleBallots, 5+ShuffleTakeoverBlocks)
+	require.Nil(t, form.PendingShuffle)
+
+	_, err = checkShuffleBatch(form, shuffleBallots, ciphervotes)
+	require.NoError(t, err)
+}
+
+func TestCountBlock(t *testing.T) {
+	snap := fake.NewSnapshot()
+
+	count, err := GetBlockCount(snap)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), count)
+
+	err = countBlock(snap, makeStep(t))
+	require.NoError(t, err)
+
+	// a transaction of the contract was executed before in the block
+	step := makeStep(t)
+	step.Previous = []txn.Transaction{makeTx(t, native.ContractArg, ContractName)}
+
+	err = countBlock(snap, step)
+	require.NoError(t, err)
+
+	// only transactions of other contracts were executed before
+	step.Previous = []txn.Transaction{makeTx(t, native.ContractArg, "other")}
+
+	err = countBlock(snap, step)
+	require.NoError(t, err)
+
+	count, err = GetBlockCount(snap)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	err = countBlock(fake.NewBadSnapshot(), makeStep(t))
+	require.ErrorContains(t, err, "failed to get block count")
+}
+
 func TestCommand_RegisterPubShares(t *testing.T) {
 	registerPubShares := types.RegisterPubShares{
 		FormID:    fakeFormID,
@@ -865,18 +1618,18 @@ func TestCommand_RegisterPubShares(t *testing.T) {
 	// Requirements:
 	form.Status = types.ShuffledBallots
 	form.PubsharesUnits = types.PubsharesUnits{
-		Pubshares: make([]types.PubsharesUnit, 0),
-		PubKeys:   make([][]byte, 0),
-		Indexes:   make([]int, 0),
-	}
-	form.ShuffleInstances = make([]types.ShuffleInstance, 1)
-	form.ShuffleInstances[0] = types.ShuffleInstance{
-		ShuffledBallots: make([]types.Ciphervote, 1),
+		IDs:     make([][]byte, 0),
+		Hashes:  make([][]byte, 0),
+		PubKeys: make([][]byte, 0),
+		Indexes: make([]int, 0),
 	}
-	form.ShuffleInstances[0].ShuffledBallots[0] = types.Ciphervote{types.EGPair{
-		K: suite.Point(),
-		C: suite.Point(),
-	}}
+
+	storeShuffles(t, snap, &form, types.ShuffleInstance{
+		ShuffledBallots: []types.Ciphervote{{types.EGPair{
+			K: suite.Point(),
+			C: suite.Point(),
+		}}},
+	})
 
 	formBuf, err = form.Serialize(ctx)
 	require.NoError(t, err)
@@ -1020,6 +1773,11 @@ func TestCommand_RegisterPubShares(t *testing.T) {
 
 	require.Equal(t, resultForm.PubsharesUnits.PubKeys[0], registerPubShares.PublicKey)
 	require.Equal(t, resultForm.PubsharesUnits.Indexes[0], registerPubShares.Index)
+
+	units, err := resultForm.Pubshares(ctx, snap)
+	require.NoError(t, err)
+	require.Len(t, units, 1)
+	require.True(t, units[0][0][0].Equal(registerPubShares.Pubshares[0][0]))
 }
 
 func TestCommand_DecryptBallots(t *testing.T) {
@@ -1069,14 +1827,15 @@ func TestCommand_DecryptBallots(t *testing.T) {
 	dummyForm.Status = types.PubSharesSubmitted
 
 	// Avoid panic (will always be the case in practice):
-	dummyForm.ShuffleInstances = make([]types.ShuffleInstance, 1)
-	dummyForm.ShuffleInstances[0] = types.ShuffleInstance{
+	shuffleInstance := types.ShuffleInstance{
 		ShuffledBallots:   make([]types.Ciphervote, 1),
 		ShuffleProofs:     nil,
 		ShufflerPublicKey: nil,
 	}
 
-	dummyForm.ShuffleInstances[0].ShuffledBallots[0] = types.Ciphervote{}
+	shuffleInstance.ShuffledBallots[0] = types.Ciphervote{}
+
+	storeShuffles(t, snap, &dummyForm, shuffleInstance)
 
 	formBuf, err = dummyForm.Serialize(ctx)
 	require.NoError(t, err)
@@ -1091,12 +1850,14 @@ func TestCommand_DecryptBallots(t *testing.T) {
 	err = cmd.combineShares(snap, makeStep(t, FormArg, string(data)))
 	require.NoError(t, err)
 
-	dummyForm.ShuffleInstances[0].ShuffledBallots[0] = make([]types.EGPair, 1)
-	dummyForm.ShuffleInstances[0].ShuffledBallots[0][0] = types.EGPair{
+	shuffleInstance.ShuffledBallots[0] = make([]types.EGPair, 1)
+	shuffleInstance.ShuffledBallots[0][0] = types.EGPair{
 		K: suite.Point(),
 		C: suite.Point(),
 	}
 
+	storeShuffles(t, snap, &dummyForm, shuffleInstance)
+
 	formBuf, err = dummyForm.Serialize(ctx)
 	require.NoError(t, err)
 
@@ -1181,6 +1942,273 @@ func TestCommand_CancelForm(t *testing.T) {
 	require.Equal(t, float64(types.Canceled), testutil.ToFloat64(PromFormStatus))
 }
 
+func TestCommand_MigrateForm(t *testing.T) {
+	migrateForm := types.MigrateForm{
+		FormID: fakeFormID,
+		UserID: dummyUserAdminID,
+		Format: string(types.FormatProtobuf),
+	}
+
+	data, err := migrateForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	dummyForm, contract := initFormAndContract(123456)
+
+	cmd := evotingCommand{
+		Contract: &contract,
+	}
+
+	err = cmd.migrateForm(fake.NewSnapshot(), makeStep(t))
+	require.EqualError(t, err, getTransactionErr)
+
+	err = cmd.migrateForm(fake.NewSnapshot(), makeStep(t, FormArg, "dummy"))
+	require.EqualError(t, err, unmarshalTransactionErr)
+
+	err = cmd.migrateForm(fake.NewBadSnapshot(), makeStep(t, FormArg, string(data)))
+	require.ErrorContains(t, err, "failed to get key")
+
+	snap := fake.NewSnapshot()
+
+	Ks, Cs, _ := fakeKCPoints(3)
+	for i := range Ks {
+		ciphervote := types.Ciphervote{types.EGPair{K: Ks[i], C: Cs[i]}}
+
+		err = dummyForm.CastVote(ctx, snap, strconv.Itoa(i), ciphervote)
+		require.NoError(t, err)
+	}
+
+	suff, err := dummyForm.Suffragia(ctx, snap)
+	require.NoError(t, err)
+
+	storeShuffles(t, snap, &dummyForm, types.ShuffleInstance{
+		ShuffledBallots:   suff.Ciphervotes,
+		ShufflerPublicKey: []byte("shuffler"),
+	})
+
+	formBuf, err := dummyForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+
+	initAdminList(t, snap, cmd)
+
+	// only the owners and the admins can migrate the form
+	migrateForm.UserID = "111111"
+	data, err = migrateForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.migrateForm(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, fmt.Sprintf(errNoOwnerPerms, "111111"))
+
+	migrateForm.UserID = dummyUserAdminID
+	migrateForm.Format = "XML"
+	data, err = migrateForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.migrateForm(snap, makeStep(t, FormArg, string(data)))
+	require.EqualError(t, err, "failed to migrate ballots: failed to get "+
+		"context: unknown format: \"XML\"")
+
+	migrateForm.Format = string(types.FormatProtobuf)
+	data, err = migrateForm.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = cmd.migrateForm(snap, makeStep(t, FormArg, string(data)))
+	require.NoError(t, err)
+
+	res, err := snap.Get(dummyFormIDBuff)
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(res))
+
+	message, err := formFac.Deserialize(ctx, res)
+	require.NoError(t, err)
+
+	form, ok := message.(types.Form)
+	require.True(t, ok)
+
+	require.Equal(t, types.FormatProtobuf, form.Format)
+	require.Equal(t, dummyForm.SuffragiaIDs, form.SuffragiaIDs)
+
+	// the hashes of the ballots don't depend on their format
+	require.Equal(t, dummyForm.SuffragiaHashes, form.SuffragiaHashes)
+
+	for _, id := range form.SuffragiaIDs {
+		buf, err := snap.Get(id)
+		require.NoError(t, err)
+		require.Equal(t, types.FormatProtobuf, types.FormatOf(buf))
+	}
+
+	migratedSuff, err := form.Suffragia(ctx, snap)
+	require.NoError(t, err)
+	require.Equal(t, suff.VoterIDs, migratedSuff.VoterIDs)
+	require.Len(t, migratedSuff.Ciphervotes, len(suff.Ciphervotes))
+
+	for i, ciphervote := range suff.Ciphervotes {
+		require.True(t, ciphervote.Equal(migratedSuff.Ciphervotes[i]))
+	}
+
+	// the shuffles are migrated as well
+	buf, err := snap.Get(form.ShuffleInstances[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(buf))
+
+	shuffleInstance, err := form.LastShuffle(ctx, snap)
+	require.NoError(t, err)
+	require.Len(t, shuffleInstance.ShuffledBallots, len(suff.Ciphervotes))
+
+	// the new ballots are stored in the format of the form
+	err = form.CastVote(ctx, snap, "3", types.Ciphervote{types.EGPair{K: Ks[0], C: Cs[0]}})
+	require.NoError(t, err)
+
+	buf, err = snap.Get(form.SuffragiaIDs[len(form.SuffragiaIDs)-1])
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(buf))
+}
+
+func TestForm_BallotProof(t *testing.T) {
+	defer func(ballotsPerBlock uint32) {
+		types.BallotsPerBlock = ballotsPerBlock
+	}(types.BallotsPerBlock)
+
+	types.BallotsPerBlock = 2
+
+	dummyForm, _ := initFormAndContract(123456)
+	snap := fake.NewSnapshot()
+
+	require.Nil(t, dummyForm.BallotsHash())
+
+	Ks, Cs, _ := fakeKCPoints(6)
+	ciphervotes := make([]types.Ciphervote, len(Ks))
+
+	for i := range Ks {
+		ciphervotes[i] = types.Ciphervote{types.EGPair{K: Ks[i], C: Cs[i]}}
+	}
+
+	for i := 0; i < 5; i++ {
+		err := dummyForm.CastVote(ctx, snap, strconv.Itoa(i), ciphervotes[i])
+		require.NoError(t, err)
+	}
+
+	// the voter 0 casts a new ballot, which lands in the last block
+	err := dummyForm.CastVote(ctx, snap, "0", ciphervotes[5])
+	require.NoError(t, err)
+
+	require.Len(t, dummyForm.SuffragiaIDs, 3)
+	require.Len(t, dummyForm.SuffragiaHashes, 3)
+	require.Equal(t, dummyForm.SuffragiaHashes[2], dummyForm.BallotsHash())
+
+	proof, found, err := dummyForm.BallotProof(ctx, snap, "2")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 1, proof.Block)
+	require.Equal(t, dummyForm.SuffragiaHashes[0], proof.Previous)
+	require.Len(t, proof.Next, 1)
+
+	err = proof.Verify("2", ciphervotes[2], dummyForm.BallotsHash())
+	require.NoError(t, err)
+
+	// only the last ballot of a voter is counted
+	proof, found, err = dummyForm.BallotProof(ctx, snap, "0")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 2, proof.Block)
+	require.Empty(t, proof.Next)
+
+	err = proof.Verify("0", ciphervotes[5], dummyForm.BallotsHash())
+	require.NoError(t, err)
+
+	err = proof.Verify("0", ciphervotes[0], dummyForm.BallotsHash())
+	require.EqualError(t, err, "the ballot of 0 doesn't match")
+
+	proof, found, err = dummyForm.BallotProof(ctx, snap, "1")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 0, proof.Block)
+
+	err = proof.Verify("1", ciphervotes[1], dummyForm.BallotsHash())
+	require.NoError(t, err)
+
+	_, found, err = dummyForm.BallotProof(ctx, snap, "5")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, _, err = dummyForm.BallotProof(ctx, fake.NewBadSnapshot(), "5")
+	require.ErrorContains(t, err, "couldn't get ballots block")
+}
+
+func TestForm_FindBallot(t *testing.T) {
+	defer func(ballotsPerBlock uint32) {
+		types.BallotsPerBlock = ballotsPerBlock
+	}(types.BallotsPerBlock)
+
+	types.BallotsPerBlock = 2
+
+	dummyForm, _ := initFormAndContract(123456)
+	snap := fake.NewSnapshot()
+
+	Ks, Cs, _ := fakeKCPoints(6)
+	ciphervotes := make([]types.Ciphervote, len(Ks))
+	hashes := make([][]byte, len(Ks))
+
+	for i := range Ks {
+		ciphervotes[i] = types.Ciphervote{types.EGPair{K: Ks[i], C: Cs[i]}}
+
+		var err error
+
+		hashes[i], err = ciphervotes[i].Hash()
+		require.NoError(t, err)
+	}
+
+	voters := []string{"0", "1", "2", "2", "0", "3"}
+
+	for i, voterID := range voters {
+		err := dummyForm.CastVote(ctx, snap, voterID, ciphervotes[i])
+		require.NoError(t, err)
+	}
+
+	location, found, err := dummyForm.FindBallot(snap, hashes[1])
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, types.BallotLocation{
+		Block:     0,
+		BlockID:   dummyForm.SuffragiaIDs[0],
+		VoterID:   "1",
+		Effective: true,
+	}, location)
+
+	// the first ballot of the voter 0 is overridden by the one in block 2
+	location, found, err = dummyForm.FindBallot(snap, hashes[0])
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 0, location.Block)
+	require.False(t, location.Effective)
+
+	location, found, err = dummyForm.FindBallot(snap, hashes[4])
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 2, location.Block)
+	require.True(t, location.Effective)
+
+	// the first ballot of the voter 2 is replaced in its block
+	_, found, err = dummyForm.FindBallot(snap, hashes[2])
+	require.NoError(t, err)
+	require.False(t, found)
+
+	location, found, err = dummyForm.FindBallot(snap, hashes[3])
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, 1, location.Block)
+	require.True(t, location.Effective)
+
+	_, found, err = dummyForm.FindBallot(snap, []byte("unknown"))
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, _, err = dummyForm.FindBallot(fake.NewBadSnapshot(), hashes[0])
+	require.ErrorContains(t, err, "failed to get ballot location")
+}
+
 func TestRegisterContract(t *testing.T) {
 	RegisterContract(native.NewExecution(), Contract{})
 }
@@ -1737,6 +2765,7 @@ func initMetrics() {
 	PromFormBallots.Reset()
 	PromFormShufflingInstances.Reset()
 	PromFormPubShares.Reset()
+	PromCommandDuration.Reset()
 }
 
 func initFormAndContract(initialOwner int) (types.Form, Contract) {
@@ -1749,7 +2778,7 @@ func initFormAndContract(initialOwner int) (types.Form, Contract) {
 		FormID:           fakeFormID,
 		Status:           0,
 		Pubkey:           nil,
-		ShuffleInstances: make([]types.ShuffleInstance, 0),
+		ShuffleInstances: make([]types.ShuffleRef, 0),
 		DecryptedBallots: nil,
 		ShuffleThreshold: 0,
 		Roster:           fake.Authority{},
@@ -1764,6 +2793,56 @@ func initFormAndContract(initialOwner int) (types.Form, Contract) {
 	return dummyForm, contract
 }
 
+// setForm stores the form in the snapshot.
+func setForm(t *testing.T, snap store.Snapshot, form types.Form) {
+	formBuf, err := form.Serialize(ctx)
+	require.NoError(t, err)
+
+	err = snap.Set(dummyFormIDBuff, formBuf)
+	require.NoError(t, err)
+}
+
+func getForm(t *testing.T, snap store.Snapshot) types.Form {
+	formBuf, err := snap.Get(dummyFormIDBuff)
+	require.NoError(t, err)
+
+	message, err := formFac.Deserialize(ctx, formBuf)
+	require.NoError(t, err)
+
+	form, ok := message.(types.Form)
+	require.True(t, ok)
+
+	return form
+}
+
+// encryptBallot encrypts the message in one pair for the public key, and
+// returns the randomness it is encrypted with.
+func encryptBallot(t *testing.T, pubkey kyber.Point, message string) (types.Ciphervote, [][]byte) {
+	M := suite.Point().Embed([]byte(message), random.New())
+
+	k := suite.Scalar().Pick(random.New())
+	K := suite.Point().Mul(k, nil)
+	S := suite.Point().Mul(k, pubkey)
+	C := S.Add(S, M)
+
+	kBuf, err := k.MarshalBinary()
+	require.NoError(t, err)
+
+	return types.Ciphervote{types.EGPair{K: K, C: C}}, [][]byte{kBuf}
+}
+
+// storeShuffles stores the shuffles as the rounds of the form.
+func storeShuffles(t *testing.T, snap store.Snapshot, form *types.Form,
+	shuffleInstances ...types.ShuffleInstance) {
+
+	form.ShuffleInstances = make([]types.ShuffleRef, 0)
+
+	for _, shuffleInstance := range shuffleInstances {
+		err := form.StoreShuffle(ctx, snap, shuffleInstance, true)
+		require.NoError(t, err)
+	}
+}
+
 func initAdminList(t *testing.T, snap store.Snapshot, cmd evotingCommand) store.Snapshot {
 	addAdmin := types.AddAdmin{TargetUserID: otherDummyUserAdminID, PerformingUserID: otherDummyUserAdminID}
 	dataAddAdmin, err := addAdmin.Serialize(ctx)
@@ -1796,7 +2875,7 @@ func initGoodShuffleBallot(t *testing.T, k int) (store.Snapshot, types.Form, typ
 	// Encrypted ballots:
 	form.Pubkey = pubKey
 	shuffleBallots.Round = 0
-	form.ShuffleInstances = make([]types.ShuffleInstance, 0)
+	form.ShuffleInstances = make([]types.ShuffleRef, 0)
 
 	snap := fake.NewSnapshot()
 	for i := 0; i < k; i++ {
@@ -1898,6 +2977,8 @@ func makeTx(t *testing.T, args ...string) txn.Transaction {
 type fakeDKG struct {
 	actor fakeDkgActor
 	err   error
+	// ceremony is true if the actor is returned as a key ceremony
+	ceremony bool
 }
 
 func (f fakeDKG) Listen(formID []byte, txmanager txn.Manager) (dkg.Actor, error) {
@@ -1908,12 +2989,29 @@ func (f fakeDKG) GetActor(formID []byte) (dkg.Actor, bool) {
 	return f.actor, false
 }
 
+func (f fakeDKG) ListenCeremony(ceremonyID []byte, txmanager txn.Manager) (dkg.Actor, error) {
+	return f.actor, f.err
+}
+
+func (f fakeDKG) GetCeremony(ceremonyID []byte) (dkg.Actor, bool) {
+	return f.actor, f.ceremony
+}
+
+func (f fakeDKG) Ceremonies() [][]byte {
+	return nil
+}
+
+func (f fakeDKG) Forms() [][]byte {
+	return nil
+}
+
 func (f fakeDKG) SetService(service ordering.Service) {
 }
 
 type fakeDkgActor struct {
-	publicKey kyber.Point
-	err       error
+	publicKey    kyber.Point
+	participants []mino.Address
+	err          error
 }
 
 func (f fakeDkgActor) Setup() (pubKey kyber.Point, err error) {
@@ -1924,6 +3022,10 @@ func (f fakeDkgActor) GetPublicKey() (kyber.Point, error) {
 	return f.publicKey, f.err
 }
 
+func (f fakeDkgActor) GetParticipants() ([]mino.Address, error) {
+	return f.participants, f.err
+}
+
 func (f fakeDkgActor) Encrypt(message []byte) (K, C kyber.Point, remainder []byte, err error) {
 	return nil, nil, nil, f.err
 }
@@ -2018,6 +3120,14 @@ func (c fakeCmd) deleteForm(snap store.Snapshot, step execution.Step) error {
 	return c.err
 }
 
+func (c fakeCmd) migrateForm(snap store.Snapshot, step execution.Step) error {
+	return c.err
+}
+
+func (c fakeCmd) auditBallot(snap store.Snapshot, step execution.Step) error {
+	return c.err
+}
+
 func (c fakeCmd) registerPubshares(snap store.Snapshot, step execution.Step) error {
 	return c.err
 }
@@ -2061,3 +3171,7 @@ func (f fakeAuthority) PublicKeyIterator() crypto.PublicKeyIterator {
 func (f fakeAuthority) Len() int {
 	return 0
 }
+
+func (f fakeAuthority) AddressIterator() mino.AddressIterator {
+	return fake.NewAddressIterator(nil)
+}
@@ -0,0 +1,123 @@
+package protobuf
+
+import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+// ciphervoteFormat is the protobuf format to encode and decode a ciphervote.
+//
+// - implements serde.FormatEngine
+type ciphervoteFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (ciphervoteFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	ciphervote, ok := msg.(types.Ciphervote)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", msg)
+	}
+
+	pairs, err := encodeCiphervote(ciphervote)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode ciphervote: %v", err)
+	}
+
+	data, err := ctx.Marshal(&CiphervoteProto{Pairs: pairs})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal ciphervote proto: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine
+func (ciphervoteFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var m CiphervoteProto
+
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal ciphervote proto: %v", err)
+	}
+
+	ciphervote, err := decodeCiphervote(m.Pairs)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode ciphervote: %v", err)
+	}
+
+	return ciphervote, nil
+}
+
+// CiphervoteProto is the protobuf representation of a ciphervote
+type CiphervoteProto struct {
+	// Pairs are the K and C points of each ElGamal pair, one after the other
+	Pairs []byte
+}
+
+// encodeCiphervote returns the points of the ElGamal pairs of the ciphervote,
+// one after the other.
+func encodeCiphervote(ciphervote types.Ciphervote) ([]byte, error) {
+	points := make([]kyber.Point, 0, len(ciphervote)*2)
+
+	for _, egpair := range ciphervote {
+		points = append(points, egpair.K, egpair.C)
+	}
+
+	return encodePoints(points)
+}
+
+// decodeCiphervote returns the ciphervote encoded by encodeCiphervote.
+func decodeCiphervote(data []byte) (types.Ciphervote, error) {
+	points, err := decodePoints(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(points)%2 != 0 {
+		return nil, xerrors.Errorf("odd number of points: %d", len(points))
+	}
+
+	ciphervote := make(types.Ciphervote, len(points)/2)
+
+	for i := range ciphervote {
+		ciphervote[i] = types.EGPair{
+			K: points[2*i],
+			C: points[2*i+1],
+		}
+	}
+
+	return ciphervote, nil
+}
+
+// encodeCiphervotes returns the encoding of each ciphervote.
+func encodeCiphervotes(ciphervotes []types.Ciphervote) ([][]byte, error) {
+	res := make([][]byte, len(ciphervotes))
+
+	for i, ciphervote := range ciphervotes {
+		buf, err := encodeCiphervote(ciphervote)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode ciphervote: %v", err)
+		}
+
+		res[i] = buf
+	}
+
+	return res, nil
+}
+
+// decodeCiphervotes returns the ciphervotes encoded by encodeCiphervotes.
+func decodeCiphervotes(data [][]byte) ([]types.Ciphervote, error) {
+	res := make([]types.Ciphervote, len(data))
+
+	for i, buf := range data {
+		ciphervote, err := decodeCiphervote(buf)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode ciphervote: %v", err)
+		}
+
+		res[i] = ciphervote
+	}
+
+	return res, nil
+}
@@ -0,0 +1,328 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	ctypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+// formFormat defines how the form messages are encoded/decoded using the
+// protobuf format.
+//
+// - implements serde.FormatEngine
+type formFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (formFormat) Encode(ctx serde.Context, message serde.Message) ([]byte, error) {
+	m, ok := message.(types.Form)
+	if !ok {
+		return nil, xerrors.Errorf("unknown format: %T", message)
+	}
+
+	var pubkey []byte
+	var err error
+
+	if m.Pubkey != nil {
+		pubkey, err = m.Pubkey.MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshall public key: %v", err)
+		}
+	}
+
+	configuration, err := json.Marshal(m.Configuration)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal configuration: %v", err)
+	}
+
+	shuffleInstances := make([]ShuffleRefProto, len(m.ShuffleInstances))
+
+	for i, ref := range m.ShuffleInstances {
+		shuffleInstances[i] = ShuffleRefProto(ref)
+	}
+
+	var pendingShuffle *ShuffleRefProto
+
+	if m.PendingShuffle != nil {
+		pending := ShuffleRefProto(*m.PendingShuffle)
+		pendingShuffle = &pending
+	}
+
+	var electoralRoll *ElectoralRollRefProto
+
+	if m.ElectoralRoll != nil {
+		ref := ElectoralRollRefProto(*m.ElectoralRoll)
+		electoralRoll = &ref
+	}
+
+	decryptedBallots, err := json.Marshal(m.DecryptedBallots)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal decrypted ballots: %v", err)
+	}
+
+	// the roster only has a JSON format
+	rosterBuf, err := m.Roster.Serialize(jsonserde.NewContext())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to serialize roster: %v", err)
+	}
+
+	formProto := FormProto{
+		Configuration:    configuration,
+		FormID:           m.FormID,
+		Status:           uint32(m.Status),
+		Pubkey:           pubkey,
+		CeremonyID:       m.CeremonyID,
+		BallotSize:       m.BallotSize,
+		SuffragiaIDs:     m.SuffragiaIDs,
+		BallotCount:      m.BallotCount,
+		SuffragiaHashes:  m.SuffragiaHashes,
+		ShuffleInstances: shuffleInstances,
+		PendingShuffle:   pendingShuffle,
+		ShuffleThreshold: m.ShuffleThreshold,
+		Mixers:           m.Mixers,
+		PubsharesUnits:   PubsharesUnitsProto(m.PubsharesUnits),
+		DecryptedBallots: decryptedBallots,
+		RosterBuf:        rosterBuf,
+		Owners:           m.Owners,
+		Voters:           m.Voters,
+		VoterKeys:        encodeVoterKeys(m.VoterKeys),
+		Anonymous:        m.Anonymous,
+		ElectoralRoll:    electoralRoll,
+		MixerThreshold:   m.MixerThreshold,
+	}
+
+	buff, err := ctx.Marshal(&formProto)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal form: %v", err)
+	}
+
+	return buff, nil
+}
+
+// Decode implements serde.FormatEngine
+func (formFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var formProto FormProto
+
+	err := ctx.Unmarshal(data, &formProto)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal form: %v", err)
+	}
+
+	var configuration types.Configuration
+
+	err = json.Unmarshal(formProto.Configuration, &configuration)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal configuration: %v", err)
+	}
+
+	var pubKey kyber.Point
+
+	if len(formProto.Pubkey) != 0 {
+		pubKey = suite.Point()
+		err = pubKey.UnmarshalBinary(formProto.Pubkey)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal pubkey: %v", err)
+		}
+	}
+
+	shuffleInstances := make([]types.ShuffleRef, len(formProto.ShuffleInstances))
+
+	for i, ref := range formProto.ShuffleInstances {
+		shuffleInstances[i] = types.ShuffleRef(ref)
+	}
+
+	var pendingShuffle *types.ShuffleRef
+
+	if formProto.PendingShuffle != nil {
+		pending := types.ShuffleRef(*formProto.PendingShuffle)
+		pendingShuffle = &pending
+	}
+
+	var electoralRoll *types.ElectoralRollRef
+
+	if formProto.ElectoralRoll != nil {
+		ref := types.ElectoralRollRef(*formProto.ElectoralRoll)
+		electoralRoll = &ref
+	}
+
+	units := formProto.PubsharesUnits
+
+	pubsharesUnits := types.PubsharesUnits{
+		IDs:     nonNilBytes(units.IDs),
+		Hashes:  nonNilBytes(units.Hashes),
+		PubKeys: nonNilBytes(units.PubKeys),
+		Indexes: append([]int{}, units.Indexes...),
+	}
+
+	var decryptedBallots []types.Ballot
+
+	err = json.Unmarshal(formProto.DecryptedBallots, &decryptedBallots)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal decrypted ballots: %v", err)
+	}
+
+	fac := ctx.GetFactory(ctypes.RosterKey{})
+	rosterFac, ok := fac.(authority.Factory)
+	if !ok {
+		return nil, xerrors.Errorf("failed to get roster factory: %T", fac)
+	}
+
+	roster, err := rosterFac.AuthorityOf(jsonserde.NewContext(), formProto.RosterBuf)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode roster: %v", err)
+	}
+
+	return types.Form{
+		Configuration:    configuration,
+		FormID:           formProto.FormID,
+		Status:           types.Status(formProto.Status),
+		Pubkey:           pubKey,
+		CeremonyID:       formProto.CeremonyID,
+		BallotSize:       formProto.BallotSize,
+		SuffragiaIDs:     nonNilBytes(formProto.SuffragiaIDs),
+		BallotCount:      formProto.BallotCount,
+		SuffragiaHashes:  nonNilBytes(formProto.SuffragiaHashes),
+		ShuffleInstances: shuffleInstances,
+		PendingShuffle:   pendingShuffle,
+		ShuffleThreshold: formProto.ShuffleThreshold,
+		Mixers:           formProto.Mixers,
+		PubsharesUnits:   pubsharesUnits,
+		DecryptedBallots: decryptedBallots,
+		Roster:           roster,
+		Owners:           append([]int{}, formProto.Owners...),
+		Voters:           append([]int{}, formProto.Voters...),
+		VoterKeys:        decodeVoterKeys(formProto.VoterKeys),
+		Anonymous:        formProto.Anonymous,
+		ElectoralRoll:    electoralRoll,
+		MixerThreshold:   formProto.MixerThreshold,
+	}, nil
+}
+
+// FormProto defines the Form in the protobuf format
+type FormProto struct {
+	// Configuration is the JSON encoded configuration of the form
+	Configuration []byte
+
+	// FormID is the hex-encoded SHA256 of the transaction ID that creates
+	// the form
+	FormID string
+
+	Status uint32
+	Pubkey []byte
+
+	// CeremonyID is the hex-encoded ID of the DKG key ceremony the form is
+	// bound to, if any.
+	CeremonyID string
+
+	BallotSize int
+
+	// SuffragiaIDs are the addresses of the Suffragia storages.
+	SuffragiaIDs [][]byte
+
+	BallotCount uint32
+
+	// SuffragiaHashes are the sha256-hashes of the ballots in every Suffragia.
+	SuffragiaHashes [][]byte
+
+	ShuffleInstances []ShuffleRefProto
+	PendingShuffle   *ShuffleRefProto
+	ShuffleThreshold int
+	Mixers           [][]byte
+
+	PubsharesUnits PubsharesUnitsProto
+
+	// DecryptedBallots are the JSON encoded decrypted ballots
+	DecryptedBallots []byte
+
+	// RosterBuf is the JSON encoded roster
+	RosterBuf []byte
+
+	Owners []int
+	Voters []int
+
+	// VoterKeys are sorted by SCIPER so that the encoding is deterministic,
+	// which the map of the form doesn't guarantee.
+	VoterKeys []VoterKeyProto
+
+	// The fields are numbered in order, so new ones go at the end.
+	Anonymous      bool
+	ElectoralRoll  *ElectoralRollRefProto
+	MixerThreshold int
+}
+
+// VoterKeyProto is the protobuf representation of the key of a voter
+type VoterKeyProto struct {
+	SCIPER    int
+	PublicKey []byte
+}
+
+// ShuffleRefProto defines the protobuf representation of the reference to a
+// shuffle
+type ShuffleRefProto struct {
+	ID                []byte
+	Hash              []byte
+	ShufflerPublicKey []byte
+	BallotCount       int
+	BatchSize         int
+	Batches           int
+	LastBlock         uint64
+}
+
+// ElectoralRollRefProto defines the protobuf representation of the reference
+// to the electoral roll
+type ElectoralRollRefProto struct {
+	Size int
+}
+
+// PubsharesUnitsProto defines the protobuf representation of the
+// types.PubsharesUnits as used in the form.
+type PubsharesUnitsProto struct {
+	IDs     [][]byte
+	Hashes  [][]byte
+	PubKeys [][]byte
+	Indexes []int
+}
+
+func encodeVoterKeys(voterKeys map[int][]byte) []VoterKeyProto {
+	res := make([]VoterKeyProto, 0, len(voterKeys))
+
+	for sciper, publicKey := range voterKeys {
+		res = append(res, VoterKeyProto{SCIPER: sciper, PublicKey: publicKey})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].SCIPER < res[j].SCIPER
+	})
+
+	return res
+}
+
+func decodeVoterKeys(voterKeys []VoterKeyProto) map[int][]byte {
+	if len(voterKeys) == 0 {
+		return nil
+	}
+
+	res := make(map[int][]byte, len(voterKeys))
+
+	for _, voterKey := range voterKeys {
+		res[voterKey.SCIPER] = voterKey.PublicKey
+	}
+
+	return res
+}
+
+// nonNilBytes returns an empty slice instead of nil, as the JSON format does
+// for the empty lists.
+func nonNilBytes(values [][]byte) [][]byte {
+	if values == nil {
+		return [][]byte{}
+	}
+
+	return values
+}
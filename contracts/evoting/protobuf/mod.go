@@ -0,0 +1,111 @@
+// Package protobuf implements the compact binary format of the forms, the
+// ballots and the transactions of the evoting contract, types.FormatProtobuf.
+//
+// The kyber points are stored as raw bytes, and the ElGamal pairs of a
+// ciphervote are concatenated, which makes the values holding ciphertexts
+// several times smaller than in JSON. The values with no point, such as the
+// configuration of a form, are kept in JSON inside the protobuf messages.
+package protobuf
+
+import (
+	"bytes"
+
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/suites"
+	"go.dedis.ch/protobuf"
+	"golang.org/x/xerrors"
+)
+
+var suite = suites.MustFind("Ed25519")
+
+// Register the protobuf formats for the form, its entries, ciphervote, and
+// transaction
+
+func init() {
+	types.RegisterContext(types.FormatProtobuf, NewContext)
+	types.RegisterFormFormat(types.FormatProtobuf, formFormat{})
+	types.RegisterSuffragiaFormat(types.FormatProtobuf, suffragiaFormat{})
+	types.RegisterShuffleFormat(types.FormatProtobuf, shuffleFormat{})
+	types.RegisterPubsharesFormat(types.FormatProtobuf, pubsharesFormat{})
+	types.RegisterCiphervoteFormat(types.FormatProtobuf, ciphervoteFormat{})
+	types.RegisterTransactionFormat(types.FormatProtobuf, transactionFormat{})
+}
+
+// NewContext returns a new context of the protobuf format.
+func NewContext() serde.Context {
+	return serde.NewContext(contextEngine{})
+}
+
+// contextEngine marshals the messages with protobuf. The data starts with
+// types.ProtobufPrefix so that it can't be mistaken for JSON.
+//
+// - implements serde.ContextEngine
+type contextEngine struct{}
+
+// GetFormat implements serde.ContextEngine.
+func (contextEngine) GetFormat() serde.Format {
+	return types.FormatProtobuf
+}
+
+// Marshal implements serde.ContextEngine. The message must be a pointer to a
+// struct.
+func (contextEngine) Marshal(message interface{}) ([]byte, error) {
+	data, err := protobuf.Encode(message)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode: %v", err)
+	}
+
+	return append(append([]byte{}, types.ProtobufPrefix...), data...), nil
+}
+
+// Unmarshal implements serde.ContextEngine.
+func (contextEngine) Unmarshal(data []byte, message interface{}) error {
+	if !bytes.HasPrefix(data, types.ProtobufPrefix) {
+		return xerrors.New("missing protobuf prefix")
+	}
+
+	err := protobuf.Decode(data[len(types.ProtobufPrefix):], message)
+	if err != nil {
+		return xerrors.Errorf("failed to decode: %v", err)
+	}
+
+	return nil
+}
+
+// encodePoints returns the points one after the other.
+func encodePoints(points []kyber.Point) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(points)*suite.PointLen()))
+
+	for _, point := range points {
+		_, err := point.MarshalTo(buf)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal point: %v", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodePoints returns the points encoded by encodePoints.
+func decodePoints(data []byte) ([]kyber.Point, error) {
+	size := suite.PointLen()
+
+	if len(data)%size != 0 {
+		return nil, xerrors.Errorf("invalid length of points: %d", len(data))
+	}
+
+	points := make([]kyber.Point, len(data)/size)
+
+	for i := range points {
+		points[i] = suite.Point()
+
+		err := points[i].UnmarshalBinary(data[i*size : (i+1)*size])
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal point: %v", err)
+		}
+	}
+
+	return points, nil
+}
@@ -0,0 +1,483 @@
+package protobuf
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"testing"
+
+	_ "github.com/c4dt/d-voting/contracts/evoting/json"
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
+	"go.dedis.ch/kyber/v3/util/random"
+)
+
+func TestContext(t *testing.T) {
+	ctx := NewContext()
+	require.Equal(t, types.FormatProtobuf, ctx.GetFormat())
+
+	data, err := ctx.Marshal(&FormActionProto{FormID: "abcd"})
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(data))
+
+	var m FormActionProto
+
+	err = ctx.Unmarshal(data, &m)
+	require.NoError(t, err)
+	require.Equal(t, "abcd", m.FormID)
+
+	err = ctx.Unmarshal(data[len(types.ProtobufPrefix):], &m)
+	require.EqualError(t, err, "missing protobuf prefix")
+
+	registered, err := types.NewContext(types.FormatProtobuf)
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, registered.GetFormat())
+}
+
+func TestCiphervote(t *testing.T) {
+	ciphervote := makeCiphervote(3)
+
+	data, err := ciphervote.Serialize(NewContext())
+	require.NoError(t, err)
+
+	msg, err := types.CiphervoteFactory{}.Deserialize(NewContext(), data)
+	require.NoError(t, err)
+	require.True(t, ciphervote.Equal(msg.(types.Ciphervote)))
+
+	// the JSON contexts read the values of the binary format as well
+	msg, err = types.CiphervoteFactory{}.Deserialize(jsonserde.NewContext(), data)
+	require.NoError(t, err)
+	require.True(t, ciphervote.Equal(msg.(types.Ciphervote)))
+
+	requireSmaller(t, ciphervote, data)
+
+	_, err = decodeCiphervote(make([]byte, suite.PointLen()))
+	require.EqualError(t, err, "odd number of points: 1")
+
+	_, err = decodeCiphervote([]byte{1})
+	require.EqualError(t, err, "invalid length of points: 1")
+}
+
+func TestSuffragia(t *testing.T) {
+	suff := makeSuffragia(10)
+
+	data, err := suff.Serialize(NewContext())
+	require.NoError(t, err)
+
+	msg, err := readSuffragia(jsonserde.NewContext(), data)
+	require.NoError(t, err)
+
+	requireSameJSON(t, suff, msg)
+	requireSmaller(t, suff, data)
+
+	data, err = NewContext().Marshal(&SuffragiaProto{VoterIDs: []string{"1"}})
+	require.NoError(t, err)
+
+	_, err = suffragiaFormat{}.Decode(NewContext(), data)
+	require.EqualError(t, err, "1 voters for 0 ciphervotes")
+
+	_, err = suffragiaFormat{}.Encode(NewContext(), fake.Message{})
+	require.EqualError(t, err, "unexpected type: fake.Message")
+}
+
+func TestShuffle(t *testing.T) {
+	shuffleInstance := makeShuffle(10)
+
+	form := types.Form{FormID: "abcd", Format: types.FormatProtobuf}
+	snap := fake.NewSnapshot()
+
+	err := form.StoreShuffle(jsonserde.NewContext(), snap, shuffleInstance, true)
+	require.NoError(t, err)
+
+	ref := form.ShuffleInstances[0]
+	require.Equal(t, 10, ref.BallotCount)
+	require.Equal(t, 2, ref.Batches)
+
+	data, err := snap.Get(ref.ID)
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(data))
+
+	msg, err := form.LastShuffle(jsonserde.NewContext(), snap)
+	require.NoError(t, err)
+
+	requireSameJSON(t, shuffleInstance, msg)
+	requireSmaller(t, shuffleInstance, data)
+
+	_, err = shuffleFormat{}.Encode(NewContext(), fake.Message{})
+	require.EqualError(t, err, "unexpected type: fake.Message")
+}
+
+func TestPubshares(t *testing.T) {
+	unit := makePubsharesUnit(10)
+
+	form := types.Form{FormID: "abcd", Format: types.FormatProtobuf}
+	snap := fake.NewSnapshot()
+
+	err := form.AddPubshares(jsonserde.NewContext(), snap, unit, []byte("node"), 2)
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, form.PubsharesUnits.Indexes)
+
+	data, err := snap.Get(form.PubsharesUnits.IDs[0])
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, types.FormatOf(data))
+
+	units, err := form.Pubshares(jsonserde.NewContext(), snap)
+	require.NoError(t, err)
+	require.Len(t, units, 1)
+
+	requireSameJSON(t, unit, units[0])
+	requireSmaller(t, unit, data)
+
+	_, err = pubsharesFormat{}.Encode(NewContext(), fake.Message{})
+	require.EqualError(t, err, "unexpected type: fake.Message")
+
+	data, err = NewContext().Marshal(&PubsharesUnitProto{Ballots: [][]byte{{1}}})
+	require.NoError(t, err)
+
+	_, err = pubsharesFormat{}.Decode(NewContext(), data)
+	require.EqualError(t, err, "failed to decode pubShares: could not "+
+		"unmarshal public shares: invalid length of points: 1")
+}
+
+func TestForm(t *testing.T) {
+	form := makeForm(10)
+	formFac := types.NewFormFactory(types.CiphervoteFactory{}, fake.Factory{})
+
+	data, err := form.Serialize(NewContext())
+	require.NoError(t, err)
+
+	msg, err := formFac.Deserialize(NewContext(), data)
+	require.NoError(t, err)
+	require.Equal(t, types.FormatProtobuf, msg.(types.Form).Format)
+
+	requireSameJSON(t, form, msg)
+	requireSmaller(t, form, data)
+
+	// the form keeps the format it is stored in
+	msg, err = formFac.Deserialize(jsonserde.NewContext(), data)
+	require.NoError(t, err)
+
+	reencoded, err := msg.(types.Form).Serialize(jsonserde.NewContext())
+	require.NoError(t, err)
+	require.Equal(t, data, reencoded)
+
+	// the encoding doesn't depend on the order of the voter keys
+	for i := 0; i < 10; i++ {
+		other, err := form.Serialize(NewContext())
+		require.NoError(t, err)
+		require.Equal(t, data, other)
+	}
+
+	_, err = formFormat{}.Encode(NewContext(), fake.Message{})
+	require.EqualError(t, err, "unknown format: fake.Message")
+
+	_, err = formFormat{}.Decode(NewContext(), []byte("{}"))
+	require.EqualError(t, err, "failed to unmarshal form: missing protobuf prefix")
+}
+
+func TestTransaction(t *testing.T) {
+	txFac := types.NewTransactionFactory(types.CiphervoteFactory{})
+
+	for _, tx := range makeTransactions() {
+		data, err := tx.Serialize(NewContext())
+		require.NoError(t, err)
+
+		msg, err := txFac.Deserialize(NewContext(), data)
+		require.NoError(t, err)
+		require.IsType(t, tx, msg)
+
+		requireSameJSON(t, tx, msg)
+	}
+
+	_, err := transactionFormat{}.Encode(NewContext(), fake.Message{})
+	require.EqualError(t, err, "unknown type: 'fake.Message")
+
+	data, err := NewContext().Marshal(&TransactionProto{})
+	require.NoError(t, err)
+
+	_, err = transactionFormat{}.Decode(NewContext(), data)
+	require.EqualError(t, err, "empty type")
+}
+
+func BenchmarkSuffragia(b *testing.B) {
+	benchmarkFormats(b, makeSuffragia(types.BallotsPerBlock), readSuffragia)
+}
+
+func BenchmarkForm(b *testing.B) {
+	formFac := types.NewFormFactory(types.CiphervoteFactory{}, fake.Factory{})
+
+	benchmarkFormats(b, makeForm(100), formFac.Deserialize)
+}
+
+func BenchmarkShuffle(b *testing.B) {
+	benchmarkFormats(b, makeShuffle(100), readShuffle)
+}
+
+func BenchmarkShuffleBallots(b *testing.B) {
+	txFac := types.NewTransactionFactory(types.CiphervoteFactory{})
+
+	benchmarkFormats(b, makeShuffleBallots(100), txFac.Deserialize)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func benchmarkFormats(b *testing.B, msg serde.Message,
+	decode func(serde.Context, []byte) (serde.Message, error)) {
+
+	contexts := []serde.Context{jsonserde.NewContext(), NewContext()}
+
+	for _, ctx := range contexts {
+		data, err := msg.Serialize(ctx)
+		require.NoError(b, err)
+
+		b.Run(string(ctx.GetFormat())+"/Encode", func(b *testing.B) {
+			b.ReportMetric(float64(len(data)), "bytes")
+
+			for i := 0; i < b.N; i++ {
+				_, err := msg.Serialize(ctx)
+				require.NoError(b, err)
+			}
+		})
+
+		b.Run(string(ctx.GetFormat())+"/Decode", func(b *testing.B) {
+			b.ReportMetric(float64(len(data)), "bytes")
+
+			for i := 0; i < b.N; i++ {
+				_, err := decode(ctx, data)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// readSuffragia decodes a block of ballots the way the forms do, as the
+// suffragia have no factory.
+func readSuffragia(ctx serde.Context, data []byte) (serde.Message, error) {
+	blockID := []byte("block")
+
+	snap := fake.NewSnapshot()
+
+	err := snap.Set(blockID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	form := types.Form{SuffragiaIDs: [][]byte{blockID}}
+
+	return form.Suffragia(ctx, snap)
+}
+
+// readShuffle decodes a shuffle the way the forms do, as the shuffles have no
+// factory.
+func readShuffle(ctx serde.Context, data []byte) (serde.Message, error) {
+	shuffleID := []byte("shuffle")
+	hash := sha256.Sum256(data)
+
+	snap := fake.NewSnapshot()
+
+	err := snap.Set(shuffleID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	form := types.Form{ShuffleInstances: []types.ShuffleRef{{
+		ID:   shuffleID,
+		Hash: hash[:],
+	}}}
+
+	return form.LastShuffle(ctx, snap)
+}
+
+// requireSameJSON checks that the messages have the same JSON encoding.
+func requireSameJSON(t *testing.T, expected, actual serde.Message) {
+	ctx := jsonserde.NewContext()
+
+	// the decoded form is written in its format otherwise
+	form, ok := actual.(types.Form)
+	if ok {
+		form.Format = ""
+		actual = form
+	}
+
+	expectedBuf, err := expected.Serialize(ctx)
+	require.NoError(t, err)
+
+	actualBuf, err := actual.Serialize(ctx)
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expectedBuf), string(actualBuf))
+}
+
+// requireSmaller checks that the data is smaller than the JSON encoding of the
+// message.
+func requireSmaller(t *testing.T, msg serde.Message, data []byte) {
+	jsonBuf, err := msg.Serialize(jsonserde.NewContext())
+	require.NoError(t, err)
+	require.Less(t, len(data), len(jsonBuf))
+}
+
+func makeCiphervote(n int) types.Ciphervote {
+	ciphervote := make(types.Ciphervote, n)
+
+	for i := range ciphervote {
+		ciphervote[i] = types.EGPair{
+			K: suite.Point().Pick(random.New()),
+			C: suite.Point().Pick(random.New()),
+		}
+	}
+
+	return ciphervote
+}
+
+func makeSuffragia(n int) types.Suffragia {
+	var suff types.Suffragia
+
+	for i := 0; i < n; i++ {
+		suff.CastVote(strconv.Itoa(100000+i), makeCiphervote(3))
+	}
+
+	return suff
+}
+
+func makeShuffle(n int) types.ShuffleInstance {
+	return types.ShuffleInstance{
+		ShuffledBallots:   makeSuffragia(n).Ciphervotes,
+		ShuffleProofs:     []byte("proof"),
+		ShufflerPublicKey: []byte("shuffler"),
+		BatchSize:         n / 2,
+		BatchProofs:       [][]byte{[]byte("batch1"), []byte("batch2")},
+	}
+}
+
+func makePubsharesUnit(n int) types.PubsharesUnit {
+	unit := make(types.PubsharesUnit, n)
+
+	for i := range unit {
+		unit[i] = []types.Pubshare{
+			suite.Point().Pick(random.New()),
+			suite.Point().Pick(random.New()),
+		}
+	}
+
+	return unit
+}
+
+func makeForm(n int) types.Form {
+	return types.Form{
+		Configuration: types.Configuration{
+			Title: types.Title{En: "title"},
+		},
+		FormID:          "abcd",
+		Status:          types.PubSharesSubmitted,
+		Pubkey:          suite.Point().Pick(random.New()),
+		CeremonyID:      "ef01",
+		BallotSize:      29,
+		SuffragiaIDs:    [][]byte{[]byte("block1"), []byte("block2")},
+		BallotCount:     uint32(n),
+		SuffragiaHashes: [][]byte{{}, []byte("hash")},
+		ShuffleInstances: []types.ShuffleRef{{
+			ID:                []byte("shuffle1"),
+			Hash:              []byte("hash1"),
+			ShufflerPublicKey: []byte("shuffler"),
+			BallotCount:       n,
+			BatchSize:         2,
+			Batches:           2,
+		}},
+		PendingShuffle: &types.ShuffleRef{
+			ID:                []byte("shuffle2"),
+			Hash:              []byte("hash2"),
+			ShufflerPublicKey: []byte("shuffler"),
+			BallotCount:       1,
+			LastBlock:         7,
+		},
+		ShuffleThreshold: 1,
+		Mixers:           [][]byte{[]byte("mixer")},
+		MixerThreshold:   1,
+		PubsharesUnits: types.PubsharesUnits{
+			IDs:     [][]byte{[]byte("pubshares1")},
+			Hashes:  [][]byte{[]byte("hash3")},
+			PubKeys: [][]byte{[]byte("node")},
+			Indexes: []int{0},
+		},
+		Roster:    fake.Authority{},
+		Owners:    []int{123456},
+		Voters:    []int{234567, 345678},
+		VoterKeys: map[int][]byte{234567: []byte("a"), 345678: []byte("b")},
+		Anonymous: true,
+		ElectoralRoll: &types.ElectoralRollRef{
+			Size: 1,
+		},
+	}
+}
+
+func makeTransactions() []serde.Message {
+	return []serde.Message{
+		types.CreateForm{
+			Configuration:  types.Configuration{Title: types.Title{En: "title"}},
+			UserID:         "123456",
+			Anonymous:      true,
+			Mixers:         [][]byte{[]byte("mixer")},
+			MixerThreshold: 1,
+		},
+		types.OpenForm{FormID: "abcd", UserID: "123456", CeremonyID: "ef01"},
+		types.CastVote{
+			FormID:     "abcd",
+			VoterID:    "234567",
+			Ballot:     makeCiphervote(3),
+			Signature:  []byte("signature"),
+			Credential: []byte("credential"),
+			Sequence:   3,
+			Ring:       1,
+		},
+		types.CloseForm{FormID: "abcd", UserID: "123456"},
+		makeShuffleBallots(10),
+		types.RegisterPubShares{
+			FormID:    "abcd",
+			Index:     2,
+			Pubshares: types.PubsharesUnit{{suite.Point().Pick(random.New())}},
+			Signature: []byte("signature"),
+			PublicKey: []byte("node"),
+		},
+		types.CombineShares{FormID: "abcd", UserID: "123456"},
+		types.CancelForm{FormID: "abcd", UserID: "123456"},
+		types.DeleteForm{FormID: "abcd", UserID: "123456"},
+		types.MigrateForm{FormID: "abcd", UserID: "123456", Format: "JSON"},
+		types.AddAdmin{TargetUserID: "234567", PerformingUserID: "123456"},
+		types.RemoveAdmin{TargetUserID: "234567", PerformingUserID: "123456"},
+		types.AddOperator{TargetUserID: "234567", PerformingUserID: "123456"},
+		types.RemoveOperator{TargetUserID: "234567", PerformingUserID: "123456"},
+		types.AddOwner{FormID: "abcd", TargetUserID: "234567", PerformingUserID: "123456"},
+		types.RemoveOwner{FormID: "abcd", TargetUserID: "234567", PerformingUserID: "123456"},
+		types.AddVoter{
+			FormID:           "abcd",
+			TargetUserID:     "234567",
+			PerformingUserID: "123456",
+			PublicKey:        []byte("voter"),
+		},
+		types.RemoveVoter{FormID: "abcd", TargetUserID: "234567", PerformingUserID: "123456"},
+		types.AddCredential{FormID: "abcd", PerformingUserID: "123456", PublicKey: []byte("credential")},
+		types.AuditBallot{
+			FormID:     "abcd",
+			Ballot:     makeCiphervote(2),
+			Randomness: [][]byte{[]byte("k1"), []byte("k2")},
+		},
+	}
+}
+
+func makeShuffleBallots(n int) types.ShuffleBallots {
+	return types.ShuffleBallots{
+		FormID:          "abcd",
+		Round:           1,
+		ShuffledBallots: makeSuffragia(n).Ciphervotes,
+		RandomVector:    types.RandomVector{[]byte("e1"), []byte("e2")},
+		Proof:           []byte("proof"),
+		Signature:       []byte("signature"),
+		PublicKey:       []byte("node"),
+		UserID:          "123456",
+		BatchSize:       10,
+		BatchIndex:      1,
+	}
+}
@@ -0,0 +1,96 @@
+package protobuf
+
+import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+// pubsharesFormat is the protobuf format to encode and decode the pubShares
+// submitted by a node.
+//
+// - implements serde.FormatEngine
+type pubsharesFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (pubsharesFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	unit, ok := msg.(types.PubsharesUnit)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", msg)
+	}
+
+	m, err := encodePubsharesUnit(unit)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode pubShares: %v", err)
+	}
+
+	data, err := ctx.Marshal(&m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal pubShares proto: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine
+func (pubsharesFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var m PubsharesUnitProto
+
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal pubShares proto: %v", err)
+	}
+
+	unit, err := decodePubsharesUnit(m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode pubShares: %v", err)
+	}
+
+	return unit, nil
+}
+
+// PubsharesUnitProto is the protobuf representation of a submission of
+// pubShares by one node. It holds the encoded pubShares of each ballot, see
+// encodePoints.
+type PubsharesUnitProto struct {
+	Ballots [][]byte
+}
+
+func encodePubsharesUnit(unit types.PubsharesUnit) (PubsharesUnitProto, error) {
+	ballots := make([][]byte, len(unit))
+
+	for i, ballotShares := range unit {
+		points := make([]kyber.Point, len(ballotShares))
+		for j, pubShare := range ballotShares {
+			points[j] = pubShare
+		}
+
+		buf, err := encodePoints(points)
+		if err != nil {
+			return PubsharesUnitProto{}, xerrors.Errorf("could not marshal public shares: %v", err)
+		}
+
+		ballots[i] = buf
+	}
+
+	return PubsharesUnitProto{Ballots: ballots}, nil
+}
+
+func decodePubsharesUnit(m PubsharesUnitProto) (types.PubsharesUnit, error) {
+	unit := make(types.PubsharesUnit, len(m.Ballots))
+
+	for i, buf := range m.Ballots {
+		points, err := decodePoints(buf)
+		if err != nil {
+			return nil, xerrors.Errorf("could not unmarshal public shares: %v", err)
+		}
+
+		unit[i] = make([]types.Pubshare, len(points))
+		for j, point := range points {
+			unit[i][j] = point
+		}
+	}
+
+	return unit, nil
+}
@@ -0,0 +1,75 @@
+package protobuf
+
+import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// shuffleFormat is the protobuf format to encode and decode the shuffle of a
+// round.
+//
+// - implements serde.FormatEngine
+type shuffleFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (shuffleFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	shuffleInstance, ok := msg.(types.ShuffleInstance)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", msg)
+	}
+
+	shuffledBallots, err := encodeCiphervotes(shuffleInstance.ShuffledBallots)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode ciphervotes: %v", err)
+	}
+
+	m := ShuffleInstanceProto{
+		ShuffledBallots:   shuffledBallots,
+		ShuffleProofs:     shuffleInstance.ShuffleProofs,
+		ShufflerPublicKey: shuffleInstance.ShufflerPublicKey,
+		BatchSize:         shuffleInstance.BatchSize,
+		BatchProofs:       shuffleInstance.BatchProofs,
+	}
+
+	data, err := ctx.Marshal(&m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal shuffle proto: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine
+func (shuffleFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var m ShuffleInstanceProto
+
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal shuffle proto: %v", err)
+	}
+
+	shuffledBallots, err := decodeCiphervotes(m.ShuffledBallots)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode ciphervotes: %v", err)
+	}
+
+	return types.ShuffleInstance{
+		ShuffledBallots:   shuffledBallots,
+		ShuffleProofs:     m.ShuffleProofs,
+		ShufflerPublicKey: m.ShufflerPublicKey,
+		BatchSize:         m.BatchSize,
+		BatchProofs:       m.BatchProofs,
+	}, nil
+}
+
+// ShuffleInstanceProto defines the protobuf representation of a shuffle
+// instance
+type ShuffleInstanceProto struct {
+	// ShuffledBallots are the encoded ciphervotes, see encodeCiphervote
+	ShuffledBallots   [][]byte
+	ShuffleProofs     []byte
+	ShufflerPublicKey []byte
+	BatchSize         int
+	BatchProofs       [][]byte
+}
@@ -0,0 +1,69 @@
+package protobuf
+
+import (
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// suffragiaFormat is the protobuf format to encode and decode a block of
+// ballots.
+//
+// - implements serde.FormatEngine
+type suffragiaFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (suffragiaFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	suffragia, ok := msg.(types.Suffragia)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type: %T", msg)
+	}
+
+	ciphervotes, err := encodeCiphervotes(suffragia.Ciphervotes)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode ciphervotes: %v", err)
+	}
+
+	m := SuffragiaProto{
+		VoterIDs:    suffragia.VoterIDs,
+		Ciphervotes: ciphervotes,
+	}
+
+	data, err := ctx.Marshal(&m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal suffragia proto: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine
+func (suffragiaFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var m SuffragiaProto
+
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal suffragia proto: %v", err)
+	}
+
+	if len(m.VoterIDs) != len(m.Ciphervotes) {
+		return nil, xerrors.Errorf("%d voters for %d ciphervotes", len(m.VoterIDs),
+			len(m.Ciphervotes))
+	}
+
+	ciphervotes, err := decodeCiphervotes(m.Ciphervotes)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode ciphervotes: %v", err)
+	}
+
+	return types.Suffragia{
+		VoterIDs:    m.VoterIDs,
+		Ciphervotes: ciphervotes,
+	}, nil
+}
+
+// SuffragiaProto is the protobuf representation of a block of ballots
+type SuffragiaProto struct {
+	VoterIDs    []string
+	Ciphervotes [][]byte
+}
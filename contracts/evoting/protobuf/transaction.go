@@ -0,0 +1,455 @@
+package protobuf
+
+import (
+	"encoding/json"
+
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// transactionFormat defines the protobuf format of a transaction
+//
+// - implements serde.FormatEngine
+type transactionFormat struct{}
+
+// Encode implements serde.FormatEngine
+func (transactionFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	var m TransactionProto
+
+	switch t := msg.(type) {
+	case types.CreateForm:
+		configuration, err := json.Marshal(t.Configuration)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal configuration: %v", err)
+		}
+
+		m.CreateForm = &CreateFormProto{
+			Configuration:  configuration,
+			UserID:         t.UserID,
+			Anonymous:      t.Anonymous,
+			Mixers:         t.Mixers,
+			MixerThreshold: t.MixerThreshold,
+		}
+	case types.OpenForm:
+		m.OpenForm = &OpenFormProto{
+			FormID:     t.FormID,
+			UserID:     t.UserID,
+			CeremonyID: t.CeremonyID,
+		}
+	case types.CastVote:
+		ballot, err := encodeCiphervote(t.Ballot)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode ballot: %v", err)
+		}
+
+		m.CastVote = &CastVoteProto{
+			FormID:     t.FormID,
+			VoterID:    t.VoterID,
+			Ciphervote: ballot,
+			Signature:  t.Signature,
+			Credential: t.Credential,
+			Sequence:   t.Sequence,
+			Ring:       t.Ring,
+		}
+	case types.CloseForm:
+		m.CloseForm = &FormActionProto{FormID: t.FormID, UserID: t.UserID}
+	case types.ShuffleBallots:
+		ciphervotes, err := encodeCiphervotes(t.ShuffledBallots)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode ciphervotes: %v", err)
+		}
+
+		m.ShuffleBallots = &ShuffleBallotsProto{
+			FormID:       t.FormID,
+			Round:        t.Round,
+			Ciphervotes:  ciphervotes,
+			RandomVector: t.RandomVector,
+			Proof:        t.Proof,
+			Signature:    t.Signature,
+			PublicKey:    t.PublicKey,
+			UserID:       t.UserID,
+			BatchSize:    t.BatchSize,
+			BatchIndex:   t.BatchIndex,
+		}
+	case types.RegisterPubShares:
+		pubShares, err := encodePubsharesUnit(t.Pubshares)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode pubShares: %v", err)
+		}
+
+		m.RegisterPubShares = &RegisterPubSharesProto{
+			FormID:    t.FormID,
+			Index:     t.Index,
+			PubShares: pubShares,
+			Signature: t.Signature,
+			PublicKey: t.PublicKey,
+		}
+	case types.CombineShares:
+		m.CombineShares = &FormActionProto{FormID: t.FormID, UserID: t.UserID}
+	case types.CancelForm:
+		m.CancelForm = &FormActionProto{FormID: t.FormID, UserID: t.UserID}
+	case types.DeleteForm:
+		m.DeleteForm = &FormActionProto{FormID: t.FormID, UserID: t.UserID}
+	case types.MigrateForm:
+		m.MigrateForm = &MigrateFormProto{
+			FormID: t.FormID,
+			UserID: t.UserID,
+			Format: t.Format,
+		}
+	case types.AddAdmin:
+		m.AddAdmin = &UserActionProto{
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.RemoveAdmin:
+		m.RemoveAdmin = &UserActionProto{
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.AddOperator:
+		m.AddOperator = &UserActionProto{
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.RemoveOperator:
+		m.RemoveOperator = &UserActionProto{
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.AddOwner:
+		m.AddOwner = &UserActionProto{
+			FormID:           t.FormID,
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.RemoveOwner:
+		m.RemoveOwner = &UserActionProto{
+			FormID:           t.FormID,
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.AddVoter:
+		m.AddVoter = &UserActionProto{
+			FormID:           t.FormID,
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+			PublicKey:        t.PublicKey,
+		}
+	case types.RemoveVoter:
+		m.RemoveVoter = &UserActionProto{
+			FormID:           t.FormID,
+			TargetUserID:     t.TargetUserID,
+			PerformingUserID: t.PerformingUserID,
+		}
+	case types.AddCredential:
+		m.AddCredential = &UserActionProto{
+			FormID:           t.FormID,
+			PerformingUserID: t.PerformingUserID,
+			PublicKey:        t.PublicKey,
+		}
+	case types.AuditBallot:
+		ballot, err := encodeCiphervote(t.Ballot)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode ballot: %v", err)
+		}
+
+		m.AuditBallot = &AuditBallotProto{
+			FormID:     t.FormID,
+			Ciphervote: ballot,
+			Randomness: t.Randomness,
+		}
+	default:
+		return nil, xerrors.Errorf("unknown type: '%T", msg)
+	}
+
+	data, err := ctx.Marshal(&m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal transaction proto: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine
+func (transactionFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	var m TransactionProto
+
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal transaction proto: %v", err)
+	}
+
+	switch {
+	case m.CreateForm != nil:
+		var configuration types.Configuration
+
+		err = json.Unmarshal(m.CreateForm.Configuration, &configuration)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal configuration: %v", err)
+		}
+
+		return types.CreateForm{
+			Configuration:  configuration,
+			UserID:         m.CreateForm.UserID,
+			Anonymous:      m.CreateForm.Anonymous,
+			Mixers:         m.CreateForm.Mixers,
+			MixerThreshold: m.CreateForm.MixerThreshold,
+		}, nil
+	case m.OpenForm != nil:
+		return types.OpenForm{
+			FormID:     m.OpenForm.FormID,
+			UserID:     m.OpenForm.UserID,
+			CeremonyID: m.OpenForm.CeremonyID,
+		}, nil
+	case m.CastVote != nil:
+		ballot, err := decodeCiphervote(m.CastVote.Ciphervote)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode cast vote: %v", err)
+		}
+
+		return types.CastVote{
+			FormID:     m.CastVote.FormID,
+			VoterID:    m.CastVote.VoterID,
+			Ballot:     ballot,
+			Signature:  m.CastVote.Signature,
+			Credential: m.CastVote.Credential,
+			Sequence:   m.CastVote.Sequence,
+			Ring:       m.CastVote.Ring,
+		}, nil
+	case m.CloseForm != nil:
+		return types.CloseForm{
+			FormID: m.CloseForm.FormID,
+			UserID: m.CloseForm.UserID,
+		}, nil
+	case m.ShuffleBallots != nil:
+		ciphervotes, err := decodeCiphervotes(m.ShuffleBallots.Ciphervotes)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode shuffle ballots: %v", err)
+		}
+
+		return types.ShuffleBallots{
+			FormID:          m.ShuffleBallots.FormID,
+			Round:           m.ShuffleBallots.Round,
+			ShuffledBallots: ciphervotes,
+			RandomVector:    m.ShuffleBallots.RandomVector,
+			Proof:           m.ShuffleBallots.Proof,
+			Signature:       m.ShuffleBallots.Signature,
+			PublicKey:       m.ShuffleBallots.PublicKey,
+			UserID:          m.ShuffleBallots.UserID,
+			BatchSize:       m.ShuffleBallots.BatchSize,
+			BatchIndex:      m.ShuffleBallots.BatchIndex,
+		}, nil
+	case m.RegisterPubShares != nil:
+		pubShares, err := decodePubsharesUnit(m.RegisterPubShares.PubShares)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode register pubShares: %v", err)
+		}
+
+		return types.RegisterPubShares{
+			FormID:    m.RegisterPubShares.FormID,
+			Index:     m.RegisterPubShares.Index,
+			Pubshares: pubShares,
+			Signature: m.RegisterPubShares.Signature,
+			PublicKey: m.RegisterPubShares.PublicKey,
+		}, nil
+	case m.CombineShares != nil:
+		return types.CombineShares{
+			FormID: m.CombineShares.FormID,
+			UserID: m.CombineShares.UserID,
+		}, nil
+	case m.CancelForm != nil:
+		return types.CancelForm{
+			FormID: m.CancelForm.FormID,
+			UserID: m.CancelForm.UserID,
+		}, nil
+	case m.DeleteForm != nil:
+		return types.DeleteForm{
+			FormID: m.DeleteForm.FormID,
+			UserID: m.DeleteForm.UserID,
+		}, nil
+	case m.MigrateForm != nil:
+		return types.MigrateForm{
+			FormID: m.MigrateForm.FormID,
+			UserID: m.MigrateForm.UserID,
+			Format: m.MigrateForm.Format,
+		}, nil
+	case m.AddAdmin != nil:
+		return types.AddAdmin{
+			TargetUserID:     m.AddAdmin.TargetUserID,
+			PerformingUserID: m.AddAdmin.PerformingUserID,
+		}, nil
+	case m.RemoveAdmin != nil:
+		return types.RemoveAdmin{
+			TargetUserID:     m.RemoveAdmin.TargetUserID,
+			PerformingUserID: m.RemoveAdmin.PerformingUserID,
+		}, nil
+	case m.AddOperator != nil:
+		return types.AddOperator{
+			TargetUserID:     m.AddOperator.TargetUserID,
+			PerformingUserID: m.AddOperator.PerformingUserID,
+		}, nil
+	case m.RemoveOperator != nil:
+		return types.RemoveOperator{
+			TargetUserID:     m.RemoveOperator.TargetUserID,
+			PerformingUserID: m.RemoveOperator.PerformingUserID,
+		}, nil
+	case m.AddOwner != nil:
+		return types.AddOwner{
+			FormID:           m.AddOwner.FormID,
+			TargetUserID:     m.AddOwner.TargetUserID,
+			PerformingUserID: m.AddOwner.PerformingUserID,
+		}, nil
+	case m.RemoveOwner != nil:
+		return types.RemoveOwner{
+			FormID:           m.RemoveOwner.FormID,
+			TargetUserID:     m.RemoveOwner.TargetUserID,
+			PerformingUserID: m.RemoveOwner.PerformingUserID,
+		}, nil
+	case m.AddVoter != nil:
+		return types.AddVoter{
+			FormID:           m.AddVoter.FormID,
+			TargetUserID:     m.AddVoter.TargetUserID,
+			PerformingUserID: m.AddVoter.PerformingUserID,
+			PublicKey:        m.AddVoter.PublicKey,
+		}, nil
+	case m.RemoveVoter != nil:
+		return types.RemoveVoter{
+			FormID:           m.RemoveVoter.FormID,
+			TargetUserID:     m.RemoveVoter.TargetUserID,
+			PerformingUserID: m.RemoveVoter.PerformingUserID,
+		}, nil
+	case m.AddCredential != nil:
+		return types.AddCredential{
+			FormID:           m.AddCredential.FormID,
+			PublicKey:        m.AddCredential.PublicKey,
+			PerformingUserID: m.AddCredential.PerformingUserID,
+		}, nil
+	case m.AuditBallot != nil:
+		ballot, err := decodeCiphervote(m.AuditBallot.Ciphervote)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode audit ballot: %v", err)
+		}
+
+		return types.AuditBallot{
+			FormID:     m.AuditBallot.FormID,
+			Ballot:     ballot,
+			Randomness: m.AuditBallot.Randomness,
+		}, nil
+	}
+
+	return nil, xerrors.New("empty type")
+}
+
+// TransactionProto is the protobuf message that wraps the different kinds of
+// transactions. Only one of the fields is set.
+type TransactionProto struct {
+	CreateForm        *CreateFormProto
+	OpenForm          *OpenFormProto
+	CastVote          *CastVoteProto
+	CloseForm         *FormActionProto
+	ShuffleBallots    *ShuffleBallotsProto
+	RegisterPubShares *RegisterPubSharesProto
+	CombineShares     *FormActionProto
+	CancelForm        *FormActionProto
+	DeleteForm        *FormActionProto
+	AddAdmin          *UserActionProto
+	RemoveAdmin       *UserActionProto
+	AddOperator       *UserActionProto
+	RemoveOperator    *UserActionProto
+	AddOwner          *UserActionProto
+	RemoveOwner       *UserActionProto
+	AddVoter          *UserActionProto
+	RemoveVoter       *UserActionProto
+	AddCredential     *UserActionProto
+	MigrateForm       *MigrateFormProto
+	AuditBallot       *AuditBallotProto
+}
+
+// CreateFormProto is the protobuf representation of a CreateForm transaction
+type CreateFormProto struct {
+	// Configuration is the JSON encoded configuration of the form
+	Configuration  []byte
+	UserID         string
+	Anonymous      bool
+	Mixers         [][]byte
+	MixerThreshold int
+}
+
+// OpenFormProto is the protobuf representation of a OpenForm transaction
+type OpenFormProto struct {
+	FormID     string
+	UserID     string
+	CeremonyID string
+}
+
+// CastVoteProto is the protobuf representation of a CastVote transaction
+type CastVoteProto struct {
+	FormID  string
+	VoterID string
+	// Ciphervote is the encoded ballot, see encodeCiphervote
+	Ciphervote []byte
+	Signature  []byte
+	Credential []byte
+	Sequence   uint64
+	Ring       int
+}
+
+// AuditBallotProto is the protobuf representation of a AuditBallot
+// transaction
+type AuditBallotProto struct {
+	FormID string
+	// Ciphervote is the encoded ballot, see encodeCiphervote
+	Ciphervote []byte
+	Randomness [][]byte
+}
+
+// FormActionProto is the protobuf representation of the transactions that
+// only need the form and the user, such as CloseForm.
+type FormActionProto struct {
+	FormID string
+	UserID string
+}
+
+// ShuffleBallotsProto is the protobuf representation of a ShuffleBallots
+// transaction
+type ShuffleBallotsProto struct {
+	FormID       string
+	Round        int
+	Ciphervotes  [][]byte
+	RandomVector [][]byte
+	Proof        []byte
+	Signature    []byte
+	PublicKey    []byte
+	UserID       string
+	BatchSize    int
+	BatchIndex   int
+}
+
+// RegisterPubSharesProto is the protobuf representation of a
+// RegisterPubShares transaction
+type RegisterPubSharesProto struct {
+	FormID    string
+	Index     int
+	PubShares PubsharesUnitProto
+	Signature []byte
+	PublicKey []byte
+}
+
+// MigrateFormProto is the protobuf representation of a MigrateForm
+// transaction
+type MigrateFormProto struct {
+	FormID string
+	UserID string
+	Format string
+}
+
+// UserActionProto is the protobuf representation of the transactions that
+// manage the users of the system or of a form, such as AddAdmin. The fields
+// that don't apply to the transaction are empty.
+type UserActionProto struct {
+	FormID           string
+	TargetUserID     string
+	PerformingUserID string
+	PublicKey        []byte
+}
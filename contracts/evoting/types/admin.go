@@ -33,6 +33,11 @@ func (adminList AdminList) Serialize(ctx serde.Context) ([]byte, error) {
 }
 
 func (adminList AdminList) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to get context: %v", err)
+	}
+
 	format := adminListFormat.Get(ctx.GetFormat())
 
 	message, err := format.Decode(ctx, data)
@@ -129,8 +134,14 @@ func AdminListFromStore(ctx serde.Context, adminListFac serde.Factory, store sto
 // - implements serde.Factory
 type AdminListFactory struct{}
 
-// Deserialize implements serde.Factory
+// Deserialize implements serde.Factory. The list is decoded in the format of
+// the data.
 func (AdminListFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	format := adminListFormat.Get(ctx.GetFormat())
 
 	message, err := format.Decode(ctx, data)
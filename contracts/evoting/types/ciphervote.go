@@ -1,6 +1,7 @@
 package types
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io"
 
@@ -66,6 +67,56 @@ func (c Ciphervote) FingerPrint(writer io.Writer) error {
 	return nil
 }
 
+// Hash returns the sha256-hash of the points of the ciphervote, which is given
+// to the voter as the receipt of its ballot.
+func (c Ciphervote) Hash() ([]byte, error) {
+	h := sha256.New()
+
+	err := c.FingerPrint(h)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fingerprint: %v", err)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// Audit checks that each pair of the ciphervote is encrypted for the public
+// key with the marshalled randomness at the same index, and returns the data
+// embedded in the pairs, in order.
+func (c Ciphervote) Audit(pubkey kyber.Point, randomness [][]byte) ([]byte, error) {
+	if len(randomness) != len(c) {
+		return nil, xerrors.Errorf("%d randomness for %d pairs", len(randomness), len(c))
+	}
+
+	var data []byte
+
+	for i, egpair := range c {
+		k := suite.Scalar()
+
+		err := k.UnmarshalBinary(randomness[i])
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal randomness %d: %v", i, err)
+		}
+
+		if !suite.Point().Mul(k, nil).Equal(egpair.K) {
+			return nil, xerrors.Errorf("pair %d is not encrypted with its randomness", i)
+		}
+
+		// M = C - k*pubkey
+		S := suite.Point().Mul(k, pubkey)
+		M := suite.Point().Sub(egpair.C, S)
+
+		chunk, err := M.Data()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get data of pair %d: %v", i, err)
+		}
+
+		data = append(data, chunk...)
+	}
+
+	return data, nil
+}
+
 // GetElGPairs returns corresponding kyber.Points from the ciphertexts
 func (c Ciphervote) GetElGPairs() (ks []kyber.Point, cs []kyber.Point) {
 	ks = make([]kyber.Point, len(c))
@@ -122,8 +173,14 @@ type CiphervoteKey struct{}
 // - implements serde.Factory
 type CiphervoteFactory struct{}
 
-// Deserialize implements serde.Factory
+// Deserialize implements serde.Factory. The ciphervote is decoded in the
+// format of the data.
 func (CiphervoteFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	format := ciphervoteFormats.Get(ctx.GetFormat())
 
 	message, err := format.Decode(ctx, data)
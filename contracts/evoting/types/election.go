@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -14,6 +15,7 @@ import (
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/registry"
 	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
 	"go.dedis.ch/kyber/v3/suites"
 	"golang.org/x/xerrors"
 )
@@ -50,8 +52,7 @@ var BallotsPerBlock = uint32(100)
 // TestCastBallots if true, automatically fills every block with ballots.
 var TestCastBallots = false
 
-// formFormat contains the supported formats for the form. Right now
-// only JSON is supported.
+// formFormat contains the supported formats for the form.
 var formFormat = registry.NewSimpleRegistry()
 
 // RegisterFormFormat registers the engine for the provided format
@@ -75,6 +76,10 @@ type Form struct {
 	Status Status
 	Pubkey kyber.Point
 
+	// CeremonyID is the hex-encoded ID of the DKG key ceremony the form is
+	// bound to. It is empty if the form has its own DKG actor.
+	CeremonyID string
+
 	// BallotSize represents the total size in bytes of one ballot. It is used
 	// to pad smaller ballots such that all  ballots cast have the same size
 	BallotSize int
@@ -87,22 +92,38 @@ type Form struct {
 	// ballots.
 	BallotCount uint32
 
-	// SuffragiaHashes holds a slice of hashes to all SuffragiaIDs.
-	// LG: not really sure if this is needed. In case a Form has also to be
-	// proven to be correct outside the nodes, the hashes are definitely
-	// needed.
+	// SuffragiaHashes holds the chained hashes of the blocks of ballots, so
+	// that the i-th hash commits to the first i+1 blocks and the last one to
+	// all the ballots cast. See Form.BallotsHash.
 	SuffragiaHashes [][]byte
 
-	// ShuffleInstances is all the shuffles, along with their proof and identity
-	// of shuffler.
-	ShuffleInstances []ShuffleInstance
+	// ShuffleInstances reference the shuffles of each round, along with their
+	// proof and identity of shuffler, which are stored under their own key.
+	// See Form.ShuffleInstance.
+	ShuffleInstances []ShuffleRef
+
+	// PendingShuffle references the shuffle of the current round while it is
+	// made in batches. It is added to ShuffleInstances once all the batches
+	// have been shuffled. See Form.Pending.
+	PendingShuffle *ShuffleRef
 
 	// ShuffleThreshold is set based on the roster. We save it so we do not have
-	// to compute it based on the roster each time we need it.
+	// to compute it based on the roster each time we need it. It is also the
+	// number of pubShares needed to decrypt the ballots.
 	ShuffleThreshold int
 
-	// PubsharesUnits is an array containing all the submission of pubShares.
-	// Each node submits its share to its personal index from the DKG service.
+	// Mixers are the public keys of the nodes authorized to shuffle the
+	// ballots in place of the roster. It is empty if the roster shuffles the
+	// ballots.
+	Mixers [][]byte
+
+	// MixerThreshold is the number of shuffles needed when the form has
+	// mixers. See Form.ShuffleRounds.
+	MixerThreshold int
+
+	// PubsharesUnits references all the submissions of pubShares, which are
+	// stored under their own key. Each node submits its share to its personal
+	// index from the DKG service. See Form.Pubshares.
 	PubsharesUnits PubsharesUnits
 
 	DecryptedBallots []Ballot
@@ -119,10 +140,34 @@ type Form struct {
 
 	// Store the list of SCIPER of user that are Voters on the form.
 	Voters []int
+
+	// VoterKeys are the public keys registered by the voters who sign their
+	// ballots, by SCIPER.
+	VoterKeys map[int][]byte
+
+	// Anonymous is true if the voters prove their eligibility with a
+	// credential of the electoral roll instead of their SCIPER. The ballots
+	// are then stored with the tag of the credential.
+	Anonymous bool
+
+	// ElectoralRoll references the public keys of the credentials of the
+	// voters of an anonymous form, which are stored under their own keys.
+	ElectoralRoll *ElectoralRollRef
+
+	// Format is the format the form and its ballots are stored in. It is not
+	// serialized but set when the form is deserialized. The form is serialized
+	// in the format of the context if it is empty.
+	Format serde.Format
 }
 
-// Serialize implements serde.Message
+// Serialize implements serde.Message. The form is serialized in its own
+// format if it is set.
 func (form Form) Serialize(ctx serde.Context) ([]byte, error) {
+	ctx, err := contextOf(ctx, form.Format)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	format := formFormat.Get(ctx.GetFormat())
 
 	data, err := format.Encode(ctx, form)
@@ -150,8 +195,14 @@ func NewFormFactory(cf serde.Factory, rf authority.Factory) FormFactory {
 	}
 }
 
-// Deserialize implements serde.Factory
+// Deserialize implements serde.Factory. The form is decoded in the format of
+// the data, which is then the format of the form.
 func (formFactory FormFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	format := formFormat.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, CiphervoteKey{}, formFactory.ciphervoteFac)
@@ -162,11 +213,21 @@ func (formFactory FormFactory) Deserialize(ctx serde.Context, data []byte) (serd
 		return nil, xerrors.Errorf("failed to decode: %v", err)
 	}
 
+	form, ok := message.(Form)
+	if ok {
+		form.Format = ctx.GetFormat()
+		message = form
+	}
+
 	return message, nil
 }
 
+// ErrFormNotFound is returned by FormFromStore when there is no form with the
+// given ID.
+var ErrFormNotFound = xerrors.New("no form found")
+
 // FormFromStore returns a form from the store given the formIDHex.
-// An error indicates a wrong storage of the form.
+// An error indicates a wrong storage of the form, or ErrFormNotFound.
 func FormFromStore(ctx serde.Context, formFac serde.Factory, formIDHex string,
 	store store.Readable) (Form, error) {
 
@@ -182,7 +243,7 @@ func FormFromStore(ctx serde.Context, formFac serde.Factory, formIDHex string,
 		return form, xerrors.Errorf("while getting data for form: %v", err)
 	}
 	if len(formBuff) == 0 {
-		return form, xerrors.Errorf("no form found")
+		return form, ErrFormNotFound
 	}
 
 	message, err := formFac.Deserialize(ctx, formBuff)
@@ -198,6 +259,19 @@ func FormFromStore(ctx serde.Context, formFac serde.Factory, formIDHex string,
 	return form, nil
 }
 
+// ShuffleRounds returns the number of shuffles needed before the ballots can
+// be decrypted: the mixer threshold if the form has mixers, otherwise the
+// shuffle threshold of the roster.
+func (form *Form) ShuffleRounds() int {
+	// the forms with mixers created before MixerThreshold store it as the
+	// shuffle threshold
+	if len(form.Mixers) != 0 && form.MixerThreshold != 0 {
+		return form.MixerThreshold
+	}
+
+	return form.ShuffleThreshold
+}
+
 // ChunksPerBallot returns the number of chunks of El Gamal pairs needed to
 // represent an encrypted ballot, knowing that one chunk is 29 bytes at most.
 func (form *Form) ChunksPerBallot() int {
@@ -213,17 +287,12 @@ func (form *Form) CastVote(ctx serde.Context, st store.Snapshot, userID string,
 	var suff Suffragia
 	var blockID []byte
 	if form.BallotCount%BallotsPerBlock == 0 {
-		// Need to create a random ID for storing the ballots.
-		// H( formID | ballotcount )
-		// should be random enough, even if it's previsible.
-		id, err := hex.DecodeString(form.FormID)
+		var err error
+
+		blockID, err = form.suffragiaBlockID(form.BallotCount)
 		if err != nil {
-			return xerrors.Errorf("couldn't decode formID: %v", err)
+			return xerrors.Errorf("couldn't get ballots block ID: %v", err)
 		}
-		h := sha256.New()
-		h.Write(id)
-		binary.LittleEndian.PutUint32(id, form.BallotCount)
-		blockID = h.Sum(id[0:4])[:32]
 		err = st.Set(blockID, []byte{})
 		if err != nil {
 			return xerrors.Errorf("couldn't store new ballot block: %v", err)
@@ -236,13 +305,15 @@ func (form *Form) CastVote(ctx serde.Context, st store.Snapshot, userID string,
 		if err != nil {
 			return xerrors.Errorf("couldn't get ballots block: %v", err)
 		}
-		format := suffragiaFormat.Get(ctx.GetFormat())
-		ctx = serde.WithFactory(ctx, CiphervoteKey{}, CiphervoteFactory{})
-		msg, err := format.Decode(ctx, buf)
+		suff, err = decodeSuffragia(ctx, buf)
 		if err != nil {
 			return xerrors.Errorf("couldn't unmarshal ballots block in cast: %v", err)
 		}
-		suff = msg.(Suffragia)
+	}
+
+	err := form.indexBallot(st, suff, userID, ciphervote, len(form.SuffragiaIDs)-1)
+	if err != nil {
+		return xerrors.Errorf("couldn't index ballot: %v", err)
 	}
 
 	suff.CastVote(userID, ciphervote)
@@ -253,18 +324,307 @@ func (form *Form) CastVote(ctx serde.Context, st store.Snapshot, userID string,
 
 		form.BallotCount += BallotsPerBlock - 1
 	}
+
+	// the ballots are stored in the format of the form
+	ctx, err = contextOf(ctx, form.Format)
+	if err != nil {
+		return xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	buf, err := suff.Serialize(ctx)
 	if err != nil {
 		return xerrors.Errorf("couldn't marshal ballots block: %v", err)
 	}
 	err = st.Set(blockID, buf)
 	if err != nil {
-		xerrors.Errorf("couldn't set new ballots block: %v", err)
+		return xerrors.Errorf("couldn't set new ballots block: %v", err)
+	}
+
+	// only the hash of the last block changes
+	last := len(form.SuffragiaIDs) - 1
+
+	err = form.chainBlock(last, suff)
+	if err != nil {
+		return xerrors.Errorf("couldn't chain ballots block: %v", err)
 	}
+
 	form.BallotCount += 1
 	return nil
 }
 
+// suffragiaBlockID returns the ID of the block of ballots created when the
+// given number of ballots have been cast.
+func (form *Form) suffragiaBlockID(ballotCount uint32) ([]byte, error) {
+	// Need to create a random ID for storing the ballots.
+	// H( formID | ballotcount )
+	// should be random enough, even if it's previsible.
+	id, err := hex.DecodeString(form.FormID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't decode formID: %v", err)
+	}
+	h := sha256.New()
+	h.Write(id)
+	binary.LittleEndian.PutUint32(id, ballotCount)
+	return h.Sum(id[0:4])[:32], nil
+}
+
+// chainBlock updates the chained hash of the block at the index, whose
+// ballots are given. The hashes of the blocks before must be up to date.
+func (form *Form) chainBlock(index int, suff Suffragia) error {
+	previous, err := form.previousHash(index)
+	if err != nil {
+		return xerrors.Errorf("couldn't get previous hash: %v", err)
+	}
+
+	blockHash, err := suff.Hash()
+	if err != nil {
+		return xerrors.Errorf("couldn't hash ballots block: %v", err)
+	}
+
+	form.SuffragiaHashes[index] = chainHash(previous, blockHash)
+
+	return nil
+}
+
+// previousHash returns the hash the block at the index is chained to, which
+// is the form ID for the first block.
+func (form *Form) previousHash(index int) ([]byte, error) {
+	if index > 0 {
+		return form.SuffragiaHashes[index-1], nil
+	}
+
+	id, err := hex.DecodeString(form.FormID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't decode formID: %v", err)
+	}
+
+	return id, nil
+}
+
+// BallotsHash returns the hash that commits to all the ballots cast, which is
+// the chained hash of the last block of ballots. It is nil if no ballot has
+// been cast.
+func (form *Form) BallotsHash() []byte {
+	if len(form.SuffragiaHashes) == 0 {
+		return nil
+	}
+
+	return form.SuffragiaHashes[len(form.SuffragiaHashes)-1]
+}
+
+// BallotProof returns the proof that the ballot of the voter is committed to
+// by the hash of the ballots. The proof is about the last block with a ballot
+// of the voter, as it is the one counted. It returns false if the voter
+// didn't cast a ballot.
+func (form *Form) BallotProof(ctx serde.Context, rd store.Readable,
+	voterID string) (BallotProof, bool, error) {
+
+	if len(form.SuffragiaHashes) != len(form.SuffragiaIDs) {
+		return BallotProof{}, false, xerrors.Errorf("%d ballots blocks for %d hashes",
+			len(form.SuffragiaIDs), len(form.SuffragiaHashes))
+	}
+
+	var next [][]byte
+
+	for i := len(form.SuffragiaIDs) - 1; i >= 0; i-- {
+		suff, err := form.suffragiaBlock(ctx, rd, i)
+		if err != nil {
+			return BallotProof{}, false, xerrors.Errorf("couldn't get ballots block: %v", err)
+		}
+
+		for _, u := range suff.VoterIDs {
+			if u != voterID {
+				continue
+			}
+
+			previous, err := form.previousHash(i)
+			if err != nil {
+				return BallotProof{}, false, xerrors.Errorf("couldn't get previous hash: %v", err)
+			}
+
+			proof := BallotProof{
+				Block:     i,
+				Suffragia: suff,
+				Previous:  previous,
+				Next:      next,
+			}
+
+			return proof, true, nil
+		}
+
+		blockHash, err := suff.Hash()
+		if err != nil {
+			return BallotProof{}, false, xerrors.Errorf("couldn't hash ballots block: %v", err)
+		}
+
+		next = append([][]byte{blockHash}, next...)
+	}
+
+	return BallotProof{}, false, nil
+}
+
+// indexBallot records the block at the index as the location of the ballot
+// of the voter, and the ballot as the last one of the voter, so that
+// FindBallot doesn't need to look through the blocks. The suffragia is the
+// block before the ballot is cast, whose previous ballot of the voter, if any,
+// is replaced and not stored anymore.
+func (form *Form) indexBallot(st store.Snapshot, suff Suffragia, voterID string,
+	ciphervote Ciphervote, block int) error {
+
+	for i, u := range suff.VoterIDs {
+		if u != voterID {
+			continue
+		}
+
+		replaced, err := suff.Ciphervotes[i].Hash()
+		if err != nil {
+			return xerrors.Errorf("couldn't hash replaced ballot: %v", err)
+		}
+
+		key, err := form.keyedEntryID(ballotEntry, replaced)
+		if err != nil {
+			return xerrors.Errorf("failed to get ballot ID: %v", err)
+		}
+
+		err = st.Delete(key)
+		if err != nil {
+			return xerrors.Errorf("failed to delete replaced ballot: %v", err)
+		}
+	}
+
+	hash, err := ciphervote.Hash()
+	if err != nil {
+		return xerrors.Errorf("couldn't hash ballot: %v", err)
+	}
+
+	key, err := form.keyedEntryID(ballotEntry, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to get ballot ID: %v", err)
+	}
+
+	location := make([]byte, 4, 4+len(voterID))
+	binary.LittleEndian.PutUint32(location, uint32(block))
+	location = append(location, voterID...)
+
+	err = st.Set(key, location)
+	if err != nil {
+		return xerrors.Errorf("failed to set ballot location: %v", err)
+	}
+
+	key, err = form.keyedEntryID(voterEntry, []byte(voterID))
+	if err != nil {
+		return xerrors.Errorf("failed to get voter ID: %v", err)
+	}
+
+	err = st.Set(key, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to set last ballot: %v", err)
+	}
+
+	return nil
+}
+
+// FindBallot returns where the ballot with the hash, see Ciphervote.Hash, is
+// stored. It returns false if there is no such ballot, which is also the case
+// when the voter replaced it with another one in the same block.
+func (form *Form) FindBallot(rd store.Readable, hash []byte) (BallotLocation,
+	bool, error) {
+
+	key, err := form.keyedEntryID(ballotEntry, hash)
+	if err != nil {
+		return BallotLocation{}, false, xerrors.Errorf("failed to get ballot ID: %v", err)
+	}
+
+	buf, err := rd.Get(key)
+	if err != nil {
+		return BallotLocation{}, false, xerrors.Errorf("failed to get ballot location: %v", err)
+	}
+
+	if len(buf) < 4 {
+		return BallotLocation{}, false, nil
+	}
+
+	block := int(binary.LittleEndian.Uint32(buf))
+	voterID := string(buf[4:])
+
+	if block >= len(form.SuffragiaIDs) {
+		return BallotLocation{}, false, xerrors.Errorf("ballot in unknown block %d", block)
+	}
+
+	key, err = form.keyedEntryID(voterEntry, []byte(voterID))
+	if err != nil {
+		return BallotLocation{}, false, xerrors.Errorf("failed to get voter ID: %v", err)
+	}
+
+	last, err := rd.Get(key)
+	if err != nil {
+		return BallotLocation{}, false, xerrors.Errorf("failed to get last ballot: %v", err)
+	}
+
+	location := BallotLocation{
+		Block:     block,
+		BlockID:   form.SuffragiaIDs[block],
+		VoterID:   voterID,
+		Effective: bytes.Equal(last, hash),
+	}
+
+	return location, true, nil
+}
+
+// IsAudited returns true if the ballot with the hash, see Ciphervote.Hash, has
+// been audited.
+func (form *Form) IsAudited(rd store.Readable, hash []byte) (bool, error) {
+	key, err := form.keyedEntryID(auditEntry, hash)
+	if err != nil {
+		return false, xerrors.Errorf("failed to get audit ID: %v", err)
+	}
+
+	buf, err := rd.Get(key)
+	if err != nil {
+		return false, xerrors.Errorf("failed to get audit: %v", err)
+	}
+
+	return len(buf) != 0, nil
+}
+
+// SetAudited records that the ballot with the hash has been audited, so that
+// it can never be cast. The audits are stored under their own key, so that
+// the form doesn't grow with them.
+func (form *Form) SetAudited(st store.Snapshot, hash []byte) error {
+	key, err := form.keyedEntryID(auditEntry, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to get audit ID: %v", err)
+	}
+
+	err = st.Set(key, []byte{1})
+	if err != nil {
+		return xerrors.Errorf("failed to set audit: %v", err)
+	}
+
+	return nil
+}
+
+// suffragiaBlock returns the ballots of the block at the index.
+func (form *Form) suffragiaBlock(ctx serde.Context, rd store.Readable,
+	index int) (Suffragia, error) {
+
+	buf, err := rd.Get(form.SuffragiaIDs[index])
+	if err != nil {
+		return Suffragia{}, xerrors.Errorf("couldn't get ballots block: %v", err)
+	}
+
+	if len(buf) == 0 {
+		return Suffragia{}, nil
+	}
+
+	suff, err := decodeSuffragia(ctx, buf)
+	if err != nil {
+		return Suffragia{}, xerrors.Errorf("couldn't unmarshal ballots block: %v", err)
+	}
+
+	return suff, nil
+}
+
 // Suffragia returns all ballots from the storage. This should only
 // be called rarely, as it might take a long time.
 // It overwrites ballots cast by the same user and keeps only
@@ -276,13 +636,10 @@ func (form *Form) Suffragia(ctx serde.Context, rd store.Readable) (Suffragia, er
 		if err != nil {
 			return suff, xerrors.Errorf("couldn't get ballot block: %v", err)
 		}
-		format := suffragiaFormat.Get(ctx.GetFormat())
-		ctx = serde.WithFactory(ctx, CiphervoteKey{}, CiphervoteFactory{})
-		msg, err := format.Decode(ctx, buf)
+		suffTmp, err := decodeSuffragia(ctx, buf)
 		if err != nil {
 			return suff, xerrors.Errorf("couldn't unmarshal ballots block in cast: %v", err)
 		}
-		suffTmp := msg.(Suffragia)
 		for i, uid := range suffTmp.VoterIDs {
 			suff.CastVote(uid, suffTmp.Ciphervotes[i])
 		}
@@ -290,6 +647,64 @@ func (form *Form) Suffragia(ctx serde.Context, rd store.Readable) (Suffragia, er
 	return suff, nil
 }
 
+// Migrate stores the ballots, the shuffles and the pubShares of the form in the
+// given format, which becomes the format of the form, and computes the hashes
+// of the ballots again. The form itself must then be stored again.
+func (form *Form) Migrate(st store.Snapshot, format serde.Format) error {
+	ctx, err := NewContext(format)
+	if err != nil {
+		return xerrors.Errorf("failed to get context: %v", err)
+	}
+
+	// the hashes of the blocks are computed again, as forms created before the
+	// chain of hashes have empty ones.
+	form.SuffragiaHashes = make([][]byte, len(form.SuffragiaIDs))
+
+	for i, id := range form.SuffragiaIDs {
+		buf, err := st.Get(id)
+		if err != nil {
+			return xerrors.Errorf("couldn't get ballots block: %v", err)
+		}
+
+		var suff Suffragia
+
+		if len(buf) > 0 {
+			suff, err = decodeSuffragia(ctx, buf)
+			if err != nil {
+				return xerrors.Errorf("couldn't unmarshal ballots block: %v", err)
+			}
+		}
+
+		err = form.chainBlock(i, suff)
+		if err != nil {
+			return xerrors.Errorf("couldn't chain ballots block: %v", err)
+		}
+
+		if len(buf) == 0 || FormatOf(buf) == format {
+			continue
+		}
+
+		buf, err = suff.Serialize(ctx)
+		if err != nil {
+			return xerrors.Errorf("couldn't marshal ballots block: %v", err)
+		}
+
+		err = st.Set(id, buf)
+		if err != nil {
+			return xerrors.Errorf("couldn't set ballots block: %v", err)
+		}
+	}
+
+	form.Format = format
+
+	err = form.migrateEntries(ctx, st)
+	if err != nil {
+		return xerrors.Errorf("failed to migrate entries: %v", err)
+	}
+
+	return nil
+}
+
 // RandomVector is a slice of kyber.Scalar (encoded) which is used to prove
 // and verify the proof of a shuffle
 type RandomVector [][]byte
@@ -337,6 +752,14 @@ type ShuffleInstance struct {
 
 	// ShufflerPublicKey is the key of the node who made the given shuffle.
 	ShufflerPublicKey []byte
+
+	// BatchSize is the maximum number of ballots shuffled together. It is 0 if
+	// all the ballots of the round were shuffled at once.
+	BatchSize int
+
+	// BatchProofs are the proofs of each batch of the round when BatchSize is
+	// not 0. ShuffleProofs is empty in that case.
+	BatchProofs [][]byte
 }
 
 // Configuration contains the configuration of a new poll.
@@ -405,11 +828,13 @@ func (pubshareUnit PubsharesUnit) Fingerprint(writer io.Writer) error {
 	return nil
 }
 
-// PubsharesUnits contains the pubshares submitted in parallel with the
+// PubsharesUnits references the pubshares submitted in parallel with the
 // necessary data to identify the nodes who submitted them and their index.
 type PubsharesUnits struct {
-	// Pubshares holds the nodes' public shares
-	Pubshares []PubsharesUnit
+	// IDs are the keys of the stored PubsharesUnit of each node
+	IDs [][]byte
+	// Hashes are the sha256-hashes of the stored PubsharesUnit of each node
+	Hashes [][]byte
 	// PubKeys contains the pubKey of the nodes who made each corresponding
 	// PubsharesUnit
 	PubKeys [][]byte
@@ -457,7 +882,92 @@ func (form *Form) RemoveVoter(userID string) error {
 		return xerrors.Errorf("Error while retrieving the index of the element.")
 	}
 
+	sciperInt := form.Voters[index]
 	form.Voters = append(form.Voters[:index], form.Voters[index+1:]...)
+
+	// once the form is open, the key is kept so that the voter can't be added
+	// again without it.
+	if form.Status == Initial {
+		delete(form.VoterKeys, sciperInt)
+	}
+
+	return nil
+}
+
+// SetVoterKey registers the public key the voter signs its ballots with. The
+// key can't be changed once the form is open.
+func (form *Form) SetVoterKey(userID string, publicKey []byte) error {
+	sciperInt, err := SciperToInt(userID)
+	if err != nil {
+		return xerrors.Errorf("failed to convert SCIPER to integer: %v", err)
+	}
+
+	err = suite.Point().UnmarshalBinary(publicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal public key: %v", err)
+	}
+
+	_, found := form.VoterKeys[sciperInt]
+	if found && form.Status != Initial {
+		return xerrors.Errorf("the key of voter %s can't be changed once the form "+
+			"is open", userID)
+	}
+
+	if form.VoterKeys == nil {
+		form.VoterKeys = make(map[int][]byte)
+	}
+
+	form.VoterKeys[sciperInt] = publicKey
+
+	return nil
+}
+
+// GetVoterKey returns the public key registered by the voter, or nil if the
+// voter has no key.
+func (form *Form) GetVoterKey(userID string) ([]byte, error) {
+	sciperInt, err := SciperToInt(userID)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to convert SCIPER to integer: %v", err)
+	}
+
+	return form.VoterKeys[sciperInt], nil
+}
+
+// UpdateSequence records the sequence of a signed vote of the voter, which
+// must be greater than the sequence of the voter's previous vote, see
+// CastVote.Sequence. The sequences are stored under their own key, as a
+// big-endian uint64.
+func (form *Form) UpdateSequence(st store.Snapshot, voterID string,
+	sequence uint64) error {
+
+	key, err := form.keyedEntryID(sequenceEntry, []byte(voterID))
+	if err != nil {
+		return xerrors.Errorf("failed to get sequence ID: %v", err)
+	}
+
+	buf, err := st.Get(key)
+	if err != nil {
+		return xerrors.Errorf("failed to get sequence: %v", err)
+	}
+
+	last := uint64(0)
+	if len(buf) == 8 {
+		last = binary.BigEndian.Uint64(buf)
+	}
+
+	if sequence <= last {
+		return xerrors.Errorf("the vote is older than the previous vote of the "+
+			"voter: sequence %d <= %d", sequence, last)
+	}
+
+	buf = make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, sequence)
+
+	err = st.Set(key, buf)
+	if err != nil {
+		return xerrors.Errorf("failed to set sequence: %v", err)
+	}
+
 	return nil
 }
 
@@ -510,6 +1020,264 @@ func (form *Form) RemoveOwner(userID string) error {
 	return nil
 }
 
+// CredentialRingSize is the number of credentials of a ring of the electoral
+// roll. An anonymous vote is signed over the ring of its credential instead of
+// the whole roll, so that the credential of a vote stays small whatever the
+// size of the roll. The rings are the credentials in the order they were
+// added, CredentialRingSize at a time, and the last ring takes the remaining
+// credentials. A ring has therefore between CredentialRingSize and
+// 2*CredentialRingSize-1 credentials, unless the roll is smaller, and a voter
+// is anonymous among the voters of its ring only.
+const CredentialRingSize = 64
+
+// ElectoralRollRef references the electoral roll of an anonymous form. The
+// public keys of the credentials are stored CredentialRingSize at a time
+// under their own keys, so that adding a credential doesn't rewrite the whole
+// roll.
+type ElectoralRollRef struct {
+	// Size is the number of credentials of the roll
+	Size int
+}
+
+// AddCredential adds the public key of a credential to the electoral roll of
+// an anonymous form. The roll can't be changed once the form is open, as the
+// credentials are proven against the rings of the roll.
+func (form *Form) AddCredential(st store.Snapshot, publicKey []byte) error {
+	if !form.Anonymous {
+		return xerrors.Errorf("the form is not anonymous")
+	}
+
+	if form.Status != Initial {
+		return xerrors.Errorf("the electoral roll can't be changed once the form "+
+			"is open, current status: %d", form.Status)
+	}
+
+	if len(publicKey) != suite.PointLen() {
+		return xerrors.Errorf("invalid public key length: %d", len(publicKey))
+	}
+
+	err := suite.Point().UnmarshalBinary(publicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal public key: %v", err)
+	}
+
+	key, err := form.keyedEntryID(credentialEntry, publicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to get credential ID: %v", err)
+	}
+
+	buf, err := st.Get(key)
+	if err != nil {
+		return xerrors.Errorf("failed to get credential: %v", err)
+	}
+
+	if len(buf) != 0 {
+		return xerrors.Errorf("credential %x is already in the roll", publicKey)
+	}
+
+	size := 0
+	if form.ElectoralRoll != nil {
+		size = form.ElectoralRoll.Size
+	}
+
+	index := make([]byte, 4)
+	binary.BigEndian.PutUint32(index, uint32(size))
+
+	err = st.Set(key, index)
+	if err != nil {
+		return xerrors.Errorf("failed to set credential: %v", err)
+	}
+
+	key, err = form.entryID(rollEntry, size/CredentialRingSize)
+	if err != nil {
+		return xerrors.Errorf("failed to get roll ID: %v", err)
+	}
+
+	buf, err = st.Get(key)
+	if err != nil {
+		return xerrors.Errorf("failed to get roll: %v", err)
+	}
+
+	err = st.Set(key, append(buf, publicKey...))
+	if err != nil {
+		return xerrors.Errorf("failed to set roll: %v", err)
+	}
+
+	form.ElectoralRoll = &ElectoralRollRef{
+		Size: size + 1,
+	}
+
+	return nil
+}
+
+// GetElectoralRoll returns the public keys of the credentials of the
+// electoral roll, in order, or nil if the roll is empty.
+func (form *Form) GetElectoralRoll(rd store.Readable) ([][]byte, error) {
+	ref := form.ElectoralRoll
+	if ref == nil {
+		return nil, nil
+	}
+
+	return form.readRoll(rd, 0, ref.Size)
+}
+
+// GetCredentialRing returns the public keys of the credentials of the ring of
+// the electoral roll, in order, see CredentialRingSize.
+func (form *Form) GetCredentialRing(rd store.Readable, ring int) ([][]byte, error) {
+	if form.ElectoralRoll == nil {
+		return nil, xerrors.Errorf("the electoral roll is empty")
+	}
+
+	start, end, err := RingBounds(ring, form.ElectoralRoll.Size)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid ring: %v", err)
+	}
+
+	return form.readRoll(rd, start, end)
+}
+
+// readRoll returns the public keys of the credentials of the electoral roll
+// from start to end, excluded.
+func (form *Form) readRoll(rd store.Readable, start, end int) ([][]byte, error) {
+	size := suite.PointLen()
+	roll := make([][]byte, 0, end-start)
+
+	for chunk := start / CredentialRingSize; len(roll) < end-start; chunk++ {
+		key, err := form.entryID(rollEntry, chunk)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get roll ID: %v", err)
+		}
+
+		buf, err := rd.Get(key)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get roll: %v", err)
+		}
+
+		first := chunk * CredentialRingSize
+		last := min(end, first+CredentialRingSize)
+
+		if len(buf)%size != 0 || len(buf)/size < last-first {
+			return nil, xerrors.Errorf("invalid length of roll %d: %d", chunk,
+				len(buf))
+		}
+
+		for i := max(start, first); i < last; i++ {
+			roll = append(roll, buf[(i-first)*size:(i-first+1)*size])
+		}
+	}
+
+	return roll, nil
+}
+
+// RingBounds returns the first and the last, excluded, indices in the
+// electoral roll of the given size of the credentials of the ring, see
+// CredentialRingSize.
+func RingBounds(ring, size int) (int, int, error) {
+	rings := CredentialRings(size)
+
+	if ring < 0 || ring >= rings {
+		return 0, 0, xerrors.Errorf("ring out of range: %d not in [0, %d)", ring,
+			rings)
+	}
+
+	end := (ring + 1) * CredentialRingSize
+	if ring == rings-1 {
+		end = size
+	}
+
+	return ring * CredentialRingSize, end, nil
+}
+
+// CredentialRings returns the number of rings of an electoral roll of the
+// given size, see CredentialRingSize.
+func CredentialRings(size int) int {
+	if size < CredentialRingSize {
+		return 1
+	}
+
+	return size / CredentialRingSize
+}
+
+// CredentialRing returns the ring of the credential at the given index of an
+// electoral roll of the given size, see CredentialRingSize.
+func CredentialRing(index, size int) int {
+	return min(index/CredentialRingSize, CredentialRings(size)-1)
+}
+
+// VerifyCredential checks that the anonymous vote is signed with a credential
+// of its ring of the electoral roll. It returns the hex-encoded tag of the
+// credential, which is the same for all the votes made with a credential on
+// the form, whatever the ring, but can't be linked to the credential itself.
+func (form *Form) VerifyCredential(rd store.Readable, castVote CastVote) (string, error) {
+	if castVote.VoterID != "" {
+		return "", xerrors.Errorf("an anonymous vote must not have a voter ID")
+	}
+
+	keys, err := form.GetCredentialRing(rd, castVote.Ring)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get ring: %v", err)
+	}
+
+	ring, err := toAnonSet(keys)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode ring: %v", err)
+	}
+
+	hash, err := castVote.Hash()
+	if err != nil {
+		return "", xerrors.Errorf("failed to hash vote: %v", err)
+	}
+
+	tag, err := anon.Verify(suite, hash, ring, []byte(form.FormID), castVote.Credential)
+	if err != nil {
+		return "", xerrors.Errorf("invalid credential: %v", err)
+	}
+
+	return hex.EncodeToString(tag), nil
+}
+
+// SignCredential returns the credential of an anonymous vote, made with the
+// secret of the credential at the given index of the ring of the electoral
+// roll, see GetCredentialRing, which must be the Ring of the vote.
+func SignCredential(castVote CastVote, ring [][]byte, index int,
+	secret kyber.Scalar) ([]byte, error) {
+
+	set, err := toAnonSet(ring)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode ring: %v", err)
+	}
+
+	if index < 0 || index >= len(set) {
+		return nil, xerrors.Errorf("index out of range: %d", index)
+	}
+
+	hash, err := castVote.Hash()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to hash vote: %v", err)
+	}
+
+	return anon.Sign(suite, hash, set, []byte(castVote.FormID), index, secret), nil
+}
+
+func toAnonSet(roll [][]byte) (anon.Set, error) {
+	if len(roll) == 0 {
+		return nil, xerrors.Errorf("the ring is empty")
+	}
+
+	set := make(anon.Set, len(roll))
+
+	for i, key := range roll {
+		set[i] = suite.Point()
+
+		err := set[i].UnmarshalBinary(key)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal credential: %v", err)
+		}
+	}
+
+	return set, nil
+}
+
 func SciperToInt(userID string) (int, error) {
 	sciperInt, err := strconv.Atoi(userID)
 	if err != nil {
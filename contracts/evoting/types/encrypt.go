@@ -0,0 +1,214 @@
+package types
+
+import (
+	"runtime"
+	"sync"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/random"
+	"golang.org/x/xerrors"
+)
+
+// Encrypter ElGamal-encrypts messages with a public key. It keeps a pool of
+// ephemeral keys that can be filled ahead of time with Precompute, so that
+// the scalar multiplications of a pair are done before the message is known.
+//
+// The shared secrets are computed with the constant-time multiplication of
+// kyber, as a lookup in a table indexed by the randomness would leak it
+// through the cache.
+type Encrypter struct {
+	pubkey kyber.Point
+	pool   chan ephemeral
+}
+
+// ephemeral is the randomness of a pair along with its ephemeral key and its
+// shared secret.
+type ephemeral struct {
+	k kyber.Scalar
+	K kyber.Point
+	S kyber.Point
+}
+
+// NewEncrypter returns an encrypter for the public key, whose pool holds at
+// most poolSize ephemeral keys.
+func NewEncrypter(pubkey kyber.Point, poolSize int) *Encrypter {
+	return &Encrypter{
+		pubkey: pubkey.Clone(),
+		pool:   make(chan ephemeral, poolSize),
+	}
+}
+
+// Precompute computes up to n ephemeral keys in parallel and adds them to the
+// pool, so that encrypting a chunk then takes an embedding and an addition.
+// It computes no more keys than the pool has room for.
+func (e *Encrypter) Precompute(n int) error {
+	free := cap(e.pool) - len(e.pool)
+	if n > free {
+		n = free
+	}
+
+	// the function never fails
+	return parallel(n, func(i int) error {
+		select {
+		case e.pool <- e.newEphemeral():
+		default:
+			// the pool was filled by a concurrent call
+		}
+
+		return nil
+	})
+}
+
+// Encrypt ElGamal-encrypts the message, or as much of it as fits in a point.
+// It returns the pair, its randomness and what is left of the message.
+func (e *Encrypter) Encrypt(message []byte) (EGPair, kyber.Scalar, []byte) {
+	max := suite.Point().EmbedLen()
+	if max > len(message) {
+		max = len(message)
+	}
+
+	M := suite.Point().Embed(message[:max], random.New())
+
+	eph := e.next()
+
+	pair := EGPair{
+		K: eph.K,
+		C: suite.Point().Add(eph.S, M),
+	}
+
+	return pair, eph.k, message[max:]
+}
+
+// EncryptBallot encrypts the ballot into the given number of chunks, the
+// ChunksPerBallot of the form. It returns the randomness of each chunk, which
+// allows auditing the ballot, see Ciphervote.Audit.
+func (e *Encrypter) EncryptBallot(ballot []byte, chunks int) (Ciphervote,
+	[]kyber.Scalar, error) {
+
+	chunkSize := suite.Point().EmbedLen()
+
+	if len(ballot) > chunks*chunkSize {
+		return nil, nil, xerrors.Errorf("the ballot is too long: %d > %d chunks of %d bytes",
+			len(ballot), chunks, chunkSize)
+	}
+
+	ciphervote := make(Ciphervote, chunks)
+	randomness := make([]kyber.Scalar, chunks)
+
+	for i := range ciphervote {
+		ciphervote[i], randomness[i], ballot = e.Encrypt(ballot)
+	}
+
+	return ciphervote, randomness, nil
+}
+
+// EncryptBallots encrypts the ballots in parallel, like EncryptBallot.
+func (e *Encrypter) EncryptBallots(ballots [][]byte, chunks int) ([]Ciphervote,
+	[][]kyber.Scalar, error) {
+
+	ciphervotes := make([]Ciphervote, len(ballots))
+	randomness := make([][]kyber.Scalar, len(ballots))
+
+	err := parallel(len(ballots), func(i int) error {
+		var err error
+
+		ciphervotes[i], randomness[i], err = e.EncryptBallot(ballots[i], chunks)
+		if err != nil {
+			return xerrors.Errorf("failed to encrypt ballot %d: %v", i, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ciphervotes, randomness, nil
+}
+
+// next returns an ephemeral key of the pool, or a new one if the pool is
+// empty.
+func (e *Encrypter) next() ephemeral {
+	select {
+	case eph := <-e.pool:
+		return eph
+	default:
+		return e.newEphemeral()
+	}
+}
+
+// newEphemeral picks a new randomness and computes its ephemeral key and its
+// shared secret.
+func (e *Encrypter) newEphemeral() ephemeral {
+	k := suite.Scalar().Pick(random.New())
+
+	return ephemeral{
+		k: k,
+		K: suite.Point().Mul(k, nil),
+		S: suite.Point().Mul(k, e.pubkey),
+	}
+}
+
+// ComputePubshares computes the public shares of the ballots with the private
+// share of a node, in parallel. The share of a pair is C - v * K.
+func ComputePubshares(v kyber.Scalar, ballots []Ciphervote) PubsharesUnit {
+	shares := make(PubsharesUnit, len(ballots))
+
+	// the function never fails
+	_ = parallel(len(ballots), func(i int) error {
+		ballotShares := make([]Pubshare, len(ballots[i]))
+
+		for j, ciphertext := range ballots[i] {
+			S := suite.Point().Mul(v, ciphertext.K)
+			ballotShares[j] = suite.Point().Sub(ciphertext.C, S)
+		}
+
+		shares[i] = ballotShares
+
+		return nil
+	})
+
+	return shares
+}
+
+// parallel calls fn for each index from 0 to n-1 from one worker per CPU,
+// which pull the indexes from a channel. It returns the error of the lowest
+// index that failed.
+func parallel(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
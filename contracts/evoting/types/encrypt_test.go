@@ -0,0 +1,164 @@
+package types
+
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+func TestEncrypter_NewEphemeral(t *testing.T) {
+	pubkey := suite.Point().Pick(suite.RandomStream())
+	e := NewEncrypter(pubkey, 0)
+
+	for i := 0; i < 20; i++ {
+		eph := e.newEphemeral()
+		require.True(t, eph.K.Equal(suite.Point().Mul(eph.k, nil)))
+		require.True(t, eph.S.Equal(suite.Point().Mul(eph.k, pubkey)))
+	}
+}
+
+func TestEncrypter_EncryptBallot(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	e := NewEncrypter(pubkey, 4)
+
+	err := e.Precompute(10)
+	require.NoError(t, err)
+	require.Len(t, e.pool, 4)
+
+	ballot := []byte("select:" + strings.Repeat("a", 40))
+
+	ciphervote, randomness, err := e.EncryptBallot(ballot, 3)
+	require.NoError(t, err)
+	require.Len(t, ciphervote, 3)
+	require.Len(t, e.pool, 1)
+
+	require.Equal(t, ballot, decrypt(t, secret, ciphervote))
+
+	buf := make([][]byte, len(randomness))
+	for i, k := range randomness {
+		buf[i], err = k.MarshalBinary()
+		require.NoError(t, err)
+	}
+
+	audited, err := ciphervote.Audit(pubkey, buf)
+	require.NoError(t, err)
+	require.Equal(t, ballot, audited)
+
+	_, _, err = e.EncryptBallot(ballot, 1)
+	require.EqualError(t, err, "the ballot is too long: 47 > 1 chunks of 29 bytes")
+}
+
+func TestEncrypter_EncryptBallots(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	e := NewEncrypter(pubkey, 0)
+
+	ballots := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+
+	ciphervotes, randomness, err := e.EncryptBallots(ballots, 2)
+	require.NoError(t, err)
+	require.Len(t, ciphervotes, 3)
+	require.Len(t, randomness, 3)
+
+	for i, ciphervote := range ciphervotes {
+		require.Equal(t, ballots[i], decrypt(t, secret, ciphervote))
+	}
+
+	ballots = append(ballots, []byte(strings.Repeat("a", 30)))
+
+	_, _, err = e.EncryptBallots(ballots, 1)
+	require.EqualError(t, err, "failed to encrypt ballot 3: the ballot is too "+
+		"long: 30 > 1 chunks of 29 bytes")
+}
+
+func TestComputePubshares(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	ballots := [][]byte{[]byte("a"), []byte("b")}
+
+	ciphervotes, _, err := NewEncrypter(pubkey, 0).EncryptBallots(ballots, 2)
+	require.NoError(t, err)
+
+	shares := ComputePubshares(secret, ciphervotes)
+	require.Len(t, shares, 2)
+
+	for i, ballotShares := range shares {
+		require.Len(t, ballotShares, 2)
+
+		// with a single node, the share is the message
+		data, err := ballotShares[0].Data()
+		require.NoError(t, err)
+		require.Equal(t, ballots[i], data)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	n := 4*runtime.NumCPU() + 1
+
+	var running, maxRunning int32
+	calls := make([]int32, n)
+
+	err := parallel(n, func(i int) error {
+		cur := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if cur <= max || atomic.CompareAndSwapInt32(&maxRunning, max, cur) {
+				break
+			}
+		}
+
+		atomic.AddInt32(&calls[i], 1)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.LessOrEqual(t, int(maxRunning), runtime.NumCPU())
+
+	for _, c := range calls {
+		require.Equal(t, int32(1), c)
+	}
+
+	err = parallel(n, func(i int) error {
+		if i == 3 || i == 5 {
+			return xerrors.Errorf("fake error %d", i)
+		}
+
+		return nil
+	})
+	require.EqualError(t, err, "fake error 3")
+
+	err = parallel(0, func(i int) error {
+		return xerrors.Errorf("fake error")
+	})
+	require.NoError(t, err)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func decrypt(t *testing.T, secret kyber.Scalar, ciphervote Ciphervote) []byte {
+	var data []byte
+
+	for _, egpair := range ciphervote {
+		S := suite.Point().Mul(secret, egpair.K)
+		M := suite.Point().Sub(egpair.C, S)
+
+		chunk, err := M.Data()
+		require.NoError(t, err)
+
+		data = append(data, chunk...)
+	}
+
+	return data
+}
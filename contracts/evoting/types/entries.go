@@ -0,0 +1,169 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// The values of a form that grow with the number of ballots or voters, such as
+// the shuffles, the pubShares and the electoral roll, are stored under their
+// own key, so that the form only holds their references and stays cheap to
+// load.
+
+const (
+	shuffleEntry    = "shuffle"
+	pubsharesEntry  = "pubshares"
+	sequenceEntry   = "sequence"
+	rollEntry       = "roll"
+	credentialEntry = "credential"
+	ballotEntry     = "ballot"
+	voterEntry      = "voter"
+	auditEntry      = "audit"
+)
+
+// entryID returns the key of the index-th entry of the kind, which is
+// H( formID | kind | index ).
+func (form *Form) entryID(kind string, index int) ([]byte, error) {
+	id, err := hex.DecodeString(form.FormID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't decode formID: %v", err)
+	}
+
+	indexBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexBuf, uint32(index))
+
+	h := sha256.New()
+	h.Write(id)
+	h.Write([]byte(kind))
+	h.Write(indexBuf)
+
+	return h.Sum(nil), nil
+}
+
+// keyedEntryID returns the key of the entry of the kind for the given name,
+// which is H( formID | kind | name ).
+func (form *Form) keyedEntryID(kind string, name []byte) ([]byte, error) {
+	id, err := hex.DecodeString(form.FormID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't decode formID: %v", err)
+	}
+
+	h := sha256.New()
+	h.Write(id)
+	h.Write([]byte(kind))
+	h.Write(name)
+
+	return h.Sum(nil), nil
+}
+
+// storeEntry stores the message under the key, in the format of the form, and
+// returns the sha256-hash of the stored value.
+func (form *Form) storeEntry(ctx serde.Context, st store.Snapshot, key []byte,
+	msg serde.Message) ([]byte, error) {
+
+	ctx, err := contextOf(ctx, form.Format)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
+	buf, err := msg.Serialize(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to serialize entry: %v", err)
+	}
+
+	err = st.Set(key, buf)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to set entry: %v", err)
+	}
+
+	hash := sha256.Sum256(buf)
+
+	return hash[:], nil
+}
+
+// readEntry returns the value stored under the key, which must have the given
+// sha256-hash.
+func readEntry(rd store.Readable, key, hash []byte) ([]byte, error) {
+	buf, err := rd.Get(key)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get entry: %v", err)
+	}
+
+	actual := sha256.Sum256(buf)
+	if !bytes.Equal(actual[:], hash) {
+		return nil, xerrors.Errorf("entry %x doesn't match its hash", key)
+	}
+
+	return buf, nil
+}
+
+// migrateEntries stores the shuffles and the pubShares of the form in the
+// format of the context, which must be the format of the form, and updates
+// their hashes.
+func (form *Form) migrateEntries(ctx serde.Context, st store.Snapshot) error {
+	refs := make([]*ShuffleRef, 0, len(form.ShuffleInstances)+1)
+	for i := range form.ShuffleInstances {
+		refs = append(refs, &form.ShuffleInstances[i])
+	}
+
+	if form.PendingShuffle != nil {
+		refs = append(refs, form.PendingShuffle)
+	}
+
+	for _, ref := range refs {
+		buf, err := readEntry(st, ref.ID, ref.Hash)
+		if err != nil {
+			return xerrors.Errorf("failed to read shuffle: %v", err)
+		}
+
+		if FormatOf(buf) == ctx.GetFormat() {
+			continue
+		}
+
+		shuffle, err := decodeShuffleInstance(ctx, buf)
+		if err != nil {
+			return xerrors.Errorf("failed to decode shuffle: %v", err)
+		}
+
+		ref.Hash, err = form.storeEntry(ctx, st, ref.ID, shuffle)
+		if err != nil {
+			return xerrors.Errorf("failed to store shuffle: %v", err)
+		}
+	}
+
+	units := &form.PubsharesUnits
+
+	if len(units.Hashes) != len(units.IDs) {
+		return xerrors.Errorf("%d pubShares for %d hashes", len(units.IDs),
+			len(units.Hashes))
+	}
+
+	for i, id := range units.IDs {
+		buf, err := readEntry(st, id, units.Hashes[i])
+		if err != nil {
+			return xerrors.Errorf("failed to read pubShares: %v", err)
+		}
+
+		if FormatOf(buf) == ctx.GetFormat() {
+			continue
+		}
+
+		unit, err := decodePubsharesUnit(ctx, buf)
+		if err != nil {
+			return xerrors.Errorf("failed to decode pubShares: %v", err)
+		}
+
+		units.Hashes[i], err = form.storeEntry(ctx, st, id, unit)
+		if err != nil {
+			return xerrors.Errorf("failed to store pubShares: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"bytes"
+	"sync"
+
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/json"
+	"golang.org/x/xerrors"
+)
+
+// FormatProtobuf is the compact binary format of the forms, the ballots and
+// the transactions. The kyber points are stored as raw bytes instead of base64
+// strings, which makes the values with many ciphertexts several times smaller
+// than in JSON.
+const FormatProtobuf serde.Format = "PROTOBUF"
+
+// ProtobufPrefix starts every value encoded in FormatProtobuf, so that the
+// format of a value is known from its data. A JSON value never starts with a
+// zero byte.
+var ProtobufPrefix = []byte{0x00, 'd', 'v', 0x01}
+
+// contexts are the functions that create a context of each format.
+var contexts = struct {
+	sync.Mutex
+	fns map[serde.Format]func() serde.Context
+}{
+	fns: map[serde.Format]func() serde.Context{
+		serde.FormatJSON: json.NewContext,
+	},
+}
+
+// RegisterContext registers the function that creates a context of the format.
+func RegisterContext(format serde.Format, fn func() serde.Context) {
+	contexts.Lock()
+	defer contexts.Unlock()
+
+	contexts.fns[format] = fn
+}
+
+// NewContext returns a new context of the format. The engines of the format
+// must be registered, usually by importing their package.
+func NewContext(format serde.Format) (serde.Context, error) {
+	contexts.Lock()
+	fn, found := contexts.fns[format]
+	contexts.Unlock()
+
+	if !found {
+		return serde.Context{}, xerrors.Errorf("unknown format: %q", format)
+	}
+
+	return fn(), nil
+}
+
+// FormatOf returns the format of the data, which is FormatProtobuf if it
+// starts with ProtobufPrefix, and JSON otherwise.
+func FormatOf(data []byte) serde.Format {
+	if bytes.HasPrefix(data, ProtobufPrefix) {
+		return FormatProtobuf
+	}
+
+	return serde.FormatJSON
+}
+
+// contextOf returns the context if it has the format, or a new context of the
+// format. The factories of the context are not kept in that case. An empty
+// format is the format of the context.
+func contextOf(ctx serde.Context, format serde.Format) (serde.Context, error) {
+	if format == "" || format == ctx.GetFormat() {
+		return ctx, nil
+	}
+
+	return NewContext(format)
+}
+
+// decodingContext returns a context to decode the data. The values are
+// decoded in the format they are stored in, whatever the format of the
+// context, so that the nodes can read the values written in any format. The
+// factories must be added to the returned context.
+func decodingContext(ctx serde.Context, data []byte) (serde.Context, error) {
+	format := FormatOf(data)
+
+	// the contexts of the other formats, such as the fake ones of the tests,
+	// are kept for the values that are not in the binary format.
+	if format == serde.FormatJSON && ctx.GetFormat() != FormatProtobuf {
+		return ctx, nil
+	}
+
+	return contextOf(ctx, format)
+}
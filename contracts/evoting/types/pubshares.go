@@ -0,0 +1,103 @@
+package types
+
+import (
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/registry"
+	"golang.org/x/xerrors"
+)
+
+// pubsharesFormats contains the supported formats for the stored pubShares.
+var pubsharesFormats = registry.NewSimpleRegistry()
+
+// RegisterPubsharesFormat registers the engine for the provided format
+func RegisterPubsharesFormat(format serde.Format, engine serde.FormatEngine) {
+	pubsharesFormats.Register(format, engine)
+}
+
+// Serialize implements serde.Message
+func (pubshareUnit PubsharesUnit) Serialize(ctx serde.Context) ([]byte, error) {
+	format := pubsharesFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, pubshareUnit)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode pubShares: %v", err)
+	}
+
+	return data, nil
+}
+
+// decodePubsharesUnit decodes a submission of pubShares in the format of the
+// data.
+func decodePubsharesUnit(ctx serde.Context, data []byte) (PubsharesUnit, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
+	format := pubsharesFormats.Get(ctx.GetFormat())
+
+	msg, err := format.Decode(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode: %v", err)
+	}
+
+	unit, ok := msg.(PubsharesUnit)
+	if !ok {
+		return nil, xerrors.Errorf("wrong message type: %T", msg)
+	}
+
+	return unit, nil
+}
+
+// AddPubshares stores the pubShares submitted by the node with the public key
+// and the index under their own key.
+func (form *Form) AddPubshares(ctx serde.Context, st store.Snapshot,
+	unit PubsharesUnit, pubKey []byte, index int) error {
+
+	units := &form.PubsharesUnits
+
+	id, err := form.entryID(pubsharesEntry, len(units.IDs))
+	if err != nil {
+		return xerrors.Errorf("failed to get pubShares ID: %v", err)
+	}
+
+	hash, err := form.storeEntry(ctx, st, id, unit)
+	if err != nil {
+		return xerrors.Errorf("failed to store pubShares: %v", err)
+	}
+
+	units.IDs = append(units.IDs, id)
+	units.Hashes = append(units.Hashes, hash)
+	units.PubKeys = append(units.PubKeys, pubKey)
+	units.Indexes = append(units.Indexes, index)
+
+	return nil
+}
+
+// Pubshares returns all the submitted pubShares from the store, in the order
+// of the submissions.
+func (form *Form) Pubshares(ctx serde.Context, rd store.Readable) ([]PubsharesUnit, error) {
+	units := form.PubsharesUnits
+
+	if len(units.Hashes) != len(units.IDs) {
+		return nil, xerrors.Errorf("%d pubShares for %d hashes", len(units.IDs),
+			len(units.Hashes))
+	}
+
+	res := make([]PubsharesUnit, len(units.IDs))
+
+	for i, id := range units.IDs {
+		buf, err := readEntry(rd, id, units.Hashes[i])
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read pubShares: %v", err)
+		}
+
+		res[i], err = decodePubsharesUnit(ctx, buf)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode pubShares: %v", err)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,214 @@
+package types
+
+import (
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/registry"
+	"golang.org/x/xerrors"
+)
+
+// shuffleFormats contains the supported formats for the stored shuffles.
+var shuffleFormats = registry.NewSimpleRegistry()
+
+// RegisterShuffleFormat registers the engine for the provided format
+func RegisterShuffleFormat(format serde.Format, engine serde.FormatEngine) {
+	shuffleFormats.Register(format, engine)
+}
+
+// MinShuffleBatchSize is the smallest batch size allowed when ballots are
+// shuffled in batches. It ensures that every batch contains at least two
+// ballots, which is needed to make a shuffle.
+const MinShuffleBatchSize = 4
+
+// ShuffleBatchCount returns the number of batches needed to shuffle nbBallots
+// with batches of at most batchSize ballots. A batchSize of 0 means that all
+// the ballots are shuffled at once.
+func ShuffleBatchCount(nbBallots, batchSize int) int {
+	if batchSize <= 0 || nbBallots <= batchSize {
+		return 1
+	}
+
+	return (nbBallots + batchSize - 1) / batchSize
+}
+
+// ShuffleBatch returns the indexes, in the input of a shuffle round, of the
+// ballots that are part of the given batch. Batches have balanced sizes. On
+// even rounds a batch is made of consecutive ballots, and on odd rounds of
+// interleaved ballots, so that ballots of different batches get mixed together
+// over the rounds. It returns nil if the batch index is out of range.
+func ShuffleBatch(round, nbBallots, batchSize, batchIndex int) []int {
+	count := ShuffleBatchCount(nbBallots, batchSize)
+
+	if batchIndex < 0 || batchIndex >= count {
+		return nil
+	}
+
+	if round%2 == 1 {
+		indexes := make([]int, 0, nbBallots/count+1)
+
+		for i := batchIndex; i < nbBallots; i += count {
+			indexes = append(indexes, i)
+		}
+
+		return indexes
+	}
+
+	start := batchIndex * nbBallots / count
+	end := (batchIndex + 1) * nbBallots / count
+
+	indexes := make([]int, 0, end-start)
+
+	for i := start; i < end; i++ {
+		indexes = append(indexes, i)
+	}
+
+	return indexes
+}
+
+// SelectCiphervotes returns the ciphervotes at the given indexes.
+func SelectCiphervotes(ciphervotes []Ciphervote, indexes []int) []Ciphervote {
+	res := make([]Ciphervote, len(indexes))
+
+	for i, index := range indexes {
+		res[i] = ciphervotes[index]
+	}
+
+	return res
+}
+
+// ShuffleRef references a shuffle of the form, which is stored under its own
+// key. It holds what the nodes need to know about the shuffle without loading
+// it.
+type ShuffleRef struct {
+	// ID is the key of the stored ShuffleInstance
+	ID []byte
+
+	// Hash is the sha256-hash of the stored ShuffleInstance
+	Hash []byte
+
+	// ShufflerPublicKey is the key of the node who made the shuffle.
+	ShufflerPublicKey []byte
+
+	// BallotCount is the number of shuffled ballots.
+	BallotCount int
+
+	// BatchSize is the maximum number of ballots shuffled together, or 0.
+	BatchSize int
+
+	// Batches is the number of batches of the shuffle when BatchSize is not 0.
+	Batches int
+
+	// LastBlock is the block count of the contract when the last batch of the
+	// pending shuffle was added.
+	LastBlock uint64
+}
+
+// Serialize implements serde.Message
+func (shuffleInstance ShuffleInstance) Serialize(ctx serde.Context) ([]byte, error) {
+	format := shuffleFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, shuffleInstance)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode shuffle instance: %v", err)
+	}
+
+	return data, nil
+}
+
+// decodeShuffleInstance decodes a shuffle in the format of the data.
+func decodeShuffleInstance(ctx serde.Context, data []byte) (ShuffleInstance, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return ShuffleInstance{}, xerrors.Errorf("failed to get context: %v", err)
+	}
+
+	format := shuffleFormats.Get(ctx.GetFormat())
+	ctx = serde.WithFactory(ctx, CiphervoteKey{}, CiphervoteFactory{})
+
+	msg, err := format.Decode(ctx, data)
+	if err != nil {
+		return ShuffleInstance{}, xerrors.Errorf("failed to decode: %v", err)
+	}
+
+	shuffleInstance, ok := msg.(ShuffleInstance)
+	if !ok {
+		return ShuffleInstance{}, xerrors.Errorf("wrong message type: %T", msg)
+	}
+
+	return shuffleInstance, nil
+}
+
+// ShuffleInstance returns the shuffle of the round from the store.
+func (form *Form) ShuffleInstance(ctx serde.Context, rd store.Readable,
+	round int) (ShuffleInstance, error) {
+
+	if round < 0 || round >= len(form.ShuffleInstances) {
+		return ShuffleInstance{}, xerrors.Errorf("no shuffle for round %d", round)
+	}
+
+	return readShuffle(ctx, rd, form.ShuffleInstances[round])
+}
+
+// LastShuffle returns the shuffle of the last round from the store.
+func (form *Form) LastShuffle(ctx serde.Context, rd store.Readable) (ShuffleInstance, error) {
+	return form.ShuffleInstance(ctx, rd, len(form.ShuffleInstances)-1)
+}
+
+// Pending returns the pending shuffle from the store, or an empty shuffle if
+// there is none.
+func (form *Form) Pending(ctx serde.Context, rd store.Readable) (ShuffleInstance, error) {
+	if form.PendingShuffle == nil {
+		return ShuffleInstance{}, nil
+	}
+
+	return readShuffle(ctx, rd, *form.PendingShuffle)
+}
+
+// StoreShuffle stores the shuffle of the current round under its own key. It
+// becomes the pending shuffle of the form, or the shuffle of the round if it
+// is complete.
+func (form *Form) StoreShuffle(ctx serde.Context, st store.Snapshot,
+	shuffleInstance ShuffleInstance, complete bool) error {
+
+	id, err := form.entryID(shuffleEntry, len(form.ShuffleInstances))
+	if err != nil {
+		return xerrors.Errorf("failed to get shuffle ID: %v", err)
+	}
+
+	hash, err := form.storeEntry(ctx, st, id, shuffleInstance)
+	if err != nil {
+		return xerrors.Errorf("failed to store shuffle: %v", err)
+	}
+
+	ref := ShuffleRef{
+		ID:                id,
+		Hash:              hash,
+		ShufflerPublicKey: shuffleInstance.ShufflerPublicKey,
+		BallotCount:       len(shuffleInstance.ShuffledBallots),
+		BatchSize:         shuffleInstance.BatchSize,
+		Batches:           len(shuffleInstance.BatchProofs),
+	}
+
+	if complete {
+		form.ShuffleInstances = append(form.ShuffleInstances, ref)
+		form.PendingShuffle = nil
+	} else {
+		form.PendingShuffle = &ref
+	}
+
+	return nil
+}
+
+func readShuffle(ctx serde.Context, rd store.Readable, ref ShuffleRef) (ShuffleInstance, error) {
+	buf, err := readEntry(rd, ref.ID, ref.Hash)
+	if err != nil {
+		return ShuffleInstance{}, xerrors.Errorf("failed to read shuffle: %v", err)
+	}
+
+	shuffleInstance, err := decodeShuffleInstance(ctx, buf)
+	if err != nil {
+		return ShuffleInstance{}, xerrors.Errorf("failed to decode shuffle: %v", err)
+	}
+
+	return shuffleInstance, nil
+}
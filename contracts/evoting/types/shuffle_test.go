@@ -0,0 +1,150 @@
+package types
+
+import (
+	"crypto/sha256"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestShuffleBatchCount(t *testing.T) {
+	require.Equal(t, 1, ShuffleBatchCount(10, 0))
+	require.Equal(t, 1, ShuffleBatchCount(10, 10))
+	require.Equal(t, 2, ShuffleBatchCount(11, 10))
+	require.Equal(t, 5, ShuffleBatchCount(50, 10))
+}
+
+func TestShuffleBatch(t *testing.T) {
+	require.Nil(t, ShuffleBatch(0, 10, 4, -1))
+	require.Nil(t, ShuffleBatch(0, 10, 4, 3))
+
+	// even rounds use consecutive ballots with balanced sizes
+	require.Equal(t, []int{0, 1, 2}, ShuffleBatch(0, 10, 4, 0))
+	require.Equal(t, []int{3, 4, 5}, ShuffleBatch(0, 10, 4, 1))
+	require.Equal(t, []int{6, 7, 8, 9}, ShuffleBatch(0, 10, 4, 2))
+
+	// odd rounds interleave the ballots
+	require.Equal(t, []int{0, 3, 6, 9}, ShuffleBatch(1, 10, 4, 0))
+	require.Equal(t, []int{1, 4, 7}, ShuffleBatch(1, 10, 4, 1))
+	require.Equal(t, []int{2, 5, 8}, ShuffleBatch(1, 10, 4, 2))
+
+	// every ballot is part of exactly one batch, which has at least two ballots
+	for _, round := range []int{0, 1} {
+		for nbBallots := 2; nbBallots < 50; nbBallots++ {
+			count := ShuffleBatchCount(nbBallots, MinShuffleBatchSize)
+			all := []int{}
+
+			for i := 0; i < count; i++ {
+				batch := ShuffleBatch(round, nbBallots, MinShuffleBatchSize, i)
+				require.GreaterOrEqual(t, len(batch), 2)
+				require.LessOrEqual(t, len(batch), MinShuffleBatchSize)
+
+				all = append(all, batch...)
+			}
+
+			sort.Ints(all)
+
+			for i := range all {
+				require.Equal(t, i, all[i])
+			}
+
+			require.Len(t, all, nbBallots)
+		}
+	}
+}
+
+func TestSelectCiphervotes(t *testing.T) {
+	ciphervotes := []Ciphervote{
+		{EGPair{}},
+		{EGPair{}, EGPair{}},
+		{EGPair{}, EGPair{}, EGPair{}},
+	}
+
+	res := SelectCiphervotes(ciphervotes, []int{2, 0})
+	require.Len(t, res, 2)
+	require.Len(t, res[0], 3)
+	require.Len(t, res[1], 1)
+}
+
+func TestForm_EntryID(t *testing.T) {
+	form := Form{FormID: "abcd"}
+
+	shuffle0, err := form.entryID(shuffleEntry, 0)
+	require.NoError(t, err)
+	require.Len(t, shuffle0, sha256.Size)
+
+	shuffle1, err := form.entryID(shuffleEntry, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, shuffle0, shuffle1)
+
+	pubshares0, err := form.entryID(pubsharesEntry, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, shuffle0, pubshares0)
+
+	other := Form{FormID: "ef01"}
+
+	otherShuffle0, err := other.entryID(shuffleEntry, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, shuffle0, otherShuffle0)
+
+	form.FormID = "not hex"
+
+	_, err = form.entryID(shuffleEntry, 0)
+	require.ErrorContains(t, err, "couldn't decode formID")
+}
+
+func TestForm_ShuffleInstance(t *testing.T) {
+	value := []byte("shuffle")
+	hash := sha256.Sum256(value)
+
+	rd := fakeReadable{"id": value}
+
+	form := Form{ShuffleInstances: []ShuffleRef{{ID: []byte("id"), Hash: []byte("hash")}}}
+
+	_, err := form.ShuffleInstance(nil, rd, 1)
+	require.EqualError(t, err, "no shuffle for round 1")
+
+	_, err = form.LastShuffle(nil, rd)
+	require.EqualError(t, err, "failed to read shuffle: entry 6964 doesn't "+
+		"match its hash")
+
+	form.ShuffleInstances = nil
+
+	_, err = form.LastShuffle(nil, rd)
+	require.EqualError(t, err, "no shuffle for round -1")
+
+	pending, err := form.Pending(nil, rd)
+	require.NoError(t, err)
+	require.Equal(t, ShuffleInstance{}, pending)
+
+	buf, err := readEntry(rd, []byte("id"), hash[:])
+	require.NoError(t, err)
+	require.Equal(t, value, buf)
+
+	_, err = readEntry(rd, []byte("unknown"), hash[:])
+	require.EqualError(t, err, "failed to get entry: not found")
+}
+
+func TestForm_Pubshares(t *testing.T) {
+	form := Form{PubsharesUnits: PubsharesUnits{IDs: [][]byte{[]byte("id")}}}
+
+	_, err := form.Pubshares(nil, fakeReadable{})
+	require.EqualError(t, err, "1 pubShares for 0 hashes")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// fakeReadable is a store.Readable over a map.
+type fakeReadable map[string][]byte
+
+func (rd fakeReadable) Get(key []byte) ([]byte, error) {
+	value, ok := rd[string(key)]
+	if !ok {
+		return nil, xerrors.New("not found")
+	}
+
+	return value, nil
+}
@@ -1,7 +1,9 @@
 package types
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/registry"
@@ -9,8 +11,7 @@ import (
 	"golang.org/x/xerrors"
 )
 
-// suffragiaFormat contains the supported formats for the form. Right now
-// only JSON is supported.
+// suffragiaFormat contains the supported formats for the blocks of ballots.
 var suffragiaFormat = registry.NewSimpleRegistry()
 
 // RegisterSuffragiaFormat registers the engine for the provided format
@@ -35,6 +36,29 @@ func (s Suffragia) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// decodeSuffragia decodes a block of ballots in the format of the data.
+func decodeSuffragia(ctx serde.Context, data []byte) (Suffragia, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return Suffragia{}, xerrors.Errorf("failed to get context: %v", err)
+	}
+
+	format := suffragiaFormat.Get(ctx.GetFormat())
+	ctx = serde.WithFactory(ctx, CiphervoteKey{}, CiphervoteFactory{})
+
+	msg, err := format.Decode(ctx, data)
+	if err != nil {
+		return Suffragia{}, err
+	}
+
+	suff, ok := msg.(Suffragia)
+	if !ok {
+		return Suffragia{}, xerrors.Errorf("wrong message type: %T", msg)
+	}
+
+	return suff, nil
+}
+
 // CastVote adds a new vote and its associated user or updates a user's vote.
 func (s *Suffragia) CastVote(voterID string, ciphervote Ciphervote) {
 	for i, u := range s.VoterIDs {
@@ -48,20 +72,113 @@ func (s *Suffragia) CastVote(voterID string, ciphervote Ciphervote) {
 	s.Ciphervotes = append(s.Ciphervotes, ciphervote.Copy())
 }
 
-// Hash returns the hash of this list of ballots.
-func (s *Suffragia) Hash(ctx serde.Context) ([]byte, error) {
+// Hash returns the hash of this list of ballots. It only depends on the
+// voters and the points of their ballots, and not on the format the list is
+// stored in, so that anyone can compute it from the ballots.
+func (s *Suffragia) Hash() ([]byte, error) {
 	h := sha256.New()
+	lenBuf := make([]byte, 4)
+
 	for i, u := range s.VoterIDs {
+		binary.LittleEndian.PutUint32(lenBuf, uint32(len(u)))
+		h.Write(lenBuf)
 		h.Write([]byte(u))
-		buf, err := s.Ciphervotes[i].Serialize(ctx)
+
+		binary.LittleEndian.PutUint32(lenBuf, uint32(len(s.Ciphervotes[i])))
+		h.Write(lenBuf)
+
+		err := s.Ciphervotes[i].FingerPrint(h)
 		if err != nil {
-			return nil, xerrors.Errorf("couldn't serialize ciphervote: %v", err)
+			return nil, xerrors.Errorf("couldn't fingerprint ciphervote: %v", err)
 		}
-		h.Write(buf)
 	}
+
 	return h.Sum(nil), nil
 }
 
+// chainHash returns the hash of a block of ballots chained to the previous
+// ones, which is H( previous | blockHash ).
+func chainHash(previous, blockHash []byte) []byte {
+	h := sha256.New()
+	h.Write(previous)
+	h.Write(blockHash)
+
+	return h.Sum(nil)
+}
+
+// BallotProof proves that the ballot of a voter is in a block of ballots of a
+// form, and that this block is committed to by the hash of the ballots of the
+// form, see Form.BallotsHash.
+type BallotProof struct {
+	// Block is the index of the block of ballots
+	Block int
+
+	// Suffragia are the ballots of the block
+	Suffragia Suffragia
+
+	// Previous is the chained hash of the blocks before, or the form ID for
+	// the first block.
+	Previous []byte
+
+	// Next are the hashes of the blocks after, in order.
+	Next [][]byte
+}
+
+// Verify checks that the ciphervote is the ballot of the voter in the block of
+// the proof, and that the block leads to the given hash of the ballots of the
+// form.
+func (p BallotProof) Verify(voterID string, ciphervote Ciphervote, ballotsHash []byte) error {
+	found := false
+
+	for i, u := range p.Suffragia.VoterIDs {
+		if u != voterID {
+			continue
+		}
+
+		if !p.Suffragia.Ciphervotes[i].Equal(ciphervote) {
+			return xerrors.Errorf("the ballot of %s doesn't match", voterID)
+		}
+
+		found = true
+	}
+
+	if !found {
+		return xerrors.Errorf("no ballot of %s in block %d", voterID, p.Block)
+	}
+
+	blockHash, err := p.Suffragia.Hash()
+	if err != nil {
+		return xerrors.Errorf("couldn't hash block: %v", err)
+	}
+
+	hash := chainHash(p.Previous, blockHash)
+	for _, next := range p.Next {
+		hash = chainHash(hash, next)
+	}
+
+	if !bytes.Equal(hash, ballotsHash) {
+		return xerrors.Errorf("block %d doesn't lead to the ballots hash", p.Block)
+	}
+
+	return nil
+}
+
+// BallotLocation tells where a ballot is stored, see Form.FindBallot.
+type BallotLocation struct {
+	// Block is the index of the block of ballots
+	Block int
+
+	// BlockID is the key the block of ballots is stored under
+	BlockID []byte
+
+	// VoterID is the voter that cast the ballot
+	VoterID string
+
+	// Effective is false if the voter cast another ballot in a later block,
+	// which is the one counted.
+	Effective bool
+}
+
 // CiphervotesFromPairs transforms two parallel lists of EGPoints to a list of
 // Ciphervotes.
 func CiphervotesFromPairs(X, Y [][]kyber.Point) ([]Ciphervote, error) {
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuffragia_Hash(t *testing.T) {
+	suff := makeSuffragia("a", "b")
+
+	hash, err := suff.Hash()
+	require.NoError(t, err)
+	require.Len(t, hash, 32)
+
+	same := Suffragia{
+		VoterIDs:    []string{"a", "b"},
+		Ciphervotes: []Ciphervote{suff.Ciphervotes[0].Copy(), suff.Ciphervotes[1].Copy()},
+	}
+
+	sameHash, err := same.Hash()
+	require.NoError(t, err)
+	require.Equal(t, hash, sameHash)
+
+	// the hash changes with the voters and with their ballots
+	other := Suffragia{
+		VoterIDs:    []string{"a", "c"},
+		Ciphervotes: suff.Ciphervotes,
+	}
+
+	otherHash, err := other.Hash()
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+
+	other = Suffragia{
+		VoterIDs:    suff.VoterIDs,
+		Ciphervotes: []Ciphervote{suff.Ciphervotes[1], suff.Ciphervotes[0]},
+	}
+
+	otherHash, err = other.Hash()
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+}
+
+func TestBallotProof_Verify(t *testing.T) {
+	blocks := []Suffragia{
+		makeSuffragia("a", "b"),
+		makeSuffragia("c"),
+		makeSuffragia("d", "e"),
+	}
+
+	previous := []byte("formID")
+	chain := make([][]byte, len(blocks))
+	blockHashes := make([][]byte, len(blocks))
+
+	for i, block := range blocks {
+		var err error
+
+		blockHashes[i], err = block.Hash()
+		require.NoError(t, err)
+
+		chain[i] = chainHash(previous, blockHashes[i])
+		previous = chain[i]
+	}
+
+	head := chain[len(chain)-1]
+
+	proof := BallotProof{
+		Block:     1,
+		Suffragia: blocks[1],
+		Previous:  chain[0],
+		Next:      blockHashes[2:],
+	}
+
+	err := proof.Verify("c", blocks[1].Ciphervotes[0], head)
+	require.NoError(t, err)
+
+	err = proof.Verify("a", blocks[1].Ciphervotes[0], head)
+	require.EqualError(t, err, "no ballot of a in block 1")
+
+	err = proof.Verify("c", blocks[0].Ciphervotes[0], head)
+	require.EqualError(t, err, "the ballot of c doesn't match")
+
+	err = proof.Verify("c", blocks[1].Ciphervotes[0], chain[1])
+	require.EqualError(t, err, "block 1 doesn't lead to the ballots hash")
+
+	proof.Next = nil
+
+	err = proof.Verify("c", blocks[1].Ciphervotes[0], chain[1])
+	require.NoError(t, err)
+
+	proof = BallotProof{
+		Block:     0,
+		Suffragia: blocks[0],
+		Previous:  []byte("formID"),
+		Next:      blockHashes[1:],
+	}
+
+	err = proof.Verify("b", blocks[0].Ciphervotes[1], head)
+	require.NoError(t, err)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// makeSuffragia returns a block with a different ballot for each voter.
+func makeSuffragia(voterIDs ...string) Suffragia {
+	var suff Suffragia
+
+	for _, voterID := range voterIDs {
+		k := suite.Point().Pick(suite.RandomStream())
+		c := suite.Point().Pick(suite.RandomStream())
+
+		suff.CastVote(voterID, Ciphervote{EGPair{K: k, C: c}})
+	}
+
+	return suff
+}
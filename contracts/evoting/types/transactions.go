@@ -2,7 +2,9 @@ package types
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"strconv"
 
@@ -73,8 +75,14 @@ func NewTransactionFactory(cf serde.Factory) TransactionFactory {
 	}
 }
 
-// Deserialize implements serde.Factory
+// Deserialize implements serde.Factory. The transaction is decoded in the
+// format of the data, so that the nodes accept the transactions of any format.
 func (transactionFactory TransactionFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	ctx, err := decodingContext(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get context: %v", err)
+	}
+
 	format := transactionFormats.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, CiphervoteKey{}, transactionFactory.ciphervoteFac)
@@ -94,6 +102,15 @@ type CreateForm struct {
 	Configuration Configuration
 	// UserID of the owner that is performing the action
 	UserID string
+	// Anonymous is true if the voters prove their eligibility with a
+	// credential of the electoral roll instead of their SCIPER.
+	Anonymous bool
+	// Mixers are the public keys of the nodes authorized to shuffle the
+	// ballots in place of the roster. It is optional.
+	Mixers [][]byte
+	// MixerThreshold is the number of shuffles made by mixers needed. It must
+	// be set if Mixers is set.
+	MixerThreshold int
 }
 
 // Serialize implements serde.Message
@@ -116,6 +133,9 @@ type OpenForm struct {
 	FormID string
 	// UserID of the owner that is performing the action
 	UserID string
+	// CeremonyID is the hex-encoded ID of the DKG key ceremony the form is
+	// bound to. If empty, the form uses its own DKG actor.
+	CeremonyID string
 }
 
 // Serialize implements serde.Message
@@ -138,6 +158,22 @@ type CastVote struct {
 	FormID  string
 	VoterID string
 	Ballot  Ciphervote
+	// Signature is the Schnorr signature of the result of Hash() with the key
+	// registered by the voter on the form. It is empty if the voter has no
+	// key, in which case the vote is authenticated by the trusted frontend.
+	Signature []byte
+	// Credential is the linkable ring signature of the result of Hash() with
+	// a credential of the electoral roll, for anonymous forms. The VoterID is
+	// empty in that case.
+	Credential []byte
+	// Ring is the index of the ring of the electoral roll the credential is
+	// made over, see CredentialRingSize.
+	Ring int
+	// Sequence is part of the signed hash and must be greater than the
+	// sequence of the previous vote of the voter, so that an earlier vote
+	// can't be replayed after a re-vote. It is only checked for the signed
+	// votes.
+	Sequence uint64
 }
 
 // Serialize implements serde.Message
@@ -152,6 +188,24 @@ func (castVote CastVote) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// Hash returns the hash of the vote that is signed by the voter.
+func (castVote CastVote) Hash() ([]byte, error) {
+	h := sha256.New()
+
+	_, err := fmt.Fprintf(h, "%s:%s:%d:", castVote.FormID, castVote.VoterID,
+		castVote.Sequence)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to write the vote IDs: %v", err)
+	}
+
+	err = castVote.Ballot.FingerPrint(h)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fingerprint the ballot: %v", err)
+	}
+
+	return h.Sum(nil), nil
+}
+
 // CloseForm defines the transaction to close a form
 //
 // - implements serde.Message
@@ -194,6 +248,12 @@ type ShuffleBallots struct {
 	PublicKey []byte
 	// UserID of the owner that is performing the action
 	UserID string
+	// BatchSize is the maximum number of ballots shuffled in a batch. If it is
+	// 0, the transaction contains the shuffle of all the ballots of the round.
+	BatchSize int
+	// BatchIndex is the index of the batch shuffled by the transaction when
+	// BatchSize is not 0.
+	BatchIndex int
 }
 
 // Serialize implements serde.Message
@@ -309,6 +369,57 @@ func (deleteForm DeleteForm) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// MigrateForm defines the transaction to store a form and its ballots in
+// another format
+//
+// - implements serde.Message
+type MigrateForm struct {
+	// FormID is hex-encoded
+	FormID string
+	// UserID of the owner that is performing the action
+	UserID string
+	// Format is the new format of the form, such as FormatProtobuf
+	Format string
+}
+
+// Serialize implements serde.Message
+func (migrateForm MigrateForm) Serialize(ctx serde.Context) ([]byte, error) {
+	format := transactionFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, migrateForm)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode migrate form: %v", err)
+	}
+
+	return data, nil
+}
+
+// AuditBallot defines the transaction to audit a ballot, by revealing the
+// randomness it is encrypted with, so that the voter can check that it
+// encrypts the choices made. An audited ballot can't be cast anymore.
+//
+// - implements serde.Message
+type AuditBallot struct {
+	// FormID is hex-encoded
+	FormID string
+	Ballot Ciphervote
+	// Randomness holds the marshalled scalar each pair of the ballot is
+	// encrypted with, see Ciphervote.Audit
+	Randomness [][]byte
+}
+
+// Serialize implements serde.Message
+func (auditBallot AuditBallot) Serialize(ctx serde.Context) ([]byte, error) {
+	format := transactionFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, auditBallot)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode audit ballot: %v", err)
+	}
+
+	return data, nil
+}
+
 // RandomID returns the hex encoding of a randomly created 32 byte ID.
 func RandomID() (string, error) {
 	buf := make([]byte, 32)
@@ -328,6 +439,14 @@ func (shuffleBallots ShuffleBallots) Fingerprint(writer io.Writer) error {
 		return xerrors.Errorf("failed to write the form ID: %v", err)
 	}
 
+	if shuffleBallots.BatchSize != 0 {
+		_, err = fmt.Fprintf(writer, "batch:%d:%d:%d", shuffleBallots.Round,
+			shuffleBallots.BatchSize, shuffleBallots.BatchIndex)
+		if err != nil {
+			return xerrors.Errorf("failed to write the batch: %v", err)
+		}
+	}
+
 	for _, ballot := range shuffleBallots.ShuffledBallots {
 		err := ballot.FingerPrint(writer)
 		if err != nil {
@@ -446,6 +565,9 @@ type AddVoter struct {
 	FormID           string
 	TargetUserID     string
 	PerformingUserID string
+	// PublicKey is the Ed25519 public key of the voter. It is optional. If it
+	// is set, the ballots of the voter must be signed with it.
+	PublicKey []byte
 }
 
 // Serialize implements serde.Message
@@ -460,6 +582,29 @@ func (addVoter AddVoter) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// AddCredential defines the transaction to add the public key of a credential
+// to the electoral roll of an anonymous form
+//
+// - implements serde.Message
+type AddCredential struct {
+	// FormID is hex-encoded
+	FormID           string
+	PublicKey        []byte
+	PerformingUserID string
+}
+
+// Serialize implements serde.Message
+func (addCredential AddCredential) Serialize(ctx serde.Context) ([]byte, error) {
+	format := transactionFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, addCredential)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode Add Credential: %v", err)
+	}
+
+	return data, nil
+}
+
 // RemoveVoter defines the transaction to Remove an Voter
 //
 // - implements serde.Message
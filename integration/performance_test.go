@@ -3,6 +3,7 @@ package integration
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -13,14 +14,18 @@ import (
 
 	"github.com/c4dt/d-voting/contracts/evoting"
 	"github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/proxy/client"
 	"github.com/c4dt/d-voting/services/dkg"
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/execution/native"
 	"go.dedis.ch/dela/core/ordering/cosipbft"
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/kyber/v3/suites"
 	"golang.org/x/xerrors"
 )
 
+var suite = suites.MustFind("Ed25519")
+
 // Check the shuffled votes versus the cast votes and a few nodes.
 // One transaction contains one vote.
 func BenchmarkIntegration_CustomVotesScenario(b *testing.B) {
@@ -33,6 +38,85 @@ func BenchmarkIntegration_CustomVotesScenario_StuffBallots(b *testing.B) {
 	customVotesScenario(b, true)
 }
 
+// Encrypt the ballots one by one, as the voters do, and in a parallel batch,
+// with and without a pool of ephemeral keys computed ahead.
+func BenchmarkEncryptBallots(b *testing.B) {
+	numBallots := 100
+	numChunks := 3
+
+	pubkey, ballots := setupEncryptBench(b, numBallots, numChunks)
+
+	b.Run("one by one", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, ballot := range ballots {
+				_, err := client.EncryptBallot(pubkey, ballot, numChunks)
+				require.NoError(b, err)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			encrypter, err := client.NewEncrypter(pubkey, 0)
+			require.NoError(b, err)
+
+			_, err = encrypter.EncryptBallots(ballots, numChunks)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("batch precomputed", func(b *testing.B) {
+		encrypter, err := client.NewEncrypter(pubkey, numBallots*numChunks)
+		require.NoError(b, err)
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			err = encrypter.Precompute(numBallots * numChunks)
+			require.NoError(b, err)
+			b.StartTimer()
+
+			_, err = encrypter.EncryptBallots(ballots, numChunks)
+			require.NoError(b, err)
+		}
+	})
+}
+
+// Compute the public shares of a node one ballot after the other, and spread
+// over the CPUs as the DKG does.
+func BenchmarkComputePubshares(b *testing.B) {
+	numBallots := 1000
+	numChunks := 3
+
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	ballots := make([][]byte, numBallots)
+	for i := range ballots {
+		ballots[i] = []byte("select:" + strconv.Itoa(i))
+	}
+
+	ciphervotes, _, err := types.NewEncrypter(pubkey, 0).EncryptBallots(ballots,
+		numChunks)
+	require.NoError(b, err)
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, ciphervote := range ciphervotes {
+				for _, egpair := range ciphervote {
+					S := suite.Point().Mul(secret, egpair.K)
+					suite.Point().Sub(egpair.C, S)
+				}
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			types.ComputePubshares(secret, ciphervotes)
+		}
+	})
+}
+
 func customVotesScenario(b *testing.B, stuffing bool) {
 	numNodes := 3
 	numVotes := 200
@@ -309,6 +393,23 @@ func castVotesNChunks(m txManager, actor dkg.Actor, form types.Form,
 	return votes, nil
 }
 
+// setupEncryptBench returns the hex-encoded public key of a form and ballots
+// filling the given number of chunks.
+func setupEncryptBench(b *testing.B, numBallots, numChunks int) (string, []string) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+
+	pubkey, err := suite.Point().Mul(secret, nil).MarshalBinary()
+	require.NoError(b, err)
+
+	ballots := make([]string, numBallots)
+	for i := range ballots {
+		ballots[i] = fmt.Sprintf("text:%d:", i)
+		ballots[i] += strings.Repeat("=", 29*numChunks-len(ballots[i]))
+	}
+
+	return hex.EncodeToString(pubkey), ballots
+}
+
 func closeNodesBench(b *testing.B, nodes []dVotingCosiDela) {
 	wait := sync.WaitGroup{}
 	wait.Add(len(nodes))
@@ -0,0 +1,264 @@
+// Package confirm submits transactions to the pool and tracks their inclusion
+// in the chain. It is shared by the services and the proxy so that they all
+// deal in the same way with nonces that are out of sync and with transactions
+// that are never included.
+package confirm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/pool"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// TimeoutReason is the reason of the result of a transaction that has not
+	// been included before the end of the watch.
+	TimeoutReason = "watch timeout"
+
+	// defaultTimeout is the time during which the inclusion of a transaction
+	// is watched.
+	defaultTimeout = time.Second * 20
+
+	// defaultAttempts is the number of times a transaction is added to the
+	// pool before giving up.
+	defaultAttempts = 5
+
+	// defaultBackoff is the time waited after the first failed attempt. It is
+	// doubled after each attempt.
+	defaultBackoff = time.Millisecond * 500
+)
+
+// MakeTx returns the transaction to submit. It is called again after the nonce
+// has been synchronized when the pool refuses the transaction.
+type MakeTx func() (txn.Transaction, error)
+
+// Result is the outcome of a submitted transaction.
+type Result struct {
+	// Included is false if the transaction has not been included before the
+	// end of the watch.
+	Included bool
+	Accepted bool
+	// Reason is the reason of the refusal when the transaction is not
+	// accepted.
+	Reason string
+}
+
+// Receipt allows to follow a transaction that has been added to the pool.
+type Receipt struct {
+	tx   txn.Transaction
+	done chan Result
+}
+
+// GetTransaction returns the submitted transaction.
+func (r Receipt) GetTransaction() txn.Transaction {
+	return r.tx
+}
+
+// Done returns a channel that receives the result of the transaction once it
+// is known.
+func (r Receipt) Done() <-chan Result {
+	return r.done
+}
+
+// Wait blocks until the result of the transaction is known.
+func (r Receipt) Wait() Result {
+	return <-r.done
+}
+
+// OnDone calls fn with the result of the transaction once it is known. It
+// doesn't block.
+func (r Receipt) OnDone(fn func(txn.Transaction, Result)) {
+	go func() {
+		fn(r.tx, <-r.done)
+	}()
+}
+
+// Service submits transactions and tracks their inclusion.
+type Service struct {
+	ordering ordering.Service
+	pool     pool.Pool
+	mngr     txn.Manager
+
+	// lock makes sure that the transactions are added to the pool in the
+	// order of their nonce, and that the nonce is not synchronized while
+	// another transaction is made.
+	lock *sync.Mutex
+
+	timeout  time.Duration
+	attempts int
+	backoff  time.Duration
+}
+
+// NewService returns a new confirmation service that adds the transactions to
+// the given pool and watches their inclusion on the ordering service. The
+// manager must be the one used to make the transactions.
+func NewService(srv ordering.Service, p pool.Pool, mngr txn.Manager) Service {
+	return Service{
+		ordering: srv,
+		pool:     p,
+		mngr:     mngr,
+		lock:     new(sync.Mutex),
+		timeout:  defaultTimeout,
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+	}
+}
+
+// Submit adds the transaction to the pool like Add, and watches its inclusion
+// until the context is done or the watch times out.
+func (s Service) Submit(ctx context.Context, makeTx MakeTx) (Receipt, error) {
+	// the watch must start before the transaction is added, otherwise its
+	// inclusion could be missed.
+	watchCtx, cancel := context.WithCancel(ctx)
+	events := s.ordering.Watch(watchCtx)
+
+	tx, err := s.Add(ctx, makeTx)
+	if err != nil {
+		cancel()
+		return Receipt{}, err
+	}
+
+	receipt := Receipt{
+		tx:   tx,
+		done: make(chan Result, 1),
+	}
+
+	// the watch times out from the moment the transaction is in the pool
+	timer := time.AfterFunc(s.timeout, cancel)
+
+	go func() {
+		defer cancel()
+		defer timer.Stop()
+
+		receipt.done <- watch(events, tx.GetID())
+		close(receipt.done)
+	}()
+
+	return receipt, nil
+}
+
+// SubmitAndWait does the same as Submit but waits for the result of the
+// transaction.
+func (s Service) SubmitAndWait(ctx context.Context, makeTx MakeTx) (txn.Transaction,
+	Result, error) {
+
+	receipt, err := s.Submit(ctx, makeTx)
+	if err != nil {
+		return nil, Result{}, xerrors.Errorf("failed to submit: %v", err)
+	}
+
+	return receipt.GetTransaction(), receipt.Wait(), nil
+}
+
+// Sync synchronizes the nonce of the manager, so that it is not changed while
+// a transaction is made and added to the pool.
+func (s Service) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.mngr.Sync()
+}
+
+// Add makes the transaction and adds it to the pool. If the pool refuses it
+// because its nonce is not in sync, the nonce is synchronized and a new
+// transaction is made and added after an exponential backoff. It returns once
+// the transaction is in the pool, or an error if it could not be added after
+// a few attempts or for another reason. Its inclusion is not watched.
+func (s Service) Add(ctx context.Context, makeTx MakeTx) (txn.Transaction, error) {
+	backoff := s.backoff
+
+	for attempt := 1; ; attempt++ {
+		tx, retry, err := s.addOnce(makeTx)
+		if !retry {
+			return tx, err
+		}
+
+		if attempt >= s.attempts {
+			return nil, xerrors.Errorf("failed to add transaction to the "+
+				"pool after %d attempts: %v", attempt, err)
+		}
+
+		dela.Logger.Warn().Err(err).Msgf("failed to add tx, nonce synced "+
+			"(attempt %d/%d)", attempt, s.attempts)
+
+		// the lock is released while waiting, so that the other
+		// transactions are not held up.
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("submission interrupted: %v", ctx.Err())
+		}
+
+		backoff *= 2
+	}
+}
+
+// addOnce makes the transaction and adds it to the pool, so that no other
+// transaction is made in between. If the pool refuses the transaction because
+// of its nonce, the nonce is synchronized and it returns true, along with the
+// error of the pool.
+func (s Service) addOnce(makeTx MakeTx) (txn.Transaction, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, err := makeTx()
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to make tx: %v", err)
+	}
+
+	err = s.pool.Add(tx)
+	if err == nil {
+		return tx, false, nil
+	}
+
+	if !isNonceError(err) {
+		return nil, false, xerrors.Errorf("failed to add transaction to the pool: %v", err)
+	}
+
+	syncErr := s.mngr.Sync()
+	if syncErr != nil {
+		return nil, false, xerrors.Errorf("failed to sync manager: %v", syncErr)
+	}
+
+	return nil, true, err
+}
+
+// isNonceError returns true if the pool refused the transaction because of its
+// nonce, in which case it is worth making it again with a fresh nonce. The
+// validation of the pool only tells it in the message of the error.
+func isNonceError(err error) bool {
+	return strings.Contains(err.Error(), "nonce")
+}
+
+// watch reads the events until the transaction is found. The result is not
+// included if the channel is closed before, which happens when the watch
+// times out.
+func watch(events <-chan ordering.Event, txID []byte) Result {
+	for event := range events {
+		for _, res := range event.Transactions {
+			if !bytes.Equal(res.GetTransaction().GetID(), txID) {
+				continue
+			}
+
+			dela.Logger.Info().Hex("id", txID).Msg("transaction included in the block")
+
+			accepted, msg := res.GetStatus()
+
+			return Result{
+				Included: true,
+				Accepted: accepted,
+				Reason:   msg,
+			}
+		}
+	}
+
+	return Result{Reason: TimeoutReason}
+}
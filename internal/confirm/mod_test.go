@@ -0,0 +1,173 @@
+package confirm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/pool"
+	"golang.org/x/xerrors"
+)
+
+func TestService_Submit(t *testing.T) {
+	service := fake.Service{}
+	pool := fake.Pool{Service: &service}
+
+	confirmer := NewService(&service, &pool, fake.Manager{})
+
+	makeTx := func() (txn.Transaction, error) {
+		return fake.Transaction{Nonce: 1, Id: []byte("tx")}, nil
+	}
+
+	// the first transaction of the fake service is rejected
+	tx, res, err := confirmer.SubmitAndWait(context.Background(), makeTx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("tx"), tx.GetID())
+	require.True(t, res.Included)
+	require.False(t, res.Accepted)
+
+	receipt, err := confirmer.Submit(context.Background(), makeTx)
+	require.NoError(t, err)
+
+	select {
+	case res = <-receipt.Done():
+	case <-time.After(time.Second):
+		t.Fatal("result not received")
+	}
+
+	require.True(t, res.Included)
+	require.True(t, res.Accepted)
+
+	_, err = confirmer.Submit(context.Background(), func() (txn.Transaction, error) {
+		return nil, fake.GetError()
+	})
+	require.EqualError(t, err, fake.Err("failed to make tx"))
+}
+
+func TestService_Add_Retry(t *testing.T) {
+	nonceErr := xerrors.Errorf("nonce '0' < '1'")
+
+	pool := &fakePool{errs: []error{nonceErr, nonceErr}}
+
+	confirmer := NewService(&fake.Service{}, pool, fake.Manager{})
+	confirmer.attempts = 2
+	confirmer.backoff = time.Millisecond
+
+	made := 0
+
+	makeTx := func() (txn.Transaction, error) {
+		made++
+		return fake.Transaction{Id: []byte("tx")}, nil
+	}
+
+	_, err := confirmer.Add(context.Background(), makeTx)
+	require.EqualError(t, err, "failed to add transaction to the pool after "+
+		"2 attempts: nonce '0' < '1'")
+
+	// the transaction is made again after the nonce is synchronized
+	require.Equal(t, 2, made)
+
+	made = 0
+	pool.errs = []error{nonceErr}
+
+	tx, err := confirmer.Add(context.Background(), makeTx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("tx"), tx.GetID())
+	require.Equal(t, 2, made)
+
+	// the other errors are not retried
+	made = 0
+	pool.errs = []error{fake.GetError()}
+
+	_, err = confirmer.Add(context.Background(), makeTx)
+	require.EqualError(t, err, fake.Err("failed to add transaction to the pool"))
+	require.Equal(t, 1, made)
+}
+
+func TestService_Add_Backoff(t *testing.T) {
+	pool := &fakePool{errs: []error{xerrors.Errorf("nonce '0' < '1'")}}
+
+	confirmer := NewService(&fake.Service{}, pool, fake.Manager{})
+	confirmer.backoff = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+
+	go func() {
+		_, err := confirmer.Add(ctx, func() (txn.Transaction, error) {
+			return fake.Transaction{Id: []byte("tx1")}, nil
+		})
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return pool.len() == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the first transaction waits for the backoff without blocking the others
+	tx, err := confirmer.Add(context.Background(), func() (txn.Transaction, error) {
+		return fake.Transaction{Id: []byte("tx2")}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("tx2"), tx.GetID())
+
+	cancel()
+
+	select {
+	case err = <-done:
+		require.EqualError(t, err, "submission interrupted: context canceled")
+	case <-time.After(time.Second):
+		t.Fatal("submission not interrupted")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	events := make(chan ordering.Event)
+	close(events)
+
+	res := watch(events, []byte("tx"))
+	require.False(t, res.Included)
+	require.Equal(t, TimeoutReason, res.Reason)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// fakePool is a pool that refuses the transactions with the errors, one per
+// transaction, and then accepts them.
+type fakePool struct {
+	pool.Pool
+	sync.Mutex
+
+	errs  []error
+	added []txn.Transaction
+}
+
+func (p *fakePool) Add(tx txn.Transaction) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.added = append(p.added, tx)
+
+	if len(p.errs) == 0 {
+		return nil
+	}
+
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+
+	return err
+}
+
+func (p *fakePool) len() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return len(p.added)
+}
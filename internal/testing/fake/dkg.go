@@ -3,6 +3,7 @@ package fake
 import (
 	"github.com/c4dt/d-voting/services/dkg"
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/kyber/v3"
 )
 
@@ -19,9 +20,26 @@ func (f BadPedersen) GetActor(formID []byte) (dkg.Actor, bool) {
 	return nil, false
 }
 
+func (f BadPedersen) ListenCeremony(ceremonyID []byte, txmngr txn.Manager) (dkg.Actor, error) {
+	return nil, f.Err
+}
+
+func (f BadPedersen) GetCeremony(ceremonyID []byte) (dkg.Actor, bool) {
+	return nil, false
+}
+
+func (f BadPedersen) Ceremonies() [][]byte {
+	return nil
+}
+
+func (f BadPedersen) Forms() [][]byte {
+	return nil
+}
+
 // - implements dkg.DKG
 type Pedersen struct {
-	Actors map[string]dkg.Actor
+	Actors         map[string]dkg.Actor
+	CeremonyActors map[string]dkg.Actor
 }
 
 func (f Pedersen) Listen(formID []byte, txmngr txn.Manager) (dkg.Actor, error) {
@@ -35,10 +53,38 @@ func (f Pedersen) GetActor(formID []byte) (dkg.Actor, bool) {
 	return a, exists
 }
 
+func (f Pedersen) ListenCeremony(ceremonyID []byte, txmngr txn.Manager) (dkg.Actor, error) {
+	actor := DKGActor{PubKey: suite.Point().Pick(suite.RandomStream())}
+	f.CeremonyActors[string(ceremonyID)] = actor
+	return actor, nil
+}
+
+func (f Pedersen) GetCeremony(ceremonyID []byte) (dkg.Actor, bool) {
+	a, exists := f.CeremonyActors[string(ceremonyID)]
+	return a, exists
+}
+
+func (f Pedersen) Ceremonies() [][]byte {
+	ids := make([][]byte, 0, len(f.CeremonyActors))
+	for id := range f.CeremonyActors {
+		ids = append(ids, []byte(id))
+	}
+	return ids
+}
+
+func (f Pedersen) Forms() [][]byte {
+	ids := make([][]byte, 0, len(f.Actors))
+	for id := range f.Actors {
+		ids = append(ids, []byte(id))
+	}
+	return ids
+}
+
 // - implements dkg.Actor
 type DKGActor struct {
-	Err    error
-	PubKey kyber.Point
+	Err          error
+	PubKey       kyber.Point
+	Participants []mino.Address
 }
 
 func (f DKGActor) Setup() (pubKey kyber.Point, err error) {
@@ -49,6 +95,10 @@ func (f DKGActor) GetPublicKey() (kyber.Point, error) {
 	return f.PubKey, f.Err
 }
 
+func (f DKGActor) GetParticipants() ([]mino.Address, error) {
+	return f.Participants, f.Err
+}
+
 func (f DKGActor) Encrypt(message []byte) (K, C kyber.Point, remainder []byte, err error) {
 	return nil, nil, nil, f.Err
 }
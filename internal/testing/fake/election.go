@@ -31,7 +31,7 @@ func NewForm(ctx serde.Context, snapshot store.Snapshot, formID string) (types.F
 		FormID:           formID,
 		Status:           types.Closed,
 		Pubkey:           pubKey,
-		ShuffleInstances: []types.ShuffleInstance{},
+		ShuffleInstances: []types.ShuffleRef{},
 		DecryptedBallots: nil,
 		ShuffleThreshold: 1,
 	}
@@ -0,0 +1,252 @@
+package tracing
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+)
+
+// instrumentationName is the name of the tracer of d-Voting.
+const instrumentationName = "github.com/c4dt/d-voting"
+
+// defaultServiceName is the name of the service of the spans when
+// OTEL_SERVICE_NAME is not set.
+const defaultServiceName = "dvoting"
+
+// FormAttr is the attribute of the spans that holds the hex-encoded ID of the
+// form.
+const FormAttr = attribute.Key("dvoting.form")
+
+// propagator carries the trace context across the transactions, in the W3C
+// Trace Context format.
+var propagator = propagation.TraceContext{}
+
+// Tracer returns the OpenTelemetry tracer of d-Voting. Its spans are dropped
+// until a provider is set with Start.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// OTLPConfigured returns true if an OTLP endpoint is set in the environment,
+// with OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT.
+func OTLPConfigured() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// NewOTLPExporter returns an exporter that sends the spans over HTTP to the
+// OTLP endpoint set in the environment.
+func NewOTLPExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create OTLP exporter: %v", err)
+	}
+
+	return exporter, nil
+}
+
+// Start sets the global tracer provider, which exports the spans with the
+// processor. Nodes use a batch processor of the OTLP exporter, and tests can
+// use a simple processor of the in-memory exporter of
+// go.opentelemetry.io/otel/sdk/trace/tracetest. The provider must be shut
+// down to flush the remaining spans.
+func Start(processor sdktrace.SpanProcessor) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(serviceName())))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create resource: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	return provider, nil
+}
+
+// Inject returns the trace context of the span of the context, to be stored
+// in a transaction, or nil if there is no span.
+func Inject(ctx context.Context) []byte {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+
+	traceparent := carrier.Get("traceparent")
+	if traceparent == "" {
+		return nil
+	}
+
+	return []byte(traceparent)
+}
+
+// Extract returns a context with the trace context given by Inject as the
+// remote parent.
+func Extract(ctx context.Context, traceparent []byte) context.Context {
+	if len(traceparent) == 0 {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{"traceparent": string(traceparent)}
+
+	return propagator.Extract(ctx, carrier)
+}
+
+// maxFormSpans is the number of forms whose last request is kept. The least
+// recently linked forms are dropped first, as the IDs of the requests are not
+// checked.
+const maxFormSpans = 1024
+
+// formSpans keeps the span of the last request about each form, so that the
+// streams of the protocols started in the background are linked to it.
+var formSpans = newSpanCache(maxFormSpans)
+
+// LinkForm records the span of the context as the last request about the
+// form. formID is hex-encoded.
+func LinkForm(ctx context.Context, formID string) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return
+	}
+
+	formSpans.put(formID, spanCtx)
+}
+
+// StartStream starts the span of a stream of a protocol about the form,
+// linked to the last request about it, and sets the protocol of the context
+// for the tracing of the messages. The request is forgotten when the span
+// ends, unless another one was linked since. formID is hex-encoded.
+func StartStream(ctx context.Context, protocol string, formID string) (context.Context, trace.Span) {
+	ctx = context.WithValue(ctx, ProtocolKey, protocol)
+
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(attribute.String(ProtocolTag, protocol), FormAttr.String(formID)),
+	}
+
+	spanCtx, found := formSpans.get(formID)
+	if !found {
+		return Tracer().Start(ctx, protocol, opts...)
+	}
+
+	opts = append(opts, trace.WithLinks(trace.Link{SpanContext: spanCtx}))
+
+	ctx, span := Tracer().Start(ctx, protocol, opts...)
+
+	return ctx, streamSpan{Span: span, formID: formID, link: spanCtx}
+}
+
+// streamSpan is the span of a stream linked to a request.
+//
+// - implements trace.Span
+type streamSpan struct {
+	trace.Span
+
+	formID string
+	link   trace.SpanContext
+}
+
+// End implements trace.Span. It forgets the request the stream is linked to.
+func (s streamSpan) End(options ...trace.SpanEndOption) {
+	formSpans.remove(s.formID, s.link)
+
+	s.Span.End(options...)
+}
+
+// spanCache is a least recently used cache of the span of the last request
+// about each form.
+type spanCache struct {
+	sync.Mutex
+
+	max int
+	// order holds the entries, the most recently linked first
+	order *list.List
+	spans map[string]*list.Element
+}
+
+// spanEntry is an entry of the cache.
+type spanEntry struct {
+	formID  string
+	spanCtx trace.SpanContext
+}
+
+// newSpanCache returns an empty cache that holds at most max forms.
+func newSpanCache(max int) *spanCache {
+	return &spanCache{
+		max:   max,
+		order: list.New(),
+		spans: make(map[string]*list.Element),
+	}
+}
+
+// put records the span of the form, and drops the least recently linked form
+// if the cache is full.
+func (c *spanCache) put(formID string, spanCtx trace.SpanContext) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, found := c.spans[formID]
+	if found {
+		elem.Value = spanEntry{formID: formID, spanCtx: spanCtx}
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.spans[formID] = c.order.PushFront(spanEntry{formID: formID, spanCtx: spanCtx})
+
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.spans, oldest.Value.(spanEntry).formID)
+	}
+}
+
+// get returns the span of the form, and false if there is none.
+func (c *spanCache) get(formID string) (trace.SpanContext, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, found := c.spans[formID]
+	if !found {
+		return trace.SpanContext{}, false
+	}
+
+	return elem.Value.(spanEntry).spanCtx, true
+}
+
+// remove forgets the form if its span is still the given one.
+func (c *spanCache) remove(formID string, spanCtx trace.SpanContext) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, found := c.spans[formID]
+	if !found || !elem.Value.(spanEntry).spanCtx.Equal(spanCtx) {
+		return
+	}
+
+	c.order.Remove(elem)
+	delete(c.spans, formID)
+}
+
+// serviceName returns the name of the service of the spans.
+func serviceName() string {
+	name := os.Getenv("OTEL_SERVICE_NAME")
+	if name == "" {
+		return defaultServiceName
+	}
+
+	return name
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	exporter := startTracing(t)
+
+	require.Nil(t, Inject(context.Background()))
+
+	ctx, span := Tracer().Start(context.Background(), "request")
+
+	traceparent := Inject(ctx)
+	require.NotEmpty(t, traceparent)
+
+	_, child := Tracer().Start(Extract(context.Background(), traceparent), "contract")
+	child.End()
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "contract", spans[0].Name)
+	require.Equal(t, span.SpanContext().TraceID(), spans[0].SpanContext.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	require.True(t, spans[0].Parent.IsRemote())
+
+	// no trace context gives a new trace
+	require.Equal(t, context.Background(), Extract(context.Background(), nil))
+}
+
+func TestStartStream(t *testing.T) {
+	exporter := startTracing(t)
+
+	ctx, request := Tracer().Start(context.Background(), "request")
+	LinkForm(ctx, "deadbeef")
+	request.End()
+
+	ctx, stream := StartStream(context.Background(), "dkg-setup", "deadbeef")
+	require.Equal(t, "dkg-setup", ctx.Value(ProtocolKey))
+	stream.End()
+
+	_, other := StartStream(context.Background(), "dkg-setup", "cafe")
+	other.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Len(t, spans[1].Links, 1)
+	require.Equal(t, request.SpanContext(), spans[1].Links[0].SpanContext)
+	require.Contains(t, spans[1].Attributes, FormAttr.String("deadbeef"))
+	require.Empty(t, spans[2].Links)
+
+	// the request is forgotten once the stream linked to it ends
+	_, found := formSpans.get("deadbeef")
+	require.False(t, found)
+
+	// a context without a span is not recorded
+	LinkForm(context.Background(), "cafe")
+	_, found = formSpans.get("cafe")
+	require.False(t, found)
+}
+
+func TestSpanCache(t *testing.T) {
+	cache := newSpanCache(2)
+
+	spanCtx := func(b byte) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{b},
+			SpanID:  trace.SpanID{b},
+		})
+	}
+
+	cache.put("a", spanCtx(1))
+	cache.put("b", spanCtx(2))
+	cache.put("a", spanCtx(3))
+
+	// the least recently linked form is dropped
+	cache.put("c", spanCtx(4))
+
+	_, found := cache.get("b")
+	require.False(t, found)
+
+	res, found := cache.get("a")
+	require.True(t, found)
+	require.Equal(t, spanCtx(3), res)
+
+	// a form linked to another request since is kept
+	cache.remove("a", spanCtx(1))
+	_, found = cache.get("a")
+	require.True(t, found)
+
+	cache.remove("a", spanCtx(3))
+	_, found = cache.get("a")
+	require.False(t, found)
+	require.Equal(t, 1, cache.order.Len())
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func startTracing(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+
+	provider, err := Start(sdktrace.NewSimpleSpanProcessor(exporter))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
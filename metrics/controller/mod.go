@@ -1,8 +1,13 @@
 package controller
 
 import (
+	"context"
+
+	"github.com/c4dt/d-voting/internal/tracing"
 	"go.dedis.ch/dela/cli"
 	"go.dedis.ch/dela/cli/node"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/xerrors"
 )
 
 // NewController returns a new controller initializer
@@ -40,8 +45,25 @@ func (m controller) SetCommands(builder node.Builder) {
 	sub.SetAction(builder.MakeAction(&StartAction{}))
 }
 
-// OnStart implements node.Initializer. It creates and registers a pedersen DKG.
+// OnStart implements node.Initializer. It starts to export the traces when an
+// OTLP endpoint is set in the environment, with the standard OTEL_* variables.
 func (m controller) OnStart(ctx cli.Flags, inj node.Injector) error {
+	if !tracing.OTLPConfigured() {
+		return nil
+	}
+
+	exporter, err := tracing.NewOTLPExporter(context.Background())
+	if err != nil {
+		return xerrors.Errorf("failed to create exporter: %v", err)
+	}
+
+	provider, err := tracing.Start(sdktrace.NewBatchSpanProcessor(exporter))
+	if err != nil {
+		return xerrors.Errorf("failed to start tracing: %v", err)
+	}
+
+	inj.Inject(provider)
+
 	return nil
 }
 
@@ -54,5 +76,16 @@ func (controller) OnStop(inj node.Injector) error {
 		srv.Stop()
 	}
 
+	var provider *sdktrace.TracerProvider
+
+	err = inj.Resolve(&provider)
+	if err == nil {
+		// the remaining spans are flushed
+		err = provider.Shutdown(context.Background())
+		if err != nil {
+			return xerrors.Errorf("failed to stop tracing: %v", err)
+		}
+	}
+
 	return nil
 }
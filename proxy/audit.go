@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/c4dt/d-voting/contracts/evoting"
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/proxy/auditlog"
+	"github.com/c4dt/d-voting/proxy/txnmanager"
+	"github.com/c4dt/d-voting/proxy/types"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// NewAudit returns the HTTP handlers of the audit log of the node. The limiter
+// can be nil.
+func NewAudit(log *auditlog.Log, srv ordering.Service, ctx serde.Context,
+	keys *KeyRing, limiter *Limiter) Audit {
+
+	return audit{
+		log:         log,
+		orderingSvc: srv,
+		context:     ctx,
+		adminFac:    etypes.AdminListFactory{},
+		verifier:    NewVerifier(keys, limiter, SignedRequestWindow),
+	}
+}
+
+// audit defines the HTTP handlers of the audit log
+//
+// - implements proxy.Audit
+type audit struct {
+	log         *auditlog.Log
+	orderingSvc ordering.Service
+	context     serde.Context
+	adminFac    serde.Factory
+	verifier    *Verifier
+}
+
+// AuditLog implements proxy.Audit. It returns the entries of the log matching
+// the filters of the request, which must be made by an admin.
+func (a audit) AuditLog(w http.ResponseWriter, r *http.Request) {
+	var req types.AuditLogRequest
+
+	err := a.verifier.GetAndVerify(r, ScopeAdmin, &req)
+	if err != nil {
+		SignedError(w, r, err, nil)
+		return
+	}
+
+	adminList, err := etypes.AdminListFromStore(a.context, a.adminFac,
+		a.orderingSvc.GetStore(), evoting.AdminListId)
+	if err != nil && err.Error() != "No list found" {
+		InternalError(w, r, xerrors.Errorf("failed to get admin list: %v", err), nil)
+		return
+	}
+
+	index, err := adminList.GetAdminIndex(req.UserID)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("invalid user: %v", err), nil)
+		return
+	}
+
+	if index < 0 {
+		ForbiddenError(w, r, xerrors.Errorf("%s is not an admin", req.UserID), nil)
+		return
+	}
+
+	filter := auditlog.Filter{
+		FormID:  req.FormID,
+		Actor:   req.Actor,
+		Command: req.Command,
+	}
+
+	if req.Since != 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+
+	if req.Until != 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+
+	txnmanager.SendResponse(w, a.log.Query(filter))
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/contracts/evoting"
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/c4dt/d-voting/proxy/auditlog"
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store/kv"
+	jsonserde "go.dedis.ch/dela/serde/json"
+)
+
+func TestAudit_AuditLog(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pk := suite.Point().Mul(secret, nil)
+
+	ctx := jsonserde.NewContext()
+
+	adminList := etypes.AdminList{AdminList: []int{123456}}
+	adminBuf, err := adminList.Serialize(ctx)
+	require.NoError(t, err)
+
+	adminListID := sha256.Sum256([]byte(evoting.AdminListId))
+
+	snap := fake.NewSnapshot()
+	err = snap.Set(adminListID[:], adminBuf)
+	require.NoError(t, err)
+
+	db := fake.NewInMemoryDB()
+	storeAuditEntries(t, db,
+		types.AuditEntry{Index: 0, Command: "CLOSE_FORM", FormID: "aa"},
+		types.AuditEntry{Index: 1, Command: "CLOSE_FORM", FormID: "bb"},
+	)
+
+	log, err := auditlog.NewLog(context.Background(), db, noBlocks{},
+		&fake.Service{})
+	require.NoError(t, err)
+
+	a := NewAudit(log, &fake.Service{BallotSnap: snap}, ctx, trustAll(pk), nil)
+
+	query := func(req types.AuditLogRequest) *httptest.ResponseRecorder {
+		buf, err := createSignedRequest(secret, http.MethodPost,
+			"/evoting/audit", req)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		a.AuditLog(w, httptest.NewRequest(http.MethodPost, "/evoting/audit",
+			bytes.NewReader(buf)))
+
+		return w
+	}
+
+	w := query(types.AuditLogRequest{UserID: "123456", FormID: "bb"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res types.GetAuditLogResponse
+
+	err = json.Unmarshal(w.Body.Bytes(), &res)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.Size)
+	require.Len(t, res.Entries, 1)
+	require.Equal(t, uint64(1), res.Entries[0].Index)
+
+	// only the admins can query the log
+	w = query(types.AuditLogRequest{UserID: "654321"})
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), "654321 is not an admin")
+
+	w = query(types.AuditLogRequest{UserID: "not a sciper"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the request must be signed by a key allowed to manage the admins
+	keys := NewKeyRing(TrustedKey{
+		Name:      "kiosk",
+		PublicKey: pk,
+		Scopes:    []Scope{ScopeVote},
+	})
+	a = NewAudit(log, &fake.Service{BallotSnap: snap}, ctx, keys, nil)
+
+	w = query(types.AuditLogRequest{UserID: "123456"})
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type noBlocks struct{}
+
+func (noBlocks) GetByIndex(index uint64) (otypes.BlockLink, error) {
+	return nil, fake.GetError()
+}
+
+func storeAuditEntries(t *testing.T, db kv.DB, entries ...types.AuditEntry) {
+	previous := ""
+
+	err := db.Update(func(tx kv.WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate([]byte(auditlog.BucketName))
+		require.NoError(t, err)
+
+		for _, entry := range entries {
+			entry.Time = time.Now().UTC()
+			entry.Previous = previous
+
+			hash, err := entry.ComputeHash()
+			require.NoError(t, err)
+
+			entry.Hash = hex.EncodeToString(hash)
+			previous = entry.Hash
+
+			buf, err := json.Marshal(entry)
+			require.NoError(t, err)
+
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, entry.Index)
+
+			err = bucket.Set(key, buf)
+			require.NoError(t, err)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+}
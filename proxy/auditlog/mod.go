@@ -0,0 +1,510 @@
+// Package auditlog implements the audit log of a node, which records the
+// privileged transactions of the evoting contract as they are included in the
+// blocks. The log is stored in the database of the node, and each entry is
+// chained to the previous one with its hash and bound to the hash of its
+// block, so that any change is detected.
+package auditlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/c4dt/d-voting/contracts/evoting"
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/proxy/types"
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/core/ordering"
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store/kv"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
+	"golang.org/x/xerrors"
+)
+
+// BucketName is the name of the bucket of the database of the node in which
+// the log is stored.
+const BucketName = "dvotingaudit"
+
+// nextBlockKey is the key of the index of the next block to add to the log.
+// The entries are stored with their index as 8 bytes keys.
+var nextBlockKey = []byte("nextblock")
+
+// privileged are the commands recorded by the log: the ones of the admins,
+// operators and owners of the forms. The ballots, shuffles and public shares
+// are not recorded.
+var privileged = map[evoting.Command]struct{}{
+	evoting.CmdCreateForm:      {},
+	evoting.CmdOpenForm:        {},
+	evoting.CmdCloseForm:       {},
+	evoting.CmdCombineShares:   {},
+	evoting.CmdCancelForm:      {},
+	evoting.CmdDeleteForm:      {},
+	evoting.CmdMigrateForm:     {},
+	evoting.CmdAddAdmin:        {},
+	evoting.CmdRemoveAdmin:     {},
+	evoting.CmdAddOperator:     {},
+	evoting.CmdRemoveOperator:  {},
+	evoting.CmdAddOwnerForm:    {},
+	evoting.CmdRemoveOwnerForm: {},
+	evoting.CmdAddVoterForm:    {},
+	evoting.CmdRemoveVoterForm: {},
+	evoting.CmdAddCredential:   {},
+}
+
+// blockReader is the part of the block store needed to add the blocks to the
+// log and to check the log against them.
+type blockReader interface {
+	GetByIndex(index uint64) (otypes.BlockLink, error)
+}
+
+// Filter selects the entries of the log. The empty fields match all the
+// entries.
+type Filter struct {
+	// FormID is hex-encoded
+	FormID  string
+	Actor   string
+	Command string
+	Since   time.Time
+	Until   time.Time
+}
+
+// Log is the audit log of a node. Entries are only appended, in the order of
+// the blocks.
+type Log struct {
+	sync.RWMutex
+
+	db      kv.DB
+	blocks  blockReader
+	context serde.Context
+	txFac   serde.Factory
+
+	entries []types.AuditEntry
+	// next is the index of the next block to add to the log
+	next uint64
+	// broken is the reason why the log was found altered when it was loaded
+	broken string
+
+	// pending are the events received and not yet added to the log
+	pendingLock sync.Mutex
+	pending     []ordering.Event
+	trigger     chan struct{}
+
+	now func() time.Time
+}
+
+// NewLog returns the audit log stored in the database. It adds the blocks of
+// the store that are missing from it, and then the new blocks as they are
+// committed, until the context is done. A log that doesn't match the blocks
+// of the store is marked as broken in the responses of Query.
+func NewLog(ctx context.Context, db kv.DB, blocks blockReader, srv ordering.Service) (*Log, error) {
+	l := &Log{
+		db:      db,
+		blocks:  blocks,
+		context: jsonserde.NewContext(),
+		txFac:   etypes.NewTransactionFactory(etypes.CiphervoteFactory{}),
+		trigger: make(chan struct{}, 1),
+		now:     time.Now,
+	}
+
+	err := l.load()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load audit log: %v", err)
+	}
+
+	err = l.Verify()
+	if err != nil {
+		dela.Logger.Err(err).Msg("the audit log has been altered")
+		l.broken = err.Error()
+	}
+
+	// the events are queued as they come so that the blocks are never
+	// delayed by the log, which adds them once the stored blocks are added.
+	events := srv.Watch(ctx)
+
+	go func() {
+		for event := range events {
+			l.pendingLock.Lock()
+			l.pending = append(l.pending, event)
+			l.pendingLock.Unlock()
+
+			select {
+			case l.trigger <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	go l.run(ctx)
+
+	return l, nil
+}
+
+// Query returns the entries matching the filter, in order, with the size and
+// the head of the log.
+func (l *Log) Query(filter Filter) types.GetAuditLogResponse {
+	l.RLock()
+	defer l.RUnlock()
+
+	response := types.GetAuditLogResponse{
+		Entries: []types.AuditEntry{},
+		Size:    uint64(len(l.entries)),
+		Broken:  l.broken,
+	}
+
+	for _, entry := range l.entries {
+		if filter.matches(entry) {
+			response.Entries = append(response.Entries, entry)
+		}
+	}
+
+	if len(l.entries) > 0 {
+		response.Head = l.entries[len(l.entries)-1].Hash
+	}
+
+	return response
+}
+
+// Verify checks that the entries of the log are complete and unaltered: they
+// are chained from the first one, and they are the privileged transactions of
+// the blocks of the store, in order.
+func (l *Log) Verify() error {
+	l.RLock()
+	defer l.RUnlock()
+
+	if len(l.entries) > 0 && (l.entries[0].Index != 0 || l.entries[0].Previous != "") {
+		return xerrors.Errorf("the first entries are missing")
+	}
+
+	err := types.VerifyAuditLog(l.entries)
+	if err != nil {
+		return err
+	}
+
+	i := 0
+
+	for index := uint64(0); index < l.next; index++ {
+		link, err := l.blocks.GetByIndex(index)
+		if err != nil {
+			return xerrors.Errorf("failed to get block %d: %v", index, err)
+		}
+
+		for _, expected := range l.entriesOf(link) {
+			if i >= len(l.entries) {
+				return xerrors.Errorf("transaction %s of block %d is missing",
+					expected.TransactionID, index)
+			}
+
+			entry := l.entries[i]
+
+			// the fields that don't come from the block are already checked
+			expected.Index = entry.Index
+			expected.Time = entry.Time
+			expected.Previous = entry.Previous
+			expected.Hash = entry.Hash
+
+			if entry != expected {
+				return xerrors.Errorf("entry %d doesn't match block %d",
+					entry.Index, index)
+			}
+
+			i++
+		}
+	}
+
+	if i < len(l.entries) {
+		return xerrors.Errorf("entry %d is not in the blocks", l.entries[i].Index)
+	}
+
+	return nil
+}
+
+// run adds the stored blocks missing from the log, and then the blocks of
+// the events as they are committed.
+func (l *Log) run(ctx context.Context) {
+	l.catchUp(math.MaxUint64, false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.trigger:
+		}
+
+		l.pendingLock.Lock()
+		events := l.pending
+		l.pending = nil
+		l.pendingLock.Unlock()
+
+		for _, event := range events {
+			// blocks are missing if the log couldn't be stored. The blocks of
+			// the events are read from the store, which has them before the
+			// event is sent, to get their hash.
+			l.catchUp(event.Index, false)
+			l.catchUp(event.Index+1, true)
+		}
+	}
+}
+
+// catchUp adds the blocks of the store, from the next one, until there is no
+// more block or until the given index, excluded. The entries are timed only
+// if the blocks were just committed.
+func (l *Log) catchUp(until uint64, committed bool) {
+	for l.next < until {
+		link, err := l.blocks.GetByIndex(l.next)
+		if err != nil {
+			return
+		}
+
+		var seen time.Time
+		if committed {
+			seen = l.now().UTC()
+		}
+
+		err = l.addBlock(l.next, l.entriesOf(link), seen)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// entriesOf returns the entries of the privileged transactions of the block,
+// without their position in the log.
+func (l *Log) entriesOf(link otypes.BlockLink) []types.AuditEntry {
+	block := link.GetBlock()
+	hash := block.GetHash()
+
+	entries := []types.AuditEntry{}
+
+	for _, res := range block.GetData().GetTransactionResults() {
+		entry, ok := l.entryOf(res)
+		if !ok {
+			continue
+		}
+
+		entry.Block = block.GetIndex()
+		entry.BlockHash = hex.EncodeToString(hash[:])
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// addBlock adds the entries of the block to the log, seen at the given time.
+func (l *Log) addBlock(index uint64, entries []types.AuditEntry, seen time.Time) error {
+	l.RLock()
+	size := uint64(len(l.entries))
+	previous := ""
+	if size > 0 {
+		previous = l.entries[size-1].Hash
+	}
+	l.RUnlock()
+
+	for i := range entries {
+		entry := &entries[i]
+
+		entry.Index = size + uint64(i)
+		entry.Time = seen
+		entry.Previous = previous
+
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			dela.Logger.Err(err).Msg("failed to hash audit entry")
+			return err
+		}
+
+		entry.Hash = hex.EncodeToString(hash)
+		previous = entry.Hash
+	}
+
+	err := l.store(entries, index+1)
+	if err != nil {
+		dela.Logger.Err(err).Uint64("block", index).Msg("failed to store audit log")
+		return err
+	}
+
+	l.Lock()
+	l.entries = append(l.entries, entries...)
+	l.next = index + 1
+	l.Unlock()
+
+	return nil
+}
+
+// entryOf returns the entry of the transaction, and false if the transaction
+// is not recorded by the log.
+func (l *Log) entryOf(res validation.TransactionResult) (types.AuditEntry, bool) {
+	tx := res.GetTransaction()
+
+	cmd := evoting.Command(tx.GetArg(evoting.CmdArg))
+
+	_, found := privileged[cmd]
+	if !found {
+		return types.AuditEntry{}, false
+	}
+
+	accepted, reason := res.GetStatus()
+
+	entry := types.AuditEntry{
+		TransactionID: hex.EncodeToString(tx.GetID()),
+		Command:       string(cmd),
+		Accepted:      accepted,
+		Reason:        reason,
+	}
+
+	// a transaction that can't be decoded is rejected by the contract, it is
+	// recorded without the form and the users.
+	msg, err := l.txFac.Deserialize(l.context, tx.GetArg(evoting.FormArg))
+	if err != nil {
+		return entry, true
+	}
+
+	switch m := msg.(type) {
+	case etypes.CreateForm:
+		// the ID of the form is the hash of the ID of the transaction
+		formID := sha256.Sum256(tx.GetID())
+		entry.FormID = hex.EncodeToString(formID[:])
+		entry.Actor = m.UserID
+	case etypes.OpenForm:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.CloseForm:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.CombineShares:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.CancelForm:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.DeleteForm:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.MigrateForm:
+		entry.FormID, entry.Actor = m.FormID, m.UserID
+	case etypes.AddAdmin:
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.RemoveAdmin:
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.AddOperator:
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.RemoveOperator:
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.AddOwner:
+		entry.FormID = m.FormID
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.RemoveOwner:
+		entry.FormID = m.FormID
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.AddVoter:
+		entry.FormID = m.FormID
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.RemoveVoter:
+		entry.FormID = m.FormID
+		entry.Actor, entry.Target = m.PerformingUserID, m.TargetUserID
+	case etypes.AddCredential:
+		entry.FormID, entry.Actor = m.FormID, m.PerformingUserID
+	}
+
+	return entry, true
+}
+
+// store writes the entries and the index of the next block in the database.
+func (l *Log) store(entries []types.AuditEntry, next uint64) error {
+	return l.db.Update(func(tx kv.WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate([]byte(BucketName))
+		if err != nil {
+			return xerrors.Errorf("failed to get bucket: %v", err)
+		}
+
+		for _, entry := range entries {
+			buf, err := json.Marshal(entry)
+			if err != nil {
+				return xerrors.Errorf("failed to marshal entry: %v", err)
+			}
+
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, entry.Index)
+
+			err = bucket.Set(key, buf)
+			if err != nil {
+				return xerrors.Errorf("failed to store entry: %v", err)
+			}
+		}
+
+		nextBuf := make([]byte, 8)
+		binary.BigEndian.PutUint64(nextBuf, next)
+
+		err = bucket.Set(nextBlockKey, nextBuf)
+		if err != nil {
+			return xerrors.Errorf("failed to store next block: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// load reads the entries and the index of the next block from the database.
+func (l *Log) load() error {
+	return l.db.View(func(tx kv.ReadableTx) error {
+		bucket := tx.GetBucket([]byte(BucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		err := bucket.ForEach(func(key, value []byte) error {
+			if string(key) == string(nextBlockKey) {
+				l.next = binary.BigEndian.Uint64(value)
+				return nil
+			}
+
+			var entry types.AuditEntry
+
+			err := json.Unmarshal(value, &entry)
+			if err != nil {
+				return xerrors.Errorf("failed to unmarshal entry: %v", err)
+			}
+
+			l.entries = append(l.entries, entry)
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(l.entries, func(i, j int) bool {
+			return l.entries[i].Index < l.entries[j].Index
+		})
+
+		return nil
+	})
+}
+
+// matches returns true if the entry is selected by the filter.
+func (f Filter) matches(entry types.AuditEntry) bool {
+	if f.FormID != "" && entry.FormID != f.FormID {
+		return false
+	}
+
+	if f.Actor != "" && entry.Actor != f.Actor {
+		return false
+	}
+
+	if f.Command != "" && entry.Command != f.Command {
+		return false
+	}
+
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+
+	return true
+}
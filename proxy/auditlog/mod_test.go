@@ -0,0 +1,389 @@
+package auditlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/contracts/evoting"
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering"
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store/kv"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
+)
+
+func TestLog_Events(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := fake.NewInMemoryDB()
+	service := &fake.Service{}
+	blocks := &fakeBlocks{}
+
+	serdeCtx := jsonserde.NewContext()
+
+	// the block stored before the start of the log is caught up
+	blocks.add(t,
+		newResult(t, serdeCtx, "tx1", evoting.CmdCreateForm,
+			etypes.CreateForm{UserID: "123456"}, true),
+		newResult(t, serdeCtx, "tx2", evoting.CmdCastVote,
+			etypes.CastVote{FormID: "abcd", VoterID: "654321"}, true),
+	)
+
+	log, err := NewLog(ctx, db, blocks, service)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return log.Query(Filter{}).Size == 1
+	}, time.Second, 10*time.Millisecond)
+
+	service.Channel <- blocks.add(t,
+		newResult(t, serdeCtx, "tx3", evoting.CmdAddAdmin,
+			etypes.AddAdmin{TargetUserID: "111111", PerformingUserID: "123456"}, true),
+		newResult(t, serdeCtx, "tx4", evoting.CmdCloseForm,
+			etypes.CloseForm{FormID: "abcd", UserID: "654321"}, false),
+	)
+
+	require.Eventually(t, func() bool {
+		return log.Query(Filter{}).Size == 3
+	}, time.Second, 10*time.Millisecond)
+
+	res := log.Query(Filter{})
+	require.Len(t, res.Entries, 3)
+	require.Equal(t, res.Entries[2].Hash, res.Head)
+	require.NoError(t, log.Verify())
+	require.NoError(t, types.VerifyAuditLog(res.Entries))
+
+	formID := sha256.Sum256([]byte("tx1"))
+
+	create := res.Entries[0]
+	require.Equal(t, uint64(0), create.Index)
+	require.Equal(t, uint64(0), create.Block)
+	require.Equal(t, hex.EncodeToString([]byte("tx1")), create.TransactionID)
+	require.Equal(t, string(evoting.CmdCreateForm), create.Command)
+	require.Equal(t, hex.EncodeToString(formID[:]), create.FormID)
+	require.Equal(t, "123456", create.Actor)
+	require.Empty(t, create.Previous)
+	require.Equal(t, blocks.hash(0), create.BlockHash)
+
+	// the time of a block caught up is unknown
+	require.True(t, create.Time.IsZero())
+
+	addAdmin := res.Entries[1]
+	require.Equal(t, uint64(1), addAdmin.Block)
+	require.Equal(t, blocks.hash(1), addAdmin.BlockHash)
+	require.False(t, addAdmin.Time.IsZero())
+	require.Equal(t, "123456", addAdmin.Actor)
+	require.Equal(t, "111111", addAdmin.Target)
+	require.Empty(t, addAdmin.FormID)
+	require.Equal(t, create.Hash, addAdmin.Previous)
+
+	closeForm := res.Entries[2]
+	require.Equal(t, "abcd", closeForm.FormID)
+	require.Equal(t, "654321", closeForm.Actor)
+	require.False(t, closeForm.Accepted)
+	require.Equal(t, "rejected", closeForm.Reason)
+
+	// a block already in the log is ignored
+	service.Channel <- ordering.Event{Index: 1}
+
+	service.Channel <- blocks.add(t)
+
+	require.Eventually(t, func() bool {
+		log.RLock()
+		defer log.RUnlock()
+
+		return log.next == 3
+	}, time.Second, 10*time.Millisecond)
+
+	require.Equal(t, uint64(3), log.Query(Filter{}).Size)
+
+	// the log is read back from the database
+	cancel()
+
+	reloaded, err := NewLog(context.Background(), db, blocks, &fake.Service{})
+	require.NoError(t, err)
+	require.Equal(t, res, reloaded.Query(Filter{}))
+	require.Equal(t, uint64(3), reloaded.next)
+
+	// the blocks of the log are not in the store anymore
+	reloaded, err = NewLog(context.Background(), db, emptyBlocks{}, &fake.Service{})
+	require.NoError(t, err)
+	require.Contains(t, reloaded.Query(Filter{}).Broken, "failed to get block 0")
+}
+
+func TestLog_Query(t *testing.T) {
+	now := time.Now()
+
+	log := &Log{
+		entries: []types.AuditEntry{
+			{Index: 0, Time: now.Add(-time.Hour), Command: "CREATE_FORM", FormID: "aa", Actor: "1"},
+			{Index: 1, Time: now.Add(-time.Minute), Command: "OPEN_FORM", FormID: "aa", Actor: "1"},
+			{Index: 2, Time: now, Command: "CLOSE_FORM", FormID: "aa", Actor: "2", Hash: "cafe"},
+		},
+	}
+
+	indexes := func(filter Filter) []uint64 {
+		res := log.Query(filter)
+		require.Equal(t, uint64(3), res.Size)
+		require.Equal(t, "cafe", res.Head)
+
+		ids := []uint64{}
+		for _, entry := range res.Entries {
+			ids = append(ids, entry.Index)
+		}
+
+		return ids
+	}
+
+	require.Equal(t, []uint64{0, 1, 2}, indexes(Filter{}))
+	require.Equal(t, []uint64{}, indexes(Filter{FormID: "bb"}))
+	require.Equal(t, []uint64{2}, indexes(Filter{Actor: "2"}))
+	require.Equal(t, []uint64{1}, indexes(Filter{Command: "OPEN_FORM"}))
+	require.Equal(t, []uint64{1, 2}, indexes(Filter{Since: now.Add(-2 * time.Minute)}))
+	require.Equal(t, []uint64{0, 1}, indexes(Filter{Until: now.Add(-time.Second)}))
+}
+
+func TestLog_Altered(t *testing.T) {
+	db := fake.NewInMemoryDB()
+	service := &fake.Service{}
+	blocks := &fakeBlocks{}
+
+	log, err := NewLog(context.Background(), db, blocks, service)
+	require.NoError(t, err)
+
+	serdeCtx := jsonserde.NewContext()
+
+	service.Channel <- blocks.add(t,
+		newResult(t, serdeCtx, "tx1", evoting.CmdCloseForm,
+			etypes.CloseForm{FormID: "abcd", UserID: "123456"}, true),
+		newResult(t, serdeCtx, "tx2", evoting.CmdCancelForm,
+			etypes.CancelForm{FormID: "abcd", UserID: "123456"}, true),
+	)
+
+	require.Eventually(t, func() bool {
+		return log.Query(Filter{}).Size == 2
+	}, time.Second, 10*time.Millisecond)
+
+	entries := log.Query(Filter{}).Entries
+
+	// someone with access to the database changes who closed the form
+	entry := entries[0]
+	entry.Actor = "654321"
+
+	storeEntry(t, db, entry)
+
+	altered, err := NewLog(context.Background(), db, blocks, &fake.Service{})
+	require.NoError(t, err)
+	require.EqualError(t, altered.Verify(), "entry 0 has been altered")
+	require.Equal(t, "entry 0 has been altered", altered.Query(Filter{}).Broken)
+
+	// the hashes of the log are computed again, but the entry doesn't match
+	// the transaction of the block anymore
+	for i := range entries {
+		if i == 0 {
+			entries[i] = entry
+		} else {
+			entries[i].Previous = entries[i-1].Hash
+		}
+
+		hash, err := entries[i].ComputeHash()
+		require.NoError(t, err)
+
+		entries[i].Hash = hex.EncodeToString(hash)
+		storeEntry(t, db, entries[i])
+	}
+
+	altered, err = NewLog(context.Background(), db, blocks, &fake.Service{})
+	require.NoError(t, err)
+	require.Equal(t, "entry 0 doesn't match block 0", altered.Query(Filter{}).Broken)
+
+	// an entry is removed from the log
+	entries[1].Index = 0
+	entries[1].Previous = ""
+
+	hash, err := entries[1].ComputeHash()
+	require.NoError(t, err)
+
+	entries[1].Hash = hex.EncodeToString(hash)
+
+	err = db.Update(func(tx kv.WritableTx) error {
+		return tx.GetBucket([]byte(BucketName)).Delete(indexKey(1))
+	})
+	require.NoError(t, err)
+
+	storeEntry(t, db, entries[1])
+
+	altered, err = NewLog(context.Background(), db, blocks, &fake.Service{})
+	require.NoError(t, err)
+	require.Equal(t, "entry 0 doesn't match block 0", altered.Query(Filter{}).Broken)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type emptyBlocks struct{}
+
+// fakeBlocks is a block store whose blocks are added by the tests.
+type fakeBlocks struct {
+	sync.Mutex
+
+	links []otypes.BlockLink
+}
+
+func (b *fakeBlocks) GetByIndex(index uint64) (otypes.BlockLink, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if index >= uint64(len(b.links)) {
+		return nil, fake.GetError()
+	}
+
+	return b.links[index], nil
+}
+
+// add stores a new block with the results, and returns the event of its
+// commit.
+func (b *fakeBlocks) add(t *testing.T, results ...validation.TransactionResult) ordering.Event {
+	b.Lock()
+	defer b.Unlock()
+
+	index := uint64(len(b.links))
+
+	block, err := otypes.NewBlock(fakeData{results: results}, otypes.WithIndex(index))
+	require.NoError(t, err)
+
+	b.links = append(b.links, fakeLink{block: block})
+
+	return ordering.Event{Index: index, Transactions: results}
+}
+
+// hash returns the hex-encoded hash of the block.
+func (b *fakeBlocks) hash(index uint64) string {
+	b.Lock()
+	defer b.Unlock()
+
+	hash := b.links[index].GetBlock().GetHash()
+
+	return hex.EncodeToString(hash[:])
+}
+
+type fakeLink struct {
+	otypes.BlockLink
+
+	block otypes.Block
+}
+
+func (link fakeLink) GetBlock() otypes.Block {
+	return link.block
+}
+
+type fakeData struct {
+	validation.Result
+
+	results []validation.TransactionResult
+}
+
+func (data fakeData) GetTransactionResults() []validation.TransactionResult {
+	return data.results
+}
+
+func (data fakeData) Fingerprint(w io.Writer) error {
+	for _, res := range data.results {
+		_, err := w.Write(res.GetTransaction().GetID())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func indexKey(index uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, index)
+
+	return key
+}
+
+func storeEntry(t *testing.T, db kv.DB, entry types.AuditEntry) {
+	buf, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx kv.WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate([]byte(BucketName))
+		require.NoError(t, err)
+
+		return bucket.Set(indexKey(entry.Index), buf)
+	})
+	require.NoError(t, err)
+}
+
+func (emptyBlocks) GetByIndex(index uint64) (otypes.BlockLink, error) {
+	return nil, fake.GetError()
+}
+
+type fakeTx struct {
+	txn.Transaction
+
+	id   []byte
+	args map[string][]byte
+}
+
+func (tx fakeTx) GetID() []byte {
+	return tx.id
+}
+
+func (tx fakeTx) GetArg(key string) []byte {
+	return tx.args[key]
+}
+
+type fakeResult struct {
+	validation.TransactionResult
+
+	tx       txn.Transaction
+	accepted bool
+}
+
+func (res fakeResult) GetTransaction() txn.Transaction {
+	return res.tx
+}
+
+func (res fakeResult) GetStatus() (bool, string) {
+	if res.accepted {
+		return true, ""
+	}
+
+	return false, "rejected"
+}
+
+func newResult(t *testing.T, ctx serde.Context, id string, cmd evoting.Command,
+	msg serde.Message, accepted bool) fakeResult {
+
+	payload, err := msg.Serialize(ctx)
+	require.NoError(t, err)
+
+	return fakeResult{
+		tx: fakeTx{
+			id: []byte(id),
+			args: map[string][]byte{
+				evoting.CmdArg:  []byte(cmd),
+				evoting.FormArg: payload,
+			},
+		},
+		accepted: accepted,
+	}
+}
@@ -0,0 +1,386 @@
+package client
+
+import (
+	"encoding/hex"
+	"time"
+
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/kyber/v3/util/random"
+	"golang.org/x/xerrors"
+)
+
+// EncryptBallot encrypts the ballot, in its text format, with the hex-encoded
+// public key of the form. The ballot is cut into the given number of chunks,
+// the ChunksPerBallot of the form, each of them holding the data that can be
+// embedded in a point. The chunks left once the ballot is cut are empty.
+func EncryptBallot(pubkey string, ballot string, chunks int) (ptypes.CiphervoteJSON, error) {
+	ciphervote, _, err := EncryptBallotForAudit(pubkey, ballot, chunks)
+
+	return ciphervote, err
+}
+
+// EncryptBallotForAudit encrypts the ballot like EncryptBallot, and also
+// returns the randomness of each chunk. The voter can then either cast the
+// ballot and forget the randomness, or audit it with Client.AuditBallot to
+// check that it was encrypted as intended, see VerifyAudit.
+func EncryptBallotForAudit(pubkey string, ballot string,
+	chunks int) (ptypes.CiphervoteJSON, [][]byte, error) {
+
+	formKey, err := decodePubkey(pubkey)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to decode public key: %v", err)
+	}
+
+	chunkSize := suite.Point().EmbedLen()
+
+	if len(ballot) > chunks*chunkSize {
+		return nil, nil, xerrors.Errorf("the ballot is too long: %d > %d chunks of %d bytes",
+			len(ballot), chunks, chunkSize)
+	}
+
+	data := []byte(ballot)
+	ciphervote := make(ptypes.CiphervoteJSON, chunks)
+	randomness := make([][]byte, chunks)
+
+	for i := range ciphervote {
+		end := chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		ciphervote[i], randomness[i], err = encrypt(formKey, data[:end])
+		if err != nil {
+			return nil, nil, xerrors.Errorf("failed to encrypt chunk %d: %v", i, err)
+		}
+
+		data = data[end:]
+	}
+
+	return ciphervote, randomness, nil
+}
+
+// encrypt ElGamal-encrypts the message, which must fit in a point. It returns
+// the marshalled randomness of the encryption along with the pair.
+func encrypt(formKey kyber.Point, message []byte) (ptypes.EGPairJSON, []byte, error) {
+	M := suite.Point().Embed(message, random.New())
+
+	// ephemeral key pair, and shared secret that blinds the message
+	k := suite.Scalar().Pick(random.New())
+	K := suite.Point().Mul(k, nil)
+	S := suite.Point().Mul(k, formKey)
+	C := S.Add(S, M)
+
+	kbuf, err := K.MarshalBinary()
+	if err != nil {
+		return ptypes.EGPairJSON{}, nil, xerrors.Errorf("failed to marshal K: %v", err)
+	}
+
+	cbuf, err := C.MarshalBinary()
+	if err != nil {
+		return ptypes.EGPairJSON{}, nil, xerrors.Errorf("failed to marshal C: %v", err)
+	}
+
+	randomness, err := k.MarshalBinary()
+	if err != nil {
+		return ptypes.EGPairJSON{}, nil, xerrors.Errorf("failed to marshal k: %v", err)
+	}
+
+	return ptypes.EGPairJSON{K: kbuf, C: cbuf}, randomness, nil
+}
+
+// Encrypter encrypts the ballots of a form. It encrypts batches of ballots in
+// parallel, and keeps a pool of ephemeral keys filled by Precompute.
+type Encrypter struct {
+	encrypter *etypes.Encrypter
+}
+
+// NewEncrypter returns an encrypter for the hex-encoded public key of the
+// form, whose pool holds at most poolSize ephemeral keys.
+func NewEncrypter(pubkey string, poolSize int) (*Encrypter, error) {
+	formKey, err := decodePubkey(pubkey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode public key: %v", err)
+	}
+
+	return &Encrypter{
+		encrypter: etypes.NewEncrypter(formKey, poolSize),
+	}, nil
+}
+
+// Precompute computes up to n ephemeral keys ahead of time, for example while
+// the voter fills in the ballot, so that encrypting it is almost immediate.
+func (e *Encrypter) Precompute(n int) error {
+	return e.encrypter.Precompute(n)
+}
+
+// EncryptBallots encrypts the ballots, in their text format, in parallel. The
+// ballots are cut into the given number of chunks, like with EncryptBallot.
+func (e *Encrypter) EncryptBallots(ballots []string,
+	chunks int) ([]ptypes.CiphervoteJSON, error) {
+
+	data := make([][]byte, len(ballots))
+	for i, ballot := range ballots {
+		data[i] = []byte(ballot)
+	}
+
+	ciphervotes, _, err := e.encrypter.EncryptBallots(data, chunks)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encrypt ballots: %v", err)
+	}
+
+	res := make([]ptypes.CiphervoteJSON, len(ciphervotes))
+
+	for i, ciphervote := range ciphervotes {
+		res[i], err = encodeCiphervote(ciphervote)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode ballot %d: %v", i, err)
+		}
+	}
+
+	return res, nil
+}
+
+// VerifyAudit decrypts the ballot with its randomness and the hex-encoded
+// public key of the form, and returns the ballot in its text format. The
+// voter checks that it is the ballot they intended to cast.
+func VerifyAudit(pubkey string, ballot ptypes.CiphervoteJSON,
+	randomness [][]byte) (string, error) {
+
+	formKey, err := decodePubkey(pubkey)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode public key: %v", err)
+	}
+
+	ciphervote, err := decodeCiphervote(ballot)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode ballot: %v", err)
+	}
+
+	data, err := ciphervote.Audit(formKey, randomness)
+	if err != nil {
+		return "", xerrors.Errorf("failed to audit ballot: %v", err)
+	}
+
+	return string(data), nil
+}
+
+// SignVote sets the signature of the vote with the key the voter registered
+// on the form, as expected by CastSignedVote. If the request has no sequence,
+// it is set to the current time, which is greater than the sequence of any
+// previous vote made the same way.
+func SignVote(secret kyber.Scalar, formID string, req *ptypes.CastVoteRequest) error {
+	if req.Sequence == 0 {
+		req.Sequence = uint64(time.Now().UnixNano())
+	}
+
+	castVote, err := toCastVote(formID, req.VoterID, req.Ballot, req.Sequence)
+	if err != nil {
+		return xerrors.Errorf("failed to get vote: %v", err)
+	}
+
+	hash, err := castVote.Hash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash vote: %v", err)
+	}
+
+	req.Signature, err = schnorr.Sign(suite, secret, hash)
+	if err != nil {
+		return xerrors.Errorf("failed to sign vote: %v", err)
+	}
+
+	return nil
+}
+
+// SignCredential sets the credential of the anonymous vote on the form and its
+// ring, made with the secret of the credential at the given index of the
+// electoral roll of the form, as expected by CastAnonymousVote. If the request
+// has no sequence, it is set to the current time, like in SignVote.
+func SignCredential(form ptypes.GetFormResponse, index int, secret kyber.Scalar,
+	req *ptypes.AnonymousVoteRequest) error {
+
+	if req.Sequence == 0 {
+		req.Sequence = uint64(time.Now().UnixNano())
+	}
+
+	roll := make([][]byte, len(form.ElectoralRoll))
+
+	for i, key := range form.ElectoralRoll {
+		var err error
+
+		roll[i], err = hex.DecodeString(key)
+		if err != nil {
+			return xerrors.Errorf("failed to decode credential %d: %v", i, err)
+		}
+	}
+
+	castVote, err := toCastVote(form.FormID, "", req.Ballot, req.Sequence)
+	if err != nil {
+		return xerrors.Errorf("failed to get vote: %v", err)
+	}
+
+	if index < 0 || index >= len(roll) {
+		return xerrors.Errorf("index out of range: %d", index)
+	}
+
+	ring := etypes.CredentialRing(index, len(roll))
+
+	start, end, err := etypes.RingBounds(ring, len(roll))
+	if err != nil {
+		return xerrors.Errorf("failed to get ring: %v", err)
+	}
+
+	req.Ring = ring
+
+	req.Credential, err = etypes.SignCredential(castVote, roll[start:end],
+		index-start, secret)
+	if err != nil {
+		return xerrors.Errorf("failed to sign credential: %v", err)
+	}
+
+	return nil
+}
+
+// VerifyBallotProof checks that the proof, see Client.BallotProof, proves that
+// the ballot is the one of the voter and that it is committed to by the
+// hex-encoded hash of the ballots, which should be the BallotsHash of the form
+// once the form is closed.
+func VerifyBallotProof(proof ptypes.BallotProofResponse, voterID string,
+	ballot ptypes.CiphervoteJSON, ballotsHash string) error {
+
+	if len(proof.VoterIDs) != len(proof.Ballots) {
+		return xerrors.Errorf("%d voters for %d ballots", len(proof.VoterIDs),
+			len(proof.Ballots))
+	}
+
+	ballotsHashBuf, err := hex.DecodeString(ballotsHash)
+	if err != nil {
+		return xerrors.Errorf("failed to decode ballots hash: %v", err)
+	}
+
+	ciphervote, err := decodeCiphervote(ballot)
+	if err != nil {
+		return xerrors.Errorf("failed to decode ballot: %v", err)
+	}
+
+	ballotProof := etypes.BallotProof{
+		Block: proof.Block,
+		Suffragia: etypes.Suffragia{
+			VoterIDs:    proof.VoterIDs,
+			Ciphervotes: make([]etypes.Ciphervote, len(proof.Ballots)),
+		},
+		Next: make([][]byte, len(proof.Next)),
+	}
+
+	for i, b := range proof.Ballots {
+		ballotProof.Suffragia.Ciphervotes[i], err = decodeCiphervote(b)
+		if err != nil {
+			return xerrors.Errorf("failed to decode ballot %d: %v", i, err)
+		}
+	}
+
+	ballotProof.Previous, err = hex.DecodeString(proof.Previous)
+	if err != nil {
+		return xerrors.Errorf("failed to decode previous hash: %v", err)
+	}
+
+	for i, next := range proof.Next {
+		ballotProof.Next[i], err = hex.DecodeString(next)
+		if err != nil {
+			return xerrors.Errorf("failed to decode hash %d: %v", i, err)
+		}
+	}
+
+	err = ballotProof.Verify(voterID, ciphervote, ballotsHashBuf)
+	if err != nil {
+		return xerrors.Errorf("invalid proof: %v", err)
+	}
+
+	return nil
+}
+
+// toCastVote returns the vote as seen by the smart contract, whose hash is
+// signed.
+func toCastVote(formID, voterID string, ballot ptypes.CiphervoteJSON,
+	sequence uint64) (etypes.CastVote, error) {
+
+	ciphervote, err := decodeCiphervote(ballot)
+	if err != nil {
+		return etypes.CastVote{}, xerrors.Errorf("failed to decode ballot: %v", err)
+	}
+
+	return etypes.CastVote{
+		FormID:   formID,
+		VoterID:  voterID,
+		Ballot:   ciphervote,
+		Sequence: sequence,
+	}, nil
+}
+
+// decodeCiphervote unmarshals the points of the encrypted ballot.
+func decodeCiphervote(ballot ptypes.CiphervoteJSON) (etypes.Ciphervote, error) {
+	ciphervote := make(etypes.Ciphervote, len(ballot))
+
+	for i, egpair := range ballot {
+		k := suite.Point()
+
+		err := k.UnmarshalBinary(egpair.K)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal K: %v", err)
+		}
+
+		c := suite.Point()
+
+		err = c.UnmarshalBinary(egpair.C)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal C: %v", err)
+		}
+
+		ciphervote[i] = etypes.EGPair{
+			K: k,
+			C: c,
+		}
+	}
+
+	return ciphervote, nil
+}
+
+// encodeCiphervote marshals the points of the encrypted ballot.
+func encodeCiphervote(ciphervote etypes.Ciphervote) (ptypes.CiphervoteJSON, error) {
+	ballot := make(ptypes.CiphervoteJSON, len(ciphervote))
+
+	for i, egpair := range ciphervote {
+		k, err := egpair.K.MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal K: %v", err)
+		}
+
+		c, err := egpair.C.MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal C: %v", err)
+		}
+
+		ballot[i] = ptypes.EGPairJSON{K: k, C: c}
+	}
+
+	return ballot, nil
+}
+
+// decodePubkey unmarshals the hex-encoded public key of a form.
+func decodePubkey(pubkey string) (kyber.Point, error) {
+	pubkeyBuf, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode: %v", err)
+	}
+
+	formKey := suite.Point()
+
+	err = formKey.UnmarshalBinary(pubkeyBuf)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal: %v", err)
+	}
+
+	return formKey, nil
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+)
+
+func TestEncryptBallot(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey, err := suite.Point().Mul(secret, nil).MarshalBinary()
+	require.NoError(t, err)
+
+	ballot := "select:" + strings.Repeat("a", 40)
+
+	ciphervote, err := EncryptBallot(hex.EncodeToString(pubkey), ballot, 3)
+	require.NoError(t, err)
+	require.Len(t, ciphervote, 3)
+
+	var decrypted []byte
+
+	for _, egpair := range ciphervote {
+		K := suite.Point()
+		require.NoError(t, K.UnmarshalBinary(egpair.K))
+
+		C := suite.Point()
+		require.NoError(t, C.UnmarshalBinary(egpair.C))
+
+		S := suite.Point().Mul(secret, K)
+		M := suite.Point().Sub(C, S)
+
+		data, err := M.Data()
+		require.NoError(t, err)
+
+		decrypted = append(decrypted, data...)
+	}
+
+	require.Equal(t, ballot, string(decrypted))
+
+	_, err = EncryptBallot(hex.EncodeToString(pubkey), ballot, 1)
+	require.EqualError(t, err, "the ballot is too long: 47 > 1 chunks of 29 bytes")
+
+	_, err = EncryptBallot("not hex", ballot, 1)
+	require.Error(t, err)
+}
+
+func TestEncrypter_EncryptBallots(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey, err := suite.Point().Mul(secret, nil).MarshalBinary()
+	require.NoError(t, err)
+
+	encrypter, err := NewEncrypter(hex.EncodeToString(pubkey), 10)
+	require.NoError(t, err)
+
+	err = encrypter.Precompute(10)
+	require.NoError(t, err)
+
+	ballots := []string{"select:" + strings.Repeat("a", 40), "select:b"}
+
+	ciphervotes, err := encrypter.EncryptBallots(ballots, 2)
+	require.NoError(t, err)
+	require.Len(t, ciphervotes, 2)
+
+	for i, ciphervote := range ciphervotes {
+		require.Len(t, ciphervote, 2)
+
+		var decrypted []byte
+
+		for _, egpair := range ciphervote {
+			K := suite.Point()
+			require.NoError(t, K.UnmarshalBinary(egpair.K))
+
+			C := suite.Point()
+			require.NoError(t, C.UnmarshalBinary(egpair.C))
+
+			M := suite.Point().Sub(C, suite.Point().Mul(secret, K))
+
+			data, err := M.Data()
+			require.NoError(t, err)
+
+			decrypted = append(decrypted, data...)
+		}
+
+		require.Equal(t, ballots[i], string(decrypted))
+	}
+
+	_, err = encrypter.EncryptBallots(ballots, 1)
+	require.EqualError(t, err, "failed to encrypt ballots: failed to encrypt "+
+		"ballot 0: the ballot is too long: 47 > 1 chunks of 29 bytes")
+
+	_, err = NewEncrypter("not hex", 0)
+	require.Error(t, err)
+}
+
+func TestVerifyAudit(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey, err := suite.Point().Mul(secret, nil).MarshalBinary()
+	require.NoError(t, err)
+
+	ballot := "select:" + strings.Repeat("a", 40)
+
+	ciphervote, randomness, err := EncryptBallotForAudit(hex.EncodeToString(pubkey), ballot, 3)
+	require.NoError(t, err)
+	require.Len(t, randomness, 3)
+
+	audited, err := VerifyAudit(hex.EncodeToString(pubkey), ciphervote, randomness)
+	require.NoError(t, err)
+	require.Equal(t, ballot, audited)
+
+	_, err = VerifyAudit(hex.EncodeToString(pubkey), ciphervote, randomness[:2])
+	require.EqualError(t, err, "failed to audit ballot: 2 randomness for 3 pairs")
+
+	randomness[0], randomness[1] = randomness[1], randomness[0]
+
+	_, err = VerifyAudit(hex.EncodeToString(pubkey), ciphervote, randomness)
+	require.EqualError(t, err, "failed to audit ballot: pair 0 is not encrypted "+
+		"with its randomness")
+
+	_, err = VerifyAudit("not hex", ciphervote, randomness)
+	require.Error(t, err)
+}
+
+func TestSignVote(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	req := ptypes.CastVoteRequest{
+		VoterID: "voter",
+		Ballot:  encryptedBallot(t),
+	}
+
+	err := SignVote(secret, "deadbeef", &req)
+	require.NoError(t, err)
+	require.NotZero(t, req.Sequence)
+
+	castVote, err := toCastVote("deadbeef", "voter", req.Ballot, req.Sequence)
+	require.NoError(t, err)
+
+	hash, err := castVote.Hash()
+	require.NoError(t, err)
+
+	require.NoError(t, schnorr.Verify(suite, pubkey, hash, req.Signature))
+}
+
+func TestSignCredential(t *testing.T) {
+	secrets := make([]kyber.Scalar, 2*etypes.CredentialRingSize+1)
+	form := ptypes.GetFormResponse{FormID: "deadbeef"}
+	storeForm := etypes.Form{FormID: "deadbeef", Anonymous: true}
+	snap := fake.NewSnapshot()
+
+	for i := range secrets {
+		secrets[i] = suite.Scalar().Pick(suite.RandomStream())
+
+		pubkey, err := suite.Point().Mul(secrets[i], nil).MarshalBinary()
+		require.NoError(t, err)
+
+		form.ElectoralRoll = append(form.ElectoralRoll, hex.EncodeToString(pubkey))
+
+		err = storeForm.AddCredential(snap, pubkey)
+		require.NoError(t, err)
+	}
+
+	req := ptypes.AnonymousVoteRequest{
+		Ballot: encryptedBallot(t),
+	}
+
+	// the last ring takes the remaining credential
+	last := len(secrets) - 1
+
+	err := SignCredential(form, last, secrets[last], &req)
+	require.NoError(t, err)
+	require.NotZero(t, req.Sequence)
+	require.Equal(t, 1, req.Ring)
+
+	castVote, err := toCastVote("deadbeef", "", req.Ballot, req.Sequence)
+	require.NoError(t, err)
+
+	castVote.Credential = req.Credential
+	castVote.Ring = req.Ring
+
+	_, err = storeForm.VerifyCredential(snap, castVote)
+	require.NoError(t, err)
+
+	// the sequence is signed
+	castVote.Sequence++
+
+	_, err = storeForm.VerifyCredential(snap, castVote)
+	require.ErrorContains(t, err, "invalid credential")
+
+	// the credential is not in the ring of the vote
+	castVote.Sequence--
+	castVote.Ring = 0
+
+	_, err = storeForm.VerifyCredential(snap, castVote)
+	require.ErrorContains(t, err, "invalid credential")
+
+	castVote.Ring = 2
+
+	_, err = storeForm.VerifyCredential(snap, castVote)
+	require.EqualError(t, err, "failed to get ring: invalid ring: ring out of "+
+		"range: 2 not in [0, 2)")
+
+	err = SignCredential(form, len(secrets), secrets[1], &req)
+	require.EqualError(t, err, fmt.Sprintf("index out of range: %d", len(secrets)))
+}
+
+func TestVerifyBallotProof(t *testing.T) {
+	ballots := []ptypes.CiphervoteJSON{encryptedBallot(t), encryptedBallot(t)}
+
+	suff := etypes.Suffragia{VoterIDs: []string{"a", "b"}}
+
+	for _, ballot := range ballots {
+		ciphervote, err := decodeCiphervote(ballot)
+		require.NoError(t, err)
+
+		suff.Ciphervotes = append(suff.Ciphervotes, ciphervote)
+	}
+
+	blockHash, err := suff.Hash()
+	require.NoError(t, err)
+
+	formID := []byte{0xde, 0xad, 0xbe, 0xef}
+	ballotsHash := sha256.Sum256(append(formID, blockHash...))
+
+	proof := ptypes.BallotProofResponse{
+		VoterIDs: suff.VoterIDs,
+		Ballots:  ballots,
+		Previous: hex.EncodeToString(formID),
+	}
+
+	err = VerifyBallotProof(proof, "b", ballots[1], hex.EncodeToString(ballotsHash[:]))
+	require.NoError(t, err)
+
+	err = VerifyBallotProof(proof, "b", ballots[0], hex.EncodeToString(ballotsHash[:]))
+	require.EqualError(t, err, "invalid proof: the ballot of b doesn't match")
+
+	err = VerifyBallotProof(proof, "b", ballots[1], "deadbeef")
+	require.EqualError(t, err, "invalid proof: block 0 doesn't lead to the ballots hash")
+
+	err = VerifyBallotProof(proof, "b", ballots[1], "X")
+	require.ErrorContains(t, err, "failed to decode ballots hash")
+
+	proof.Ballots = ballots[:1]
+
+	err = VerifyBallotProof(proof, "b", ballots[1], hex.EncodeToString(ballotsHash[:]))
+	require.EqualError(t, err, "2 voters for 1 ballots")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func encryptedBallot(t *testing.T) ptypes.CiphervoteJSON {
+	pubkey, err := suite.Point().Pick(suite.RandomStream()).MarshalBinary()
+	require.NoError(t, err)
+
+	ballot, err := EncryptBallot(hex.EncodeToString(pubkey), "select:abc", 2)
+	require.NoError(t, err)
+
+	return ballot
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/c4dt/d-voting/proxy/txnmanager"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"golang.org/x/xerrors"
+)
+
+// CreateForm creates a form. The transaction can be waited on with the token
+// of the response.
+func (c *Client) CreateForm(ctx context.Context,
+	req ptypes.CreateFormRequest) (ptypes.CreateFormResponse, error) {
+
+	var res ptypes.CreateFormResponse
+
+	err := c.doSigned(ctx, http.MethodPost, formsPath, req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to create form: %w", err)
+	}
+
+	return res, nil
+}
+
+// Forms returns the light version of all the forms.
+func (c *Client) Forms(ctx context.Context) (ptypes.GetFormsResponse, error) {
+	var res ptypes.GetFormsResponse
+
+	err := c.doJSON(ctx, http.MethodGet, formsPath, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get forms: %w", err)
+	}
+
+	return res, nil
+}
+
+// Form returns the form with the hex-encoded ID.
+func (c *Client) Form(ctx context.Context, formID string) (ptypes.GetFormResponse, error) {
+	var res ptypes.GetFormResponse
+
+	err := c.doJSON(ctx, http.MethodGet, formPath(formID), nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get form: %w", err)
+	}
+
+	return res, nil
+}
+
+// Ballot returns where the ballot with the hex-encoded hash of a receipt is
+// stored, and whether it is the one counted for its voter.
+func (c *Client) Ballot(ctx context.Context, formID,
+	receiptHash string) (ptypes.GetBallotResponse, error) {
+
+	var res ptypes.GetBallotResponse
+
+	err := c.doJSON(ctx, http.MethodGet, formPath(formID)+"/ballots/"+receiptHash, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get ballot: %w", err)
+	}
+
+	return res, nil
+}
+
+// BallotProof returns the proof that the ballot of the voter is committed to
+// by the form, see VerifyBallotProof.
+func (c *Client) BallotProof(ctx context.Context, formID,
+	voterID string) (ptypes.BallotProofResponse, error) {
+
+	var res ptypes.BallotProofResponse
+
+	path := formPath(formID) + "/ballots/" + voterID + "/proof"
+
+	err := c.doJSON(ctx, http.MethodGet, path, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get ballot proof: %w", err)
+	}
+
+	return res, nil
+}
+
+// UpdateForm opens, closes, combines the shares of, cancels or migrates a
+// form, depending on the action of the request.
+func (c *Client) UpdateForm(ctx context.Context, formID string,
+	req ptypes.UpdateFormRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doSigned(ctx, http.MethodPut, formPath(formID), req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to %s form: %w", req.Action, err)
+	}
+
+	return res, nil
+}
+
+// DeleteForm deletes a form on behalf of the user.
+func (c *Client) DeleteForm(ctx context.Context, formID,
+	userID string) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	req := ptypes.DeleteFormRequest{UserID: userID}
+
+	err := c.doSigned(ctx, http.MethodDelete, formPath(formID), req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to delete form: %w", err)
+	}
+
+	return res, nil
+}
+
+// CastVote casts a vote on behalf of the voter of the request, as a trusted
+// frontend.
+func (c *Client) CastVote(ctx context.Context, formID string,
+	req ptypes.CastVoteRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doSigned(ctx, http.MethodPost, formPath(formID)+"/vote", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to cast vote: %w", err)
+	}
+
+	return res, nil
+}
+
+// CastSignedVote casts a vote signed by the voter, see SignVote. The request
+// doesn't need to be signed by a trusted frontend.
+func (c *Client) CastSignedVote(ctx context.Context, formID string,
+	req ptypes.CastVoteRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doJSON(ctx, http.MethodPost, formPath(formID)+"/vote/signed", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to cast signed vote: %w", err)
+	}
+
+	return res, nil
+}
+
+// CastAnonymousVote casts a vote on an anonymous form with a credential of
+// the electoral roll, see SignCredential. The request doesn't need to be
+// signed by a trusted frontend.
+func (c *Client) CastAnonymousVote(ctx context.Context, formID string,
+	req ptypes.AnonymousVoteRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doJSON(ctx, http.MethodPost, formPath(formID)+"/vote/anonymous", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to cast anonymous vote: %w", err)
+	}
+
+	return res, nil
+}
+
+// AuditBallot audits a ballot instead of casting it, see
+// EncryptBallotForAudit. The audited ballot can't be cast afterwards.
+func (c *Client) AuditBallot(ctx context.Context, formID string,
+	req ptypes.AuditBallotRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doJSON(ctx, http.MethodPost, formPath(formID)+"/vote/audit", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to audit ballot: %w", err)
+	}
+
+	return res, nil
+}
+
+// AddOwner adds an owner to a form.
+func (c *Client) AddOwner(ctx context.Context, formID string,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, formPath(formID)+"/addowner", req)
+}
+
+// RemoveOwner removes an owner from a form.
+func (c *Client) RemoveOwner(ctx context.Context, formID string,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, formPath(formID)+"/removeowner", req)
+}
+
+// AddVoter adds a voter to a form.
+func (c *Client) AddVoter(ctx context.Context, formID string,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, formPath(formID)+"/addvoter", req)
+}
+
+// RemoveVoter removes a voter from a form.
+func (c *Client) RemoveVoter(ctx context.Context, formID string,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, formPath(formID)+"/removevoter", req)
+}
+
+// AddCredential adds a credential to the electoral roll of an anonymous form.
+func (c *Client) AddCredential(ctx context.Context, formID string,
+	req ptypes.AddCredentialRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doSigned(ctx, http.MethodPost, formPath(formID)+"/credentials", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to add credential: %w", err)
+	}
+
+	return res, nil
+}
+
+// AddAdmin adds an admin.
+func (c *Client) AddAdmin(ctx context.Context,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, evotingPrefix+"addadmin", req)
+}
+
+// RemoveAdmin removes an admin.
+func (c *Client) RemoveAdmin(ctx context.Context,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, evotingPrefix+"removeadmin", req)
+}
+
+// Admins returns the admins.
+func (c *Client) Admins(ctx context.Context) (ptypes.GetAdminsResponse, error) {
+	var res ptypes.GetAdminsResponse
+
+	err := c.doJSON(ctx, http.MethodGet, evotingPrefix+"adminlist", nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get admins: %w", err)
+	}
+
+	return res, nil
+}
+
+// AddOperator adds an operator.
+func (c *Client) AddOperator(ctx context.Context,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, evotingPrefix+"addoperator", req)
+}
+
+// RemoveOperator removes an operator.
+func (c *Client) RemoveOperator(ctx context.Context,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	return c.permission(ctx, evotingPrefix+"removeoperator", req)
+}
+
+// Operators returns the operators.
+func (c *Client) Operators(ctx context.Context) (ptypes.GetOperatorsResponse, error) {
+	var res ptypes.GetOperatorsResponse
+
+	err := c.doJSON(ctx, http.MethodGet, evotingPrefix+"operatorlist", nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get operators: %w", err)
+	}
+
+	return res, nil
+}
+
+// AuditLog returns the entries of the audit log of the node matching the
+// filters of the request. The entries can be checked with
+// types.VerifyAuditLog when none is filtered out.
+func (c *Client) AuditLog(ctx context.Context,
+	req ptypes.AuditLogRequest) (ptypes.GetAuditLogResponse, error) {
+
+	var res ptypes.GetAuditLogResponse
+
+	err := c.doSigned(ctx, http.MethodPost, evotingPrefix+"audit", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get audit log: %w", err)
+	}
+
+	return res, nil
+}
+
+// permission sends a signed permission operation to the endpoint.
+func (c *Client) permission(ctx context.Context, path string,
+	req ptypes.PermissionOperationRequest) (txnmanager.TransactionClientInfo, error) {
+
+	var res txnmanager.TransactionClientInfo
+
+	err := c.doSigned(ctx, http.MethodPost, path, req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to update permission: %w", err)
+	}
+
+	return res, nil
+}
+
+// formPath returns the path of the form with the hex-encoded ID.
+func formPath(formID string) string {
+	return formsPath + "/" + formID
+}
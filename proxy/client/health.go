@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"golang.org/x/xerrors"
+)
+
+// Health returns the state of the node of the proxy. Its Ready field tells if
+// the node is ready to serve the requests that add transactions.
+func (c *Client) Health(ctx context.Context) (ptypes.HealthResponse, error) {
+	var res ptypes.HealthResponse
+
+	err := c.doJSON(ctx, http.MethodGet, healthPath, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get health: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,211 @@
+// Package client implements a Go client of the public API of the proxy.
+//
+// The requests that must be signed by a trusted frontend are signed with the
+// secret given to the client, the ballots are encrypted with the public key of
+// the form, and the transactions can be waited on with their token.
+//
+// For the API specification look at /docs/api.md.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/suites"
+	"golang.org/x/xerrors"
+)
+
+var suite = suites.MustFind("ed25519")
+
+const (
+	contentType = "application/json"
+
+	formsPath     = "/evoting/forms"
+	dkgPath       = "/evoting/services/dkg/actors"
+	ceremonyPath  = "/evoting/services/dkg/ceremonies"
+	shufflePath   = "/evoting/services/shuffle"
+	txnPath       = "/evoting/transactions"
+	healthPath    = "/healthz"
+	evotingPrefix = "/evoting/"
+
+	// defaultPollInterval is the time between two polls of a transaction or
+	// of the shuffle.
+	defaultPollInterval = time.Second
+)
+
+// Error is returned when the proxy answers with an error. It holds the JSON
+// error of the proxy, or the raw body if the answer is not JSON.
+type Error struct {
+	ptypes.HTTPError
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("%d %s: %s", e.Code, e.Title, e.Message)
+	}
+
+	return fmt.Sprintf("%d %s (%s): %s", e.Code, e.Title, e.ErrorCode, e.Message)
+}
+
+// Client is a client of the proxy of a node.
+type Client struct {
+	addr   string
+	secret kyber.Scalar
+	http   *http.Client
+
+	// PollInterval is the time between two polls of a transaction or of the
+	// shuffle.
+	PollInterval time.Duration
+}
+
+// NewClient returns a new client of the proxy at the given address, for
+// example "http://localhost:9080". The secret is used to sign the requests
+// and must be the one of a key trusted by the proxy. It can be nil if only
+// the unsigned endpoints are used. The default HTTP client is used if
+// httpClient is nil.
+func NewClient(addr string, secret kyber.Scalar, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		addr:         addr,
+		secret:       secret,
+		http:         httpClient,
+		PollInterval: defaultPollInterval,
+	}
+}
+
+// signedBody returns the JSON of the signed request of the message for the
+// endpoint.
+func (c *Client) signedBody(method, path string, msg interface{}) ([]byte, error) {
+	if c.secret == nil {
+		return nil, xerrors.New("the client has no secret to sign requests")
+	}
+
+	signed, err := ptypes.SignRequest(c.secret, method, path, msg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to sign request: %v", err)
+	}
+
+	body, err := json.Marshal(signed)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal signed request: %v", err)
+	}
+
+	return body, nil
+}
+
+// doSigned sends the message in a signed request and decodes the response in
+// res, if not nil.
+func (c *Client) doSigned(ctx context.Context, method, path string, msg,
+	res interface{}) error {
+
+	body, err := c.signedBody(method, path, msg)
+	if err != nil {
+		return xerrors.Errorf("failed to create signed request: %v", err)
+	}
+
+	return c.do(ctx, method, path, body, res)
+}
+
+// doJSON sends the JSON of the message, if not nil, and decodes the response
+// in res, if not nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, msg,
+	res interface{}) error {
+
+	var body []byte
+
+	if msg != nil {
+		var err error
+
+		body, err = json.Marshal(msg)
+		if err != nil {
+			return xerrors.Errorf("failed to marshal request: %v", err)
+		}
+	}
+
+	return c.do(ctx, method, path, body, res)
+}
+
+// do sends the request and decodes the response in res, if not nil. An answer
+// other than 200 is returned as an *Error.
+func (c *Client) do(ctx context.Context, method, path string, body []byte,
+	res interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path,
+		bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("failed to create request: %v", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to send %s %s: %w", method, path, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readError(resp)
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return xerrors.Errorf("failed to decode response of %s %s: %v",
+			method, path, err)
+	}
+
+	return nil
+}
+
+// readError returns the error of a response.
+func readError(resp *http.Response) error {
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return xerrors.Errorf("unexpected status %s, failed to read body: %v",
+			resp.Status, err)
+	}
+
+	var httpErr ptypes.HTTPError
+
+	err = json.Unmarshal(buf, &httpErr)
+	if err != nil || httpErr.Code == 0 {
+		httpErr = ptypes.HTTPError{
+			Title:   http.StatusText(resp.StatusCode),
+			Code:    uint(resp.StatusCode),
+			Message: string(bytes.TrimSpace(buf)),
+		}
+	}
+
+	return &Error{HTTPError: httpErr}
+}
+
+// sleep waits for the poll interval, or until the context is done.
+func (c *Client) sleep(ctx context.Context) error {
+	timer := time.NewTimer(c.PollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
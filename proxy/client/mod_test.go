@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/txnmanager"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SignedRequest(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signed, err := ptypes.NewSignedRequest(r.Body)
+		require.NoError(t, err)
+
+		require.NoError(t, signed.Verify(pubkey))
+		require.Equal(t, http.MethodPut, signed.Method)
+		require.Equal(t, r.URL.Path, signed.Path)
+
+		var req ptypes.UpdateFormRequest
+
+		err = signed.GetMessage(&req)
+		require.NoError(t, err)
+		require.Equal(t, "open", req.Action)
+
+		json.NewEncoder(w).Encode(txnmanager.TransactionClientInfo{Token: "abc"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, secret, nil)
+
+	info, err := c.UpdateForm(context.Background(), "deadbeef",
+		ptypes.UpdateFormRequest{Action: "open"})
+	require.NoError(t, err)
+	require.Equal(t, "abc", info.Token)
+
+	c = NewClient(server.URL, nil, nil)
+
+	_, err = c.UpdateForm(context.Background(), "deadbeef",
+		ptypes.UpdateFormRequest{Action: "close"})
+	require.EqualError(t, err, "failed to close form: failed to create signed request: "+
+		"the client has no secret to sign requests")
+}
+
+func TestClient_DeleteForm(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pubkey := suite.Point().Mul(secret, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+		require.Equal(t, "/evoting/forms/deadbeef", r.URL.Path)
+
+		signed, err := ptypes.NewSignedRequest(r.Body)
+		require.NoError(t, err)
+
+		require.NoError(t, signed.Verify(pubkey))
+		require.Equal(t, http.MethodDelete, signed.Method)
+		require.Equal(t, r.URL.Path, signed.Path)
+
+		var req ptypes.DeleteFormRequest
+
+		err = signed.GetMessage(&req)
+		require.NoError(t, err)
+		require.Equal(t, "user", req.UserID)
+
+		json.NewEncoder(w).Encode(txnmanager.TransactionClientInfo{})
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, secret, nil).DeleteForm(context.Background(),
+		"deadbeef", "user")
+	require.NoError(t, err)
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/evoting/forms/deadbeef" {
+			ptypes.WriteError(w, ptypes.HTTPError{
+				Title:     "Not Found",
+				Code:      http.StatusNotFound,
+				ErrorCode: ptypes.ErrCodeFormNotFound,
+				Message:   "form not found",
+			})
+			return
+		}
+
+		http.Error(w, "oops", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, nil, nil)
+
+	_, err := c.Form(context.Background(), "deadbeef")
+
+	var httpErr *Error
+	require.True(t, errors.As(err, &httpErr))
+	require.Equal(t, ptypes.ErrCodeFormNotFound, httpErr.ErrorCode)
+	require.EqualError(t, err, "failed to get form: 404 Not Found (FORM_NOT_FOUND): "+
+		"form not found")
+
+	_, err = c.Forms(context.Background())
+	require.True(t, errors.As(err, &httpErr))
+	require.Equal(t, uint(http.StatusBadGateway), httpErr.Code)
+	require.Equal(t, "oops", httpErr.Message)
+}
+
+func TestClient_WaitTransaction(t *testing.T) {
+	var polls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&polls, 1)
+
+		// the token is updated at each poll
+		info := txnmanager.TransactionClientInfo{Token: path.Base(r.URL.Path)}
+
+		switch r.URL.Path {
+		case "/evoting/transactions/included":
+			if n > 2 {
+				info.Status = txnmanager.IncludedTransaction
+			}
+		case "/evoting/transactions/rejected":
+			info.Status = txnmanager.RejectedTransaction
+			info.Reason = "the form is not open"
+			info.ErrorCode = ptypes.ErrCodeWrongStatus
+		}
+
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, nil, nil)
+	c.PollInterval = time.Millisecond
+
+	err := c.WaitTransaction(context.Background(), "included")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&polls))
+
+	err = c.WaitTransaction(context.Background(), "rejected")
+
+	var rejected *RejectedError
+	require.True(t, errors.As(err, &rejected))
+	require.Equal(t, ptypes.ErrCodeWrongStatus, rejected.Info.ErrorCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = c.WaitTransaction(ctx, "pending")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/c4dt/d-voting/proxy/openapi"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/c4dt/d-voting/services/shuffle"
+	"golang.org/x/xerrors"
+)
+
+// InitDKG creates the DKG actor of the form on the node of the proxy. It must
+// be called on every node of the roster of the form.
+func (c *Client) InitDKG(ctx context.Context, formID string) error {
+	req := ptypes.NewDKGRequest{
+		FormID: formID,
+	}
+
+	err := c.doSigned(ctx, http.MethodPost, dkgPath, req, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to init DKG: %w", err)
+	}
+
+	return nil
+}
+
+// DKGActor returns the status of the DKG actor of the form.
+func (c *Client) DKGActor(ctx context.Context, formID string) (ptypes.GetActorInfo, error) {
+	var res ptypes.GetActorInfo
+
+	err := c.doJSON(ctx, http.MethodGet, dkgPath+"/"+formID, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get DKG actor: %w", err)
+	}
+
+	return res, nil
+}
+
+// UpdateDKG sets up the DKG or computes the public shares of the form,
+// depending on the action, "setup" or "computePubshares".
+func (c *Client) UpdateDKG(ctx context.Context, formID, action string) error {
+	req := ptypes.UpdateDKG{
+		Action: action,
+	}
+
+	err := c.doSigned(ctx, http.MethodPut, dkgPath+"/"+formID, req, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to %s DKG: %w", action, err)
+	}
+
+	return nil
+}
+
+// NewCeremony creates a key ceremony on the node of the proxy.
+func (c *Client) NewCeremony(ctx context.Context, ceremonyID string) error {
+	req := ptypes.NewCeremonyRequest{
+		CeremonyID: ceremonyID,
+	}
+
+	err := c.doSigned(ctx, http.MethodPost, ceremonyPath, req, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to create ceremony: %w", err)
+	}
+
+	return nil
+}
+
+// Ceremonies returns the key ceremonies of the node of the proxy.
+func (c *Client) Ceremonies(ctx context.Context) (ptypes.GetCeremoniesResponse, error) {
+	var res ptypes.GetCeremoniesResponse
+
+	err := c.doJSON(ctx, http.MethodGet, ceremonyPath, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get ceremonies: %w", err)
+	}
+
+	return res, nil
+}
+
+// Ceremony returns a key ceremony.
+func (c *Client) Ceremony(ctx context.Context, ceremonyID string) (ptypes.CeremonyInfo, error) {
+	var res ptypes.CeremonyInfo
+
+	err := c.doJSON(ctx, http.MethodGet, ceremonyPath+"/"+ceremonyID, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get ceremony: %w", err)
+	}
+
+	return res, nil
+}
+
+// UpdateCeremony sets up a key ceremony.
+func (c *Client) UpdateCeremony(ctx context.Context, ceremonyID, action string) error {
+	req := ptypes.UpdateCeremony{
+		Action: action,
+	}
+
+	err := c.doSigned(ctx, http.MethodPut, ceremonyPath+"/"+ceremonyID, req, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to %s ceremony: %w", action, err)
+	}
+
+	return nil
+}
+
+// Shuffle starts the shuffle of the form on behalf of the user. Use
+// WaitShuffle to wait for its end.
+func (c *Client) Shuffle(ctx context.Context, formID, userID string) error {
+	req := ptypes.UpdateShuffle{
+		Action: "shuffle",
+		UserID: userID,
+	}
+
+	err := c.doSigned(ctx, http.MethodPut, shufflePath+"/"+formID, req, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to shuffle: %w", err)
+	}
+
+	return nil
+}
+
+// ShuffleStatus returns the progress of the shuffle of the form.
+func (c *Client) ShuffleStatus(ctx context.Context,
+	formID string) (ptypes.GetShuffleResponse, error) {
+
+	var res ptypes.GetShuffleResponse
+
+	err := c.doJSON(ctx, http.MethodGet, shufflePath+"/"+formID, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get shuffle status: %w", err)
+	}
+
+	return res, nil
+}
+
+// WaitShuffle polls the progress of the shuffle of the form until it is over.
+// The context bounds the time spent waiting.
+func (c *Client) WaitShuffle(ctx context.Context, formID string) error {
+	for {
+		status, err := c.ShuffleStatus(ctx, formID)
+		if err != nil {
+			return xerrors.Errorf("failed to wait for shuffle: %w", err)
+		}
+
+		switch shuffle.StatusCode(status.Status) {
+		case shuffle.Done:
+			return nil
+		case shuffle.Failed:
+			return xerrors.Errorf("shuffle failed: %s", status.Error.Message)
+		}
+
+		err = c.sleep(ctx)
+		if err != nil {
+			return xerrors.Errorf("shuffle not done in time: %w", err)
+		}
+	}
+}
+
+// ShuffleBallots returns the ballots to shuffle in the current round of the
+// shuffle of the form.
+func (c *Client) ShuffleBallots(ctx context.Context,
+	formID string) (ptypes.GetShuffleBallotsResponse, error) {
+
+	var res ptypes.GetShuffleBallotsResponse
+
+	err := c.doJSON(ctx, http.MethodGet, shufflePath+"/"+formID+"/ballots", nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get ballots to shuffle: %w", err)
+	}
+
+	return res, nil
+}
+
+// SubmitShuffle submits the shuffle of a mixer, which is signed by the mixer
+// itself.
+func (c *Client) SubmitShuffle(ctx context.Context, formID string,
+	req ptypes.SubmitShuffleRequest) (ptypes.SubmitShuffleResponse, error) {
+
+	var res ptypes.SubmitShuffleResponse
+
+	err := c.doJSON(ctx, http.MethodPost, shufflePath+"/"+formID+"/ballots", req, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to submit shuffle: %w", err)
+	}
+
+	return res, nil
+}
+
+// OpenAPI returns the OpenAPI document of the proxy.
+func (c *Client) OpenAPI(ctx context.Context) (openapi.Spec, error) {
+	var res openapi.Spec
+
+	err := c.doJSON(ctx, http.MethodGet, openapi.Path, nil, &res)
+	if err != nil {
+		return res, xerrors.Errorf("failed to get OpenAPI document: %w", err)
+	}
+
+	return res, nil
+}
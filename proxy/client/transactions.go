@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/c4dt/d-voting/proxy/txnmanager"
+	"golang.org/x/xerrors"
+)
+
+// RejectedError is returned when a transaction is rejected by the smart
+// contract.
+type RejectedError struct {
+	Info txnmanager.TransactionClientInfo
+}
+
+// Error implements error.
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("transaction rejected in block %d (%s): %s",
+		e.Info.BlockIdx, e.Info.ErrorCode, e.Info.Reason)
+}
+
+// Transaction returns the status of the transaction of the token.
+func (c *Client) Transaction(ctx context.Context,
+	token string) (txnmanager.TransactionClientInfo, error) {
+
+	var info txnmanager.TransactionClientInfo
+
+	err := c.doJSON(ctx, http.MethodGet, txnPath+"/"+token, nil, &info)
+	if err != nil {
+		return info, xerrors.Errorf("failed to get transaction: %w", err)
+	}
+
+	return info, nil
+}
+
+// WaitTransaction polls the status of the transaction of the token until it
+// is included, in which case it returns nil, or rejected, in which case it
+// returns a *RejectedError. The context bounds the time spent waiting.
+func (c *Client) WaitTransaction(ctx context.Context, token string) error {
+	for {
+		info, err := c.Transaction(ctx, token)
+		if err != nil {
+			return xerrors.Errorf("failed to wait for transaction: %w", err)
+		}
+
+		switch info.Status {
+		case txnmanager.IncludedTransaction:
+			return nil
+		case txnmanager.RejectedTransaction:
+			return &RejectedError{Info: info}
+		}
+
+		// the token is updated with the last block seen by the proxy
+		token = info.Token
+
+		err = c.sleep(ctx)
+		if err != nil {
+			return xerrors.Errorf("transaction not included in time: %w", err)
+		}
+	}
+}
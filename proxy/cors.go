@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.dedis.ch/dela/cli"
+)
+
+// corsMaxAge is the time, in seconds, the browsers can cache the answer of a
+// preflight request.
+const corsMaxAge = 600
+
+// corsHeaders are the headers the browsers are allowed to send.
+const corsHeaders = "Content-Type"
+
+// CORSFlags are the flags of the origins allowed to call the proxy from a
+// browser, see NewCORSFromFlags.
+var CORSFlags = []cli.Flag{
+	cli.StringFlag{
+		Name: "proxyorigins",
+		Usage: "the comma separated origins allowed to call the proxy from a " +
+			"browser, \"*\" for all, none by default",
+		Required: false,
+	},
+}
+
+// CORS sets the Cross-Origin Resource Sharing headers of the answers to the
+// requests of the allowed origins. The browsers don't let the pages of the
+// other origins read the answers.
+type CORS struct {
+	all     bool
+	origins map[string]struct{}
+}
+
+// NewCORS returns a new CORS allowing the origins, for example
+// "https://dvoting.example.com". The origin "*" allows all of them.
+func NewCORS(origins ...string) *CORS {
+	c := &CORS{
+		origins: make(map[string]struct{}),
+	}
+
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+
+		switch origin {
+		case "":
+			// the flag is not set
+		case "*":
+			c.all = true
+		default:
+			c.origins[origin] = struct{}{}
+		}
+	}
+
+	return c
+}
+
+// NewCORSFromFlags returns the CORS defined by the flags of CORSFlags.
+func NewCORSFromFlags(flags cli.Flags) *CORS {
+	return NewCORS(strings.Split(flags.String("proxyorigins"), ",")...)
+}
+
+// Middleware sets the CORS headers and answers the preflight requests. It can
+// be used with mux.Router.Use, before the other middlewares so that their
+// errors can be read by the browsers.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		w.Header().Add("Vary", "Origin")
+
+		if origin == "" || !c.allows(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.all {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		preflight := r.Method == http.MethodOptions &&
+			r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) allows(origin string) bool {
+	if c.all {
+		return true
+	}
+
+	_, found := c.origins[origin]
+
+	return found
+}
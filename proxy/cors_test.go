@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS_Middleware(t *testing.T) {
+	cors := NewCORS("https://dvoting.example.com/", " https://admin.example.com")
+
+	handler := cors.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/evoting/forms", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		if preflight {
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := serve(http.MethodGet, "https://dvoting.example.com", false)
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.Equal(t, "https://dvoting.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = serve(http.MethodOptions, "https://admin.example.com", true)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, corsHeaders, w.Header().Get("Access-Control-Allow-Headers"))
+	require.NotEmpty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// the other origins get no CORS header
+	w = serve(http.MethodGet, "https://evil.example.com", false)
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = serve(http.MethodOptions, "https://evil.example.com", true)
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = serve(http.MethodGet, "", false)
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_AllOrigins(t *testing.T) {
+	handler := NewCORS("*").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/evoting/forms", nil)
+	r.Header.Set("Origin", "https://dvoting.example.com")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// no origin is allowed by default
+	handler = NewCORS("").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -3,25 +3,24 @@ package proxy
 import (
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 
 	"net/http"
+	"sort"
 
 	"github.com/c4dt/d-voting/proxy/types"
 	dkgSrv "github.com/c4dt/d-voting/services/dkg"
 	"github.com/gorilla/mux"
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/txn"
-	"go.dedis.ch/kyber/v3"
 	"golang.org/x/xerrors"
 )
 
-// NewDKG returns a new initialized DKG proxy
-func NewDKG(mngr txn.Manager, d dkgSrv.DKG, pk kyber.Point) DKG {
+// NewDKG returns a new initialized DKG proxy. The limiter can be nil.
+func NewDKG(mngr txn.Manager, d dkgSrv.DKG, keys *KeyRing, limiter *Limiter) DKG {
 	return dkg{
 		manager:    mngr,
 		dkgService: d,
-		pk:         pk,
+		verifier:   NewVerifier(keys, limiter, SignedRequestWindow),
 	}
 }
 
@@ -33,8 +32,8 @@ type dkg struct {
 	manager txn.Manager
 	// dkgService is the DKG service
 	dkgService dkgSrv.DKG
-	// pk is the public key of the proxy
-	pk kyber.Point
+	// verifier verifies the requests signed by the trusted keys
+	verifier *Verifier
 }
 
 // NewDKGActor implements proxy.DKG
@@ -43,29 +42,21 @@ func (d dkg) NewDKGActor(w http.ResponseWriter, r *http.Request) {
 
 	var req types.NewDKGRequest
 
-	// Read the request
-	signed, err := types.NewSignedRequest(r.Body)
+	// get the request and verify the signature
+	err := d.verifier.GetAndVerify(r, ScopeDKG, &req)
 	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
-		return
-	}
-
-	// Verify the request
-	err = signed.GetAndVerify(d.pk, &req)
-	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
 	formIDBuf, err := hex.DecodeString(req.FormID)
 	if err != nil {
-		http.Error(w, "failed to decode formID: "+req.FormID,
-			http.StatusBadRequest)
+		BadRequestError(w, r, xerrors.Errorf("failed to decode formID: %v", err), nil)
 		return
 	}
 
 	if len(formIDBuf) == 0 {
-		http.Error(w, "formID is empty", http.StatusBadRequest)
+		BadRequestError(w, r, xerrors.New("formID is empty"), nil)
 		return
 	}
 
@@ -77,8 +68,7 @@ func (d dkg) NewDKGActor(w http.ResponseWriter, r *http.Request) {
 	// subscribe to the DKG service
 	_, err = d.dkgService.Listen(formIDBuf, d.manager)
 	if err != nil {
-		http.Error(w, "failed to start actor: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to start actor: %v", err), nil)
 		return
 	}
 }
@@ -86,14 +76,11 @@ func (d dkg) NewDKGActor(w http.ResponseWriter, r *http.Request) {
 // Actor implements proxy.DKG
 // Send the actor status
 func (d dkg) Actor(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-
 	vars := mux.Vars(r)
 
 	// check if the formID is present
 	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("formID not found: %v", vars), nil)
 		return
 	}
 
@@ -111,25 +98,8 @@ func (d dkg) Actor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get the status
-	status := actor.Status()
-	var httpErr types.HTTPError
-
-	// if the status has an error, return it
-	if status.Err != nil {
-		httpErr = types.HTTPError{
-			Title:   "Setup failed",
-			Code:    0,
-			Message: status.Err.Error(),
-			Args:    status.Args,
-		}
-	}
-
 	// return the status
-	response := types.GetActorInfo{
-		Status: int(status.Status),
-		Error:  httpErr,
-	}
+	response := getActorInfo(actor)
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -146,23 +116,16 @@ func (d dkg) Actor(w http.ResponseWriter, r *http.Request) {
 func (d dkg) EditDKGActor(w http.ResponseWriter, r *http.Request) {
 	var req types.UpdateDKG
 
-	// Read the request
-	signed, err := types.NewSignedRequest(r.Body)
-	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
-		return
-	}
-
-	// Verify the signature
-	err = signed.GetAndVerify(d.pk, &req)
+	// get the request and verify the signature
+	err := d.verifier.GetAndVerify(r, ScopeDKG, &req)
 	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
 	vars := mux.Vars(r)
 	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("formID not found: %v", vars), nil)
 		return
 	}
 
@@ -170,14 +133,14 @@ func (d dkg) EditDKGActor(w http.ResponseWriter, r *http.Request) {
 
 	formIDBuf, err := hex.DecodeString(formID)
 	if err != nil {
-		http.Error(w, "failed to decode formID: "+formID, http.StatusBadRequest)
+		BadRequestError(w, r, xerrors.Errorf("failed to decode formID: %v", err), nil)
 		return
 	}
 
 	// get the actor
 	a, exists := d.dkgService.GetActor(formIDBuf)
 	if !exists {
-		http.Error(w, "actor does not exist", http.StatusInternalServerError)
+		NotFoundErr(w, r, xerrors.New("actor not found"), nil)
 		return
 	}
 
@@ -196,7 +159,7 @@ func (d dkg) EditDKGActor(w http.ResponseWriter, r *http.Request) {
 	case "computePubshares":
 		err = a.ComputePubshares()
 		if err != nil {
-			http.Error(w, "failed to compute pubshares: "+err.Error(), http.StatusInternalServerError)
+			InternalError(w, r, xerrors.Errorf("failed to compute pubshares: %v", err), nil)
 			return
 		}
 	default:
@@ -204,3 +167,220 @@ func (d dkg) EditDKGActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// NewCeremony implements proxy.DKG
+// Create a new key ceremony for the given ceremonyID
+func (d dkg) NewCeremony(w http.ResponseWriter, r *http.Request) {
+	var req types.NewCeremonyRequest
+
+	// get the request and verify the signature
+	err := d.verifier.GetAndVerify(r, ScopeDKG, &req)
+	if err != nil {
+		SignedError(w, r, err, nil)
+		return
+	}
+
+	ceremonyIDBuf, err := hex.DecodeString(req.CeremonyID)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode ceremonyID: %v", err), nil)
+		return
+	}
+
+	if len(ceremonyIDBuf) == 0 {
+		BadRequestError(w, r, xerrors.New("ceremonyID is empty"), nil)
+		return
+	}
+
+	_, found := d.dkgService.GetCeremony(ceremonyIDBuf)
+	if found {
+		return
+	}
+
+	// subscribe to the DKG service
+	_, err = d.dkgService.ListenCeremony(ceremonyIDBuf, d.manager)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to start key ceremony: %v", err), nil)
+		return
+	}
+}
+
+// Ceremonies implements proxy.DKG
+// Send the list of key ceremonies
+func (d dkg) Ceremonies(w http.ResponseWriter, r *http.Request) {
+	ids := d.dkgService.Ceremonies()
+
+	response := types.GetCeremoniesResponse{
+		Ceremonies: make([]types.CeremonyInfo, 0, len(ids)),
+	}
+
+	for _, id := range ids {
+		actor, found := d.dkgService.GetCeremony(id)
+		if !found {
+			continue
+		}
+
+		info, err := getCeremonyInfo(id, actor)
+		if err != nil {
+			InternalError(w, r, xerrors.Errorf("failed to get ceremony info: %v", err), nil)
+			return
+		}
+
+		response.Ceremonies = append(response.Ceremonies, info)
+	}
+
+	sort.Slice(response.Ceremonies, func(i, j int) bool {
+		return response.Ceremonies[i].CeremonyID < response.Ceremonies[j].CeremonyID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to write response: %v", err), nil)
+		return
+	}
+}
+
+// Ceremony implements proxy.DKG
+// Send the key ceremony status and public key
+func (d dkg) Ceremony(w http.ResponseWriter, r *http.Request) {
+	ceremonyIDBuf, ok := extractCeremonyID(w, r)
+	if !ok {
+		return
+	}
+
+	actor, found := d.dkgService.GetCeremony(ceremonyIDBuf)
+	if !found {
+		NotFoundErr(w, r, xerrors.New("key ceremony not found"), nil)
+		return
+	}
+
+	info, err := getCeremonyInfo(ceremonyIDBuf, actor)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get ceremony info: %v", err), nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(info)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to write response: %v", err), nil)
+		return
+	}
+}
+
+// EditCeremony implements proxy.DKG
+// Setups the key ceremony
+func (d dkg) EditCeremony(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateCeremony
+
+	// get the request and verify the signature
+	err := d.verifier.GetAndVerify(r, ScopeDKG, &req)
+	if err != nil {
+		SignedError(w, r, err, nil)
+		return
+	}
+
+	ceremonyIDBuf, ok := extractCeremonyID(w, r)
+	if !ok {
+		return
+	}
+
+	a, exists := d.dkgService.GetCeremony(ceremonyIDBuf)
+	if !exists {
+		NotFoundErr(w, r, xerrors.New("key ceremony not found"), nil)
+		return
+	}
+
+	switch req.Action {
+	// setup the key ceremony
+	case "setup":
+		// As for a form, the setup is run asynchronously and its progress can
+		// be followed with the status of the ceremony.
+		go func() {
+			_, err := a.Setup()
+			if err != nil {
+				dela.Logger.Err(err).Msg("failed to setup key ceremony")
+			}
+		}()
+	default:
+		BadRequestError(w, r, xerrors.Errorf("invalid action: %s", req.Action), nil)
+		return
+	}
+}
+
+// extractCeremonyID returns the decoded ceremonyID of the request URL. It
+// writes the error and returns false if the ID is missing or invalid.
+func extractCeremonyID(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	vars := mux.Vars(r)
+
+	if vars == nil || vars["ceremonyID"] == "" {
+		BadRequestError(w, r, xerrors.Errorf("ceremonyID not found: %v", vars), nil)
+		return nil, false
+	}
+
+	ceremonyIDBuf, err := hex.DecodeString(vars["ceremonyID"])
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode ceremonyID: %v", err), nil)
+		return nil, false
+	}
+
+	return ceremonyIDBuf, true
+}
+
+// getActorInfo returns the status of a DKG actor
+func getActorInfo(actor dkgSrv.Actor) types.GetActorInfo {
+	status := actor.Status()
+	var httpErr types.HTTPError
+
+	// if the status has an error, return it
+	if status.Err != nil {
+		httpErr = types.HTTPError{
+			Title:   "Setup failed",
+			Code:    0,
+			Message: status.Err.Error(),
+			Args:    status.Args,
+		}
+	}
+
+	return types.GetActorInfo{
+		Status: int(status.Status),
+		Error:  httpErr,
+	}
+}
+
+// getCeremonyInfo returns the information about a key ceremony
+func getCeremonyInfo(ceremonyID []byte, actor dkgSrv.Actor) (types.CeremonyInfo, error) {
+	status := actor.Status()
+
+	info := types.CeremonyInfo{
+		CeremonyID: hex.EncodeToString(ceremonyID),
+		Status:     int(status.Status),
+	}
+
+	if status.Err != nil {
+		info.Error = types.HTTPError{
+			Title:   "Setup failed",
+			Code:    0,
+			Message: status.Err.Error(),
+			Args:    status.Args,
+		}
+	}
+
+	if status.Status == dkgSrv.Setup {
+		pubkey, err := actor.GetPublicKey()
+		if err != nil {
+			return info, xerrors.Errorf("failed to get public key: %v", err)
+		}
+
+		pubkeyBuf, err := pubkey.MarshalBinary()
+		if err != nil {
+			return info, xerrors.Errorf("failed to marshal public key: %v", err)
+		}
+
+		info.Pubkey = hex.EncodeToString(pubkeyBuf)
+	}
+
+	return info, nil
+}
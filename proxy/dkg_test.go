@@ -1,8 +1,6 @@
 package proxy
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -18,7 +16,6 @@ import (
 	"go.dedis.ch/dela/cli/node"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/kyber/v3"
-	"go.dedis.ch/kyber/v3/sign/schnorr"
 	"golang.org/x/xerrors"
 )
 
@@ -33,18 +30,17 @@ func TestNewDkg(t *testing.T) {
 	ctx.Injector.Inject(&mngr)
 	var d dkgSrv.DKG
 	ctx.Injector.Inject(&d)
-	var pk kyber.Point
-	ctx.Injector.Inject(&pk)
+	keys := NewKeyRing()
 
-	dkgInterface := NewDKG(mngr, d, pk)
+	dkgInterface := NewDKG(mngr, d, keys, nil)
 	//check that the dkg is not nil
 	require.NotNil(t, dkgInterface)
 	//the txn.Manager of the dkg should be the same as the one we injected$
 	require.Equal(t, mngr, dkgInterface.(dkg).manager)
 	//the dkg of the dkg should be the same as the one we injected
 	require.Equal(t, d, dkgInterface.(dkg).dkgService)
-	//the pk of the dkg should be the same as the one we injected
-	require.Equal(t, pk, dkgInterface.(dkg).pk)
+	//the keys of the dkg should be the ones we gave
+	require.Equal(t, keys, dkgInterface.(dkg).verifier.keys)
 }
 
 // test that NewDKGActor is working properly
@@ -80,9 +76,9 @@ func TestNewDKGActor(t *testing.T) {
 		FormID: "abcd",
 	}
 
-	dkgInterface := NewDKG(mngr, mockDKGService{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGService{}, trustAll(public), nil)
 
-	requestt, e := createSignedRequest(secret, request)
+	requestt, e := createSignedRequest(secret, "POST", "/dkg", request)
 	require.NoError(t, e)
 
 	r, e := http.NewRequest("POST", "/dkg", strings.NewReader(string(requestt)))
@@ -121,7 +117,7 @@ func TestNewDKGActorInvalidRequest(t *testing.T) {
 	err = secret.UnmarshalBinary(secretkeyBuf)
 	require.NoError(t, err)
 
-	dkgInterface := NewDKG(mngr, mockDKGService{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGService{}, trustAll(public), nil)
 
 	r, e := http.NewRequest("POST", "/dkg", strings.NewReader("abcd"))
 	if e != nil {
@@ -130,7 +126,7 @@ func TestNewDKGActorInvalidRequest(t *testing.T) {
 
 	dkgInterface.NewDKGActor(w, r)
 
-	require.Equal(t, 500, w.(*httptest.ResponseRecorder).Result().StatusCode)
+	require.Equal(t, 400, w.(*httptest.ResponseRecorder).Result().StatusCode)
 
 }
 
@@ -167,9 +163,9 @@ func TestNewDKGActorInvalidSignature(t *testing.T) {
 		FormID: "abcd",
 	}
 
-	dkgInterface := NewDKG(mngr, mockDKGService{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGService{}, trustAll(public), nil)
 
-	requestt, err := createSignedRequest(secret, request)
+	requestt, err := createSignedRequest(secret, "POST", "/dkg", request)
 	require.NoError(t, err)
 
 	r, err := http.NewRequest("POST", "/dkg", strings.NewReader(string(requestt)))
@@ -177,7 +173,7 @@ func TestNewDKGActorInvalidSignature(t *testing.T) {
 
 	dkgInterface.NewDKGActor(w, r)
 
-	require.Equal(t, 500, w.(*httptest.ResponseRecorder).Result().StatusCode)
+	require.Equal(t, 401, w.(*httptest.ResponseRecorder).Result().StatusCode)
 
 }
 
@@ -214,9 +210,9 @@ func TestNewDKGActorInvalidFormID(t *testing.T) {
 		FormID: "abcdefg",
 	}
 
-	dkgInterface := NewDKG(mngr, mockDKGService{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGService{}, trustAll(public), nil)
 
-	requestt, err := createSignedRequest(secret, request)
+	requestt, err := createSignedRequest(secret, "POST", "/dkg", request)
 
 	require.NoError(t, err)
 
@@ -263,9 +259,9 @@ func TestNewDKGActorFormDoesNotExist(t *testing.T) {
 		FormID: "abcd",
 	}
 
-	dkgInterface := NewDKG(mngr, mockDKGServiceError{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGServiceError{}, trustAll(public), nil)
 
-	requestt, err := createSignedRequest(secret, request)
+	requestt, err := createSignedRequest(secret, "POST", "/dkg", request)
 
 	require.NoError(t, err)
 
@@ -310,9 +306,9 @@ func TestActor(t *testing.T) {
 		FormID: "abcd",
 	}
 
-	dkgInterface := NewDKG(mngr, mockDKGService{}, public)
+	dkgInterface := NewDKG(mngr, mockDKGService{}, trustAll(public), nil)
 
-	requestt, err := createSignedRequest(secret, request)
+	requestt, err := createSignedRequest(secret, "POST", "/dkg", request)
 	require.NoError(t, err)
 
 	r, err := http.NewRequest("GET", "/services/dkg/actors/1234", strings.NewReader(string(requestt)))
@@ -352,27 +348,12 @@ func (m mockDKGServiceError) GetActor(formID []byte) (dkgSrv.Actor, bool) {
 	return nil, false
 }
 
-func createSignedRequest(secret kyber.Scalar, msg interface{}) ([]byte, error) {
-	jsonMsg, err := json.Marshal(msg)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to marshal json: %v", err)
-	}
-
-	payload := base64.URLEncoding.EncodeToString(jsonMsg)
+func createSignedRequest(secret kyber.Scalar, method, path string,
+	msg interface{}) ([]byte, error) {
 
-	hash := sha256.New()
-
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
-
-	signature, err := schnorr.Sign(suite, secret, md)
+	signed, err := types.SignRequest(secret, method, path, msg)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to sign: %v", err)
-	}
-
-	signed := types.SignedRequest{
-		Payload:   payload,
-		Signature: hex.EncodeToString(signature),
+		return nil, xerrors.Errorf("failed to sign request: %v", err)
 	}
 
 	signedJSON, err := json.Marshal(signed)
@@ -382,3 +363,12 @@ func createSignedRequest(secret kyber.Scalar, msg interface{}) ([]byte, error) {
 
 	return signedJSON, nil
 }
+
+// trustAll returns a key ring that trusts the key for all the scopes.
+func trustAll(pk kyber.Point) *KeyRing {
+	return NewKeyRing(TrustedKey{
+		Name:      "test",
+		PublicKey: pk,
+		Scopes:    []Scope{ScopeAll},
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -19,22 +18,13 @@ import (
 	"go.dedis.ch/dela/core/ordering"
 	"go.dedis.ch/dela/core/txn/pool"
 	"go.dedis.ch/dela/serde"
-	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/schnorr"
 	"golang.org/x/xerrors"
 )
 
-func newSignedErr(err error) error {
-	return xerrors.Errorf("failed to created signed request: %v", err)
-}
-
-func getSignedErr(err error) error {
-	return xerrors.Errorf("failed to get and verify signed request: %v", err)
-}
-
-// NewForm returns a new initialized form proxy
-func NewForm(srv ordering.Service, p pool.Pool,
-	ctx serde.Context, fac serde.Factory, pk kyber.Point, txnManaxer txnmanager.Manager) Form {
+// NewForm returns a new initialized form proxy. The limiter can be nil.
+func NewForm(srv ordering.Service, p pool.Pool, ctx serde.Context, fac serde.Factory,
+	keys *KeyRing, limiter *Limiter, txnManaxer txnmanager.Manager) Form {
 
 	logger := dela.Logger.With().Timestamp().Str("role", "evoting-proxy").Logger()
 
@@ -58,7 +48,7 @@ func NewForm(srv ordering.Service, p pool.Pool,
 		adminFac:       types.AdminListFactory{},
 		mngr:           txnManaxer,
 		pool:           p,
-		pk:             pk,
+		verifier:       NewVerifier(keys, limiter, SignedRequestWindow),
 		adminListID:    adminListID,
 		operatorListID: operatorListID,
 	}
@@ -77,7 +67,7 @@ type form struct {
 	adminFac       serde.Factory
 	mngr           txnmanager.Manager
 	pool           pool.Pool
-	pk             kyber.Point
+	verifier       *Verifier
 	adminListID    string
 	operatorListID string
 }
@@ -86,37 +76,42 @@ type form struct {
 func (form *form) NewForm(w http.ResponseWriter, r *http.Request) {
 	var req ptypes.CreateFormRequest
 
-	// get the signed request
-	signed, err := ptypes.NewSignedRequest(r.Body)
+	// get the request and verify the signature
+	err := form.verifier.GetAndVerify(r, ScopeForms, &req)
 	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
-	// get the request and verify the signature
-	err = signed.GetAndVerify(form.pk, &req)
-	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
-		return
+	mixers := make([][]byte, len(req.Mixers))
+
+	for i, mixer := range req.Mixers {
+		mixers[i], err = hex.DecodeString(mixer)
+		if err != nil {
+			BadRequestError(w, r, xerrors.Errorf("failed to decode mixer: %v", err), nil)
+			return
+		}
 	}
 
 	createForm := types.CreateForm{
-		Configuration: req.Configuration,
-		UserID:        req.UserID,
+		Configuration:  req.Configuration,
+		UserID:         req.UserID,
+		Anonymous:      req.Anonymous,
+		Mixers:         mixers,
+		MixerThreshold: req.MixerThreshold,
 	}
 
 	// serialize the transaction
 	data, err := createForm.Serialize(form.context)
 	if err != nil {
-		http.Error(w, "failed to marshal CreateFormTransaction: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to marshal CreateFormTransaction: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
 	txnID, blockIdx, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCreateForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -128,7 +123,7 @@ func (form *form) NewForm(w http.ResponseWriter, r *http.Request) {
 	// create it to get the  token
 	transactionClientInfo, err := form.mngr.CreateTransactionResult(txnID, blockIdx, txnmanager.UnknownTransactionStatus)
 	if err != nil {
-		http.Error(w, "failed to create transaction info: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to create transaction info: %v", err), nil)
 		return
 	}
 
@@ -140,7 +135,7 @@ func (form *form) NewForm(w http.ResponseWriter, r *http.Request) {
 	// send the response json
 	err = txnmanager.SendResponse(w, response)
 	if err != nil {
-		fmt.Printf("Caught unhandled error: %+v", err)
+		form.logger.Err(err).Msg("failed to send response")
 	}
 }
 
@@ -148,147 +143,347 @@ func (form *form) NewForm(w http.ResponseWriter, r *http.Request) {
 func (form *form) NewFormVote(w http.ResponseWriter, r *http.Request) {
 	var req ptypes.CastVoteRequest
 
-	// get the signed request
-	signed, err := ptypes.NewSignedRequest(r.Body)
+	// get the request and verify the signature
+	err := form.verifier.GetAndVerify(r, ScopeVote, &req)
 	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
-	// get the request and verify the signature
-	err = signed.GetAndVerify(form.pk, &req)
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID,
+		form.orderingSvc.GetStore())
 	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
 		return
 	}
 
-	vars := mux.Vars(r)
+	form.castVote(w, r, formFromStore, req)
+}
 
-	// check if the formID is valid
-	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+// NewSignedFormVote implements proxy.Proxy. The vote is not signed by a
+// trusted frontend but by the voter, with the key registered on the form.
+func (form *form) NewSignedFormVote(w http.ResponseWriter, r *http.Request) {
+	var req ptypes.CastVoteRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode request: %v", err), nil)
 		return
 	}
 
-	formID := vars["formID"]
+	if len(req.Signature) == 0 {
+		BadRequestError(w, r, xerrors.New("the vote must be signed by the voter"), nil)
+		return
+	}
 
-	elecMD, err := form.getFormsMetadata()
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID,
+		form.orderingSvc.GetStore())
 	if err != nil {
-		http.Error(w, "failed to get form metadata", http.StatusNotFound)
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
 		return
 	}
 
-	// check if the form exist
-	if elecMD.FormsIDs.Contains(formID) < 0 {
-		http.Error(w, "the form does not exist", http.StatusNotFound)
+	publicKey, err := formFromStore.GetVoterKey(req.VoterID)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to get voter key: %v", err), nil)
 		return
 	}
 
-	ciphervote := make(types.Ciphervote, len(req.Ballot))
+	if publicKey == nil {
+		ForbiddenError(w, r, xerrors.Errorf("voter %s has no registered key", req.VoterID), nil)
+		return
+	}
 
-	// unmarshal the encrypted ballot
-	for i, egpair := range req.Ballot {
-		k := suite.Point()
+	// the signature is checked by the contract too, but this avoids adding
+	// transactions to the pool that are sure to be rejected.
+	err = verifyVote(publicKey, formID, req)
+	if err != nil {
+		CodedError(w, r, xerrors.Errorf("failed to verify vote: %v", err),
+			http.StatusForbidden, ptypes.ErrCodeInvalidSignature, nil)
+		return
+	}
 
-		err = k.UnmarshalBinary(egpair.K)
-		if err != nil {
-			http.Error(w, "failed to unmarshal K: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	form.castVote(w, r, formFromStore, req)
+}
 
-		c := suite.Point()
+// NewAnonymousFormVote implements proxy.Proxy. The vote is not signed by a
+// trusted frontend: the voter proves with a credential of the electoral roll
+// that it is allowed to vote, without revealing who it is.
+func (form *form) NewAnonymousFormVote(w http.ResponseWriter, r *http.Request) {
+	var req ptypes.AnonymousVoteRequest
 
-		err = c.UnmarshalBinary(egpair.C)
-		if err != nil {
-			http.Error(w, "failed to unmarshal C: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode request: %v", err), nil)
+		return
+	}
 
-		ciphervote[i] = types.EGPair{
-			K: k,
-			C: c,
-		}
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID,
+		form.orderingSvc.GetStore())
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
+		return
+	}
+
+	if !formFromStore.Anonymous {
+		BadRequestError(w, r, xerrors.Errorf("form %s is not anonymous", formID), nil)
+		return
+	}
+
+	ciphervote, err := decodeCiphervote(req.Ballot)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode ballot: %v", err), nil)
+		return
 	}
 
 	castVote := types.CastVote{
-		FormID:  formID,
-		VoterID: req.VoterID,
-		Ballot:  ciphervote,
+		FormID:     formID,
+		Ballot:     ciphervote,
+		Credential: req.Credential,
+		Sequence:   req.Sequence,
+		Ring:       req.Ring,
 	}
 
-	// serialize the vote
-	data, err := castVote.Serialize(form.context)
+	// the credential is checked by the contract too, but this avoids adding
+	// transactions to the pool that are sure to be rejected.
+	_, err = formFromStore.VerifyCredential(form.orderingSvc.GetStore(), castVote)
+	if err != nil {
+		CodedError(w, r, xerrors.Errorf("failed to verify credential: %v", err),
+			http.StatusForbidden, ptypes.ErrCodeInvalidSignature, nil)
+		return
+	}
+
+	form.submitVote(w, r, formFromStore, castVote)
+}
+
+// NewBallotAudit implements proxy.Proxy. The voter reveals the randomness of
+// a ballot to check that it was encrypted as intended. The audited ballot is
+// recorded by the form so that it is never counted. The request is not signed
+// because anyone can audit a ballot that was not cast.
+func (form *form) NewBallotAudit(w http.ResponseWriter, r *http.Request) {
+	var req ptypes.AuditBallotRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode request: %v", err), nil)
+		return
+	}
+
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	store := form.orderingSvc.GetStore()
+
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID, store)
 	if err != nil {
-		http.Error(w, "failed to marshal CastVoteTransaction: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
+		return
+	}
+
+	if formFromStore.Status != types.Open {
+		CodedError(w, r, xerrors.Errorf("the form is not open, current status: %d",
+			formFromStore.Status), http.StatusConflict, ptypes.ErrCodeWrongStatus, nil)
+		return
+	}
+
+	ciphervote, err := decodeCiphervote(req.Ballot)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode ballot: %v", err), nil)
+		return
+	}
+
+	// the audit is checked by the contract too, but this avoids adding
+	// transactions to the pool that are sure to be rejected.
+	_, err = ciphervote.Audit(formFromStore.Pubkey, req.Randomness)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to audit ballot: %v", err), nil)
+		return
+	}
+
+	hash, err := ciphervote.Hash()
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to hash ballot: %v", err), nil)
+		return
+	}
+
+	audited, err := formFromStore.IsAudited(store, hash)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to check audit: %v", err), nil)
+		return
+	}
+
+	if audited {
+		CodedError(w, r, xerrors.Errorf("the ballot has already been audited"),
+			http.StatusConflict, ptypes.ErrCodeBallotAudited, nil)
+		return
+	}
+
+	_, found, err := formFromStore.FindBallot(store, hash)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to find ballot: %v", err), nil)
+		return
+	}
+
+	if found {
+		CodedError(w, r, xerrors.Errorf("the ballot has already been cast"),
+			http.StatusConflict, ptypes.ErrCodeBallotCast, nil)
+		return
+	}
+
+	auditBallot := types.AuditBallot{
+		FormID:     formID,
+		Ballot:     ciphervote,
+		Randomness: req.Randomness,
+	}
+
+	data, err := auditBallot.Serialize(form.context)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to marshal AuditBallot: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
-	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCastVote, evoting.FormArg, data)
+	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAuditBallot, evoting.FormArg, data)
 	if err != nil {
-		form.logger.Err(err).Msg("failed to submit txn")
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
-	// send the transaction's information
-	err = form.mngr.SendTransactionInfo(w, txnID, lastBlock, txnmanager.UnknownTransactionStatus)
+	form.mngr.SendTransactionInfo(w, txnID, lastBlock, txnmanager.UnknownTransactionStatus)
+}
+
+// castVote submits the vote and sends the transaction's information.
+func (form *form) castVote(w http.ResponseWriter, r *http.Request, formFromStore types.Form,
+	req ptypes.CastVoteRequest) {
+
+	ciphervote, err := decodeCiphervote(req.Ballot)
 	if err != nil {
-		http.Error(w, "couldn't send transaction info: "+err.Error(), http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("failed to decode ballot: %v", err), nil)
 		return
 	}
+
+	castVote := types.CastVote{
+		FormID:    formFromStore.FormID,
+		VoterID:   req.VoterID,
+		Ballot:    ciphervote,
+		Signature: req.Signature,
+		Sequence:  req.Sequence,
+	}
+
+	form.submitVote(w, r, formFromStore, castVote)
 }
 
-// EditForm implements proxy.Proxy
-func (form *form) EditForm(w http.ResponseWriter, r *http.Request) {
-	var req ptypes.UpdateFormRequest
+// submitVote submits the vote transaction and sends the transaction's
+// information. The vote is checked against the form first, so that the voter
+// learns right away about a vote that the smart contract would reject.
+func (form *form) submitVote(w http.ResponseWriter, r *http.Request, formFromStore types.Form,
+	castVote types.CastVote) {
+
+	if formFromStore.Status != types.Open {
+		CodedError(w, r, xerrors.Errorf("the form is not open, current status: %d",
+			formFromStore.Status), http.StatusConflict, ptypes.ErrCodeWrongStatus, nil)
+		return
+	}
 
-	// get the signed request
-	signed, err := ptypes.NewSignedRequest(r.Body)
+	if len(castVote.Ballot) != formFromStore.ChunksPerBallot() {
+		CodedError(w, r, xerrors.Errorf("the ballot has unexpected length: %d != %d",
+			len(castVote.Ballot), formFromStore.ChunksPerBallot()), http.StatusBadRequest,
+			ptypes.ErrCodeInvalidBallotLength, nil)
+		return
+	}
+
+	// serialize the vote
+	data, err := castVote.Serialize(form.context)
 	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
+		InternalError(w, r, xerrors.Errorf("failed to marshal CastVoteTransaction: %v", err), nil)
 		return
 	}
 
-	// get the request and verify the signature
-	err = signed.GetAndVerify(form.pk, &req)
+	receipt, err := ballotReceipt(castVote.Ballot)
 	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		InternalError(w, r, xerrors.Errorf("failed to create receipt: %v", err), nil)
 		return
 	}
 
-	vars := mux.Vars(r)
+	// create the transaction and add it to the pool
+	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCastVote, evoting.FormArg, data)
+	if err != nil {
+		form.logger.Err(err).Msg("failed to submit txn")
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
+		return
+	}
 
-	// check if the formID is valid
-	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+	// send the transaction's information along with the receipt
+	info, err := form.mngr.CreateTransactionResult(txnID, lastBlock,
+		txnmanager.UnknownTransactionStatus)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to create transaction info: %v", err), nil)
 		return
 	}
 
-	formID := vars["formID"]
+	info.Receipt = &receipt
 
-	elecMD, err := form.getFormsMetadata()
+	err = txnmanager.SendResponse(w, info)
+	if err != nil {
+		form.logger.Err(err).Msg("failed to send response")
+	}
+}
+
+// ballotReceipt returns the receipt of the ballot. Its block is looked up by
+// the hash once the vote is included, see Ballot.
+func ballotReceipt(ciphervote types.Ciphervote) (ptypes.BallotReceipt, error) {
+	hash, err := ciphervote.Hash()
 	if err != nil {
-		http.Error(w, "failed to get form metadata", http.StatusNotFound)
+		return ptypes.BallotReceipt{}, xerrors.Errorf("failed to hash ballot: %v", err)
+	}
+
+	return ptypes.BallotReceipt{Hash: hex.EncodeToString(hash)}, nil
+}
+
+// EditForm implements proxy.Proxy
+func (form *form) EditForm(w http.ResponseWriter, r *http.Request) {
+	var req ptypes.UpdateFormRequest
+
+	// get the request and verify the signature
+	err := form.verifier.GetAndVerify(r, ScopeForms, &req)
+	if err != nil {
+		SignedError(w, r, err, nil)
 		return
 	}
 
-	// check if the form exists
-	if elecMD.FormsIDs.Contains(formID) < 0 {
-		http.Error(w, "the form does not exist", http.StatusNotFound)
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
 		return
 	}
 
 	switch req.Action {
 	case "open":
-		form.openForm(formID, req.UserID, w, r)
+		form.openForm(formID, req.UserID, req.CeremonyID, w, r)
 	case "close":
 		form.closeForm(formID, req.UserID, w, r)
 	case "combineShares":
 		form.combineShares(formID, req.UserID, w, r)
 	case "cancel":
 		form.cancelForm(formID, req.UserID, w, r)
+	case "migrate":
+		form.migrateForm(formID, req.UserID, req.Format, w, r)
 	default:
 		BadRequestError(w, r, xerrors.Errorf("invalid action: %s", req.Action), nil)
 		return
@@ -296,25 +491,27 @@ func (form *form) EditForm(w http.ResponseWriter, r *http.Request) {
 }
 
 // openForm allows opening a form, which sets the public key based on
-// the DKG actor.
-func (form *form) openForm(formID string, userID string, w http.ResponseWriter, r *http.Request) {
+// the DKG actor, or on the key ceremony if ceremonyID is not empty.
+func (form *form) openForm(formID string, userID string, ceremonyID string,
+	w http.ResponseWriter, r *http.Request) {
+
 	openForm := types.OpenForm{
-		FormID: formID,
-		UserID: userID,
+		FormID:     formID,
+		UserID:     userID,
+		CeremonyID: ceremonyID,
 	}
 
 	// serialize the transaction
 	data, err := openForm.Serialize(form.context)
 	if err != nil {
-		http.Error(w, "failed to marshal OpenFormTransaction: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to marshal OpenFormTransaction: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdOpenForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -333,15 +530,14 @@ func (form *form) closeForm(formIDHex string, userID string, w http.ResponseWrit
 	// serialize the transaction
 	data, err := closeForm.Serialize(form.context)
 	if err != nil {
-		http.Error(w, "failed to marshal CloseFormTransaction: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to marshal CloseFormTransaction: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCloseForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -355,13 +551,13 @@ func (form *form) combineShares(formIDHex string, userID string, w http.Response
 
 	formFromStore, err := types.FormFromStore(form.context, form.formFac, formIDHex, form.orderingSvc.GetStore())
 	if err != nil {
-		http.Error(w, "failed to get form: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
 		return
 	}
 	if formFromStore.Status != types.PubSharesSubmitted {
-		http.Error(w, "the submission of public shares must be over!",
-			http.StatusUnauthorized)
+		CodedError(w, r, xerrors.Errorf("the submission of public shares must be "+
+			"over, current status: %d", formFromStore.Status), http.StatusConflict,
+			ptypes.ErrCodeWrongStatus, nil)
 		return
 	}
 
@@ -373,15 +569,14 @@ func (form *form) combineShares(formIDHex string, userID string, w http.Response
 	// serialize the transaction
 	data, err := decryptBallots.Serialize(form.context)
 	if err != nil {
-		http.Error(w, "failed to marshal decryptBallots: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to marshal decryptBallots: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCombineShares, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -400,15 +595,14 @@ func (form *form) cancelForm(formIDHex string, userID string, w http.ResponseWri
 	// serialize the transaction
 	data, err := cancelForm.Serialize(form.context)
 	if err != nil {
-		http.Error(w, "failed to marshal CancelForm: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to marshal CancelForm: %v", err), nil)
 		return
 	}
 
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdCancelForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -416,26 +610,54 @@ func (form *form) cancelForm(formIDHex string, userID string, w http.ResponseWri
 	form.mngr.SendTransactionInfo(w, txnID, lastBlock, txnmanager.UnknownTransactionStatus)
 }
 
-// Form implements proxy.Proxy. The request should not be signed because it
-// is fetching public data.
-func (form *form) Form(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
+// migrateForm stores a form and its ballots in another serialization format.
+func (form *form) migrateForm(formIDHex string, userID string, format string,
+	w http.ResponseWriter, r *http.Request) {
 
-	vars := mux.Vars(r)
+	// the nodes don't accept a format they don't know either, but the error
+	// is only known once the transaction is executed.
+	_, err := types.NewContext(serde.Format(format))
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("invalid format: %v", err), nil)
+		return
+	}
 
-	// check if the form exists
-	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+	migrateForm := types.MigrateForm{
+		FormID: formIDHex,
+		UserID: userID,
+		Format: format,
+	}
+
+	// serialize the transaction
+	data, err := migrateForm.Serialize(form.context)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to marshal MigrateForm: %v", err), nil)
 		return
 	}
 
-	formID := vars["formID"]
+	// create the transaction and add it to the pool
+	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdMigrateForm, evoting.FormArg, data)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
+		return
+	}
+
+	// send the transaction's informations
+	form.mngr.SendTransactionInfo(w, txnID, lastBlock, txnmanager.UnknownTransactionStatus)
+}
+
+// Form implements proxy.Proxy. The request should not be signed because it
+// is fetching public data.
+func (form *form) Form(w http.ResponseWriter, r *http.Request) {
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
 
 	// get the form
 	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID, form.orderingSvc.GetStore())
 	if err != nil {
-		http.Error(w, xerrors.Errorf("failed to get form: %v", err).Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
 		return
 	}
 
@@ -445,8 +667,7 @@ func (form *form) Form(w http.ResponseWriter, r *http.Request) {
 	if formFromStore.Pubkey != nil {
 		pubkeyBuf, err = formFromStore.Pubkey.MarshalBinary()
 		if err != nil {
-			http.Error(w, "failed to marshal pubkey: "+err.Error(),
-				http.StatusInternalServerError)
+			InternalError(w, r, xerrors.Errorf("failed to marshal pubkey: %v", err), nil)
 			return
 		}
 	}
@@ -460,8 +681,7 @@ func (form *form) Form(w http.ResponseWriter, r *http.Request) {
 
 	suff, err := formFromStore.Suffragia(form.context, form.orderingSvc.GetStore())
 	if err != nil {
-		http.Error(w, "couldn't get ballots: "+err.Error(),
-			http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("couldn't get ballots: %v", err), nil)
 		return
 	}
 
@@ -475,11 +695,23 @@ func (form *form) Form(w http.ResponseWriter, r *http.Request) {
 		ownersAsStr[i] = strconv.Itoa(formFromStore.Owners[i])
 	}
 
+	roll, err := formFromStore.GetElectoralRoll(form.orderingSvc.GetStore())
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("couldn't get electoral roll: %v", err), nil)
+		return
+	}
+
+	electoralRoll := make([]string, len(roll))
+	for i, key := range roll {
+		electoralRoll[i] = hex.EncodeToString(key)
+	}
+
 	response := ptypes.GetFormResponse{
 		FormID:          string(formFromStore.FormID),
 		Configuration:   formFromStore.Configuration,
 		Status:          uint16(formFromStore.Status),
 		Pubkey:          hex.EncodeToString(pubkeyBuf),
+		CeremonyID:      formFromStore.CeremonyID,
 		Result:          formFromStore.DecryptedBallots,
 		Roster:          roster,
 		ChunksPerBallot: formFromStore.ChunksPerBallot(),
@@ -487,18 +719,120 @@ func (form *form) Form(w http.ResponseWriter, r *http.Request) {
 		BallotVoters:    suff.VoterIDs,
 		Voters:          votersAsStr,
 		Owners:          ownersAsStr,
+		Anonymous:       formFromStore.Anonymous,
+		ElectoralRoll:   electoralRoll,
+		BallotsHash:     hex.EncodeToString(formFromStore.BallotsHash()),
+	}
+
+	txnmanager.SendResponse(w, response)
+
+}
+
+// BallotProof implements proxy.Proxy. It returns the proof that the ballot of
+// the voter is committed to by the hash of the ballots of the form. The
+// request should not be signed because it is fetching public data.
+func (form *form) BallotProof(w http.ResponseWriter, r *http.Request) {
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	voterID := mux.Vars(r)["voterID"]
+	if voterID == "" {
+		BadRequestError(w, r, xerrors.New("voterID not found"), nil)
+		return
+	}
+
+	store := form.orderingSvc.GetStore()
+
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID, store)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
+		return
+	}
+
+	proof, found, err := formFromStore.BallotProof(form.context, store, voterID)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get proof: %v", err), nil)
+		return
+	}
+
+	if !found {
+		NotFoundErr(w, r, xerrors.Errorf("no ballot of %s", voterID), nil)
+		return
+	}
+
+	response := ptypes.BallotProofResponse{
+		BallotsHash: hex.EncodeToString(formFromStore.BallotsHash()),
+		Block:       proof.Block,
+		VoterIDs:    proof.Suffragia.VoterIDs,
+		Ballots:     make([]ptypes.CiphervoteJSON, len(proof.Suffragia.Ciphervotes)),
+		Previous:    hex.EncodeToString(proof.Previous),
+		Next:        make([]string, len(proof.Next)),
+	}
+
+	for i, ciphervote := range proof.Suffragia.Ciphervotes {
+		response.Ballots[i], err = encodeCiphervote(ciphervote)
+		if err != nil {
+			InternalError(w, r, xerrors.Errorf("failed to encode ballot: %v", err), nil)
+			return
+		}
+	}
+
+	for i, next := range proof.Next {
+		response.Next[i] = hex.EncodeToString(next)
 	}
 
 	txnmanager.SendResponse(w, response)
+}
+
+// Ballot implements proxy.Proxy. It tells whether the ballot with the hash of
+// a receipt is stored, and whether it is the one counted for its voter. The
+// request should not be signed because it is fetching public data.
+func (form *form) Ballot(w http.ResponseWriter, r *http.Request) {
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	hash, err := hex.DecodeString(mux.Vars(r)["receiptHash"])
+	if err != nil || len(hash) == 0 {
+		BadRequestError(w, r, xerrors.Errorf("invalid receipt hash: %v", err), nil)
+		return
+	}
+
+	store := form.orderingSvc.GetStore()
 
+	formFromStore, err := types.FormFromStore(form.context, form.formFac, formID, store)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
+		return
+	}
+
+	location, found, err := formFromStore.FindBallot(store, hash)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to find ballot: %v", err), nil)
+		return
+	}
+
+	if !found {
+		NotFoundErr(w, r, xerrors.Errorf("no ballot with hash %x", hash), nil)
+		return
+	}
+
+	response := ptypes.GetBallotResponse{
+		Hash:      hex.EncodeToString(hash),
+		Block:     location.Block,
+		BlockID:   hex.EncodeToString(location.BlockID),
+		Effective: location.Effective,
+	}
+
+	txnmanager.SendResponse(w, response)
 }
 
 // Forms implements proxy.Proxy. The request should not be signed because it
 // is fecthing public data.
 func (form *form) Forms(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-
 	elecMD, err := form.getFormsMetadata()
 	if err != nil {
 		InternalError(w, r, xerrors.Errorf("failed to get form metadata: %v", err), nil)
@@ -561,48 +895,24 @@ func (form *form) Forms(w http.ResponseWriter, r *http.Request) {
 
 // DeleteForm implements proxy.Proxy
 func (form *form) DeleteForm(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-
-	// check if the formID is valid
-	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
-		return
-	}
-
-	formID := vars["formID"]
-
-	elecMD, err := form.getFormsMetadata()
-	if err != nil {
-		http.Error(w, "failed to get form metadata", http.StatusNotFound)
-		return
-	}
-
-	// check if the form exists
-	if elecMD.FormsIDs.Contains(formID) < 0 {
-		http.Error(w, "the form does not exist", http.StatusNotFound)
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
 		return
 	}
 
-	// auth should contain the hex-encoded signature on the hex-encoded form
-	// ID
-	auth := r.Header.Get("Authorization")
+	var req ptypes.DeleteFormRequest
 
-	signature, err := hex.DecodeString(auth)
+	// get the request and verify the signature, which covers the path and
+	// therefore the form ID
+	err := form.verifier.GetAndVerify(r, ScopeForms, &req)
 	if err != nil {
-		BadRequestError(w, r, xerrors.Errorf("failed to decode auth: %v", err), nil)
-		return
-	}
-
-	// check if the signature is valid
-	err = schnorr.Verify(suite, form.pk, []byte(formID), signature)
-	if err != nil {
-		ForbiddenError(w, r, xerrors.Errorf("signature verification failed: %v", err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
 	deleteForm := types.DeleteForm{
 		FormID: formID,
-		UserID: r.Header.Get("UserId"),
+		UserID: req.UserID,
 	}
 
 	data, err := deleteForm.Serialize(form.context)
@@ -614,7 +924,7 @@ func (form *form) DeleteForm(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdDeleteForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -624,7 +934,7 @@ func (form *form) DeleteForm(w http.ResponseWriter, r *http.Request) {
 
 // POST /addtoadminlist
 func (form *form) AddAdmin(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeAdmin)
 	if err != nil {
 		return
 	}
@@ -643,7 +953,7 @@ func (form *form) AddAdmin(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAddAdmin, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -653,7 +963,7 @@ func (form *form) AddAdmin(w http.ResponseWriter, r *http.Request) {
 
 // POST /removetoadminlist
 func (form *form) RemoveAdmin(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeAdmin)
 	if err != nil {
 		return
 	}
@@ -672,7 +982,7 @@ func (form *form) RemoveAdmin(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdRemoveAdmin, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -681,9 +991,6 @@ func (form *form) RemoveAdmin(w http.ResponseWriter, r *http.Request) {
 
 // GET /adminlist
 func (form *form) AdminList(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-
 	adminList, err := types.AdminListFromStore(form.context, form.adminFac, form.orderingSvc.GetStore(), evoting.AdminListId)
 	if err != nil && err.Error() != "No list found" {
 		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
@@ -699,7 +1006,7 @@ func (form *form) AdminList(w http.ResponseWriter, r *http.Request) {
 
 // POST /addoperator
 func (form *form) AddOperator(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeAdmin)
 	if err != nil {
 		return
 	}
@@ -718,7 +1025,7 @@ func (form *form) AddOperator(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAddOperator, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -727,7 +1034,7 @@ func (form *form) AddOperator(w http.ResponseWriter, r *http.Request) {
 
 // POST /removeoperator
 func (form *form) RemoveOperator(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeAdmin)
 	if err != nil {
 		return
 	}
@@ -746,7 +1053,7 @@ func (form *form) RemoveOperator(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdRemoveOperator, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -755,9 +1062,6 @@ func (form *form) RemoveOperator(w http.ResponseWriter, r *http.Request) {
 
 // GET /operatorlist
 func (form *form) OperatorList(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-
 	operatorList, err := types.AdminListFromStore(form.context, form.adminFac, form.orderingSvc.GetStore(), evoting.OperatorListId)
 	if err != nil && err.Error() != "No list found" {
 		InternalError(w, r, xerrors.Errorf("failed to get form: %v", err), nil)
@@ -775,7 +1079,7 @@ func (form *form) OperatorList(w http.ResponseWriter, r *http.Request) {
 
 // POST /forms/{formID}/addowner
 func (form *form) AddOwnerToForm(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeForms)
 	if err != nil {
 		return
 	}
@@ -800,7 +1104,7 @@ func (form *form) AddOwnerToForm(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAddOwnerForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -809,7 +1113,7 @@ func (form *form) AddOwnerToForm(w http.ResponseWriter, r *http.Request) {
 
 // POST /forms/{formID}/removeowner
 func (form *form) RemoveOwnerToForm(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeForms)
 	if err != nil {
 		return
 	}
@@ -834,7 +1138,7 @@ func (form *form) RemoveOwnerToForm(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdRemoveOwnerForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -843,7 +1147,7 @@ func (form *form) RemoveOwnerToForm(w http.ResponseWriter, r *http.Request) {
 
 // POST /forms/{formID}/addvoter
 func (form *form) AddVoterToForm(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeForms)
 	if err != nil {
 		return
 	}
@@ -857,6 +1161,7 @@ func (form *form) AddVoterToForm(w http.ResponseWriter, r *http.Request) {
 		FormID:           formID,
 		TargetUserID:     req.TargetUserID,
 		PerformingUserID: req.PerformingUserID,
+		PublicKey:        req.PublicKey,
 	}
 
 	data, err := addVoter.Serialize(form.context)
@@ -868,7 +1173,45 @@ func (form *form) AddVoterToForm(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAddVoterForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
+		return
+	}
+
+	form.mngr.SendTransactionInfo(w, txnID, lastBlock, txnmanager.UnknownTransactionStatus)
+}
+
+// POST /forms/{formID}/credentials
+func (form *form) AddCredential(w http.ResponseWriter, r *http.Request) {
+	var req ptypes.AddCredentialRequest
+
+	// get the request and verify the signature
+	err := form.verifier.GetAndVerify(r, ScopeForms, &req)
+	if err != nil {
+		SignedError(w, r, err, nil)
+		return
+	}
+
+	formID, hasFailed := form.extractAndRetrieveFormID(w, r)
+	if hasFailed {
+		return
+	}
+
+	addCredential := types.AddCredential{
+		FormID:           formID,
+		PublicKey:        req.PublicKey,
+		PerformingUserID: req.PerformingUserID,
+	}
+
+	data, err := addCredential.Serialize(form.context)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to marshal AddCredential: %v", err), nil)
+		return
+	}
+
+	// create the transaction and add it to the pool
+	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdAddCredential, evoting.FormArg, data)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -877,7 +1220,7 @@ func (form *form) AddVoterToForm(w http.ResponseWriter, r *http.Request) {
 
 // POST /forms/{formID}/removevoter
 func (form *form) RemoveVoterToForm(w http.ResponseWriter, r *http.Request) {
-	req, err := form.getPermissionOpRequest(w, r)
+	req, err := form.getPermissionOpRequest(w, r, ScopeForms)
 	if err != nil {
 		return
 	}
@@ -902,7 +1245,7 @@ func (form *form) RemoveVoterToForm(w http.ResponseWriter, r *http.Request) {
 	// create the transaction and add it to the pool
 	txnID, lastBlock, err := form.mngr.SubmitTxn(r.Context(), evoting.CmdRemoveVoterForm, evoting.FormArg, data)
 	if err != nil {
-		http.Error(w, "failed to submit txn: "+err.Error(), http.StatusInternalServerError)
+		InternalError(w, r, xerrors.Errorf("failed to submit txn: %v", err), nil)
 		return
 	}
 
@@ -932,20 +1275,15 @@ func (form *form) getFormsMetadata() (types.FormsMetadata, error) {
 	return md, nil
 }
 
-func (form *form) getPermissionOpRequest(w http.ResponseWriter, r *http.Request) (ptypes.PermissionOperationRequest, error) {
-	var req ptypes.PermissionOperationRequest
+func (form *form) getPermissionOpRequest(w http.ResponseWriter, r *http.Request,
+	scope Scope) (ptypes.PermissionOperationRequest, error) {
 
-	// get the signed request
-	signed, err := ptypes.NewSignedRequest(r.Body)
-	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
-		return ptypes.PermissionOperationRequest{}, err
-	}
+	var req ptypes.PermissionOperationRequest
 
 	// get the request and verify the signature
-	err = signed.GetAndVerify(form.pk, &req)
+	err := form.verifier.GetAndVerify(r, scope, &req)
 	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return ptypes.PermissionOperationRequest{}, err
 	}
 	return req, err
@@ -956,7 +1294,7 @@ func (form *form) extractAndRetrieveFormID(w http.ResponseWriter, r *http.Reques
 
 	// check if the formID is valid
 	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("formID not found: %v", vars), nil)
 		return "", true
 	}
 
@@ -964,14 +1302,97 @@ func (form *form) extractAndRetrieveFormID(w http.ResponseWriter, r *http.Reques
 
 	elecMD, err := form.getFormsMetadata()
 	if err != nil {
-		http.Error(w, "failed to get form metadata", http.StatusNotFound)
+		InternalError(w, r, xerrors.Errorf("failed to get form metadata: %v", err), nil)
 		return "", true
 	}
 
 	// check if the form exists
 	if elecMD.FormsIDs.Contains(formID) < 0 {
-		http.Error(w, "the form does not exist", http.StatusNotFound)
+		FormNotFoundErr(w, r, formID, nil)
 		return "", true
 	}
 	return formID, false
 }
+
+// decodeCiphervote unmarshals the encrypted ballot of a vote request.
+func decodeCiphervote(ballot ptypes.CiphervoteJSON) (types.Ciphervote, error) {
+	ciphervote := make(types.Ciphervote, len(ballot))
+
+	for i, egpair := range ballot {
+		k := suite.Point()
+
+		err := k.UnmarshalBinary(egpair.K)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal K: %v", err)
+		}
+
+		c := suite.Point()
+
+		err = c.UnmarshalBinary(egpair.C)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal C: %v", err)
+		}
+
+		ciphervote[i] = types.EGPair{
+			K: k,
+			C: c,
+		}
+	}
+
+	return ciphervote, nil
+}
+
+// encodeCiphervote marshals an encrypted ballot for a response.
+func encodeCiphervote(ciphervote types.Ciphervote) (ptypes.CiphervoteJSON, error) {
+	ballot := make(ptypes.CiphervoteJSON, len(ciphervote))
+
+	for i, egpair := range ciphervote {
+		k, err := egpair.K.MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal K: %v", err)
+		}
+
+		c, err := egpair.C.MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal C: %v", err)
+		}
+
+		ballot[i] = ptypes.EGPairJSON{K: k, C: c}
+	}
+
+	return ballot, nil
+}
+
+// verifyVote checks that the vote is signed with the voter's key.
+func verifyVote(publicKey []byte, formID string, req ptypes.CastVoteRequest) error {
+	voterKey := suite.Point()
+
+	err := voterKey.UnmarshalBinary(publicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal voter key: %v", err)
+	}
+
+	ciphervote, err := decodeCiphervote(req.Ballot)
+	if err != nil {
+		return xerrors.Errorf("failed to decode ballot: %v", err)
+	}
+
+	castVote := types.CastVote{
+		FormID:   formID,
+		VoterID:  req.VoterID,
+		Ballot:   ciphervote,
+		Sequence: req.Sequence,
+	}
+
+	hash, err := castVote.Hash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash vote: %v", err)
+	}
+
+	err = schnorr.Verify(suite, voterKey, hash, req.Signature)
+	if err != nil {
+		return xerrors.Errorf("invalid signature: %v", err)
+	}
+
+	return nil
+}
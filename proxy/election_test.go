@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/c4dt/d-voting/contracts/evoting/types"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+)
+
+func TestVerifyVote(t *testing.T) {
+	secret, pk := newKeyPair()
+
+	publicKey, err := pk.MarshalBinary()
+	require.NoError(t, err)
+
+	k := suite.Point().Pick(suite.RandomStream())
+	c := suite.Point().Pick(suite.RandomStream())
+
+	kBuf, err := k.MarshalBinary()
+	require.NoError(t, err)
+
+	cBuf, err := c.MarshalBinary()
+	require.NoError(t, err)
+
+	castVote := types.CastVote{
+		FormID:   "abcd",
+		VoterID:  "123456",
+		Ballot:   types.Ciphervote{types.EGPair{K: k, C: c}},
+		Sequence: 7,
+	}
+
+	hash, err := castVote.Hash()
+	require.NoError(t, err)
+
+	signature, err := schnorr.Sign(suite, secret, hash)
+	require.NoError(t, err)
+
+	req := ptypes.CastVoteRequest{
+		VoterID:   "123456",
+		Ballot:    ptypes.CiphervoteJSON{{K: kBuf, C: cBuf}},
+		Signature: signature,
+		Sequence:  7,
+	}
+
+	err = verifyVote(publicKey, "abcd", req)
+	require.NoError(t, err)
+
+	// the sequence is signed
+	req.Sequence = 8
+
+	err = verifyVote(publicKey, "abcd", req)
+	require.ErrorContains(t, err, "invalid signature")
+
+	req.Sequence = 7
+
+	// the vote is sent to another form
+	err = verifyVote(publicKey, "ffff", req)
+	require.ErrorContains(t, err, "invalid signature")
+
+	// the vote is sent for another voter
+	req.VoterID = "654321"
+
+	err = verifyVote(publicKey, "abcd", req)
+	require.ErrorContains(t, err, "invalid signature")
+
+	err = verifyVote([]byte("fake"), "abcd", req)
+	require.ErrorContains(t, err, "failed to unmarshal voter key")
+
+	req.Ballot = ptypes.CiphervoteJSON{{K: []byte("fake"), C: cBuf}}
+
+	err = verifyVote(publicKey, "abcd", req)
+	require.ErrorContains(t, err, "failed to decode ballot: failed to unmarshal K")
+}
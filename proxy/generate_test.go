@@ -1,12 +1,12 @@
 package proxy
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
-	"github.com/stretchr/testify/require"
-	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"encoding/json"
 	"testing"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
 )
 
 /*
@@ -16,25 +16,24 @@ debug environment.
 
 How to use it:
 - Feel free to change the INPUT following docs/api.md
-- Run the test and use the provided result as a cURL request.
+- Run the test and use the provided result as a cURL request, it expires
+after SignedRequestWindow and can only be sent once.
 */
 func TestGenerateSignatureAndB64Payload(t *testing.T) {
 	t.Skip("developer helper snippet; not part of the automated test suite")
 	// #### INPUT ####
 	// rawPayload must be built following docs/api.md
-	rawPayload := `{"TargetUserID" : "654321", "PerformingUserID" : "123456"}`
+	rawPayload := json.RawMessage(`{"TargetUserID" : "654321", "PerformingUserID" : "123456"}`)
+
+	// method and path of the endpoint the request is sent to
+	method := "POST"
+	path := "/evoting/addadmin"
 
 	// pk must be set according to the public key used to run the system.
 	pk := "6aadf480d068ac896330b726802abd0da2a5f3824f791fe8dbd4cd555e80b809"
 	// #### END INPUT ####
 
 	// #### DO NOT MODIFY BELOW ####
-	payload := base64.URLEncoding.EncodeToString([]byte(rawPayload))
-
-	hash := sha256.New()
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
-
 	pkhex, err := hex.DecodeString(pk)
 	require.NoError(t, err)
 
@@ -42,11 +41,11 @@ func TestGenerateSignatureAndB64Payload(t *testing.T) {
 	err = point.UnmarshalBinary(pkhex)
 	require.NoError(t, err)
 
+	signed, err := types.SignRequest(point, method, path, rawPayload)
 	require.NoError(t, err)
 
-	signature, err := schnorr.Sign(suite, point, md)
+	buf, err := json.Marshal(signed)
 	require.NoError(t, err)
 
-	println("Signature: " + hex.EncodeToString(signature))
-	println("Payload: " + payload)
+	println("Signed request: " + string(buf))
 }
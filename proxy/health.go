@@ -0,0 +1,269 @@
+package proxy
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	dkgSrv "github.com/c4dt/d-voting/services/dkg"
+	shuffleSrv "github.com/c4dt/d-voting/services/shuffle"
+	"go.dedis.ch/dela/cli"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/txn/pool"
+	"go.dedis.ch/dela/mino"
+	"golang.org/x/xerrors"
+)
+
+// HealthFlags are the flags of the readiness of the node, see
+// NewHealthFromFlags.
+var HealthFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name: "proxystall",
+		Usage: "the time without new block, while transactions are waiting " +
+			"in the pool, after which the node is not ready",
+		Value: time.Minute,
+	},
+}
+
+// poolInterval is the time between two checks of the pool, so that the time
+// transactions start waiting in it is known even if the readiness is not
+// polled.
+const poolInterval = time.Second
+
+// rosterProvider is implemented by the ordering services that know the
+// current roster of the chain.
+type rosterProvider interface {
+	GetRoster() (authority.Authority, error)
+}
+
+// lastBlockReader is the part of the block store needed to get the height of
+// the chain when the node starts.
+type lastBlockReader interface {
+	Last() (otypes.BlockLink, error)
+}
+
+// Health reports the state of the node: the chain, the pool and the DKG and
+// shuffle services. The chain is stalled when transactions are waiting in the
+// pool but no block was committed for the stall timeout.
+type Health struct {
+	sync.Mutex
+
+	service ordering.Service
+	pool    pool.Pool
+	addr    mino.Address
+	dkg     dkgSrv.DKG
+	shuffle shuffleSrv.Actor
+
+	stallTimeout time.Duration
+
+	height uint64
+	// lastBlock is the time the last block was committed, or the time the
+	// health started
+	lastBlock time.Time
+	// pendingSince is the time transactions were first seen waiting in the
+	// pool, zero if it is empty. The pool is checked every poolInterval.
+	pendingSince time.Time
+}
+
+// NewHealth returns the health of the node whose address is given. It follows
+// the new blocks and checks the pool until the context is done. The shuffle actor can be nil if
+// the node doesn't shuffle.
+func NewHealth(ctx context.Context, srv ordering.Service, blocks lastBlockReader,
+	p pool.Pool, addr mino.Address, dkg dkgSrv.DKG, shuffle shuffleSrv.Actor,
+	stallTimeout time.Duration) *Health {
+
+	h := &Health{
+		service:      srv,
+		pool:         p,
+		addr:         addr,
+		dkg:          dkg,
+		shuffle:      shuffle,
+		stallTimeout: stallTimeout,
+		lastBlock:    time.Now(),
+	}
+
+	// the watch starts before reading the last block, so that no block is
+	// missed in between.
+	events := srv.Watch(ctx)
+
+	link, err := blocks.Last()
+	if err == nil {
+		h.height = link.GetBlock().GetIndex() + 1
+	}
+
+	go func() {
+		for event := range events {
+			h.Lock()
+			if event.Index+1 > h.height {
+				h.height = event.Index + 1
+			}
+			h.lastBlock = time.Now()
+			h.Unlock()
+		}
+	}()
+
+	h.checkPool(p.Len(), time.Now())
+
+	go func() {
+		ticker := time.NewTicker(poolInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				h.checkPool(h.pool.Len(), now)
+			}
+		}
+	}()
+
+	return h
+}
+
+// checkPool records the time transactions started waiting in the pool, given
+// its size.
+func (h *Health) checkPool(size int, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+
+	if size == 0 {
+		h.pendingSince = time.Time{}
+	} else if h.pendingSince.IsZero() {
+		h.pendingSince = now
+	}
+}
+
+// NewHealthFromFlags returns the health of the node with the stall timeout of
+// the flags of HealthFlags.
+func NewHealthFromFlags(ctx context.Context, flags cli.Flags, srv ordering.Service,
+	blocks lastBlockReader, p pool.Pool, addr mino.Address, dkg dkgSrv.DKG,
+	shuffle shuffleSrv.Actor) *Health {
+
+	return NewHealth(ctx, srv, blocks, p, addr, dkg, shuffle,
+		flags.Duration("proxystall"))
+}
+
+// HealthZ returns the state of the node. It always answers with a 200 status
+// so that it can be read by the monitoring even when the node is not ready.
+func (h *Health) HealthZ(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, h.report(), http.StatusOK)
+}
+
+// ReadyZ returns the state of the node, with a 503 status if the node is not
+// ready to serve the requests that add transactions: the chain has no roster
+// or is stalled.
+func (h *Health) ReadyZ(w http.ResponseWriter, r *http.Request) {
+	report := h.report()
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	h.writeReport(w, r, report, status)
+}
+
+// report returns the state of the node.
+func (h *Health) report() types.HealthResponse {
+	now := time.Now()
+
+	report := types.HealthResponse{
+		PoolSize:  h.pool.Len(),
+		DKGActors: make(map[string]types.GetActorInfo),
+		Shuffle:   h.shuffle != nil,
+	}
+
+	h.checkPool(report.PoolSize, now)
+
+	h.Lock()
+
+	report.Height = h.height
+	report.SinceLastBlock = now.Sub(h.lastBlock).Seconds()
+
+	// the stall timeout runs from the last block or, if the pool was empty
+	// for longer, from the time transactions started waiting in it.
+	waitingSince := h.lastBlock
+	if h.pendingSince.After(waitingSince) {
+		waitingSince = h.pendingSince
+	}
+
+	h.Unlock()
+
+	if report.PoolSize > 0 && now.Sub(waitingSince) > h.stallTimeout {
+		report.Problems = append(report.Problems, fmt.Sprintf("consensus is "+
+			"stalled: no block for %s while %d transactions are waiting",
+			now.Sub(waitingSince).Round(time.Second), report.PoolSize))
+	}
+
+	err := h.fillRoster(&report)
+	if err != nil {
+		report.Problems = append(report.Problems, err.Error())
+	}
+
+	for _, formID := range h.dkg.Forms() {
+		actor, found := h.dkg.GetActor(formID)
+		if !found {
+			continue
+		}
+
+		report.DKGActors[hex.EncodeToString(formID)] = getActorInfo(actor)
+	}
+
+	report.Ready = len(report.Problems) == 0
+
+	return report
+}
+
+// fillRoster sets the size of the roster of the chain and whether the node is
+// one of its members.
+func (h *Health) fillRoster(report *types.HealthResponse) error {
+	provider, ok := h.service.(rosterProvider)
+	if !ok {
+		return xerrors.Errorf("the ordering service can't provide the roster")
+	}
+
+	roster, err := provider.GetRoster()
+	if err != nil {
+		return xerrors.Errorf("failed to get roster: %v", err)
+	}
+
+	iter := roster.AddressIterator()
+	for iter.HasNext() {
+		addr := iter.GetNext()
+
+		report.RosterSize++
+
+		if h.addr != nil && addr.Equal(h.addr) {
+			report.Member = true
+		}
+	}
+
+	if report.RosterSize == 0 {
+		return xerrors.Errorf("the roster is empty")
+	}
+
+	return nil
+}
+
+// writeReport writes the state of the node with the status.
+func (h *Health) writeReport(w http.ResponseWriter, r *http.Request,
+	report types.HealthResponse, status int) {
+
+	buf, err := json.Marshal(report)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to marshal report: %v", err), nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
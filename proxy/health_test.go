@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/c4dt/d-voting/proxy/types"
+	dkgSrv "github.com/c4dt/d-voting/services/dkg"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"golang.org/x/xerrors"
+)
+
+func TestHealth_HealthZ(t *testing.T) {
+	srv := &rosterService{
+		Service: &fake.Service{},
+		roster:  fake.Authority{CollectiveAuthority: fake.NewAuthority(3, fake.NewSigner)},
+	}
+
+	dkg := fake.Pedersen{Actors: map[string]dkgSrv.Actor{
+		"\xab\xcd": fake.DKGActor{},
+	}}
+
+	h := NewHealth(context.Background(), srv, emptyChain{}, &fakePool{len: 2},
+		fake.NewAddress(1), dkg, nil, time.Minute)
+
+	res := getReport(t, h.HealthZ)
+	require.Equal(t, http.StatusOK, res.status)
+	require.True(t, res.report.Ready)
+	require.Empty(t, res.report.Problems)
+	require.Equal(t, uint64(0), res.report.Height)
+	require.Equal(t, 3, res.report.RosterSize)
+	require.True(t, res.report.Member)
+	require.Equal(t, 2, res.report.PoolSize)
+	require.False(t, res.report.Shuffle)
+	require.Equal(t, map[string]types.GetActorInfo{
+		hex.EncodeToString([]byte("\xab\xcd")): {Status: int(dkgSrv.Initialized)},
+	}, res.report.DKGActors)
+
+	srv.Channel <- ordering.Event{Index: 4}
+
+	require.Eventually(t, func() bool {
+		return getReport(t, h.HealthZ).report.Height == 5
+	}, time.Second, 10*time.Millisecond)
+
+	// the health is reported even if the node is not ready
+	h = NewHealth(context.Background(), &fake.Service{}, emptyChain{},
+		&fakePool{}, fake.NewAddress(1), dkg, nil, time.Minute)
+
+	res = getReport(t, h.HealthZ)
+	require.Equal(t, http.StatusOK, res.status)
+	require.False(t, res.report.Ready)
+	require.Equal(t, []string{"the ordering service can't provide the roster"},
+		res.report.Problems)
+}
+
+func TestHealth_ReadyZ(t *testing.T) {
+	srv := &rosterService{
+		Service: &fake.Service{},
+		roster:  fake.Authority{CollectiveAuthority: fake.NewAuthority(3, fake.NewSigner)},
+	}
+
+	p := &fakePool{}
+
+	h := NewHealth(context.Background(), srv, emptyChain{}, p,
+		fake.NewAddress(5), fake.Pedersen{}, nil, 50*time.Millisecond)
+
+	res := getReport(t, h.ReadyZ)
+	require.Equal(t, http.StatusOK, res.status)
+	require.True(t, res.report.Ready)
+	require.False(t, res.report.Member)
+
+	// the transactions are waiting for less than the stall timeout
+	p.setLen(2)
+
+	res = getReport(t, h.ReadyZ)
+	require.Equal(t, http.StatusOK, res.status)
+
+	time.Sleep(100 * time.Millisecond)
+
+	res = getReport(t, h.ReadyZ)
+	require.Equal(t, http.StatusServiceUnavailable, res.status)
+	require.False(t, res.report.Ready)
+	require.Len(t, res.report.Problems, 1)
+	require.Contains(t, res.report.Problems[0], "consensus is stalled")
+
+	// a new block ends the stall
+	srv.Channel <- ordering.Event{Index: 0}
+
+	require.Eventually(t, func() bool {
+		return getReport(t, h.ReadyZ).status == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	srv.err = xerrors.New("oops")
+
+	res = getReport(t, h.ReadyZ)
+	require.Equal(t, http.StatusServiceUnavailable, res.status)
+	require.Equal(t, []string{"failed to get roster: oops"}, res.report.Problems)
+
+	// the stall is detected even if the readiness was not polled while the
+	// transactions were waiting
+	srv.err = nil
+
+	h = NewHealth(context.Background(), srv, emptyChain{}, &fakePool{len: 2},
+		fake.NewAddress(5), fake.Pedersen{}, nil, 50*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+
+	res = getReport(t, h.ReadyZ)
+	require.Equal(t, http.StatusServiceUnavailable, res.status)
+	require.Contains(t, res.report.Problems[0], "consensus is stalled")
+	require.Greater(t, res.report.SinceLastBlock, 0.05)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type healthResult struct {
+	status int
+	report types.HealthResponse
+}
+
+func getReport(t *testing.T, handler http.HandlerFunc) healthResult {
+	w := httptest.NewRecorder()
+
+	handler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var report types.HealthResponse
+
+	err := json.Unmarshal(w.Body.Bytes(), &report)
+	require.NoError(t, err)
+
+	return healthResult{status: w.Code, report: report}
+}
+
+type rosterService struct {
+	*fake.Service
+
+	roster authority.Authority
+	err    error
+}
+
+func (s *rosterService) GetRoster() (authority.Authority, error) {
+	return s.roster, s.err
+}
+
+type emptyChain struct{}
+
+func (emptyChain) Last() (otypes.BlockLink, error) {
+	return nil, xerrors.New("no block")
+}
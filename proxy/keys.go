@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/cli"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+// keysCheckInterval is the minimum time between two checks of the file of
+// trusted keys for changes.
+const keysCheckInterval = time.Second * 5
+
+// Scope is a set of operations that a trusted key can sign requests for.
+type Scope string
+
+const (
+	// ScopeAll allows all the operations
+	ScopeAll Scope = "*"
+	// ScopeForms allows creating, editing and deleting forms, and managing
+	// their owners and voters
+	ScopeForms Scope = "forms"
+	// ScopeVote allows casting votes
+	ScopeVote Scope = "vote"
+	// ScopeAdmin allows managing the admins and the operators
+	ScopeAdmin Scope = "admin"
+	// ScopeDKG allows setting up the DKG and the key ceremonies
+	ScopeDKG Scope = "dkg"
+	// ScopeShuffle allows starting the shuffle of the ballots
+	ScopeShuffle Scope = "shuffle"
+)
+
+// TrustedKey is a frontend key trusted to sign the requests of a set of
+// scopes.
+type TrustedKey struct {
+	Name      string
+	PublicKey kyber.Point
+	Scopes    []Scope
+}
+
+// allows returns true if the key can sign requests of the scope.
+func (k TrustedKey) allows(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeysFile is the JSON representation of the file of trusted keys.
+type KeysFile struct {
+	Keys []KeyJSON
+}
+
+// KeyJSON is the JSON representation of a trusted key.
+type KeyJSON struct {
+	Name string
+	// PublicKey is the hex encoded Ed25519 public key
+	PublicKey string
+	Scopes    []Scope
+}
+
+// KeyRing holds the keys trusted by the proxy. When it is loaded from a file,
+// the file is read again once it changes, which allows to rotate the keys
+// without restarting the node.
+type KeyRing struct {
+	sync.Mutex
+
+	keys []TrustedKey
+
+	// path is the file of the keys, empty if the keys are fixed
+	path      string
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewKeyRing returns a new key ring with fixed keys.
+func NewKeyRing(keys ...TrustedKey) *KeyRing {
+	return &KeyRing{
+		keys: keys,
+	}
+}
+
+// LoadKeyRing returns a new key ring with the keys of the file. The file is
+// watched for changes.
+func LoadKeyRing(path string) (*KeyRing, error) {
+	k := &KeyRing{
+		path: path,
+	}
+
+	err := k.load()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load keys: %v", err)
+	}
+
+	return k, nil
+}
+
+// NewKeyRingFromFlags returns the key ring defined by the flags. The keys are
+// loaded from the file given by "proxykeys" if it is set. Otherwise, the key
+// given by "proxykey" is trusted for all the scopes.
+func NewKeyRingFromFlags(flags cli.Flags) (*KeyRing, error) {
+	path := flags.String("proxykeys")
+	if path != "" {
+		return LoadKeyRing(path)
+	}
+
+	proxykey, err := decodeKey(flags.String("proxykey"))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode proxy key: %v", err)
+	}
+
+	return NewKeyRing(TrustedKey{
+		Name:      "proxykey",
+		PublicKey: proxykey,
+		Scopes:    []Scope{ScopeAll},
+	}), nil
+}
+
+// Verify calls verify with the trusted keys until one of them is accepted. It
+// returns the key, or an error if no key is accepted or if the key is not
+// allowed to sign requests of the scope.
+func (k *KeyRing) Verify(scope Scope, verify func(kyber.Point) error) (TrustedKey, error) {
+	keys := k.getKeys()
+
+	for _, key := range keys {
+		err := verify(key.PublicKey)
+		if err != nil {
+			continue
+		}
+
+		if !key.allows(scope) {
+			return key, newRejectedErr(http.StatusForbidden, "not authorized / forbidden",
+				types.ErrCodeNotAuthorized,
+				xerrors.Errorf("key %q is not allowed to sign %q requests", key.Name, scope))
+		}
+
+		return key, nil
+	}
+
+	return TrustedKey{}, newRejectedErr(http.StatusUnauthorized, "unauthorized",
+		types.ErrCodeInvalidSignature,
+		xerrors.Errorf("signature not made by any of the %d trusted keys", len(keys)))
+}
+
+// getKeys returns the current keys. The file is read again if it changed.
+func (k *KeyRing) getKeys() []TrustedKey {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.path != "" && time.Since(k.lastCheck) > keysCheckInterval {
+		k.lastCheck = time.Now()
+
+		info, err := os.Stat(k.path)
+		if err != nil {
+			dela.Logger.Warn().Err(err).Msgf("failed to check keys file %s", k.path)
+		} else if !info.ModTime().Equal(k.modTime) {
+			// the previous keys are kept if the file is invalid, so that an
+			// error in the file doesn't lock out all the frontends.
+			err = k.load()
+			if err != nil {
+				dela.Logger.Warn().Err(err).Msgf("failed to reload keys file %s", k.path)
+			} else {
+				dela.Logger.Info().Msgf("reloaded %d keys from %s", len(k.keys), k.path)
+			}
+		}
+	}
+
+	return k.keys
+}
+
+// load reads the keys from the file. The lock must be held.
+func (k *KeyRing) load() error {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return xerrors.Errorf("failed to stat file: %v", err)
+	}
+
+	buf, err := os.ReadFile(k.path)
+	if err != nil {
+		return xerrors.Errorf("failed to read file: %v", err)
+	}
+
+	var file KeysFile
+
+	err = json.Unmarshal(buf, &file)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal file: %v", err)
+	}
+
+	if len(file.Keys) == 0 {
+		return xerrors.New("no key in the file")
+	}
+
+	keys := make([]TrustedKey, len(file.Keys))
+
+	for i, keyJSON := range file.Keys {
+		publicKey, err := decodeKey(keyJSON.PublicKey)
+		if err != nil {
+			return xerrors.Errorf("failed to decode key %q: %v", keyJSON.Name, err)
+		}
+
+		for _, scope := range keyJSON.Scopes {
+			if !validScope(scope) {
+				return xerrors.Errorf("unknown scope of key %q: %s", keyJSON.Name, scope)
+			}
+		}
+
+		keys[i] = TrustedKey{
+			Name:      keyJSON.Name,
+			PublicKey: publicKey,
+			Scopes:    keyJSON.Scopes,
+		}
+	}
+
+	k.keys = keys
+	k.modTime = info.ModTime()
+
+	return nil
+}
+
+func decodeKey(keyHex string) (kyber.Point, error) {
+	keyBuf, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode hex: %v", err)
+	}
+
+	key := suite.Point()
+
+	err = key.UnmarshalBinary(keyBuf)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal key: %v", err)
+	}
+
+	return key, nil
+}
+
+func validScope(scope Scope) bool {
+	switch scope {
+	case ScopeAll, ScopeForms, ScopeVote, ScopeAdmin, ScopeDKG, ScopeShuffle:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/cli/node"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+)
+
+func TestKeyRing_Verify(t *testing.T) {
+	kioskSecret, kioskPk := newKeyPair()
+	adminSecret, adminPk := newKeyPair()
+	otherSecret, _ := newKeyPair()
+
+	keys := NewKeyRing(
+		TrustedKey{Name: "kiosk", PublicKey: kioskPk, Scopes: []Scope{ScopeVote}},
+		TrustedKey{Name: "admin", PublicKey: adminPk, Scopes: []Scope{ScopeForms, ScopeAdmin}},
+	)
+
+	key, err := keys.Verify(ScopeVote, verifySigned(t, kioskSecret))
+	require.NoError(t, err)
+	require.Equal(t, "kiosk", key.Name)
+
+	key, err = keys.Verify(ScopeForms, verifySigned(t, adminSecret))
+	require.NoError(t, err)
+	require.Equal(t, "admin", key.Name)
+
+	_, err = keys.Verify(ScopeForms, verifySigned(t, kioskSecret))
+	requireRejected(t, err, http.StatusForbidden, `key "kiosk" is not allowed to sign "forms" requests`)
+
+	_, err = keys.Verify(ScopeVote, verifySigned(t, otherSecret))
+	requireRejected(t, err, http.StatusUnauthorized, "signature not made by any of the 2 trusted keys")
+}
+
+func TestKeyRing_Load(t *testing.T) {
+	_, kioskPk := newKeyPair()
+	_, adminPk := newKeyPair()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	writeKeysFile(t, path, KeyJSON{Name: "kiosk", PublicKey: encodeKey(t, kioskPk),
+		Scopes: []Scope{ScopeVote}})
+
+	keys, err := LoadKeyRing(path)
+	require.NoError(t, err)
+	require.Len(t, keys.getKeys(), 1)
+	require.True(t, keys.getKeys()[0].PublicKey.Equal(kioskPk))
+
+	// the keys are rotated
+	writeKeysFile(t, path, KeyJSON{Name: "admin", PublicKey: encodeKey(t, adminPk),
+		Scopes: []Scope{ScopeAll}})
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	keys.lastCheck = time.Time{}
+
+	require.Len(t, keys.getKeys(), 1)
+	require.Equal(t, "admin", keys.getKeys()[0].Name)
+
+	// an invalid file doesn't replace the keys
+	writeKeysFile(t, path, KeyJSON{Name: "bad", PublicKey: "zz"})
+
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	keys.lastCheck = time.Time{}
+
+	require.Len(t, keys.getKeys(), 1)
+	require.Equal(t, "admin", keys.getKeys()[0].Name)
+}
+
+func TestKeyRing_Load_Invalid(t *testing.T) {
+	_, pk := newKeyPair()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	_, err := LoadKeyRing(path)
+	require.ErrorContains(t, err, "failed to load keys: failed to stat file")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), os.ModePerm))
+
+	_, err = LoadKeyRing(path)
+	require.ErrorContains(t, err, "failed to load keys: failed to unmarshal file")
+
+	writeKeysFile(t, path)
+
+	_, err = LoadKeyRing(path)
+	require.EqualError(t, err, "failed to load keys: no key in the file")
+
+	writeKeysFile(t, path, KeyJSON{Name: "kiosk", PublicKey: "zz"})
+
+	_, err = LoadKeyRing(path)
+	require.ErrorContains(t, err, `failed to load keys: failed to decode key "kiosk"`)
+
+	writeKeysFile(t, path, KeyJSON{Name: "kiosk", PublicKey: encodeKey(t, pk),
+		Scopes: []Scope{"fake"}})
+
+	_, err = LoadKeyRing(path)
+	require.EqualError(t, err, `failed to load keys: unknown scope of key "kiosk": fake`)
+}
+
+func TestNewKeyRingFromFlags(t *testing.T) {
+	_, pk := newKeyPair()
+
+	keys, err := NewKeyRingFromFlags(node.FlagSet{"proxykey": encodeKey(t, pk)})
+	require.NoError(t, err)
+	require.Len(t, keys.getKeys(), 1)
+	require.True(t, keys.getKeys()[0].allows(ScopeDKG))
+
+	_, err = NewKeyRingFromFlags(node.FlagSet{"proxykey": "zz"})
+	require.ErrorContains(t, err, "failed to decode proxy key")
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	writeKeysFile(t, path, KeyJSON{Name: "kiosk", PublicKey: encodeKey(t, pk),
+		Scopes: []Scope{ScopeVote}})
+
+	keys, err = NewKeyRingFromFlags(node.FlagSet{"proxykeys": path})
+	require.NoError(t, err)
+	require.Equal(t, "kiosk", keys.getKeys()[0].Name)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func newKeyPair() (kyber.Scalar, kyber.Point) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	return secret, suite.Point().Mul(secret, nil)
+}
+
+func encodeKey(t *testing.T, pk kyber.Point) string {
+	buf, err := pk.MarshalBinary()
+	require.NoError(t, err)
+
+	return hex.EncodeToString(buf)
+}
+
+func verifySigned(t *testing.T, secret kyber.Scalar) func(kyber.Point) error {
+	msg := []byte("message")
+
+	signature, err := schnorr.Sign(suite, secret, msg)
+	require.NoError(t, err)
+
+	return func(pk kyber.Point) error {
+		return schnorr.Verify(suite, pk, msg, signature)
+	}
+}
+
+func writeKeysFile(t *testing.T, path string, keys ...KeyJSON) {
+	buf, err := json.Marshal(KeysFile{Keys: keys})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, buf, os.ModePerm))
+}
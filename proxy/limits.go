@@ -0,0 +1,343 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	dvoting "github.com/c4dt/d-voting"
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.dedis.ch/dela/cli"
+	"go.dedis.ch/dela/cli/node"
+	"go.dedis.ch/dela/core/txn/pool"
+	"golang.org/x/xerrors"
+)
+
+// purgeInterval is the minimum time between two purges of the buckets of the
+// clients that have not sent requests lately.
+const purgeInterval = time.Minute
+
+var (
+	// PromProxyRejected counts the requests rejected by the limiter of the
+	// proxy, by reason.
+	PromProxyRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dvoting_proxy_rejected_requests_total",
+		Help: "requests rejected by the limits of the proxy",
+	}, []string{"reason"})
+)
+
+// reasons of the rejection of a request, used as label of PromProxyRejected
+const (
+	rejectIPRate   = "ip_rate"
+	rejectKeyRate  = "key_rate"
+	rejectBodySize = "body_size"
+	rejectPoolFull = "pool_full"
+)
+
+func init() {
+	dvoting.PromCollectors = append(dvoting.PromCollectors, PromProxyRejected)
+}
+
+// Limits are the limits the proxy enforces on the requests it receives. A
+// zero value disables the limit.
+type Limits struct {
+	// IPRate is the number of requests per second a client address can send.
+	// Bursts of twice that number are allowed.
+	IPRate int
+	// KeyRate is the number of signed requests per second a trusted key can
+	// sign. Bursts of twice that number are allowed.
+	KeyRate int
+	// MaxBodySize is the maximum size of the body of a request, in bytes.
+	MaxBodySize int64
+	// MaxPoolSize is the number of transactions in the pool from which the
+	// requests that may add transactions are rejected, until it is emptied.
+	MaxPoolSize int
+}
+
+// LimitFlags are the flags of the limits of the proxy, see
+// NewLimitsFromFlags.
+var LimitFlags = []cli.Flag{
+	// the requests of the voters usually all come from the address of the
+	// frontend, which the rate of the trusted key limits already.
+	cli.IntFlag{
+		Name:  "proxyiprate",
+		Usage: "the requests per second a client address can send to the proxy, 0 for no limit",
+		Value: 0,
+	},
+	cli.IntFlag{
+		Name:  "proxykeyrate",
+		Usage: "the requests per second a trusted key can sign, 0 for no limit",
+		Value: 100,
+	},
+	cli.IntFlag{
+		Name:  "proxymaxbody",
+		Usage: "the maximum size of the body of a request to the proxy in bytes, 0 for no limit",
+		Value: 8 << 20,
+	},
+	cli.IntFlag{
+		Name: "proxymaxpool",
+		Usage: "the number of transactions in the pool from which the proxy " +
+			"rejects the requests that may add more, 0 for no limit",
+		Value: 1000,
+	},
+}
+
+// NewLimitsFromFlags returns the limits defined by the flags of LimitFlags.
+func NewLimitsFromFlags(flags cli.Flags) Limits {
+	return Limits{
+		IPRate:      flags.Int("proxyiprate"),
+		KeyRate:     flags.Int("proxykeyrate"),
+		MaxBodySize: int64(flags.Int("proxymaxbody")),
+		MaxPoolSize: flags.Int("proxymaxpool"),
+	}
+}
+
+// Limiter enforces the limits of the proxy. It is shared by all the routers of
+// the proxy so that a client has the same budget for all the endpoints. A nil
+// limiter doesn't limit anything.
+type Limiter struct {
+	limits Limits
+	pool   pool.Pool
+
+	ips  *rateLimiter
+	keys *rateLimiter
+}
+
+// NewLimiter returns a new limiter. The pool is the one the transactions are
+// added to, it can be nil if the size of the pool is not limited.
+func NewLimiter(limits Limits, p pool.Pool) *Limiter {
+	return &Limiter{
+		limits: limits,
+		pool:   p,
+		ips:    newRateLimiter(limits.IPRate),
+		keys:   newRateLimiter(limits.KeyRate),
+	}
+}
+
+// ResolveLimiter returns the limiter injected by the registration of another
+// router of the proxy, or creates one from the flags and injects it.
+func ResolveLimiter(inj node.Injector, flags cli.Flags) (*Limiter, error) {
+	var limiter *Limiter
+
+	err := inj.Resolve(&limiter)
+	if err == nil {
+		return limiter, nil
+	}
+
+	var p pool.Pool
+
+	err = inj.Resolve(&p)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to resolve pool.Pool: %v", err)
+	}
+
+	limiter = NewLimiter(NewLimitsFromFlags(flags), p)
+	inj.Inject(limiter)
+
+	return limiter, nil
+}
+
+// Middleware limits the requests of each client address, the size of their
+// body, and rejects the requests that may add transactions when the pool is
+// full. It can be used with mux.Router.Use.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := clientAddr(r)
+
+		wait := l.ips.allow(addr)
+		if wait > 0 {
+			PromProxyRejected.WithLabelValues(rejectIPRate).Inc()
+			limitError(w, r, xerrors.Errorf("too many requests from %s", addr),
+				http.StatusTooManyRequests, types.ErrCodeRateLimited, wait)
+			return
+		}
+
+		tooLarge, err := l.limitBody(w, r)
+		if tooLarge {
+			PromProxyRejected.WithLabelValues(rejectBodySize).Inc()
+			limitError(w, r, err, http.StatusRequestEntityTooLarge,
+				types.ErrCodeRequestTooLarge, 0)
+			return
+		}
+
+		if err != nil {
+			BadRequestError(w, r, xerrors.Errorf("failed to read body: %v", err), nil)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodOptions &&
+			r.Method != http.MethodHead && l.poolFull() {
+
+			PromProxyRejected.WithLabelValues(rejectPoolFull).Inc()
+			limitError(w, r, xerrors.Errorf("the pool is full, %d transactions "+
+				"are waiting", l.pool.Len()), http.StatusServiceUnavailable,
+				types.ErrCodePoolFull, time.Second)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowKey checks the rate of the requests signed by the trusted key. The
+// error is a rejectedErr.
+func (l *Limiter) allowKey(name string) error {
+	if l == nil {
+		return nil
+	}
+
+	wait := l.keys.allow(name)
+	if wait == 0 {
+		return nil
+	}
+
+	PromProxyRejected.WithLabelValues(rejectKeyRate).Inc()
+
+	return newRejectedErr(http.StatusTooManyRequests, "too many requests",
+		types.ErrCodeRateLimited, xerrors.Errorf("too many requests signed by "+
+			"%s, retry in %s", name, wait.Round(time.Millisecond)))
+}
+
+// limitBody reads the body, up to the maximum size, so that the handlers
+// don't have to deal with bodies that are too large. It returns true if the
+// body is too large.
+func (l *Limiter) limitBody(w http.ResponseWriter, r *http.Request) (bool, error) {
+	if l.limits.MaxBodySize <= 0 || r.Body == nil {
+		return false, nil
+	}
+
+	if r.ContentLength > l.limits.MaxBodySize {
+		return true, xerrors.Errorf("body too large: %d > %d", r.ContentLength,
+			l.limits.MaxBodySize)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, l.limits.MaxBodySize))
+
+	var maxErr *http.MaxBytesError
+	if xerrors.As(err, &maxErr) {
+		return true, xerrors.Errorf("body too large: more than %d bytes",
+			l.limits.MaxBodySize)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	return false, nil
+}
+
+func (l *Limiter) poolFull() bool {
+	return l.limits.MaxPoolSize > 0 && l.pool != nil &&
+		l.pool.Len() >= l.limits.MaxPoolSize
+}
+
+// limitError writes the error of a rejected request, with the time after
+// which the client can try again, if known.
+func limitError(w http.ResponseWriter, r *http.Request, err error, code uint,
+	errCode types.ErrorCode, retryAfter time.Duration) {
+
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	CodedError(w, r, err, code, errCode, nil)
+}
+
+// clientAddr returns the address of the client, without the port.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// rateLimiter limits the rate of the requests of each client with a token
+// bucket, refilled at the rate and holding at most twice the rate.
+type rateLimiter struct {
+	sync.Mutex
+
+	rate  float64
+	burst float64
+
+	buckets   map[string]*bucket
+	lastPurge time.Time
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{
+		rate:      float64(rate),
+		burst:     float64(2 * rate),
+		buckets:   make(map[string]*bucket),
+		lastPurge: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// allow takes a token from the bucket of the client. It returns 0 if there was
+// one, otherwise the time until there is one.
+func (l *rateLimiter) allow(client string) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+
+	// the buckets that are full again are the same as new ones
+	if now.Sub(l.lastPurge) > purgeInterval {
+		for c, b := range l.buckets {
+			if b.refill(now, l.rate, l.burst) >= l.burst {
+				delete(l.buckets, c)
+			}
+		}
+
+		l.lastPurge = now
+	}
+
+	b, found := l.buckets[client]
+	if !found {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	tokens := b.refill(now, l.rate, l.burst)
+	if tokens < 1 {
+		return time.Duration((1 - tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens = tokens - 1
+
+	return 0
+}
+
+// refill adds the tokens earned since the last refill.
+func (b *bucket) refill(now time.Time, rate, burst float64) float64 {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	return b.tokens
+}
@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/txn/pool"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Now()
+
+	limiter := newRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+
+	// the burst is twice the rate
+	for i := 0; i < 4; i++ {
+		require.Zero(t, limiter.allow("a"))
+	}
+
+	require.Equal(t, 500*time.Millisecond, limiter.allow("a"))
+
+	// the clients have their own bucket
+	require.Zero(t, limiter.allow("b"))
+
+	now = now.Add(500 * time.Millisecond)
+	require.Zero(t, limiter.allow("a"))
+	require.Equal(t, 500*time.Millisecond, limiter.allow("a"))
+
+	// the full buckets are forgotten
+	now = now.Add(purgeInterval * 2)
+	require.Zero(t, limiter.allow("a"))
+	require.Len(t, limiter.buckets, 1)
+
+	// no limit
+	limiter = newRateLimiter(0)
+	for i := 0; i < 100; i++ {
+		require.Zero(t, limiter.allow("a"))
+	}
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	p := &fakePool{}
+
+	limiter := NewLimiter(Limits{IPRate: 1, MaxBodySize: 10, MaxPoolSize: 2}, p)
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		w.Write(body)
+	}))
+
+	serve := func(method, addr, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/evoting/forms", strings.NewReader(body))
+		r.RemoteAddr = addr
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := serve(http.MethodPost, "10.0.0.1:1234", "hello")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "hello", w.Body.String())
+
+	// the port of the client doesn't matter
+	serve(http.MethodGet, "10.0.0.1:1235", "")
+
+	w = serve(http.MethodGet, "10.0.0.1:1236", "")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+	requireErrorCode(t, w, types.ErrCodeRateLimited)
+
+	w = serve(http.MethodPost, "10.0.0.2:1234", "hello world")
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	requireErrorCode(t, w, types.ErrCodeRequestTooLarge)
+
+	// the length of the body is not always known in advance
+	r := httptest.NewRequest(http.MethodPost, "/evoting/forms",
+		io.NopCloser(bytes.NewBufferString("hello world")))
+	r.RemoteAddr = "10.0.0.3:1234"
+	r.ContentLength = -1
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	p.setLen(2)
+
+	w = serve(http.MethodPost, "10.0.0.4:1234", "")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	requireErrorCode(t, w, types.ErrCodePoolFull)
+
+	// the requests that only read are not affected by the pool
+	w = serve(http.MethodGet, "10.0.0.5:1234", "")
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLimiter_NoLimits(t *testing.T) {
+	var limiter *Limiter
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	require.NoError(t, limiter.allowKey("key"))
+}
+
+func TestVerifier_KeyRate(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pk := suite.Point().Mul(secret, nil)
+
+	verifier := NewVerifier(trustAll(pk), NewLimiter(Limits{KeyRate: 1}, nil), time.Minute)
+
+	send := func() error {
+		signed, err := types.SignRequest(secret, http.MethodPut, "/evoting/forms/abcd",
+			types.UpdateFormRequest{Action: "close"})
+		require.NoError(t, err)
+
+		buf, err := json.Marshal(signed)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPut, "/evoting/forms/abcd", bytes.NewReader(buf))
+
+		var req types.UpdateFormRequest
+
+		return verifier.GetAndVerify(r, ScopeForms, &req)
+	}
+
+	require.NoError(t, send())
+	require.NoError(t, send())
+
+	err := send()
+	requireRejected(t, err, http.StatusTooManyRequests, "too many requests signed by test")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// fakePool is a pool whose size can be changed while it is read by the
+// health of the node.
+type fakePool struct {
+	pool.Pool
+	sync.Mutex
+
+	len int
+}
+
+func (p *fakePool) Len() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.len
+}
+
+func (p *fakePool) setLen(size int) {
+	p.Lock()
+	p.len = size
+	p.Unlock()
+}
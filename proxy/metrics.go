@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	dvoting "github.com/c4dt/d-voting"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// PromProxyRequestDuration observes the latency of the requests of the
+	// proxy, by route.
+	PromProxyRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dvoting_proxy_request_duration_seconds",
+		Help:    "latency of the requests of the proxy",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+)
+
+func init() {
+	dvoting.PromCollectors = append(dvoting.PromCollectors, PromProxyRequestDuration)
+}
+
+// MetricsMiddleware observes the latency of the requests. The route is the
+// path template of the route, so that the IDs in the paths don't add labels.
+// It can be used with mux.Router.Use, first so that the requests rejected by
+// the other middlewares are observed too.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		PromProxyRequestDuration.WithLabelValues(routeOf(r), r.Method,
+			strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeOf returns the path template of the route of the request.
+func routeOf(r *http.Request) string {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return "unknown"
+	}
+
+	template, err := current.GetPathTemplate()
+	if err != nil {
+		return "unknown"
+	}
+
+	return template
+}
+
+// statusRecorder keeps the status written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	PromProxyRequestDuration.Reset()
+
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware)
+	router.HandleFunc("/evoting/forms/{formID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods(http.MethodGet)
+
+	for _, formID := range []string{"aa", "bb"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/evoting/forms/"+formID, nil))
+		require.Equal(t, http.StatusTeapot, w.Code)
+	}
+
+	// both requests are observed under the template of the route, so getting
+	// its observer doesn't add a series
+	PromProxyRequestDuration.WithLabelValues("/evoting/forms/{formID}",
+		http.MethodGet, "418")
+	require.Equal(t, 1, testutil.CollectAndCount(PromProxyRequestDuration))
+}
@@ -7,12 +7,12 @@
 package proxy
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/c4dt/d-voting/proxy/types"
 	"go.dedis.ch/kyber/v3/suites"
+	"golang.org/x/xerrors"
 )
 
 var suite = suites.MustFind("ed25519")
@@ -23,12 +23,22 @@ type Form interface {
 	NewForm(http.ResponseWriter, *http.Request)
 	// POST /forms/{formID}/vote
 	NewFormVote(http.ResponseWriter, *http.Request)
+	// POST /forms/{formID}/vote/signed
+	NewSignedFormVote(http.ResponseWriter, *http.Request)
+	// POST /forms/{formID}/vote/anonymous
+	NewAnonymousFormVote(http.ResponseWriter, *http.Request)
+	// POST /forms/{formID}/vote/audit
+	NewBallotAudit(http.ResponseWriter, *http.Request)
 	// PUT /forms/{formID}
 	EditForm(http.ResponseWriter, *http.Request)
 	// GET /forms
 	Forms(http.ResponseWriter, *http.Request)
 	// GET /forms/{formID}
 	Form(http.ResponseWriter, *http.Request)
+	// GET /forms/{formID}/ballots/{receiptHash}
+	Ballot(http.ResponseWriter, *http.Request)
+	// GET /forms/{formID}/ballots/{voterID}/proof
+	BallotProof(http.ResponseWriter, *http.Request)
 	// DELETE /forms/{formID}
 	DeleteForm(http.ResponseWriter, *http.Request)
 	// TODO CHECK CAUSE NEW -> modif according to blockchain
@@ -52,6 +62,8 @@ type Form interface {
 	AddVoterToForm(http.ResponseWriter, *http.Request)
 	// POST /forms/{formID}/removevoter
 	RemoveVoterToForm(http.ResponseWriter, *http.Request)
+	// POST /forms/{formID}/credentials
+	AddCredential(http.ResponseWriter, *http.Request)
 }
 
 // DKG defines the public HTTP API of the DKG service
@@ -62,75 +74,101 @@ type DKG interface {
 	Actor(http.ResponseWriter, *http.Request)
 	// PUT /services/dkg/{formID}
 	EditDKGActor(http.ResponseWriter, *http.Request)
+	// POST /services/dkg/ceremonies
+	NewCeremony(http.ResponseWriter, *http.Request)
+	// GET /services/dkg/ceremonies
+	Ceremonies(http.ResponseWriter, *http.Request)
+	// GET /services/dkg/ceremonies/{ceremonyID}
+	Ceremony(http.ResponseWriter, *http.Request)
+	// PUT /services/dkg/ceremonies/{ceremonyID}
+	EditCeremony(http.ResponseWriter, *http.Request)
 }
 
 // Shuffle defines the public HTTP API of the shuffling service
 type Shuffle interface {
+	// GET /services/shuffle/{formID}
+	Shuffle(http.ResponseWriter, *http.Request)
 	// PUT /services/shuffle/{formID}
 	EditShuffle(http.ResponseWriter, *http.Request)
+	// GET /services/shuffle/{formID}/ballots
+	Ballots(http.ResponseWriter, *http.Request)
+	// POST /services/shuffle/{formID}/ballots
+	SubmitBallots(http.ResponseWriter, *http.Request)
+}
+
+// Audit defines the public HTTP API of the audit log of the node
+type Audit interface {
+	// POST /evoting/audit
+	AuditLog(http.ResponseWriter, *http.Request)
 }
 
 // NotFoundHandler defines a generic handler for 404
 func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
-	err := types.HTTPError{
-		Title:   "Not found",
-		Code:    http.StatusNotFound,
-		Message: "The requested endpoint was not found",
+	types.WriteError(w, types.HTTPError{
+		Title:     "Not found",
+		Code:      http.StatusNotFound,
+		ErrorCode: types.ErrCodeNotFound,
+		Message:   "The requested endpoint was not found",
 		Args: map[string]interface{}{
 			"url":    r.URL.String(),
 			"method": r.Method,
 		},
-	}
-
-	buf, _ := json.MarshalIndent(&err, "", "  ")
-
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(http.StatusNotFound)
-	fmt.Fprintln(w, string(buf))
+	})
 }
 
 // NotAllowedHandler degines a generic handler for 405
 func NotAllowedHandler(w http.ResponseWriter, r *http.Request) {
-	err := types.HTTPError{
-		Title:   "Not allowed",
-		Code:    http.StatusMethodNotAllowed,
-		Message: "The requested endpoint was not allowed",
+	types.WriteError(w, types.HTTPError{
+		Title:     "Not allowed",
+		Code:      http.StatusMethodNotAllowed,
+		ErrorCode: types.ErrCodeMethodNotAllowed,
+		Message:   "The requested endpoint was not allowed",
 		Args: map[string]interface{}{
 			"url":    r.URL.String(),
 			"method": r.Method,
 		},
-	}
-
-	buf, _ := json.MarshalIndent(&err, "", "  ")
-
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(http.StatusMethodNotAllowed)
-	fmt.Fprintln(w, string(buf))
+	})
 }
 
 // InternalError sets an internal server error
 func InternalError(w http.ResponseWriter, r *http.Request, err error, args map[string]interface{}) {
-	httpErr(w, r, err, http.StatusInternalServerError, "Internal server error", args)
+	httpErr(w, r, err, http.StatusInternalServerError, "Internal server error",
+		types.ErrCodeInternal, args)
 }
 
 // BadRequestError sets an bad request error
 func BadRequestError(w http.ResponseWriter, r *http.Request, err error, args map[string]interface{}) {
-	httpErr(w, r, err, http.StatusBadRequest, "bad request", args)
+	httpErr(w, r, err, http.StatusBadRequest, "bad request", types.ErrCodeBadRequest, args)
 }
 
 // ForbiddenError sets a forbidden error error
 func ForbiddenError(w http.ResponseWriter, r *http.Request, err error, args map[string]interface{}) {
-	httpErr(w, r, err, http.StatusForbidden, "not authorized / forbidden", args)
+	httpErr(w, r, err, http.StatusForbidden, "not authorized / forbidden",
+		types.ErrCodeNotAuthorized, args)
 }
 
 // NotFoundErr sets a not found error
 func NotFoundErr(w http.ResponseWriter, r *http.Request, err error, args map[string]interface{}) {
-	httpErr(w, r, err, http.StatusNotFound, "not found", args)
+	httpErr(w, r, err, http.StatusNotFound, "not found", types.ErrCodeNotFound, args)
 }
 
-func httpErr(w http.ResponseWriter, r *http.Request, err error, code uint, title string, args map[string]interface{}) {
+// FormNotFoundErr sets a not found error for a form that doesn't exist
+func FormNotFoundErr(w http.ResponseWriter, r *http.Request, formID string, args map[string]interface{}) {
+	httpErr(w, r, xerrors.Errorf("form %s not found", formID), http.StatusNotFound,
+		"not found", types.ErrCodeFormNotFound, args)
+}
+
+// CodedError sets an error with a specific code, when the code of one of the
+// other helpers doesn't describe the error well enough.
+func CodedError(w http.ResponseWriter, r *http.Request, err error, status uint,
+	code types.ErrorCode, args map[string]interface{}) {
+
+	httpErr(w, r, err, status, strings.ToLower(http.StatusText(int(status))), code, args)
+}
+
+func httpErr(w http.ResponseWriter, r *http.Request, err error, code uint, title string,
+	errCode types.ErrorCode, args map[string]interface{}) {
+
 	if args == nil {
 		args = make(map[string]interface{})
 	}
@@ -139,24 +177,18 @@ func httpErr(w http.ResponseWriter, r *http.Request, err error, code uint, title
 	args["url"] = r.URL.String()
 	args["method"] = r.Method
 
-	errMsg := types.HTTPError{
-		Title:   title,
-		Code:    code,
-		Message: "A problem occurred on the proxy",
-		Args:    args,
-	}
-
-	buf, _ := json.MarshalIndent(&errMsg, "", "  ")
-
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(int(code))
-	fmt.Fprintln(w, string(buf))
+	types.WriteError(w, types.HTTPError{
+		Title:     title,
+		Code:      code,
+		ErrorCode: errCode,
+		Message:   "A problem occurred on the proxy",
+		Args:      args,
+	})
 }
 
-// AllowCORS defines a basic handler that adds wide Access Control Allow origin
-// headers.
+// AllowCORS defines a basic handler for the OPTIONS requests. The preflight
+// requests of the allowed origins are answered by the CORS middleware, so the
+// other ones get no CORS header.
 func AllowCORS(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
+	w.WriteHeader(http.StatusNoContent)
 }
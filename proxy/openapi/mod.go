@@ -0,0 +1,240 @@
+// Package openapi builds the OpenAPI 3 document of the proxy from the Go types
+// of the requests and responses, so that it can't drift from them.
+//
+// The document is served by the proxy at Path.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	dvoting "github.com/c4dt/d-voting"
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/gorilla/mux"
+	"go.dedis.ch/dela"
+)
+
+// Path is the well-known path of the OpenAPI document.
+const Path = "/evoting/openapi.json"
+
+const (
+	openAPIVersion = "3.0.3"
+	contentType    = "application/json"
+)
+
+var pathParam = regexp.MustCompile(`{([^}]+)}`)
+
+// Spec is the OpenAPI document.
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path, by lowercase HTTP method.
+type PathItem map[string]*OperationObject
+
+// OperationObject describes an operation in the document.
+type OperationObject struct {
+	Summary     string              `json:"summary,omitempty"`
+	OperationID string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	// SignedPayload is the schema of the payload of a signed request
+	SignedPayload *Schema `json:"x-signed-payload,omitempty"`
+}
+
+// Parameter is a parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body of the request of an operation.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a content.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced in the document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Document returns the OpenAPI document of the operations.
+func Document(operations []Operation) Spec {
+	s := newSchemas()
+
+	spec := Spec{
+		OpenAPI: openAPIVersion,
+		Info: Info{
+			Title: "D-Voting proxy",
+			Description: "Requests with x-signed-payload are encapsulated in a " +
+				"signed request, see docs/msg_sig.md",
+			Version: dvoting.Version,
+		},
+		Paths: make(map[string]PathItem),
+	}
+
+	errorSchema := s.of(types.HTTPError{})
+
+	for _, op := range operations {
+		item, found := spec.Paths[op.Path]
+		if !found {
+			item = make(PathItem)
+			spec.Paths[op.Path] = item
+		}
+
+		obj := &OperationObject{
+			Summary:     op.Summary,
+			OperationID: operationID(op),
+			Tags:        []string{op.Tag},
+			Responses: map[string]Response{
+				"default": {
+					Description: "error",
+					Content:     jsonContent(errorSchema),
+				},
+			},
+		}
+
+		for _, match := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+			obj.Parameters = append(obj.Parameters, Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+
+		if op.Signed {
+			obj.RequestBody = &RequestBody{
+				Description: "signed request, whose payload is x-signed-payload",
+				Required:    true,
+				Content:     jsonContent(s.of(types.SignedRequest{})),
+			}
+
+			if op.Request != nil {
+				obj.SignedPayload = s.of(op.Request)
+			}
+		} else if op.Request != nil {
+			obj.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(s.of(op.Request)),
+			}
+		}
+
+		ok := Response{Description: "OK"}
+		if op.Response != nil {
+			ok.Content = jsonContent(s.of(op.Response))
+		}
+
+		obj.Responses["200"] = ok
+
+		item[strings.ToLower(op.Method)] = obj
+	}
+
+	spec.Components.Schemas = s.components
+
+	return spec
+}
+
+// Handler serves the OpenAPI document of all the operations of the proxy.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(Document(Operations))
+	if err != nil {
+		http.Error(w, "failed to write document: "+err.Error(),
+			http.StatusInternalServerError)
+	}
+}
+
+// Undocumented returns the routes of the router that are not in the
+// operations, as "METHOD path". The OPTIONS routes are ignored.
+func Undocumented(router *mux.Router, operations []Operation) []string {
+	documented := make(map[string]bool)
+	for _, op := range operations {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	var missing []string
+
+	// the error is the one of the walk function, which never fails
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if method == http.MethodOptions || documented[method+" "+path] {
+				continue
+			}
+
+			missing = append(missing, method+" "+path)
+		}
+
+		return nil
+	})
+
+	sort.Strings(missing)
+
+	return missing
+}
+
+// WarnUndocumented logs a warning for each route of the router that is not
+// in the OpenAPI document.
+func WarnUndocumented(router *mux.Router) {
+	for _, route := range Undocumented(router, Operations) {
+		dela.Logger.Warn().Msgf("route %s is not in the OpenAPI document", route)
+	}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{
+		contentType: {Schema: schema},
+	}
+}
+
+// operationID returns the ID of the operation, made of the method and the
+// path, for example "put_evoting_forms_formID".
+func operationID(op Operation) string {
+	replacer := strings.NewReplacer("/", "_", "{", "", "}", "", ".", "_")
+
+	return strings.ToLower(op.Method) + replacer.Replace(op.Path)
+}
@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	spec := Document(Operations)
+
+	require.Equal(t, openAPIVersion, spec.OpenAPI)
+
+	for _, op := range Operations {
+		item, found := spec.Paths[op.Path]
+		require.True(t, found, op.Path)
+		require.Contains(t, item, map[string]string{
+			http.MethodGet:    "get",
+			http.MethodPost:   "post",
+			http.MethodPut:    "put",
+			http.MethodDelete: "delete",
+		}[op.Method])
+	}
+
+	vote := spec.Paths["/evoting/forms/{formID}/vote"]["post"]
+	require.Equal(t, "#/components/schemas/SignedRequest",
+		vote.RequestBody.Content[contentType].Schema.Ref)
+	require.Equal(t, "#/components/schemas/CastVoteRequest", vote.SignedPayload.Ref)
+	require.Len(t, vote.Parameters, 1)
+	require.Equal(t, "formID", vote.Parameters[0].Name)
+
+	castVote := spec.Components.Schemas["CastVoteRequest"]
+	require.Equal(t, []string{"VoterID", "Ballot"}, castVote.Required)
+	require.Equal(t, "byte", castVote.Properties["Signature"].Format)
+
+	// the subjects of a form reference themselves
+	subject := spec.Components.Schemas["Subject"]
+	require.Equal(t, "#/components/schemas/Subject", subject.Properties["Subjects"].Items.Ref)
+
+	_, err := json.Marshal(spec)
+	require.NoError(t, err)
+}
+
+func TestSchemas_NameCollision(t *testing.T) {
+	type HTTPError struct {
+		Other string
+	}
+
+	s := newSchemas()
+
+	require.Equal(t, "#/components/schemas/HTTPError", s.of(types.HTTPError{}).Ref)
+	require.Equal(t, "#/components/schemas/openapi.HTTPError", s.of(HTTPError{}).Ref)
+	require.Equal(t, "#/components/schemas/HTTPError", s.of(&types.HTTPError{}).Ref)
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	Handler(w, httptest.NewRequest(http.MethodGet, Path, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec Spec
+
+	err := json.Unmarshal(w.Body.Bytes(), &spec)
+	require.NoError(t, err)
+	require.Len(t, spec.Paths["/evoting/forms"], 2)
+}
+
+func TestUndocumented(t *testing.T) {
+	handler := func(http.ResponseWriter, *http.Request) {}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/evoting/forms", handler).Methods("GET")
+	router.HandleFunc("/evoting/forms", handler).Methods("OPTIONS")
+	router.HandleFunc("/evoting/forms/{formID}/fake", handler).Methods("POST")
+
+	missing := Undocumented(router, Operations)
+	require.Equal(t, []string{"POST /evoting/forms/{formID}/fake"}, missing)
+}
@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/c4dt/d-voting/proxy/txnmanager"
+	"github.com/c4dt/d-voting/proxy/types"
+)
+
+// Operation describes an endpoint of the proxy with the Go types of its
+// request and response.
+type Operation struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	// Signed is true if the request is encapsulated in a signed request, in
+	// which case Request is the type of its payload.
+	Signed bool
+	// Request is a value of the type of the body, nil if there is no body
+	Request interface{}
+	// Response is a value of the type of the response, nil if there is no
+	// content
+	Response interface{}
+}
+
+const (
+	tagForms    = "forms"
+	tagAdmin    = "admin"
+	tagDKG      = "dkg"
+	tagShuffle  = "shuffle"
+	tagTxn      = "transactions"
+	tagHealth   = "health"
+	formIDPath  = "/evoting/forms/{formID}"
+	actorPath   = "/evoting/services/dkg/actors/{formID}"
+	shufflePath = "/evoting/services/shuffle/{formID}"
+)
+
+// Operations are all the endpoints of the proxy. An endpoint added to one of
+// the routers must be added here too, see Undocumented.
+var Operations = []Operation{
+	// forms
+	{Method: http.MethodPost, Path: "/evoting/forms", Tag: tagForms, Signed: true,
+		Summary:  "Create a form",
+		Request:  types.CreateFormRequest{},
+		Response: types.CreateFormResponse{}},
+	{Method: http.MethodGet, Path: "/evoting/forms", Tag: tagForms,
+		Summary:  "Get the light version of all the forms",
+		Response: types.GetFormsResponse{}},
+	{Method: http.MethodGet, Path: formIDPath, Tag: tagForms,
+		Summary:  "Get a form",
+		Response: types.GetFormResponse{}},
+	{Method: http.MethodGet, Path: formIDPath + "/ballots/{receiptHash}", Tag: tagForms,
+		Summary:  "Get where the ballot with the hash of a receipt is stored",
+		Response: types.GetBallotResponse{}},
+	{Method: http.MethodGet, Path: formIDPath + "/ballots/{voterID}/proof", Tag: tagForms,
+		Summary:  "Get the proof that the ballot of a voter is committed to by the form",
+		Response: types.BallotProofResponse{}},
+	{Method: http.MethodPut, Path: formIDPath, Tag: tagForms, Signed: true,
+		Summary:  "Open, close, combine the shares of, cancel or migrate a form",
+		Request:  types.UpdateFormRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodDelete, Path: formIDPath, Tag: tagForms, Signed: true,
+		Summary:  "Delete a form",
+		Request:  types.DeleteFormRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/vote", Tag: tagForms, Signed: true,
+		Summary:  "Cast a vote",
+		Request:  types.CastVoteRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/vote/signed", Tag: tagForms,
+		Summary:  "Cast a vote signed by the voter",
+		Request:  types.CastVoteRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/vote/anonymous", Tag: tagForms,
+		Summary:  "Cast a vote with a credential of the electoral roll",
+		Request:  types.AnonymousVoteRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/vote/audit", Tag: tagForms,
+		Summary:  "Audit a ballot by revealing its encryption randomness",
+		Request:  types.AuditBallotRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/addowner", Tag: tagForms, Signed: true,
+		Summary:  "Add an owner to a form",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/removeowner", Tag: tagForms, Signed: true,
+		Summary:  "Remove an owner from a form",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/addvoter", Tag: tagForms, Signed: true,
+		Summary:  "Add a voter to a form",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/removevoter", Tag: tagForms, Signed: true,
+		Summary:  "Remove a voter from a form",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: formIDPath + "/credentials", Tag: tagForms, Signed: true,
+		Summary:  "Add a credential to the electoral roll of an anonymous form",
+		Request:  types.AddCredentialRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+
+	// admins and operators
+	{Method: http.MethodPost, Path: "/evoting/addadmin", Tag: tagAdmin, Signed: true,
+		Summary:  "Add an admin",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: "/evoting/removeadmin", Tag: tagAdmin, Signed: true,
+		Summary:  "Remove an admin",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodGet, Path: "/evoting/adminlist", Tag: tagAdmin,
+		Summary:  "Get the admins",
+		Response: types.GetAdminsResponse{}},
+	{Method: http.MethodPost, Path: "/evoting/addoperator", Tag: tagAdmin, Signed: true,
+		Summary:  "Add an operator",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodPost, Path: "/evoting/removeoperator", Tag: tagAdmin, Signed: true,
+		Summary:  "Remove an operator",
+		Request:  types.PermissionOperationRequest{},
+		Response: txnmanager.TransactionClientInfo{}},
+	{Method: http.MethodGet, Path: "/evoting/operatorlist", Tag: tagAdmin,
+		Summary:  "Get the operators",
+		Response: types.GetOperatorsResponse{}},
+
+	{Method: http.MethodPost, Path: "/evoting/audit", Tag: tagAdmin, Signed: true,
+		Summary:  "Query the audit log of the privileged transactions seen by the node",
+		Request:  types.AuditLogRequest{},
+		Response: types.GetAuditLogResponse{}},
+
+	// transactions
+	{Method: http.MethodGet, Path: "/evoting/transactions/{token}", Tag: tagTxn,
+		Summary:  "Get the status of a transaction",
+		Response: txnmanager.TransactionClientInfo{}},
+
+	// DKG
+	{Method: http.MethodPost, Path: "/evoting/services/dkg/actors", Tag: tagDKG, Signed: true,
+		Summary: "Create the DKG actor of a form",
+		Request: types.NewDKGRequest{}},
+	{Method: http.MethodGet, Path: actorPath, Tag: tagDKG,
+		Summary:  "Get the status of the DKG actor of a form",
+		Response: types.GetActorInfo{}},
+	{Method: http.MethodPut, Path: actorPath, Tag: tagDKG, Signed: true,
+		Summary: "Set up the DKG or compute the public shares of a form",
+		Request: types.UpdateDKG{}},
+	{Method: http.MethodPost, Path: "/evoting/services/dkg/ceremonies", Tag: tagDKG, Signed: true,
+		Summary: "Create a key ceremony",
+		Request: types.NewCeremonyRequest{}},
+	{Method: http.MethodGet, Path: "/evoting/services/dkg/ceremonies", Tag: tagDKG,
+		Summary:  "Get the key ceremonies",
+		Response: types.GetCeremoniesResponse{}},
+	{Method: http.MethodGet, Path: "/evoting/services/dkg/ceremonies/{ceremonyID}", Tag: tagDKG,
+		Summary:  "Get a key ceremony",
+		Response: types.CeremonyInfo{}},
+	{Method: http.MethodPut, Path: "/evoting/services/dkg/ceremonies/{ceremonyID}", Tag: tagDKG,
+		Signed:  true,
+		Summary: "Set up a key ceremony",
+		Request: types.UpdateCeremony{}},
+
+	// shuffle
+	{Method: http.MethodGet, Path: shufflePath, Tag: tagShuffle,
+		Summary:  "Get the progress of the shuffle of a form",
+		Response: types.GetShuffleResponse{}},
+	{Method: http.MethodPut, Path: shufflePath, Tag: tagShuffle, Signed: true,
+		Summary: "Start the shuffle of a form",
+		Request: types.UpdateShuffle{}},
+	{Method: http.MethodGet, Path: shufflePath + "/ballots", Tag: tagShuffle,
+		Summary:  "Get the ballots to shuffle in the current round",
+		Response: types.GetShuffleBallotsResponse{}},
+	{Method: http.MethodPost, Path: shufflePath + "/ballots", Tag: tagShuffle,
+		Summary:  "Submit the shuffle of a mixer",
+		Request:  types.SubmitShuffleRequest{},
+		Response: types.SubmitShuffleResponse{}},
+
+	// health
+	{Method: http.MethodGet, Path: "/healthz", Tag: tagHealth,
+		Summary:  "Get the state of the node",
+		Response: types.HealthResponse{}},
+	{Method: http.MethodGet, Path: "/readyz", Tag: tagHealth,
+		Summary:  "Get the state of the node, with a 503 status if it is not ready",
+		Response: types.HealthResponse{}},
+
+	// this document
+	{Method: http.MethodGet, Path: Path, Tag: tagForms,
+		Summary: "Get the OpenAPI document of the proxy"},
+}
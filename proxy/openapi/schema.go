@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Schema is an OpenAPI schema object. Only the parts needed to describe the
+// types of the proxy are supported.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// schemas builds the schemas of Go types the way encoding/json marshals them.
+// The named structs are added to the components and referenced, which allows
+// recursive types such as the subjects of a form.
+type schemas struct {
+	components map[string]*Schema
+	// names are the component names given to the types, as two packages may
+	// have a type with the same name.
+	names map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema of the value's type.
+func (s *schemas) of(value interface{}) *Schema {
+	return s.typeOf(reflect.TypeOf(value))
+}
+
+func (s *schemas) typeOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.typeOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+
+		return &Schema{Type: "integer", Format: intFormat(t)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json marshals the bytes as a base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: s.typeOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.typeOf(t.Elem())}
+	case reflect.Struct:
+		return s.structOf(t)
+	default:
+		// interfaces can hold anything
+		return &Schema{}
+	}
+}
+
+func (s *schemas) structOf(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return s.properties(t)
+	}
+
+	name, found := s.names[t]
+	if !found {
+		name = s.componentName(t)
+		s.names[t] = name
+
+		// the name is reserved before the fields are described, so that a
+		// type referencing itself ends up with a reference.
+		s.components[name] = &Schema{}
+		*s.components[name] = *s.properties(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (s *schemas) componentName(t reflect.Type) string {
+	name := t.Name()
+
+	_, taken := s.components[name]
+	if !taken {
+		return name
+	}
+
+	pkg := t.PkgPath()
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+}
+
+func (s *schemas) properties(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		schema.Properties[name] = s.typeOf(field.Type)
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// jsonName returns the name of the field in JSON, whether it is omitted when
+// empty, and whether it is never marshalled.
+func jsonName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	omitEmpty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+func intFormat(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		return "int64"
+	default:
+		return "int32"
+	}
+}
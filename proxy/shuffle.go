@@ -2,21 +2,26 @@ package proxy
 
 import (
 	"encoding/hex"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
 	"github.com/c4dt/d-voting/proxy/types"
 	shuffleSrv "github.com/c4dt/d-voting/services/shuffle"
 	"github.com/gorilla/mux"
-	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
 	"golang.org/x/xerrors"
 )
 
-// NewShuffle returns a new initialized shuffle
-func NewShuffle(actor shuffleSrv.Actor, pk kyber.Point) Shuffle {
+// NewShuffle returns a new initialized shuffle. The limiter can be nil.
+func NewShuffle(actor shuffleSrv.Actor, keys *KeyRing, limiter *Limiter) Shuffle {
 	return shuffle{
-		actor: actor,
-		pk:    pk,
+		actor:    actor,
+		verifier: NewVerifier(keys, limiter, SignedRequestWindow),
+		context:  jsonserde.NewContext(),
+		txFac:    etypes.NewTransactionFactory(etypes.CiphervoteFactory{}),
 	}
 }
 
@@ -26,25 +31,21 @@ func NewShuffle(actor shuffleSrv.Actor, pk kyber.Point) Shuffle {
 type shuffle struct {
 	// actor is the shuffle actor
 	actor shuffleSrv.Actor
-	// pk is the public key of the proxy
-	pk kyber.Point
+	// verifier verifies the requests signed by the trusted keys
+	verifier *Verifier
+
+	context serde.Context
+	txFac   serde.Factory
 }
 
 // EditShuffle implements proxy.Shuffle
 func (s shuffle) EditShuffle(w http.ResponseWriter, r *http.Request) {
 	var req types.UpdateShuffle
 
-	// Read the request
-	signed, err := types.NewSignedRequest(r.Body)
-	if err != nil {
-		InternalError(w, r, newSignedErr(err), nil)
-		return
-	}
-
-	// Verify the signature and get the request
-	err = signed.GetAndVerify(s.pk, &req)
+	// get the request and verify the signature
+	err := s.verifier.GetAndVerify(r, ScopeShuffle, &req)
 	if err != nil {
-		InternalError(w, r, getSignedErr(err), nil)
+		SignedError(w, r, err, nil)
 		return
 	}
 
@@ -52,7 +53,7 @@ func (s shuffle) EditShuffle(w http.ResponseWriter, r *http.Request) {
 
 	// check if the formID is present
 	if vars == nil || vars["formID"] == "" {
-		http.Error(w, fmt.Sprintf("formID not found: %v", vars), http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("formID not found: %v", vars), nil)
 		return
 	}
 
@@ -61,16 +62,29 @@ func (s shuffle) EditShuffle(w http.ResponseWriter, r *http.Request) {
 
 	formIDBuf, err := hex.DecodeString(formID)
 	if err != nil {
-		http.Error(w, "failed to decode formID: "+formID, http.StatusInternalServerError)
+		BadRequestError(w, r, xerrors.Errorf("failed to decode formID: %v", err), nil)
 		return
 	}
 
 	switch req.Action {
 	// shuffle the ballots
 	case "shuffle":
-		err = s.actor.Shuffle(formIDBuf, userID)
+		// As the shuffle can be long, it runs asynchronously. One can fetch
+		// the status of the shuffle to know when it is over.
+		err = s.actor.StartShuffle(formIDBuf, userID)
+		if errors.Is(err, etypes.ErrFormNotFound) {
+			FormNotFoundErr(w, r, formID, nil)
+			return
+		}
+
+		if errors.Is(err, shuffleSrv.ErrWrongStatus) {
+			CodedError(w, r, xerrors.Errorf("failed to shuffle: %v", err),
+				http.StatusConflict, types.ErrCodeWrongStatus, nil)
+			return
+		}
+
 		if err != nil {
-			http.Error(w, "failed to shuffle: "+err.Error(), http.StatusInternalServerError)
+			InternalError(w, r, xerrors.Errorf("failed to shuffle: %v", err), nil)
 			return
 		}
 	default:
@@ -78,3 +92,238 @@ func (s shuffle) EditShuffle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// Shuffle implements proxy.Shuffle
+// Send the progress of the shuffle of a form
+func (s shuffle) Shuffle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// check if the formID is present
+	if vars == nil || vars["formID"] == "" {
+		BadRequestError(w, r, xerrors.Errorf("formID not found: %v", vars), nil)
+		return
+	}
+
+	formID := vars["formID"]
+
+	formIDBuf, err := hex.DecodeString(formID)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode formID: %v", err), nil)
+		return
+	}
+
+	status, err := s.actor.Status(formIDBuf)
+	if errors.Is(err, etypes.ErrFormNotFound) {
+		FormNotFoundErr(w, r, formID, nil)
+		return
+	}
+
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get shuffle status: %v", err), nil)
+		return
+	}
+
+	response := types.GetShuffleResponse{
+		Status:       int(status.Status),
+		Round:        status.Round,
+		Threshold:    status.Threshold,
+		Batches:      status.Batches,
+		BatchCount:   status.BatchCount,
+		Contributors: make([]types.ShuffleContribution, len(status.Contributors)),
+		Waiting:      status.Waiting,
+		Transactions: make([]types.ShuffleTransaction, len(status.Transactions)),
+	}
+
+	// if the status has an error, return it
+	if status.Err != nil {
+		response.Error = types.HTTPError{
+			Title:   "Shuffle failed",
+			Code:    0,
+			Message: status.Err.Error(),
+		}
+	}
+
+	for i, contribution := range status.Contributors {
+		response.Contributors[i] = types.ShuffleContribution{
+			Round:     contribution.Round,
+			Node:      contribution.Node,
+			PublicKey: hex.EncodeToString(contribution.PublicKey),
+		}
+	}
+
+	for i, tx := range status.Transactions {
+		response.Transactions[i] = types.ShuffleTransaction{
+			ID:         hex.EncodeToString(tx.ID),
+			Round:      tx.Round,
+			BatchIndex: tx.BatchIndex,
+			PublicKey:  hex.EncodeToString(tx.PublicKey),
+			Status:     int(tx.Status),
+			Reason:     tx.Reason,
+		}
+	}
+
+	if !status.StartedAt.IsZero() {
+		response.StartedAt = status.StartedAt.Unix()
+	}
+
+	if !status.EstimatedEnd.IsZero() {
+		response.EstimatedEnd = status.EstimatedEnd.Unix()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode the response
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to write response: %v", err), nil)
+		return
+	}
+}
+
+// Ballots implements proxy.Shuffle
+// Send the ballots to shuffle in the current round, for the mixers
+func (s shuffle) Ballots(w http.ResponseWriter, r *http.Request) {
+	formIDBuf, err := extractFormID(r)
+	if err != nil {
+		BadRequestError(w, r, err, nil)
+		return
+	}
+
+	round, err := s.actor.GetRound(formIDBuf)
+	if errors.Is(err, etypes.ErrFormNotFound) {
+		FormNotFoundErr(w, r, hex.EncodeToString(formIDBuf), nil)
+		return
+	}
+
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to get round: %v", err), nil)
+		return
+	}
+
+	response := types.GetShuffleBallotsResponse{
+		Round:      round.Index,
+		Threshold:  round.Threshold,
+		FormStatus: int(round.FormStatus),
+		Ballots:    make([]types.CiphervoteJSON, len(round.Ciphervotes)),
+		Shufflers:  make([]string, len(round.Shufflers)),
+		Mixers:     make([]string, len(round.Mixers)),
+		Batched:    round.Batched,
+	}
+
+	if round.Pubkey != nil {
+		pubkey, err := round.Pubkey.MarshalBinary()
+		if err != nil {
+			InternalError(w, r, xerrors.Errorf("failed to marshal pubkey: %v", err), nil)
+			return
+		}
+
+		response.Pubkey = hex.EncodeToString(pubkey)
+	}
+
+	for i, ciphervote := range round.Ciphervotes {
+		response.Ballots[i] = make(types.CiphervoteJSON, len(ciphervote))
+
+		for j, egpair := range ciphervote {
+			k, err := egpair.K.MarshalBinary()
+			if err != nil {
+				InternalError(w, r, xerrors.Errorf("failed to marshal K: %v", err), nil)
+				return
+			}
+
+			c, err := egpair.C.MarshalBinary()
+			if err != nil {
+				InternalError(w, r, xerrors.Errorf("failed to marshal C: %v", err), nil)
+				return
+			}
+
+			response.Ballots[i][j] = types.EGPairJSON{K: k, C: c}
+		}
+	}
+
+	for i, shuffler := range round.Shufflers {
+		response.Shufflers[i] = hex.EncodeToString(shuffler)
+	}
+
+	for i, mixer := range round.Mixers {
+		response.Mixers[i] = hex.EncodeToString(mixer)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to write response: %v", err), nil)
+		return
+	}
+}
+
+// SubmitBallots implements proxy.Shuffle
+// Submit the shuffle of a mixer. The request is not signed by the proxy key, as
+// the shuffle is signed by the mixer.
+func (s shuffle) SubmitBallots(w http.ResponseWriter, r *http.Request) {
+	var req types.SubmitShuffleRequest
+
+	formIDBuf, err := extractFormID(r)
+	if err != nil {
+		BadRequestError(w, r, err, nil)
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to decode request: %v", err), nil)
+		return
+	}
+
+	msg, err := s.txFac.Deserialize(s.context, req.ShuffleBallots)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to deserialize shuffle: %v", err), nil)
+		return
+	}
+
+	shuffleBallots, ok := msg.(etypes.ShuffleBallots)
+	if !ok {
+		BadRequestError(w, r, xerrors.Errorf("expected shuffle ballots, got %T", msg), nil)
+		return
+	}
+
+	if shuffleBallots.FormID != hex.EncodeToString(formIDBuf) {
+		BadRequestError(w, r, xerrors.Errorf("shuffle of another form: %s",
+			shuffleBallots.FormID), nil)
+		return
+	}
+
+	txID, err := s.actor.SubmitShuffle(shuffleBallots)
+	if err != nil {
+		BadRequestError(w, r, xerrors.Errorf("failed to submit shuffle: %v", err), nil)
+		return
+	}
+
+	response := types.SubmitShuffleResponse{
+		TxID: hex.EncodeToString(txID),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		InternalError(w, r, xerrors.Errorf("failed to write response: %v", err), nil)
+		return
+	}
+}
+
+// extractFormID returns the decoded formID of the request's URL
+func extractFormID(r *http.Request) ([]byte, error) {
+	vars := mux.Vars(r)
+
+	if vars == nil || vars["formID"] == "" {
+		return nil, xerrors.Errorf("formID not found: %v", vars)
+	}
+
+	formIDBuf, err := hex.DecodeString(vars["formID"])
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode formID: %v", err)
+	}
+
+	return formIDBuf, nil
+}
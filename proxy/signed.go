@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// SignedRequestWindow is the maximum difference between the timestamp of
+	// a signed request and the time of the proxy.
+	SignedRequestWindow = time.Minute * 5
+
+	// maxNonceLen is the maximum length of the nonce of a signed request.
+	maxNonceLen = 64
+)
+
+// rejectedErr is the error returned when a signed request is rejected. It
+// holds the HTTP status that describes the reason of the rejection.
+type rejectedErr struct {
+	code    uint
+	title   string
+	errCode types.ErrorCode
+	err     error
+}
+
+// Error implements error.
+func (e rejectedErr) Error() string {
+	return e.err.Error()
+}
+
+func newRejectedErr(code uint, title string, errCode types.ErrorCode, err error) rejectedErr {
+	return rejectedErr{
+		code:    code,
+		title:   title,
+		errCode: errCode,
+		err:     err,
+	}
+}
+
+// Verifier verifies the signed requests received by the proxy. On top of the
+// signature, it checks that a request has been signed by a key allowed to, for
+// the endpoint that receives it, recently, and that it has not been received
+// before.
+type Verifier struct {
+	sync.Mutex
+
+	keys    *KeyRing
+	limiter *Limiter
+	window  time.Duration
+
+	// nonces are the nonces of the requests already received, with the time
+	// after which the requests are not fresh anymore, and can therefore be
+	// forgotten.
+	nonces    map[string]time.Time
+	lastPurge time.Time
+
+	now func() time.Time
+}
+
+// NewVerifier returns a new verifier of the requests signed by the trusted
+// keys. The timestamp of a request must be within the window. The limiter
+// limits the rate of the requests of each key, it can be nil.
+func NewVerifier(keys *KeyRing, limiter *Limiter, window time.Duration) *Verifier {
+	return &Verifier{
+		keys:      keys,
+		limiter:   limiter,
+		window:    window,
+		nonces:    make(map[string]time.Time),
+		lastPurge: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// GetAndVerify reads the signed request from the body of r, verifies it and
+// unmarshals its payload in el, which MUST be a pointer. The request must be
+// signed by a key allowed to sign requests of the scope. The error should be
+// written with SignedError.
+func (v *Verifier) GetAndVerify(r *http.Request, scope Scope, el interface{}) error {
+	signed, err := types.NewSignedRequest(r.Body)
+	if err != nil {
+		return newRejectedErr(http.StatusBadRequest, "bad request", types.ErrCodeBadRequest,
+			xerrors.Errorf("failed to decode signed request: %v", err))
+	}
+
+	if signed.Timestamp == 0 || signed.Nonce == "" || signed.Method == "" ||
+		signed.Path == "" {
+
+		return newRejectedErr(http.StatusBadRequest, "bad request", types.ErrCodeBadRequest,
+			xerrors.New("timestamp, nonce, method and path must be set"))
+	}
+
+	if len(signed.Nonce) > maxNonceLen {
+		return newRejectedErr(http.StatusBadRequest, "bad request", types.ErrCodeBadRequest,
+			xerrors.Errorf("nonce too long: %d > %d", len(signed.Nonce), maxNonceLen))
+	}
+
+	key, err := v.keys.Verify(scope, signed.Verify)
+	if err != nil {
+		return err
+	}
+
+	if signed.Method != r.Method || signed.Path != r.URL.Path {
+		return newRejectedErr(http.StatusForbidden, "not authorized / forbidden",
+			types.ErrCodeNotAuthorized,
+			xerrors.Errorf("request signed for %s %s", signed.Method, signed.Path))
+	}
+
+	err = v.limiter.allowKey(key.Name)
+	if err != nil {
+		return err
+	}
+
+	err = v.checkFresh(signed.Timestamp, signed.Nonce)
+	if err != nil {
+		return err
+	}
+
+	err = signed.GetMessage(el)
+	if err != nil {
+		return newRejectedErr(http.StatusBadRequest, "bad request", types.ErrCodeBadRequest,
+			xerrors.Errorf("failed to get message: %v", err))
+	}
+
+	return nil
+}
+
+// checkFresh checks that the timestamp is within the window and that the nonce
+// has not been seen before. The nonce is then recorded.
+func (v *Verifier) checkFresh(timestamp int64, nonce string) error {
+	v.Lock()
+	defer v.Unlock()
+
+	now := v.now()
+	signedAt := time.Unix(timestamp, 0)
+
+	if signedAt.Before(now.Add(-v.window)) {
+		return newRejectedErr(http.StatusUnauthorized, "unauthorized",
+			types.ErrCodeExpiredRequest,
+			xerrors.Errorf("request expired: signed at %s", signedAt.UTC()))
+	}
+
+	if signedAt.After(now.Add(v.window)) {
+		return newRejectedErr(http.StatusUnauthorized, "unauthorized",
+			types.ErrCodeExpiredRequest,
+			xerrors.Errorf("request from the future: signed at %s", signedAt.UTC()))
+	}
+
+	// the nonces of the requests that are not fresh anymore are not needed,
+	// as these requests are rejected anyway.
+	if now.Sub(v.lastPurge) > v.window {
+		for n, expiry := range v.nonces {
+			if now.After(expiry) {
+				delete(v.nonces, n)
+			}
+		}
+
+		v.lastPurge = now
+	}
+
+	_, found := v.nonces[nonce]
+	if found {
+		return newRejectedErr(http.StatusConflict, "conflict", types.ErrCodeReplayedRequest,
+			xerrors.Errorf("request already received: nonce %s", nonce))
+	}
+
+	v.nonces[nonce] = signedAt.Add(v.window)
+
+	return nil
+}
+
+// SignedError sets the error of a signed request rejected by the verifier. Any
+// other error is an internal server error.
+func SignedError(w http.ResponseWriter, r *http.Request, err error, args map[string]interface{}) {
+	rejected, ok := err.(rejectedErr)
+	if !ok {
+		InternalError(w, r, err, args)
+		return
+	}
+
+	httpErr(w, r, rejected, rejected.code, rejected.title, rejected.errCode, args)
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestVerifier_GetAndVerify(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pk := suite.Point().Mul(secret, nil)
+
+	verifier := NewVerifier(trustAll(pk), nil, time.Minute)
+
+	signed, err := types.SignRequest(secret, http.MethodPut, "/evoting/forms/abcd",
+		types.UpdateFormRequest{Action: "close"})
+	require.NoError(t, err)
+
+	newRequest := func(method, path string, signed types.SignedRequest) *http.Request {
+		buf, err := json.Marshal(signed)
+		require.NoError(t, err)
+
+		return httptest.NewRequest(method, path, bytes.NewReader(buf))
+	}
+
+	var req types.UpdateFormRequest
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	require.NoError(t, err)
+	require.Equal(t, "close", req.Action)
+
+	// the same request is replayed
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusConflict, "request already received")
+
+	// the request is sent to another endpoint
+	err = verifier.GetAndVerify(newRequest(http.MethodPost, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusForbidden, "request signed for PUT /evoting/forms/abcd")
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/ffff", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusForbidden, "request signed for PUT /evoting/forms/abcd")
+
+	// the request is tampered with
+	tampered := signed
+	tampered.Nonce = "deadbeef"
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", tampered), ScopeForms, &req)
+	requireRejected(t, err, http.StatusUnauthorized, "signature not made by any of the 1 trusted keys")
+
+	// the request is too old, or from the future
+	verifier.now = func() time.Time { return time.Now().Add(time.Minute * 2) }
+
+	signed, err = types.SignRequest(secret, http.MethodPut, "/evoting/forms/abcd",
+		types.UpdateFormRequest{Action: "close"})
+	require.NoError(t, err)
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusUnauthorized, "request expired")
+
+	verifier.now = func() time.Time { return time.Now().Add(-time.Minute * 2) }
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusUnauthorized, "request from the future")
+
+	// the request has no metadata
+	signed.Nonce = ""
+
+	err = verifier.GetAndVerify(newRequest(http.MethodPut, "/evoting/forms/abcd", signed), ScopeForms, &req)
+	requireRejected(t, err, http.StatusBadRequest, "timestamp, nonce, method and path must be set")
+
+	r := httptest.NewRequest(http.MethodPut, "/evoting/forms/abcd", bytes.NewBufferString("{"))
+
+	err = verifier.GetAndVerify(r, ScopeForms, &req)
+	requireRejected(t, err, http.StatusBadRequest, "failed to decode signed request")
+}
+
+func TestVerifier_PurgeNonces(t *testing.T) {
+	verifier := NewVerifier(NewKeyRing(), nil, time.Minute)
+
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+
+	err := verifier.checkFresh(now.Unix(), "aa")
+	require.NoError(t, err)
+
+	err = verifier.checkFresh(now.Unix(), "aa")
+	require.Error(t, err)
+
+	// the nonce is forgotten once the request is not fresh anymore
+	now = now.Add(time.Minute * 3)
+
+	err = verifier.checkFresh(now.Unix(), "bb")
+	require.NoError(t, err)
+	require.Len(t, verifier.nonces, 1)
+}
+
+func TestSignedError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+
+	SignedError(w, r, newRejectedErr(http.StatusConflict, "conflict",
+		types.ErrCodeReplayedRequest, fakeErr), nil)
+	require.Equal(t, http.StatusConflict, w.Code)
+	requireErrorCode(t, w, types.ErrCodeReplayedRequest)
+
+	w = httptest.NewRecorder()
+
+	SignedError(w, r, fakeErr, nil)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	requireErrorCode(t, w, types.ErrCodeInternal)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+var fakeErr = xerrors.New("fake error")
+
+func requireErrorCode(t *testing.T, w *httptest.ResponseRecorder, code types.ErrorCode) {
+	var httpErr types.HTTPError
+
+	err := json.Unmarshal(w.Body.Bytes(), &httpErr)
+	require.NoError(t, err)
+	require.Equal(t, code, httpErr.ErrorCode)
+}
+
+func requireRejected(t *testing.T, err error, code uint, msg string) {
+	rejected, ok := err.(rejectedErr)
+	require.True(t, ok, err)
+	require.Equal(t, code, rejected.code)
+	require.Contains(t, rejected.Error(), msg)
+}
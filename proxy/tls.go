@@ -0,0 +1,293 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/cli"
+	"golang.org/x/xerrors"
+)
+
+// certsCheckInterval is the minimum time between two checks of the files of
+// the certificates for changes.
+const certsCheckInterval = time.Second * 5
+
+// shutdownTimeout is the time given to the requests in progress to finish when
+// the server is stopped.
+const shutdownTimeout = time.Second * 5
+
+// TLSFlags are the flags of the TLS configuration of the proxy, see
+// NewTLSConfigFromFlags.
+var TLSFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:     "proxycert",
+		Usage:    "the PEM certificate of the proxy, which is then served with TLS",
+		Required: false,
+	},
+	cli.StringFlag{
+		Name:     "proxycertkey",
+		Usage:    "the PEM private key of the certificate of the proxy",
+		Required: false,
+	},
+	cli.StringFlag{
+		Name: "proxyclientca",
+		Usage: "the PEM certificates of the authorities of the client " +
+			"certificates, the clients must then present one",
+		Required: false,
+	},
+}
+
+// TLSConfig is the TLS configuration of the proxy. The files are read again
+// once they change, which allows to renew the certificates without restarting
+// the node.
+type TLSConfig struct {
+	// CertFile is the certificate of the proxy, followed by its chain
+	CertFile string
+	// KeyFile is the private key of the certificate
+	KeyFile string
+	// ClientCAFile is the certificates of the authorities trusted to issue
+	// the client certificates. The clients are not authenticated if it is
+	// empty.
+	ClientCAFile string
+}
+
+// NewTLSConfigFromFlags returns the TLS configuration defined by the flags of
+// TLSFlags.
+func NewTLSConfigFromFlags(flags cli.Flags) TLSConfig {
+	return TLSConfig{
+		CertFile:     flags.String("proxycert"),
+		KeyFile:      flags.String("proxycertkey"),
+		ClientCAFile: flags.String("proxyclientca"),
+	}
+}
+
+// Enabled returns true if the proxy must be served with TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.ClientCAFile != ""
+}
+
+// TLSServer is an HTTPS server of the proxy.
+//
+// - implements proxy.Proxy
+type TLSServer struct {
+	sync.Mutex
+
+	mux        *http.ServeMux
+	server     *http.Server
+	ln         net.Listener
+	listenAddr string
+}
+
+// NewTLSServer returns a new HTTPS server that listens on the address. The
+// files of the configuration are loaded right away, so that an invalid
+// configuration is reported before the node starts.
+func NewTLSServer(listenAddr string, config TLSConfig) (*TLSServer, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, xerrors.New("the certificate and its key must be set")
+	}
+
+	certs, err := newCertLoader(config)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load certificates: %v", err)
+	}
+
+	mux := http.NewServeMux()
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetConfigForClient: certs.getConfig,
+		},
+	}
+
+	return &TLSServer{
+		mux:        mux,
+		server:     server,
+		listenAddr: listenAddr,
+	}, nil
+}
+
+// Listen implements proxy.Proxy. It blocks until the server is stopped.
+func (s *TLSServer) Listen() {
+	ln, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		dela.Logger.Error().Err(err).Msgf("failed to listen on %s", s.listenAddr)
+		return
+	}
+
+	s.Lock()
+	s.ln = ln
+	s.Unlock()
+
+	dela.Logger.Info().Msgf("proxy served with TLS on %s", ln.Addr())
+
+	err = s.server.Serve(tls.NewListener(ln, s.server.TLSConfig))
+	if err != nil && err != http.ErrServerClosed {
+		dela.Logger.Error().Err(err).Msg("proxy server stopped")
+	}
+}
+
+// Stop implements proxy.Proxy.
+func (s *TLSServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		dela.Logger.Error().Err(err).Msg("failed to shut down the proxy server")
+	}
+}
+
+// RegisterHandler implements proxy.Proxy.
+func (s *TLSServer) RegisterHandler(path string,
+	handler func(http.ResponseWriter, *http.Request)) {
+
+	s.mux.HandleFunc(path, handler)
+}
+
+// GetAddr implements proxy.Proxy. It returns nil until the server listens.
+func (s *TLSServer) GetAddr() net.Addr {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.ln == nil {
+		return nil
+	}
+
+	return s.ln.Addr()
+}
+
+// certLoader holds the TLS configuration given to the clients, and builds it
+// again when one of the files changes.
+type certLoader struct {
+	sync.Mutex
+
+	config  TLSConfig
+	current *tls.Config
+
+	modTimes  []time.Time
+	lastCheck time.Time
+}
+
+func newCertLoader(config TLSConfig) (*certLoader, error) {
+	l := &certLoader{
+		config: config,
+	}
+
+	err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// getConfig returns the current configuration, for tls.Config's
+// GetConfigForClient. The files are read again if they changed.
+func (l *certLoader) getConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	if time.Since(l.lastCheck) > certsCheckInterval {
+		l.lastCheck = time.Now()
+
+		modTimes, err := l.modTimesOf()
+		if err != nil {
+			dela.Logger.Warn().Err(err).Msg("failed to check the certificates")
+		} else if !sameTimes(modTimes, l.modTimes) {
+			// the previous configuration is kept if the new files are
+			// invalid, which may happen while they are being replaced.
+			err = l.load()
+			if err != nil {
+				dela.Logger.Warn().Err(err).Msg("failed to reload the certificates")
+			} else {
+				dela.Logger.Info().Msgf("reloaded the certificate %s", l.config.CertFile)
+			}
+		}
+	}
+
+	return l.current, nil
+}
+
+// load reads the files and builds the configuration. The lock must be held or
+// the loader not shared yet.
+func (l *certLoader) load() error {
+	modTimes, err := l.modTimesOf()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.config.CertFile, l.config.KeyFile)
+	if err != nil {
+		return xerrors.Errorf("failed to load certificate: %v", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if l.config.ClientCAFile != "" {
+		buf, err := os.ReadFile(l.config.ClientCAFile)
+		if err != nil {
+			return xerrors.Errorf("failed to read client CA: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return xerrors.Errorf("no certificate found in %s", l.config.ClientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	l.current = config
+	l.modTimes = modTimes
+
+	return nil
+}
+
+// modTimesOf returns the modification times of the files.
+func (l *certLoader) modTimesOf() ([]time.Time, error) {
+	paths := []string{l.config.CertFile, l.config.KeyFile}
+	if l.config.ClientCAFile != "" {
+		paths = append(paths, l.config.ClientCAFile)
+	}
+
+	modTimes := make([]time.Time, len(paths))
+
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to stat file: %v", err)
+		}
+
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
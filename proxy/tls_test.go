@@ -0,0 +1,233 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSServer_Listen(t *testing.T) {
+	dir := t.TempDir()
+
+	config := TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+
+	serverCert := writeCert(t, config.CertFile, config.KeyFile, "proxy")
+
+	server, err := NewTLSServer("127.0.0.1:0", config)
+	require.NoError(t, err)
+
+	server.RegisterHandler("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	go server.Listen()
+	defer server.Stop()
+
+	addr := waitAddr(t, server)
+
+	resp, err := newTLSClient(serverCert, nil).Get("https://" + addr + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the proxy doesn't answer plain HTTP
+	resp, err = http.Get("http://" + addr + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTLSServer_ClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	config := TLSConfig{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: filepath.Join(dir, "client.pem"),
+	}
+
+	serverCert := writeCert(t, config.CertFile, config.KeyFile, "proxy")
+	writeCert(t, config.ClientCAFile, filepath.Join(dir, "client.key"), "backend")
+
+	clientCert, err := tls.LoadX509KeyPair(config.ClientCAFile, filepath.Join(dir, "client.key"))
+	require.NoError(t, err)
+
+	server, err := NewTLSServer("127.0.0.1:0", config)
+	require.NoError(t, err)
+
+	server.RegisterHandler("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	go server.Listen()
+	defer server.Stop()
+
+	addr := waitAddr(t, server)
+
+	resp, err := newTLSClient(serverCert, &clientCert).Get("https://" + addr + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = newTLSClient(serverCert, nil).Get("https://" + addr + "/")
+	require.Error(t, err)
+}
+
+func TestNewTLSServer_Invalid(t *testing.T) {
+	_, err := NewTLSServer("127.0.0.1:0", TLSConfig{ClientCAFile: "ca.pem"})
+	require.EqualError(t, err, "the certificate and its key must be set")
+
+	dir := t.TempDir()
+
+	_, err = NewTLSServer("127.0.0.1:0", TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load certificates: failed to stat file")
+
+	config := TLSConfig{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: filepath.Join(dir, "key.pem"),
+	}
+
+	writeCert(t, config.CertFile, config.KeyFile, "proxy")
+
+	_, err = NewTLSServer("127.0.0.1:0", config)
+	require.EqualError(t, err, "failed to load certificates: no certificate found in "+
+		config.ClientCAFile)
+}
+
+func TestCertLoader_Reload(t *testing.T) {
+	dir := t.TempDir()
+
+	config := TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+
+	first := writeCert(t, config.CertFile, config.KeyFile, "first")
+
+	loader, err := newCertLoader(config)
+	require.NoError(t, err)
+
+	requireCert(t, loader, first)
+
+	second := writeCert(t, config.CertFile, config.KeyFile, "second")
+	touch(t, config.CertFile, time.Hour)
+
+	// the files are not checked again right away
+	requireCert(t, loader, first)
+
+	loader.lastCheck = time.Time{}
+	requireCert(t, loader, second)
+
+	// the previous certificate is kept if the new one is invalid
+	err = os.WriteFile(config.KeyFile, []byte("invalid"), os.ModePerm)
+	require.NoError(t, err)
+	touch(t, config.KeyFile, 2*time.Hour)
+
+	loader.lastCheck = time.Time{}
+	requireCert(t, loader, second)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// writeCert writes a new self-signed certificate and its key, and returns the
+// certificate.
+func writeCert(t *testing.T, certFile, keyFile, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		os.ModePerm)
+	require.NoError(t, err)
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		os.ModePerm)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+// touch changes the modification time of the file, so that the change is seen
+// even if the file is written twice within the resolution of the clock of the
+// file system.
+func touch(t *testing.T, path string, shift time.Duration) {
+	modTime := time.Now().Add(shift)
+
+	err := os.Chtimes(path, modTime, modTime)
+	require.NoError(t, err)
+}
+
+func requireCert(t *testing.T, loader *certLoader, cert *x509.Certificate) {
+	config, err := loader.getConfig(nil)
+	require.NoError(t, err)
+	require.Len(t, config.Certificates, 1)
+	require.Equal(t, cert.Raw, config.Certificates[0].Certificate[0])
+}
+
+func newTLSClient(serverCert *x509.Certificate, clientCert *tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCert)
+
+	config := &tls.Config{
+		RootCAs: pool,
+	}
+
+	if clientCert != nil {
+		config.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: config},
+		Timeout:   5 * time.Second,
+	}
+}
+
+func waitAddr(t *testing.T, server *TLSServer) string {
+	for i := 0; i < 100 && server.GetAddr() == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NotNil(t, server.GetAddr())
+
+	return server.GetAddr().String()
+}
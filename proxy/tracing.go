@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/c4dt/d-voting/internal/tracing"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a span for each request, child of the trace
+// context of the request if any. The span is in the context of the request,
+// so that the transactions it submits carry it. The requests about a form
+// are recorded as the last ones of the form, to which the streams of the
+// protocols are linked. It can be used with mux.Router.Use.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(),
+			propagation.HeaderCarrier(r.Header))
+
+		route := routeOf(r)
+
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+			))
+		defer span.End()
+
+		vars := mux.Vars(r)
+
+		for _, key := range []string{"formID", "ceremonyID"} {
+			id := vars[key]
+			if id != "" {
+				span.SetAttributes(tracing.FormAttr.String(id))
+				tracing.LinkForm(ctx, id)
+			}
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
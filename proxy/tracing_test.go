@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c4dt/d-voting/internal/tracing"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	provider, err := tracing.Start(sdktrace.NewSimpleSpanProcessor(exporter))
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background())
+
+	var handlerSpan trace.SpanContext
+
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.HandleFunc("/evoting/forms/{formID}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods(http.MethodGet)
+
+	parent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	r := httptest.NewRequest(http.MethodGet, "/evoting/forms/deadbeef", nil)
+	r.Header.Set("traceparent", parent)
+
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "GET /evoting/forms/{formID}", span.Name)
+	require.Equal(t, trace.SpanKindServer, span.SpanKind)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext.TraceID().String())
+	require.Equal(t, "b7ad6b7169203331", span.Parent.SpanID().String())
+	require.Contains(t, span.Attributes, tracing.FormAttr.String("deadbeef"))
+	require.Contains(t, span.Attributes, attribute.Int("http.response.status_code", 500))
+	require.Equal(t, codes.Error, span.Status.Code)
+
+	// the handler gets the span in the context of the request, and the
+	// streams about the form are linked to it
+	require.Equal(t, span.SpanContext, handlerSpan)
+
+	_, stream := tracing.StartStream(context.Background(), "dkg-setup", "deadbeef")
+	stream.End()
+
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Len(t, spans[1].Links, 1)
+	require.Equal(t, span.SpanContext, spans[1].Links[0].SpanContext)
+}
@@ -0,0 +1,160 @@
+package txnmanager
+
+import (
+	"context"
+	"sync"
+
+	dvoting "github.com/c4dt/d-voting"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/txn"
+)
+
+var (
+	// PromRejectedTransactions counts the transactions rejected by the smart
+	// contract in the new blocks, by the code of the reason of the rejection.
+	PromRejectedTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dvoting_rejected_transactions_total",
+		Help: "transactions rejected by the smart contract",
+	}, []string{"reason"})
+)
+
+func init() {
+	dvoting.PromCollectors = append(dvoting.PromCollectors, PromRejectedTransactions)
+}
+
+// blockReader is the part of the block store needed to index the blocks that
+// were stored before the index started.
+type blockReader interface {
+	GetByIndex(index uint64) (types.BlockLink, error)
+}
+
+// txResult is the result of a transaction in a block, as found in the block
+// store and in the events of the ordering service.
+type txResult interface {
+	GetTransaction() txn.Transaction
+	GetStatus() (bool, string)
+}
+
+// txEntry is the outcome of a transaction included in a block.
+type txEntry struct {
+	BlockIdx uint64
+	Accepted bool
+	// Reason is the reason of the refusal when the transaction is not
+	// accepted.
+	Reason string
+}
+
+// txIndex maps the ID of the transactions to the block they were included in,
+// so that the status of a transaction is found without going through the
+// chain. It is filled with the blocks already stored when it starts, and then
+// with the new blocks as they are committed.
+type txIndex struct {
+	sync.RWMutex
+
+	entries map[string]txEntry
+	// ready is true once the blocks stored before the start are indexed. A
+	// transaction not found before that is not known to be missing.
+	ready bool
+}
+
+// newTxIndex returns a new index of the transactions of the chain. It is filled
+// in the background until the context is done.
+func newTxIndex(ctx context.Context, blocks blockReader, srv ordering.Service) *txIndex {
+	idx := &txIndex{
+		entries: make(map[string]txEntry),
+	}
+
+	// the watch starts before reading the stored blocks, so that no block is
+	// missed in between. A block may be indexed twice, which is harmless.
+	events := srv.Watch(ctx)
+
+	go func() {
+		for event := range events {
+			for _, res := range event.Transactions {
+				entry, isNew := idx.add(event.Index, res)
+
+				// only the transactions of the new blocks are counted, and
+				// once even if the block was indexed by the catch up.
+				if isNew && !entry.Accepted {
+					PromRejectedTransactions.WithLabelValues(
+						string(ptypes.ErrorCodeOf(entry.Reason))).Inc()
+				}
+			}
+		}
+	}()
+
+	go idx.catchUp(blocks)
+
+	return idx
+}
+
+// Get returns the outcome of the transaction and true if it is included in a
+// block, or false if it is not, or not yet, indexed.
+func (idx *txIndex) Get(txID []byte) (txEntry, bool) {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	entry, found := idx.entries[string(txID)]
+
+	return entry, found
+}
+
+// IsReady returns true once the blocks stored before the start of the index
+// are indexed.
+func (idx *txIndex) IsReady() bool {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	return idx.ready
+}
+
+// catchUp indexes the blocks of the store, starting from the genesis, until
+// there is no more block.
+func (idx *txIndex) catchUp(blocks blockReader) {
+	var blockIdx uint64
+
+	for {
+		link, err := blocks.GetByIndex(blockIdx)
+		if err != nil {
+			break
+		}
+
+		for _, res := range link.GetBlock().GetData().GetTransactionResults() {
+			idx.add(blockIdx, res)
+		}
+
+		blockIdx++
+	}
+
+	idx.Lock()
+	idx.ready = true
+	idx.Unlock()
+
+	dela.Logger.Info().Msgf("indexed the transactions of %d blocks", blockIdx)
+}
+
+// add indexes the result of the transaction, and returns its entry and true
+// if the transaction was not indexed before.
+func (idx *txIndex) add(blockIdx uint64, res txResult) (txEntry, bool) {
+	accepted, reason := res.GetStatus()
+
+	idx.Lock()
+	defer idx.Unlock()
+
+	key := string(res.GetTransaction().GetID())
+	_, found := idx.entries[key]
+
+	entry := txEntry{
+		BlockIdx: blockIdx,
+		Accepted: accepted,
+		Reason:   reason,
+	}
+
+	idx.entries[key] = entry
+
+	return entry, !found
+}
@@ -0,0 +1,57 @@
+package txnmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+)
+
+func TestTxIndex_Events(t *testing.T) {
+	service := fake.Service{}
+
+	PromRejectedTransactions.Reset()
+
+	idx := newTxIndex(context.Background(), emptyBlocks{}, &service)
+
+	require.Eventually(t, idx.IsReady, time.Second, time.Millisecond*10)
+
+	_, found := idx.Get([]byte("tx"))
+	require.False(t, found)
+
+	// the first transaction of the fake service is rejected
+	service.AddTx(fake.Transaction{Id: []byte("tx")})
+
+	require.Eventually(t, func() bool {
+		_, found := idx.Get([]byte("tx"))
+		return found
+	}, time.Second, time.Millisecond*10)
+
+	entry, _ := idx.Get([]byte("tx"))
+	require.False(t, entry.Accepted)
+	require.Equal(t, uint64(0), entry.BlockIdx)
+
+	entry, found = idx.Get([]byte("dummyId1"))
+	require.True(t, found)
+	require.True(t, entry.Accepted)
+
+	rejected := PromRejectedTransactions.WithLabelValues(
+		string(ptypes.ErrCodeTransactionRejected))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(rejected) == 1
+	}, time.Second, time.Millisecond*10)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type emptyBlocks struct{}
+
+func (emptyBlocks) GetByIndex(index uint64) (types.BlockLink, error) {
+	return nil, fake.GetError()
+}
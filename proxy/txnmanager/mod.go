@@ -3,8 +3,10 @@ package txnmanager
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/c4dt/d-voting/contracts/evoting"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
 )
 
 // Manager defines the public HTTP API of the transaction manager
@@ -22,6 +24,11 @@ type Manager interface {
 	SendTransactionInfo(w http.ResponseWriter, txnID []byte, lastBlockIdx uint64, status TransactionStatus) error
 }
 
+// TxExpiry is how long after its submission a transaction that is not in the
+// chain is reported as rejected. Such a transaction was dropped by the pool,
+// for example because it was refused by the validation of the nodes.
+const TxExpiry = 10 * time.Minute
+
 // TransactionStatus is the status of a transaction
 type TransactionStatus byte
 
@@ -52,4 +59,14 @@ type transactionInternalInfo struct {
 type TransactionClientInfo struct {
 	Status TransactionStatus // 0 if not yet included, 1 if included, 2 if rejected
 	Token  string
+	// BlockIdx is the index of the block that includes the transaction, once
+	// it is included or rejected
+	BlockIdx uint64 `json:",omitempty"`
+	// Reason is the reason given by the smart contract when the transaction
+	// is rejected
+	Reason string `json:",omitempty"`
+	// ErrorCode is the code of the reason when the transaction is rejected
+	ErrorCode ptypes.ErrorCode `json:",omitempty"`
+	// Receipt is the receipt of the ballot when the transaction casts a vote
+	Receipt *ptypes.BallotReceipt `json:",omitempty"`
 }
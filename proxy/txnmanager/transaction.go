@@ -5,44 +5,48 @@ import (
 	"context"
 	"crypto/sha256"
 	b64 "encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"go.dedis.ch/dela/core/validation"
 	"net/http"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/c4dt/d-voting/contracts/evoting"
+	"github.com/c4dt/d-voting/internal/confirm"
+	"github.com/c4dt/d-voting/internal/tracing"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/execution/native"
+	"go.dedis.ch/dela/core/ordering"
 	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/pool"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/serde"
-	"go.dedis.ch/kyber/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/xerrors"
 )
 
-const (
-	maxTimeTransactionCheck = 10 * time.Minute
-)
-
 // NewTransactionManager returns a new initialized transaction manager
-func NewTransactionManager(mngr txn.Manager, p pool.Pool,
-	ctx serde.Context, pk kyber.Point, blocks blockstore.BlockStore, signer crypto.Signer, val validation.Service) Manager {
+func NewTransactionManager(mngr txn.Manager, p pool.Pool, srv ordering.Service,
+	ctx serde.Context, blocks blockstore.BlockStore, signer crypto.Signer, val validation.Service) Manager {
 
 	logger := dela.Logger.With().Timestamp().Str("role", "proxy-txmanager").Logger()
 
 	return &manager{
 		logger:  logger,
+		index:   newTxIndex(context.Background(), blocks, srv),
 		context: ctx,
 		mngr:    mngr,
 		pool:    p,
-		pk:      pk,
+		confirm: confirm.NewService(srv, p, mngr),
 		blocks:  blocks,
 		signer:  signer,
 		val:     val,
@@ -53,13 +57,12 @@ func NewTransactionManager(mngr txn.Manager, p pool.Pool,
 //
 // - implements proxy.Transaction
 type manager struct {
-	sync.Mutex
-
 	logger  zerolog.Logger
+	index   *txIndex
 	context serde.Context
 	mngr    txn.Manager
 	pool    pool.Pool
-	pk      kyber.Point
+	confirm confirm.Service
 	blocks  blockstore.BlockStore
 	signer  crypto.Signer
 	val     validation.Service
@@ -72,7 +75,8 @@ func (h *manager) StatusHandlerGet(w http.ResponseWriter, r *http.Request) {
 
 	// check if the token is valid
 	if vars == nil || vars["token"] == "" {
-		http.Error(w, fmt.Sprintf("token not found: %v", vars), http.StatusInternalServerError)
+		sendError(w, r, xerrors.Errorf("token not found: %v", vars), http.StatusBadRequest,
+			ptypes.ErrCodeBadRequest)
 		return
 	}
 
@@ -81,7 +85,8 @@ func (h *manager) StatusHandlerGet(w http.ResponseWriter, r *http.Request) {
 	// decode the token
 	marshall, err := b64.URLEncoding.DecodeString(token)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to decode token: %v", err), http.StatusInternalServerError)
+		sendError(w, r, xerrors.Errorf("failed to decode token: %v", err), http.StatusBadRequest,
+			ptypes.ErrCodeInvalidToken)
 		return
 	}
 
@@ -89,43 +94,83 @@ func (h *manager) StatusHandlerGet(w http.ResponseWriter, r *http.Request) {
 	var content transactionInternalInfo
 	err = json.Unmarshal(marshall, &content)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to unmarshall token: %v", err), http.StatusInternalServerError)
+		sendError(w, r, xerrors.Errorf("failed to unmarshall token: %v", err), http.StatusBadRequest,
+			ptypes.ErrCodeInvalidToken)
 		return
 	}
 
 	err = content.validate(h)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid content: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// check if if was submited not to long ago
-	if time.Now().Unix()-content.Time > int64(maxTimeTransactionCheck) {
-		// if it was submited to long ago, we reject the transaction
-		err = h.SendTransactionInfo(w, content.TransactionID, 0, RejectedTransaction)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to send transaction info: %v", err), http.StatusInternalServerError)
-			return
-		}
+		sendError(w, r, xerrors.Errorf("Invalid content: %v", err), http.StatusBadRequest,
+			ptypes.ErrCodeInvalidToken)
 		return
 	}
 
 	// check if the transaction time stamp is possible
 	if time.Now().Unix()-content.Time < 0 {
-		http.Error(w, "the transaction is from the future", http.StatusInternalServerError)
+		sendError(w, r, xerrors.New("the transaction is from the future"), http.StatusBadRequest,
+			ptypes.ErrCodeInvalidToken)
 		return
 	}
 
 	// check if the transaction is included in the blockchain
-	newStatus, idx := h.checkTxnIncluded(content.TransactionID, content.LastBlockIdx)
+	entry, found := h.index.Get(content.TransactionID)
+	if !found {
+		h.sendNotFound(w, r, content)
+		return
+	}
+
+	status := IncludedTransaction
+	if !entry.Accepted {
+		status = RejectedTransaction
+	}
+
+	response, err := h.CreateTransactionResult(content.TransactionID, entry.BlockIdx, status)
+	if err != nil {
+		sendError(w, r, xerrors.Errorf("failed to create transaction info: %v", err),
+			http.StatusInternalServerError, ptypes.ErrCodeInternal)
+		return
+	}
+
+	response.BlockIdx = entry.BlockIdx
+	response.Reason = entry.Reason
+
+	if !entry.Accepted {
+		response.ErrorCode = ptypes.ErrorCodeOf(entry.Reason)
+	}
+
+	SendResponse(w, response)
+}
 
-	// send the transaction info
-	err = h.SendTransactionInfo(w, content.TransactionID, idx, newStatus)
+// sendNotFound answers the status of a transaction that is not in the index.
+// Once the index holds all the blocks, a transaction still missing long after
+// its submission is reported as rejected, otherwise the client is told to ask
+// again later with a token that keeps the time of the submission.
+func (h *manager) sendNotFound(w http.ResponseWriter, r *http.Request,
+	content transactionInternalInfo) {
+
+	submitted := time.Unix(content.Time, 0)
+
+	status := UnknownTransactionStatus
+	if h.index.IsReady() && time.Since(submitted) > TxExpiry {
+		status = RejectedTransaction
+	}
+
+	response, err := h.createTransactionResult(content.TransactionID,
+		content.LastBlockIdx, status, content.Time)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to send transaction info: %v", err), http.StatusInternalServerError)
+		sendError(w, r, xerrors.Errorf("failed to create transaction info: %v", err),
+			http.StatusInternalServerError, ptypes.ErrCodeInternal)
 		return
 	}
 
+	if status == RejectedTransaction {
+		response.Reason = fmt.Sprintf("the transaction expired: it is not "+
+			"included %s after its submission", TxExpiry)
+		response.ErrorCode = ptypes.ErrCodeTransactionExpired
+	}
+
+	SendResponse(w, response)
 }
 
 // validate checks if the transaction is valid
@@ -169,54 +214,26 @@ func (h *manager) checkSignature(Hash []byte, Signature crypto.Signature) bool {
 	return h.signer.GetPublicKey().Verify(Hash, Signature) == nil
 }
 
-// checkTxnIncluded checks if the transaction is included in the blockchain
-func (h *manager) checkTxnIncluded(transactionID []byte, lastBlockIdx uint64) (TransactionStatus, uint64) {
-	// we start at the last block index
-	// which is the index of the last block that was checked
-	// or the last block before the transaction was submited
-	idx := lastBlockIdx
-
-	for {
-		// first get the block
-		blockLink, err := h.blocks.GetByIndex(idx)
-
-		// if we reached the end of the blockchain
-		if err != nil {
-			return UnknownTransactionStatus, idx - 1
-		}
-
-		// check if the transaction is in the block
-		transactions := blockLink.GetBlock().GetData().GetTransactionResults() //blockLink.GetBlock().GetTransactions()
-
-		for _, txn := range transactions {
-
-			if bytes.Equal(txn.GetTransaction().GetID(), transactionID) {
-				accepted, _ := txn.GetStatus()
-				if accepted {
-					return IncludedTransaction, blockLink.GetBlock().GetIndex()
-				} else {
-					return RejectedTransaction, blockLink.GetBlock().GetIndex()
-				}
-
-			}
-
-		}
-
-		idx++
-	}
-}
-
 // SubmitTxn submits a transaction
 // Returns the transaction ID.
 func (h *manager) SubmitTxn(ctx context.Context, cmd evoting.Command,
 	cmdArg string, payload []byte) ([]byte, uint64, error) {
 
-	h.Lock()
-	defer h.Unlock()
+	ctx, span := tracing.Tracer().Start(ctx, "submit transaction",
+		trace.WithAttributes(attribute.String("dvoting.command", string(cmd))))
+	defer span.End()
 
-	tx, err := createTransaction(h.mngr, cmd, cmdArg, payload)
-	if err != nil {
-		return nil, 0, xerrors.Errorf("failed to create transaction: %v", err)
+	// the contract continues the trace of the request when it executes the
+	// transaction
+	traceparent := tracing.Inject(ctx)
+
+	makeTx := func() (txn.Transaction, error) {
+		tx, err := createTransaction(h.mngr, cmd, cmdArg, payload, traceparent)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create transaction: %v", err)
+		}
+
+		return tx, nil
 	}
 
 	// get the last block
@@ -226,11 +243,19 @@ func (h *manager) SubmitTxn(ctx context.Context, cmd evoting.Command,
 	}
 	lastBlockIdx := lastBlock.GetBlock().GetIndex()
 
-	err = h.pool.Add(tx)
+	// the inclusion is not watched: the client follows the transaction with
+	// the status handler, which looks it up in the index.
+	tx, err := h.confirm.Add(ctx, makeTx)
 	if err != nil {
-		return nil, 0, xerrors.Errorf("failed to add transaction to the pool: %v", err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, xerrors.Errorf("failed to submit transaction: %v", err)
 	}
 
+	span.SetAttributes(attribute.String("dvoting.transaction",
+		hex.EncodeToString(tx.GetID())))
+
+	h.logger.Info().Hex("id", tx.GetID()).Msgf("transaction %s added to the pool", cmd)
+
 	return tx.GetID(), lastBlockIdx, nil
 }
 
@@ -245,8 +270,14 @@ func (h *manager) SendTransactionInfo(w http.ResponseWriter, txnID []byte, lastB
 }
 
 func (h *manager) CreateTransactionResult(txnID []byte, lastBlockIdx uint64, status TransactionStatus) (TransactionClientInfo, error) {
+	return h.createTransactionResult(txnID, lastBlockIdx, status, time.Now().Unix())
+}
+
+// createTransactionResult creates the response with a token for a transaction
+// submitted at the given time.
+func (h *manager) createTransactionResult(txnID []byte, lastBlockIdx uint64,
+	status TransactionStatus, time int64) (TransactionClientInfo, error) {
 
-	time := time.Now().Unix()
 	hash := hashInfos(status, txnID, lastBlockIdx, time)
 	signature, err := h.signer.Sign(hash)
 
@@ -299,9 +330,28 @@ func SendResponse(w http.ResponseWriter, response any) error {
 	return nil
 }
 
+// sendError writes the error in the same format as the other endpoints of the
+// proxy.
+func sendError(w http.ResponseWriter, r *http.Request, err error, status uint,
+	code ptypes.ErrorCode) {
+
+	ptypes.WriteError(w, ptypes.HTTPError{
+		Title:     strings.ToLower(http.StatusText(int(status))),
+		Code:      status,
+		ErrorCode: code,
+		Message:   "A problem occurred on the proxy",
+		Args: map[string]interface{}{
+			"error":  err.Error(),
+			"url":    r.URL.String(),
+			"method": r.Method,
+		},
+	})
+}
+
 // createTransaction creates a transaction with the given command and payload.
+// The trace context, if any, is added to the arguments.
 func createTransaction(manager txn.Manager, commandType evoting.Command,
-	commandArg string, buf []byte) (txn.Transaction, error) {
+	commandArg string, buf []byte, traceparent []byte) (txn.Transaction, error) {
 
 	args := []txn.Arg{
 		{
@@ -318,6 +368,10 @@ func createTransaction(manager txn.Manager, commandType evoting.Command,
 		},
 	}
 
+	if len(traceparent) != 0 {
+		args = append(args, txn.Arg{Key: evoting.TraceArg, Value: traceparent})
+	}
+
 	tx, err := manager.Make(args...)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to create transaction from manager: %v", err)
@@ -0,0 +1,90 @@
+package txnmanager
+
+import (
+	b64 "encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c4dt/d-voting/internal/testing/fake"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StatusHandlerGet_Expired(t *testing.T) {
+	idx := &txIndex{entries: make(map[string]txEntry)}
+
+	h := &manager{
+		index:   idx,
+		context: fake.NewContext(),
+		signer: fake.NewSignerWithSignatureFactory(
+			fake.NewSignatureFactory(fake.Signature{})),
+	}
+
+	old := time.Now().Add(-TxExpiry - time.Minute).Unix()
+
+	info, err := h.createTransactionResult([]byte("tx"), 2,
+		UnknownTransactionStatus, old)
+	require.NoError(t, err)
+
+	// the transaction may still be in the blocks not indexed yet
+	res := getStatus(t, h, info.Token)
+	require.Equal(t, UnknownTransactionStatus, res.Status)
+
+	// the new token keeps the time of the submission
+	require.Equal(t, old, decodeToken(t, res.Token).Time)
+
+	idx.ready = true
+
+	res = getStatus(t, h, res.Token)
+	require.Equal(t, RejectedTransaction, res.Status)
+	require.Equal(t, ptypes.ErrCodeTransactionExpired, res.ErrorCode)
+	require.Contains(t, res.Reason, "the transaction expired")
+
+	info, err = h.CreateTransactionResult([]byte("tx"), 2,
+		UnknownTransactionStatus)
+	require.NoError(t, err)
+
+	res = getStatus(t, h, info.Token)
+	require.Equal(t, UnknownTransactionStatus, res.Status)
+
+	idx.entries["tx"] = txEntry{BlockIdx: 3, Accepted: true}
+
+	res = getStatus(t, h, info.Token)
+	require.Equal(t, IncludedTransaction, res.Status)
+	require.Equal(t, uint64(3), res.BlockIdx)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func getStatus(t *testing.T, h *manager, token string) TransactionClientInfo {
+	r := httptest.NewRequest(http.MethodGet, "/evoting/transactions/"+token, nil)
+	r = mux.SetURLVars(r, map[string]string{"token": token})
+
+	w := httptest.NewRecorder()
+	h.StatusHandlerGet(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res TransactionClientInfo
+
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	require.NoError(t, err)
+
+	return res
+}
+
+func decodeToken(t *testing.T, token string) transactionInternalInfo {
+	buf, err := b64.URLEncoding.DecodeString(token)
+	require.NoError(t, err)
+
+	var content transactionInternalInfo
+
+	err = json.Unmarshal(buf, &content)
+	require.NoError(t, err)
+
+	return content
+}
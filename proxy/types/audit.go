@@ -0,0 +1,142 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// AuditEntry defines an entry of the audit log of a node, which records a
+// privileged transaction of the evoting contract included in a block. Each
+// entry commits to the entries before with the hash of the previous one.
+type AuditEntry struct {
+	// Index is the position of the entry in the log, starting at 0
+	Index uint64
+	// Time is the time at which the node saw the block committed. It is zero
+	// for the blocks added while catching up with the chain, for which it is
+	// unknown.
+	Time time.Time
+	// Block is the index of the block including the transaction, and
+	// BlockHash its hex-encoded hash
+	Block     uint64
+	BlockHash string
+	// TransactionID is the hex-encoded ID of the transaction
+	TransactionID string
+	Command       string
+	// FormID is the hex-encoded ID of the form, empty for the admin and
+	// operator lists
+	FormID string `json:",omitempty"`
+	// Actor is the SCIPER of the user who performed the action
+	Actor string `json:",omitempty"`
+	// Target is the SCIPER of the user added or removed, if any
+	Target string `json:",omitempty"`
+	// Accepted is false if the transaction was rejected by the contract, for
+	// the given reason
+	Accepted bool
+	Reason   string `json:",omitempty"`
+	// Previous is the hex-encoded hash of the previous entry, empty for the
+	// first one, and Hash the hex-encoded hash of this entry
+	Previous string `json:",omitempty"`
+	Hash     string
+}
+
+// ComputeHash returns the hash of the entry, which is H( previous | fields ).
+// It doesn't depend on the Hash field.
+func (e AuditEntry) ComputeHash() ([]byte, error) {
+	previous, err := hex.DecodeString(e.Previous)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode previous hash: %v", err)
+	}
+
+	h := sha256.New()
+	h.Write(previous)
+
+	// the zero time has no unix time in nanoseconds
+	var nanos uint64
+	if !e.Time.IsZero() {
+		nanos = uint64(e.Time.UnixNano())
+	}
+
+	intBuf := make([]byte, 8)
+
+	for _, i := range []uint64{e.Index, nanos, e.Block} {
+		binary.LittleEndian.PutUint64(intBuf, i)
+		h.Write(intBuf)
+	}
+
+	lenBuf := make([]byte, 4)
+
+	for _, s := range []string{e.BlockHash, e.TransactionID, e.Command,
+		e.FormID, e.Actor, e.Target, e.Reason} {
+
+		binary.LittleEndian.PutUint32(lenBuf, uint32(len(s)))
+		h.Write(lenBuf)
+		h.Write([]byte(s))
+	}
+
+	if e.Accepted {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+
+	return h.Sum(nil), nil
+}
+
+// VerifyAuditLog checks the hash of each entry, and that each entry is
+// chained to the one before it. The entries must be consecutive, starting
+// from any index.
+func VerifyAuditLog(entries []AuditEntry) error {
+	for i, entry := range entries {
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return xerrors.Errorf("failed to hash entry %d: %v", entry.Index, err)
+		}
+
+		if hex.EncodeToString(hash) != entry.Hash {
+			return xerrors.Errorf("entry %d has been altered", entry.Index)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		if entry.Index != entries[i-1].Index+1 || entry.Previous != entries[i-1].Hash {
+			return xerrors.Errorf("entry %d doesn't follow entry %d", entry.Index,
+				entries[i-1].Index)
+		}
+	}
+
+	return nil
+}
+
+// AuditLogRequest defines the HTTP request to query the audit log of a node.
+// The empty filters match all the entries.
+type AuditLogRequest struct {
+	// UserID is the SCIPER of the admin querying the log
+	UserID string
+	// FormID is hex-encoded
+	FormID string
+	// Actor is the SCIPER of the user who performed the actions
+	Actor   string
+	Command string
+	// Since and Until bound the time of the entries, in unix seconds. 0 for
+	// no bound.
+	Since int64
+	Until int64
+}
+
+// GetAuditLogResponse defines the HTTP response of a query of the audit log
+type GetAuditLogResponse struct {
+	Entries []AuditEntry
+	// Size is the number of entries of the log, and Head the hex-encoded hash
+	// of the last one, which commits to the whole log
+	Size uint64
+	Head string
+	// Broken tells why the log doesn't match the chain, when the node found
+	// it altered at its start. The entries can't be trusted then.
+	Broken string `json:",omitempty"`
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAuditLog(t *testing.T) {
+	entries := make([]AuditEntry, 3)
+	previous := ""
+
+	for i := range entries {
+		entries[i] = AuditEntry{
+			Index:     uint64(i),
+			Time:      time.Unix(int64(1000+i), 0),
+			Block:     uint64(2 * i),
+			BlockHash: "cafe",
+			Command:   "CLOSE_FORM",
+			FormID:    "abcd",
+			Actor:     "123456",
+			Accepted:  true,
+			Previous:  previous,
+		}
+
+		// the time of the blocks added while catching up is unknown
+		if i == 0 {
+			entries[i].Time = time.Time{}
+		}
+
+		hash, err := entries[i].ComputeHash()
+		require.NoError(t, err)
+
+		entries[i].Hash = hex.EncodeToString(hash)
+		previous = entries[i].Hash
+	}
+
+	require.NoError(t, VerifyAuditLog(entries))
+	require.NoError(t, VerifyAuditLog(entries[1:]))
+	require.NoError(t, VerifyAuditLog(nil))
+
+	altered := append([]AuditEntry{}, entries...)
+	altered[1].Actor = "654321"
+	require.EqualError(t, VerifyAuditLog(altered), "entry 1 has been altered")
+
+	altered = append([]AuditEntry{}, entries...)
+	altered[1].BlockHash = "beef"
+	require.EqualError(t, VerifyAuditLog(altered), "entry 1 has been altered")
+
+	altered = append([]AuditEntry{}, entries...)
+	altered[1].Accepted = false
+	require.EqualError(t, VerifyAuditLog(altered), "entry 1 has been altered")
+
+	err := VerifyAuditLog([]AuditEntry{entries[0], entries[2]})
+	require.EqualError(t, err, "entry 2 doesn't follow entry 0")
+
+	altered = append([]AuditEntry{}, entries...)
+	altered[0].Previous = "not hex"
+	err = VerifyAuditLog(altered)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to hash entry 0")
+}
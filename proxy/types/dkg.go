@@ -15,3 +15,28 @@ type GetActorInfo struct {
 	Status int
 	Error  HTTPError
 }
+
+// NewCeremonyRequest defines the request to create a new key ceremony
+type NewCeremonyRequest struct {
+	CeremonyID string // hex-encoded
+}
+
+// UpdateCeremony defines the input used to update a key ceremony
+type UpdateCeremony struct {
+	Action string
+}
+
+// CeremonyInfo defines the information about a key ceremony
+type CeremonyInfo struct {
+	CeremonyID string // hex-encoded
+	Status     int
+	// Pubkey is hex-encoded and empty until the ceremony is set up
+	Pubkey string
+	Error  HTTPError
+}
+
+// GetCeremoniesResponse defines the HTTP response when getting the key
+// ceremonies
+type GetCeremoniesResponse struct {
+	Ceremonies []CeremonyInfo
+}
@@ -8,6 +8,14 @@ import (
 type CreateFormRequest struct {
 	UserID        string
 	Configuration etypes.Configuration
+	// Anonymous is true if the voters prove their eligibility with a
+	// credential of the electoral roll instead of their SCIPER. It is
+	// optional.
+	Anonymous bool `json:",omitempty"`
+	// Mixers are the hex-encoded public keys of the nodes authorized to
+	// shuffle the ballots in place of the roster. It is optional.
+	Mixers         []string `json:",omitempty"`
+	MixerThreshold int      `json:",omitempty"`
 }
 
 // PermissionOperationRequest defines the HTTP request for performing
@@ -15,6 +23,9 @@ type CreateFormRequest struct {
 type PermissionOperationRequest struct {
 	TargetUserID     string
 	PerformingUserID string
+	// PublicKey is the Ed25519 public key the voter signs its ballots with.
+	// It is optional, and only used when adding a voter.
+	PublicKey []byte `json:",omitempty"`
 }
 
 // CreateFormResponse defines the HTTP response when creating a form
@@ -28,6 +39,47 @@ type CastVoteRequest struct {
 	VoterID string
 	// Marshalled representation of Ciphervote. It contains []{K:,C:}
 	Ballot CiphervoteJSON
+	// Signature is the signature of the voter on the hash of the vote. It is
+	// required if the voter registered a key on the form.
+	Signature []byte `json:",omitempty"`
+	// Sequence is signed with the vote and must be greater than the sequence
+	// of the previous signed vote of the voter.
+	Sequence uint64 `json:",omitempty"`
+}
+
+// AnonymousVoteRequest defines the HTTP request for casting a vote on an
+// anonymous form
+type AnonymousVoteRequest struct {
+	// Marshalled representation of Ciphervote. It contains []{K:,C:}
+	Ballot CiphervoteJSON
+	// Credential is the linkable ring signature of the hash of the vote with
+	// a credential of the ring of the electoral roll
+	Credential []byte
+	// Ring is the index of the ring of the electoral roll the credential is
+	// made over, see types.CredentialRingSize.
+	Ring int `json:",omitempty"`
+	// Sequence is signed with the vote and must be greater than the sequence
+	// of the previous vote made with the credential.
+	Sequence uint64 `json:",omitempty"`
+}
+
+// AuditBallotRequest defines the HTTP request for auditing a ballot instead
+// of casting it. Revealing the randomness lets anyone decrypt the ballot, so
+// it can never be cast afterwards.
+type AuditBallotRequest struct {
+	// Marshalled representation of Ciphervote. It contains []{K:,C:}
+	Ballot CiphervoteJSON
+	// Randomness is the marshalled scalar used to encrypt each pair of the
+	// ballot
+	Randomness [][]byte
+}
+
+// AddCredentialRequest defines the HTTP request for adding a credential to
+// the electoral roll of an anonymous form
+type AddCredentialRequest struct {
+	// PublicKey is the Ed25519 public key of the credential
+	PublicKey        []byte
+	PerformingUserID string
 }
 
 // CiphervoteJSON is the JSON representation of a ciphervote
@@ -39,10 +91,44 @@ type EGPairJSON struct {
 	C []byte
 }
 
+// BallotReceipt is given to the voters when they cast a vote, so that they
+// can check that their ballot is stored. The block of ballots it is stored in
+// is only known once the vote is included, see GetBallotResponse.
+type BallotReceipt struct {
+	// Hash is the hex-encoded hash of the ballot
+	Hash string
+}
+
+// GetBallotResponse defines the HTTP response when getting a ballot by the
+// hash of its receipt
+type GetBallotResponse struct {
+	// Hash is the hex-encoded hash of the ballot
+	Hash string
+	// Block is the index of the block of ballots the ballot is stored in
+	Block int
+	// BlockID is the hex-encoded ID of the block of ballots
+	BlockID string
+	// Effective is false if the voter cast another ballot since, which is the
+	// one counted
+	Effective bool
+}
+
 // UpdateFormRequest defines the HTTP request for updating a form
 type UpdateFormRequest struct {
 	Action string
 	UserID string
+	// CeremonyID is the hex-encoded ID of a DKG key ceremony. It is optional
+	// and only used by the "open" action to bind the form to the ceremony.
+	CeremonyID string `json:",omitempty"`
+	// Format is the serialization format, such as "JSON" or "PROTOBUF", the
+	// form and its ballots are stored in. It is only used by the "migrate"
+	// action.
+	Format string `json:",omitempty"`
+}
+
+// DeleteFormRequest defines the HTTP request for deleting a form
+type DeleteFormRequest struct {
+	UserID string
 }
 
 // GetFormResponse defines the HTTP response when getting the form info
@@ -59,6 +145,36 @@ type GetFormResponse struct {
 	BallotVoters    []string
 	Voters          []string
 	Owners          []string
+	// CeremonyID is the hex-encoded ID of the DKG key ceremony the form is
+	// bound to, if any
+	CeremonyID string `json:",omitempty"`
+	// Anonymous is true if the voters prove their eligibility with a
+	// credential. BallotVoters then holds the tags of the credentials.
+	Anonymous bool `json:",omitempty"`
+	// ElectoralRoll are the hex-encoded public keys of the credentials of an
+	// anonymous form
+	ElectoralRoll []string `json:",omitempty"`
+	// BallotsHash is the hex-encoded hash that commits to all the ballots
+	// cast. It is empty if no ballot has been cast.
+	BallotsHash string `json:",omitempty"`
+}
+
+// BallotProofResponse defines the HTTP response with the proof that the
+// ballot of a voter is committed to by the hash of the ballots of the form,
+// see VerifyBallotProof in the client.
+type BallotProofResponse struct {
+	// BallotsHash is the hex-encoded hash of the ballots of the form
+	BallotsHash string
+	// Block is the index of the block of ballots holding the ballot
+	Block int
+	// VoterIDs and Ballots are the ballots of the block
+	VoterIDs []string
+	Ballots  []CiphervoteJSON
+	// Previous is the hex-encoded chained hash of the blocks before, or the
+	// form ID for the first block
+	Previous string
+	// Next are the hex-encoded hashes of the blocks after
+	Next []string
 }
 
 // LightForm represents a light version of the form
@@ -79,10 +195,13 @@ type GetFormsResponse struct {
 
 // HTTPError defines the standard error format
 type HTTPError struct {
-	Title   string
-	Code    uint
-	Message string
-	Args    map[string]interface{}
+	Title string
+	// Code is the HTTP status
+	Code uint
+	// ErrorCode tells what went wrong, see the ErrCode* constants
+	ErrorCode ErrorCode `json:",omitempty"`
+	Message   string
+	Args      map[string]interface{}
 }
 
 type GetAdminsResponse struct {
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is the machine-readable code of an HTTPError, that the clients
+// can rely on instead of parsing the message.
+type ErrorCode string
+
+const (
+	// ErrCodeInternal is the code of an unexpected error on the node
+	ErrCodeInternal ErrorCode = "INTERNAL"
+	// ErrCodeBadRequest is the code of a request that can't be decoded or
+	// that misses a parameter
+	ErrCodeBadRequest ErrorCode = "BAD_REQUEST"
+	// ErrCodeNotFound is the code of an unknown endpoint or resource
+	ErrCodeNotFound ErrorCode = "NOT_FOUND"
+	// ErrCodeMethodNotAllowed is the code of a method not supported by an
+	// endpoint
+	ErrCodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	// ErrCodeFormNotFound is the code of a request on an unknown form
+	ErrCodeFormNotFound ErrorCode = "FORM_NOT_FOUND"
+	// ErrCodeWrongStatus is the code of an operation that is not possible in
+	// the current status of the form
+	ErrCodeWrongStatus ErrorCode = "WRONG_STATUS"
+	// ErrCodeNotAuthorized is the code of an operation that the user, the
+	// voter or the signing key is not allowed to do
+	ErrCodeNotAuthorized ErrorCode = "NOT_AUTHORIZED"
+	// ErrCodeInvalidSignature is the code of a request or a vote whose
+	// signature is not valid
+	ErrCodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	// ErrCodeExpiredRequest is the code of a signed request whose timestamp
+	// is out of the accepted window
+	ErrCodeExpiredRequest ErrorCode = "EXPIRED_REQUEST"
+	// ErrCodeReplayedRequest is the code of a signed request received before
+	ErrCodeReplayedRequest ErrorCode = "REPLAYED_REQUEST"
+	// ErrCodeInvalidBallotLength is the code of a vote whose ballot doesn't
+	// have the number of chunks expected by the form
+	ErrCodeInvalidBallotLength ErrorCode = "INVALID_BALLOT_LENGTH"
+	// ErrCodeInvalidToken is the code of a transaction token that can't be
+	// decoded or that was not issued by the node
+	ErrCodeInvalidToken ErrorCode = "INVALID_TOKEN"
+	// ErrCodeTransactionRejected is the code of a transaction rejected by the
+	// smart contract for a reason that has no specific code
+	ErrCodeTransactionRejected ErrorCode = "TRANSACTION_REJECTED"
+	// ErrCodeTransactionExpired is the code of a transaction that is still
+	// not included long after its submission
+	ErrCodeTransactionExpired ErrorCode = "TRANSACTION_EXPIRED"
+	// ErrCodeRateLimited is the code of a request rejected because the client
+	// or the key that signed it sent too many requests
+	ErrCodeRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrCodeRequestTooLarge is the code of a request whose body is too large
+	ErrCodeRequestTooLarge ErrorCode = "REQUEST_TOO_LARGE"
+	// ErrCodePoolFull is the code of a request rejected because the pool of
+	// transactions of the node is full
+	ErrCodePoolFull ErrorCode = "POOL_FULL"
+	// ErrCodeBallotAudited is the code of a vote whose ballot was audited, or
+	// of an audit of a ballot that was already audited
+	ErrCodeBallotAudited ErrorCode = "BALLOT_AUDITED"
+	// ErrCodeBallotCast is the code of an audit of a ballot that was cast
+	ErrCodeBallotCast ErrorCode = "BALLOT_CAST"
+)
+
+// reasonCodes maps the fragments of the errors returned by the smart contract
+// to the code of the error. The first fragment found in a reason gives its
+// code, so the more specific ones come first.
+var reasonCodes = []struct {
+	fragment string
+	code     ErrorCode
+}{
+	{"ballot has unexpected length", ErrCodeInvalidBallotLength},
+	{"ballot has been audited", ErrCodeBallotAudited},
+	{"ballot has already been audited", ErrCodeBallotAudited},
+	{"ballot has already been cast", ErrCodeBallotCast},
+	{"current status", ErrCodeWrongStatus},
+	{"is not open", ErrCodeWrongStatus},
+	{"is not in state", ErrCodeWrongStatus},
+	{"signature does not match", ErrCodeInvalidSignature},
+	{"must be signed", ErrCodeInvalidSignature},
+	{"invalid credential", ErrCodeInvalidSignature},
+	{"doesn't have the", ErrCodeNotAuthorized},
+	{"has no registered key", ErrCodeNotAuthorized},
+	{"failed check the permission", ErrCodeNotAuthorized},
+	{"failed to get form", ErrCodeFormNotFound},
+}
+
+// ErrorCodeOf returns the code of the reason given by the smart contract when
+// it rejects a transaction.
+func ErrorCodeOf(reason string) ErrorCode {
+	for _, rc := range reasonCodes {
+		if strings.Contains(reason, rc.fragment) {
+			return rc.code
+		}
+	}
+
+	return ErrCodeTransactionRejected
+}
+
+// WriteError writes the error as the JSON response, with the HTTP status of
+// the error.
+func WriteError(w http.ResponseWriter, httpErr HTTPError) {
+	buf, _ := json.MarshalIndent(&httpErr, "", "  ")
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(int(httpErr.Code))
+	fmt.Fprintln(w, string(buf))
+}
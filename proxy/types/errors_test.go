@@ -0,0 +1,47 @@
+package types
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	reasons := map[string]ErrorCode{
+		"failed to cast vote: failed to get form: failed to get key":                    ErrCodeFormNotFound,
+		"failed to cast vote: the form is not open, current status: 0":                  ErrCodeWrongStatus,
+		"failed to cast vote: the ballot has unexpected length: 1 != 2":                 ErrCodeInvalidBallotLength,
+		"failed to close form: The user 123 doesn't have the Owner permission":          ErrCodeNotAuthorized,
+		"failed to cast vote: failed to check voter signature: must be signed":          ErrCodeInvalidSignature,
+		"failed to cast vote: failed to verify credential: invalid credential: invalid": ErrCodeInvalidSignature,
+		"failed to cast vote: the ballot has been audited":                              ErrCodeBallotAudited,
+		"failed to audit ballot: the ballot has already been cast":                      ErrCodeBallotCast,
+		"failed to shuffle ballots: something else":                                     ErrCodeTransactionRejected,
+	}
+
+	for reason, code := range reasons {
+		require.Equal(t, code, ErrorCodeOf(reason), reason)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteError(w, HTTPError{
+		Title:     "not found",
+		Code:      http.StatusNotFound,
+		ErrorCode: ErrCodeFormNotFound,
+	})
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var httpErr HTTPError
+
+	err := json.Unmarshal(w.Body.Bytes(), &httpErr)
+	require.NoError(t, err)
+	require.Equal(t, ErrCodeFormNotFound, httpErr.ErrorCode)
+}
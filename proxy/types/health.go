@@ -0,0 +1,30 @@
+package types
+
+// HealthResponse defines the HTTP response of the health and readiness
+// endpoints
+type HealthResponse struct {
+	// Ready is false when the node can't serve the requests that add
+	// transactions, for the reasons given by Problems
+	Ready    bool
+	Problems []string `json:",omitempty"`
+
+	// Height is the number of blocks of the chain
+	Height uint64
+	// SinceLastBlock is the number of seconds since the last block was
+	// committed, or since the node started if no block was committed since
+	// then
+	SinceLastBlock float64
+
+	// RosterSize is the number of members of the roster of the chain, and
+	// Member is true if the node is one of them
+	RosterSize int
+	Member     bool
+
+	// PoolSize is the number of transactions waiting in the pool
+	PoolSize int
+
+	// DKGActors are the DKG actors loaded by the node, by hex-encoded form ID
+	DKGActors map[string]GetActorInfo
+	// Shuffle is true if the shuffle actor of the node is available
+	Shuffle bool
+}
@@ -5,3 +5,75 @@ type UpdateShuffle struct {
 	Action string
 	UserID string
 }
+
+// GetShuffleResponse defines the HTTP response when getting the progress of the
+// shuffle of a form
+type GetShuffleResponse struct {
+	// Status is 0 if the shuffle was not started by this node, 1 while it is
+	// shuffling, 2 when it is done and 3 if it failed
+	Status int
+	Error  HTTPError
+
+	Round     int
+	Threshold int
+	// Batches and BatchCount describe the current round when it is shuffled in
+	// batches
+	Batches    int
+	BatchCount int
+
+	Contributors []ShuffleContribution
+	// Waiting contains the address of the nodes that have not contributed yet
+	Waiting      []string
+	Transactions []ShuffleTransaction
+
+	// StartedAt and EstimatedEnd are unix timestamps in seconds, 0 if unknown
+	StartedAt    int64
+	EstimatedEnd int64
+}
+
+// ShuffleContribution defines the accepted shuffle of a round
+type ShuffleContribution struct {
+	Round     int
+	Node      string
+	PublicKey string // hex-encoded
+}
+
+// ShuffleTransaction defines a shuffle transaction seen by the node
+type ShuffleTransaction struct {
+	ID         string // hex-encoded
+	Round      int
+	BatchIndex int
+	PublicKey  string // hex-encoded
+	// Status is 0 if pending, 1 if accepted and 2 if rejected
+	Status int
+	Reason string
+}
+
+// GetShuffleBallotsResponse defines the HTTP response when getting the ballots
+// to shuffle in the current round of a form
+type GetShuffleBallotsResponse struct {
+	Round      int
+	Threshold  int
+	FormStatus int
+	Pubkey     string // hex-encoded
+	// Ballots is empty if the form is not closed or the shuffle is over
+	Ballots []CiphervoteJSON
+	// Shufflers and Mixers are hex-encoded public keys
+	Shufflers []string
+	Mixers    []string
+	// Batched is true if the round is being shuffled in batches
+	Batched bool
+}
+
+// SubmitShuffleRequest defines the HTTP request to submit the shuffle of a
+// mixer
+type SubmitShuffleRequest struct {
+	// ShuffleBallots is the serialized ShuffleBallots transaction
+	ShuffleBallots []byte
+}
+
+// SubmitShuffleResponse defines the HTTP response when submitting the shuffle
+// of a mixer
+type SubmitShuffleResponse struct {
+	TxID string // hex-encoded
+}
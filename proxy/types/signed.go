@@ -1,11 +1,14 @@
 package types
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"io"
+	"strconv"
+	"time"
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/schnorr"
@@ -28,10 +31,69 @@ func NewSignedRequest(r io.Reader) (SignedRequest, error) {
 	return req, nil
 }
 
-// SignedRequest represents a frontend request signed by the web backend.
+// nonceSize is the number of random bytes of the nonce of a signed request.
+const nonceSize = 16
+
+// SignRequest returns a new signed request for the given endpoint. The message
+// is JSON encoded in the payload and a fresh timestamp and nonce are set.
+func SignRequest(secret kyber.Scalar, method, path string,
+	msg interface{}) (SignedRequest, error) {
+
+	jsonMsg, err := json.Marshal(msg)
+	if err != nil {
+		return SignedRequest{}, xerrors.Errorf("failed to marshal json: %v", err)
+	}
+
+	nonce := make([]byte, nonceSize)
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return SignedRequest{}, xerrors.Errorf("failed to generate nonce: %v", err)
+	}
+
+	signed := SignedRequest{
+		Payload:   base64.URLEncoding.EncodeToString(jsonMsg),
+		Timestamp: time.Now().Unix(),
+		Nonce:     hex.EncodeToString(nonce),
+		Method:    method,
+		Path:      path,
+	}
+
+	signature, err := schnorr.Sign(suite, secret, signed.Hash())
+	if err != nil {
+		return SignedRequest{}, xerrors.Errorf("failed to sign: %v", err)
+	}
+
+	signed.Signature = hex.EncodeToString(signature)
+
+	return signed, nil
+}
+
+// SignedRequest represents a frontend request signed by the web backend. The
+// signature covers the payload as well as the timestamp, the nonce and the
+// endpoint of the request so that it cannot be replayed.
 type SignedRequest struct {
-	Payload   string // url base64 encoded json message
-	Signature string // hex encoded signature on sha256(Payload)
+	Payload string // url base64 encoded json message
+	// Timestamp is the unix time, in seconds, at which the request was signed
+	Timestamp int64
+	// Nonce is a random string that is never used twice
+	Nonce string
+	// Method and Path are the HTTP method and the URL path of the endpoint the
+	// request is sent to
+	Method    string
+	Path      string
+	Signature string // hex encoded signature on Hash()
+}
+
+// Hash returns the hash that is signed, which is the sha256 of the payload,
+// the method, the path, the timestamp and the nonce separated by new lines.
+func (s SignedRequest) Hash() []byte {
+	hash := sha256.New()
+
+	hash.Write([]byte(s.Payload + "\n" + s.Method + "\n" + s.Path + "\n" +
+		strconv.FormatInt(s.Timestamp, 10) + "\n" + s.Nonce))
+
+	return hash.Sum(nil)
 }
 
 // GetMessage JSON unmarshals the payload to the given element. The given
@@ -50,17 +112,14 @@ func (s SignedRequest) GetMessage(el interface{}) error {
 	return nil
 }
 
-// Verify checks the signature. The signature should be on the sha256 of the
-// payload.
+// Verify checks the signature. The signature should be on Hash(). The freshness
+// of the request is not checked.
 func (s SignedRequest) Verify(pk kyber.Point) error {
 	if len(s.Payload) == 0 {
 		return xerrors.Errorf("cannot verify empty payload")
 	}
 
-	hash := sha256.New()
-
-	hash.Write([]byte(s.Payload))
-	md := hash.Sum(nil)
+	md := s.Hash()
 
 	sig, err := hex.DecodeString(s.Signature)
 	if err != nil {
@@ -2,7 +2,6 @@ package types
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"testing"
@@ -127,17 +126,14 @@ func TestVerify_ok(t *testing.T) {
 
 	payload := "xx"
 
-	hash := sha256.New()
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
+	signed := SignedRequest{
+		Payload: payload,
+	}
 
-	signature, err := schnorr.Sign(suite, secret, md)
+	signature, err := schnorr.Sign(suite, secret, signed.Hash())
 	require.NoError(t, err)
 
-	signed := SignedRequest{
-		Signature: hex.EncodeToString(signature),
-		Payload:   payload,
-	}
+	signed.Signature = hex.EncodeToString(signature)
 
 	err = signed.Verify(pk)
 	require.NoError(t, err)
@@ -163,17 +159,14 @@ func TestGetAndVerify_wrong_message(t *testing.T) {
 	msg := `{invalid json}`
 	payload := base64.URLEncoding.EncodeToString([]byte(msg))
 
-	hash := sha256.New()
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
+	signed := SignedRequest{
+		Payload: payload,
+	}
 
-	signature, err := schnorr.Sign(suite, secret, md)
+	signature, err := schnorr.Sign(suite, secret, signed.Hash())
 	require.NoError(t, err)
 
-	signed := SignedRequest{
-		Signature: hex.EncodeToString(signature),
-		Payload:   payload,
-	}
+	signed.Signature = hex.EncodeToString(signature)
 
 	var req map[string]interface{}
 
@@ -190,17 +183,14 @@ func TestGetAndVerify_ok(t *testing.T) {
 	msg := `{"Foo": "bar"}`
 	payload := base64.URLEncoding.EncodeToString([]byte(msg))
 
-	hash := sha256.New()
-	hash.Write([]byte(payload))
-	md := hash.Sum(nil)
+	signed := SignedRequest{
+		Payload: payload,
+	}
 
-	signature, err := schnorr.Sign(suite, secret, md)
+	signature, err := schnorr.Sign(suite, secret, signed.Hash())
 	require.NoError(t, err)
 
-	signed := SignedRequest{
-		Signature: hex.EncodeToString(signature),
-		Payload:   payload,
-	}
+	signed.Signature = hex.EncodeToString(signature)
 
 	type dummy struct {
 		Foo string
@@ -217,3 +207,31 @@ func TestGetAndVerify_ok(t *testing.T) {
 
 	require.Equal(t, expected, req)
 }
+
+func TestSignRequest(t *testing.T) {
+	secret := suite.Scalar().Pick(suite.RandomStream())
+	pk := suite.Point().Mul(secret, nil)
+
+	signed, err := SignRequest(secret, "PUT", "/evoting/forms/abcd",
+		map[string]string{"Foo": "bar"})
+	require.NoError(t, err)
+	require.Equal(t, "PUT", signed.Method)
+	require.Equal(t, "/evoting/forms/abcd", signed.Path)
+	require.NotZero(t, signed.Timestamp)
+	require.Len(t, signed.Nonce, nonceSize*2)
+
+	var req map[string]string
+
+	err = signed.GetAndVerify(pk, &req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Foo": "bar"}, req)
+
+	// the signature covers the endpoint
+	signed.Path = "/evoting/forms/ffff"
+
+	err = signed.Verify(pk)
+	require.ErrorContains(t, err, "invalid signature")
+
+	_, err = SignRequest(secret, "PUT", "/", make(chan int))
+	require.ErrorContains(t, err, "failed to marshal json")
+}
@@ -79,6 +79,7 @@ function init_nodes() {
     mkdir -p $NODEDIR
     rm -f $NODEDIR/node.log
     dvoting --config $NODEDIR start --postinstall --proxyaddr :$PROXYPORT --proxykey $PUBLIC_KEY \
+      --proxyorigins=$FRONTEND_URL \
       --listen tcp://0.0.0.0:$NODEPORT --public grpc://localhost:$NODEPORT --routing tree --noTLS |
       ts "Node-$n: " | tee $NODEDIR/node.log &
   done
@@ -2,6 +2,7 @@ package dkg
 
 import (
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/kyber/v3"
 )
 
@@ -41,14 +42,35 @@ type DKG interface {
 	Listen(formID []byte, txmngr txn.Manager) (Actor, error)
 
 	// GetActor allows to retrieve the Actor corresponding to a given
-	// formID. formID is NOT hex-encoded.
+	// formID. If the form is bound to a key ceremony, the returned Actor uses
+	// the ceremony's key but computes the public shares of that form. formID
+	// is NOT hex-encoded.
 	GetActor(formID []byte) (Actor, bool)
+
+	// ListenCeremony starts the RPC of a key ceremony, which is a DKG that is
+	// not linked to a form. Forms can be bound to it when they are opened.
+	// This function should be called on each node that wishes to participate
+	// in the ceremony. ceremonyID is NOT hex-encoded.
+	ListenCeremony(ceremonyID []byte, txmngr txn.Manager) (Actor, error)
+
+	// GetCeremony allows to retrieve the Actor of a key ceremony. ceremonyID
+	// is NOT hex-encoded.
+	GetCeremony(ceremonyID []byte) (Actor, bool)
+
+	// Ceremonies returns the IDs of all the key ceremonies known by this
+	// node. IDs are NOT hex-encoded.
+	Ceremonies() [][]byte
+
+	// Forms returns the IDs of the forms whose actor is loaded by this node,
+	// without the forms bound to a key ceremony. IDs are NOT hex-encoded.
+	Forms() [][]byte
 }
 
 // Actor defines the primitives to use a DKG protocol
 //
-// An actor is directly linked to a form; one should not be able to create
-// an Actor for a form that does not exist
+// An actor is either directly linked to a form, in which case one should not be
+// able to create an Actor for a form that does not exist, or to a key ceremony
+// that can be shared by several forms.
 type Actor interface {
 	// Setup must be first called by ONE of the actors to use the subsequent
 	// functions. It creates the public distributed key and the private share on
@@ -60,6 +82,11 @@ type Actor interface {
 	// setup has not been done.
 	GetPublicKey() (kyber.Point, error)
 
+	// GetParticipants returns the addresses of the nodes that took part in
+	// the setup and hold a share of the key. Returns an error if the setup
+	// has not been done.
+	GetParticipants() ([]mino.Address, error)
+
 	Encrypt(message []byte) (K, C kyber.Point, remainder []byte, err error)
 
 	// ComputePubshares sends a decryption request to all nodes. Nodes will then
@@ -20,14 +20,12 @@ import (
 	"go.dedis.ch/dela/core/store/kv"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/mino/proxy"
-	"go.dedis.ch/kyber/v3/suites"
 	"golang.org/x/xerrors"
 
 	eproxy "github.com/c4dt/d-voting/proxy"
+	"github.com/c4dt/d-voting/proxy/openapi"
 )
 
-var suite = suites.MustFind("Ed25519")
-
 // initAction is an action to initialize the DKG protocol
 //
 // - implements node.ActionTemplate
@@ -121,6 +119,92 @@ func (a *setupAction) Execute(ctx node.Context) error {
 	return nil
 }
 
+// initCeremonyAction is an action to initialize a key ceremony that forms
+// can be bound to
+//
+// - implements node.ActionTemplate
+type initCeremonyAction struct {
+}
+
+// Execute implements node.ActionTemplate. It creates an actor from the
+// dkgPedersen instance for the key ceremony.
+func (a *initCeremonyAction) Execute(ctx node.Context) error {
+	ceremonyID := ctx.Flags.String("ceremonyID")
+
+	ceremonyIDBuf, err := hex.DecodeString(ceremonyID)
+	if err != nil {
+		return xerrors.Errorf("failed to decode ceremonyID: %v", err)
+	}
+
+	var dkg dkg.DKG
+	err = ctx.Injector.Resolve(&dkg)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve DKG: %v", err)
+	}
+
+	signer, err := getSigner(ctx.Flags)
+	if err != nil {
+		return xerrors.Errorf("failed to get signer: %v", err)
+	}
+
+	client, err := makeClient(ctx.Injector)
+	if err != nil {
+		return xerrors.Errorf("failed to make client: %v", err)
+	}
+
+	_, err = dkg.ListenCeremony(ceremonyIDBuf, signed.NewManager(signer, &client))
+	if err != nil {
+		return xerrors.Errorf("failed to start the RPC: %v", err)
+	}
+
+	dela.Logger.Info().Msgf("key ceremony %s was successfully initialized", ceremonyID)
+
+	return nil
+}
+
+// setupCeremonyAction is an action to setup a key ceremony and generate its
+// collective public key
+//
+// - implements node.ActionTemplate
+type setupCeremonyAction struct {
+}
+
+// Execute implements node.ActionTemplate. It requests the setup of the key
+// ceremony.
+func (a *setupCeremonyAction) Execute(ctx node.Context) error {
+	ceremonyIDBuf, err := hex.DecodeString(ctx.Flags.String("ceremonyID"))
+	if err != nil {
+		return xerrors.Errorf("failed to decode ceremonyID: %v", err)
+	}
+
+	var dkg dkg.DKG
+	err = ctx.Injector.Resolve(&dkg)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve DKG: %v", err)
+	}
+
+	actor, exists := dkg.GetCeremony(ceremonyIDBuf)
+	if !exists {
+		return xerrors.Errorf("key ceremony %x not found", ceremonyIDBuf)
+	}
+
+	pubkey, err := actor.Setup()
+	if err != nil {
+		return xerrors.Errorf("failed to setup key ceremony: %v", err)
+	}
+
+	pubkeyBuf, err := pubkey.MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("failed to encode pubkey: %v", err)
+	}
+
+	dela.Logger.Info().
+		Hex("DKG public key", pubkeyBuf).
+		Msg("DKG public key")
+
+	return nil
+}
+
 // exportInfoAction is an action to display a base64 string describing the node.
 // It can be used to transmit the identity of a node to another one.
 //
@@ -262,23 +346,23 @@ func (a *RegisterHandlersAction) Execute(ctx node.Context) error {
 
 	mngr := signed.NewManager(signer, &client)
 
-	proxykeyHex := ctx.Flags.String("proxykey")
-
-	proxykeyBuf, err := hex.DecodeString(proxykeyHex)
+	keys, err := eproxy.NewKeyRingFromFlags(ctx.Flags)
 	if err != nil {
-		return xerrors.Errorf("failed to decode proxykeyHex: %v", err)
+		return xerrors.Errorf("failed to get proxy keys: %v", err)
 	}
 
-	proxykey := suite.Point()
-
-	err = proxykey.UnmarshalBinary(proxykeyBuf)
+	limiter, err := eproxy.ResolveLimiter(ctx.Injector, ctx.Flags)
 	if err != nil {
-		return xerrors.Errorf("failed to unmarshal proxy key: %v", err)
+		return xerrors.Errorf("failed to get proxy limiter: %v", err)
 	}
 
 	router := mux.NewRouter()
+	router.Use(eproxy.MetricsMiddleware)
+	router.Use(eproxy.TracingMiddleware)
+	router.Use(eproxy.NewCORSFromFlags(ctx.Flags).Middleware)
+	router.Use(limiter.Middleware)
 
-	ep := eproxy.NewDKG(mngr, dkg, proxykey)
+	ep := eproxy.NewDKG(mngr, dkg, keys, limiter)
 
 	// Link the request to the proxy
 	router.HandleFunc("/evoting/services/dkg/actors", ep.NewDKGActor).Methods("POST")
@@ -286,6 +370,14 @@ func (a *RegisterHandlersAction) Execute(ctx node.Context) error {
 	router.HandleFunc("/evoting/services/dkg/actors/{formID}", ep.Actor).Methods("GET")
 	router.HandleFunc("/evoting/services/dkg/actors/{formID}", ep.EditDKGActor).Methods("PUT")
 	router.HandleFunc("/evoting/services/dkg/actors/{formID}", eproxy.AllowCORS).Methods("OPTIONS")
+	router.HandleFunc("/evoting/services/dkg/ceremonies", ep.Ceremonies).Methods("GET")
+	router.HandleFunc("/evoting/services/dkg/ceremonies", ep.NewCeremony).Methods("POST")
+	router.HandleFunc("/evoting/services/dkg/ceremonies", eproxy.AllowCORS).Methods("OPTIONS")
+	router.HandleFunc("/evoting/services/dkg/ceremonies/{ceremonyID}", ep.Ceremony).Methods("GET")
+	router.HandleFunc("/evoting/services/dkg/ceremonies/{ceremonyID}", ep.EditCeremony).Methods("PUT")
+	router.HandleFunc("/evoting/services/dkg/ceremonies/{ceremonyID}", eproxy.AllowCORS).Methods("OPTIONS")
+
+	openapi.WarnUndocumented(router)
 
 	router.NotFoundHandler = http.HandlerFunc(eproxy.NotFoundHandler)
 	router.MethodNotAllowedHandler = http.HandlerFunc(eproxy.NotAllowedHandler)
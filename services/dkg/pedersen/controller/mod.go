@@ -62,6 +62,24 @@ func (m controller) SetCommands(builder node.Builder) {
 	sub.SetFlags(formIDFlag)
 	sub.SetAction(builder.MakeAction(&setupAction{}))
 
+	ceremonyIDFlag := cli.StringFlag{
+		Name:     "ceremonyID",
+		Usage:    "the key ceremony ID, formatted in hexadecimal",
+		Required: true,
+	}
+
+	// dvoting --config /tmp/node1 dkg initCeremony --ceremonyID ceremonyID
+	sub = cmd.SetSubCommand("initCeremony")
+	sub.SetDescription("initialize a DKG key ceremony that forms can be bound to")
+	sub.SetFlags(ceremonyIDFlag)
+	sub.SetAction(builder.MakeAction(&initCeremonyAction{}))
+
+	// dvoting --config /tmp/node1 dkg setupCeremony --ceremonyID ceremonyID
+	sub = cmd.SetSubCommand("setupCeremony")
+	sub.SetDescription("create the public distributed key of a key ceremony")
+	sub.SetFlags(ceremonyIDFlag)
+	sub.SetAction(builder.MakeAction(&setupCeremonyAction{}))
+
 	sub = cmd.SetSubCommand("export")
 	sub.SetDescription("export the node address and public key")
 	sub.SetAction(builder.MakeAction(&exportInfoAction{}))
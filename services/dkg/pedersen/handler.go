@@ -1,18 +1,17 @@
 package pedersen
 
 import (
-	"bytes"
 	"container/list"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"io"
-	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/c4dt/d-voting/contracts/evoting"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"go.dedis.ch/dela/core/execution/native"
 	"go.dedis.ch/dela/core/txn"
@@ -22,6 +21,7 @@ import (
 	jsondela "go.dedis.ch/dela/serde/json"
 
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/confirm"
 	"github.com/c4dt/d-voting/services/dkg"
 	"github.com/c4dt/d-voting/services/dkg/pedersen/types"
 	"go.dedis.ch/dela"
@@ -59,6 +59,9 @@ type Handler struct {
 	txmnger         txn.Manager
 	pubSharesSigner crypto.Signer
 
+	// confirm submits the transactions of the handler one after the other
+	confirm confirm.Service
+
 	// These are persistent, see HandlerData
 	startRes  *state
 	privShare *share.PriShare
@@ -95,6 +98,7 @@ func NewHandler(me mino.Address, service ordering.Service, pool pool.Pool,
 		pool:            pool,
 		txmnger:         txnmngr,
 		pubSharesSigner: pubSharesSigner,
+		confirm:         confirm.NewService(service, pool, txnmngr),
 
 		startRes:  startRes,
 		privShare: privShare,
@@ -264,15 +268,22 @@ func (h *Handler) start(start types.Start, deals, resps *list.List, from mino.Ad
 func (h *Handler) doDKG(deals, resps *list.List, out mino.Sender, from mino.Address) {
 	h.log.Info().Str("action", "deal").Msg("new state")
 	*h.status = dkg.Status{Status: dkg.Dealing}
+	timer := prometheus.NewTimer(evoting.PromDkgPhaseDuration.WithLabelValues("deal"))
 	h.deal(out)
+	timer.ObserveDuration()
 
 	h.log.Info().Str("action", "respond").Msg("new state")
 	*h.status = dkg.Status{Status: dkg.Responding}
+	timer = prometheus.NewTimer(evoting.PromDkgPhaseDuration.WithLabelValues("respond"))
 	h.respond(deals, out)
+	timer.ObserveDuration()
 
 	h.log.Info().Str("action", "certify").Msg("new state")
 	*h.status = dkg.Status{Status: dkg.Certifying}
+	timer = prometheus.NewTimer(evoting.PromDkgPhaseDuration.WithLabelValues("certify"))
 	err := h.certify(resps, out)
+	timer.ObserveDuration()
+
 	if err != nil {
 		dela.Logger.Error().Msgf("failed to certify: %v", err)
 		return
@@ -435,34 +446,23 @@ func (h *Handler) handleDeal(msg types.Deal, out mino.Sender) error {
 // handleDecryptRequest computes the public shares of a form and sends them
 // to the chain to allow decryption to proceed.
 func (h *Handler) handleDecryptRequest(formID string) error {
-	shuffleInstances, err := h.getShuffleIfValid(formID)
+	lastShuffle, err := h.getShuffleIfValid(formID)
 	if err != nil {
 		return xerrors.Errorf("failed to check if the shuffle is over: %v", err)
 	}
 
-	numberOfShuffles := len(shuffleInstances)
-	numberOfBallots := len(shuffleInstances[numberOfShuffles-1].ShuffledBallots)
-	publicShares := make([][]etypes.Pubshare, numberOfBallots)
-
 	h.RLock()
+	v := h.privShare.V.Clone()
+	h.RUnlock()
 
-	for i, ballot := range shuffleInstances[numberOfShuffles-1].ShuffledBallots {
-		ballotShares := make([]etypes.Pubshare, len(ballot))
-
-		for j, ciphertext := range ballot {
-			S := suite.Point().Mul(h.privShare.V, ciphertext.K)
-
-			partialVal := suite.Point().Sub(ciphertext.C, S)
-
-			ballotShares[j] = partialVal
-		}
+	timer := prometheus.NewTimer(evoting.PromPubSharesComputation)
 
-		publicShares[i] = ballotShares
-	}
+	// the ballots are spread over the CPUs
+	publicShares := etypes.ComputePubshares(v, lastShuffle.ShuffledBallots)
 
-	h.RUnlock()
+	timer.ObserveDuration()
 
-	err = h.txmnger.Sync()
+	err = h.confirm.Sync()
 	if err != nil {
 		return xerrors.Errorf("failed to sync manager: %v", err)
 	}
@@ -477,7 +477,7 @@ func (h *Handler) handleDecryptRequest(formID string) error {
 
 		//TODO: Works with current "shuffleThreshold", but the shuffle threshold
 		// should be smaller in theory ? (1/3 + 1 vs 2/3 + 1 ? )
-		nbrSubmissions := len(form.PubsharesUnits.Pubshares)
+		nbrSubmissions := len(form.PubsharesUnits.IDs)
 
 		if nbrSubmissions >= form.ShuffleThreshold {
 			dela.Logger.Info().Msgf("decryption possible with shares from %d nodes",
@@ -485,60 +485,55 @@ func (h *Handler) handleDecryptRequest(formID string) error {
 			return nil
 		}
 
-		tx, err := makeTx(h.context, &form, publicShares, h.privShare.I,
-			h.txmnger, h.pubSharesSigner)
-
-		if err != nil {
-			return xerrors.Errorf("failed to make tx: %v", err)
+		makePubSharesTx := func() (txn.Transaction, error) {
+			return makeTx(h.context, &form, publicShares, h.privShare.I,
+				h.txmnger, h.pubSharesSigner)
 		}
 
-		// TODO: Define in term of size of form ? (same in shuffle)
-		watchTimeout := 4 + rand.Intn(form.ShuffleThreshold)
-		watchCtx, cancel := context.WithTimeout(context.Background(), time.Duration(watchTimeout)*time.Second)
-		defer cancel()
-
-		events := h.service.Watch(watchCtx)
-
-		err = h.pool.Add(tx)
+		_, res, err := h.confirm.SubmitAndWait(context.Background(), makePubSharesTx)
 		if err != nil {
-			return xerrors.Errorf("failed to add transaction to the pool: %v", err)
+			return xerrors.Errorf("failed to submit tx: %v", err)
 		}
 
-		accepted, msg := watchTx(events, tx.GetID())
-
-		if accepted {
+		if res.Accepted {
 			dela.Logger.Info().Msgf("pubShares accepted on the chain (index: %d)", h.privShare.I)
 			return nil
 		}
 
-		err = h.txmnger.Sync()
+		err = h.confirm.Sync()
 		if err != nil {
 			return xerrors.Errorf("failed to sync manager: %v", err)
 		}
 
-		dela.Logger.Info().Msgf("submission of pubShares denied: %s", msg)
-
-		cancel()
+		dela.Logger.Info().Msgf("submission of pubShares denied: %s", res.Reason)
 	}
 }
 
 // getShuffleIfValid allows checking if enough shuffles have been made on the
-// ballots.
-func (h *Handler) getShuffleIfValid(formID string) ([]etypes.ShuffleInstance, error) {
-	form, err := etypes.FormFromStore(h.context, h.formFac, formID, h.service.GetStore())
+// ballots. It returns the last shuffle.
+func (h *Handler) getShuffleIfValid(formID string) (etypes.ShuffleInstance, error) {
+	store := h.service.GetStore()
+
+	form, err := etypes.FormFromStore(h.context, h.formFac, formID, store)
 	if err != nil {
-		return nil, xerrors.Errorf("could not get the form: %v", err)
+		return etypes.ShuffleInstance{}, xerrors.Errorf("could not get the form: %v", err)
 	}
 
 	if len(form.ShuffleInstances) == 0 {
-		return nil, xerrors.New("form has no shuffles")
+		return etypes.ShuffleInstance{}, xerrors.New("form has no shuffles")
 	}
 
 	if form.Status != etypes.ShuffledBallots {
-		return nil, xerrors.New("ballots have not been shuffled")
+		return etypes.ShuffleInstance{}, xerrors.New("ballots have not been shuffled")
+	}
+
+	lastShuffle, err := form.LastShuffle(h.context, store)
+	if err != nil {
+		return etypes.ShuffleInstance{}, xerrors.Errorf("could not get the last "+
+			"shuffle: %v", err)
 	}
 
-	return form.ShuffleInstances, nil
+	return lastShuffle, nil
 }
 
 // MarshalJSON returns a JSON-encoded bytestring containing all the data in the
@@ -804,30 +799,6 @@ func (s *state) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// watchTx checks the transaction to find one that match txID. Returns if the
-// transaction has been accepted or not. Will also return false if/when the
-// events chan is closed, which is expected to happen.
-func watchTx(events <-chan ordering.Event, txID []byte) (bool, string) {
-	for event := range events {
-		for _, res := range event.Transactions {
-			if !bytes.Equal(res.GetTransaction().GetID(), txID) {
-				continue
-			}
-
-			dela.Logger.Info().Hex("id", txID).Msg("transaction included in the block")
-
-			accepted, msg := res.GetStatus()
-			if accepted {
-				return true, ""
-			}
-
-			return false, msg
-		}
-	}
-
-	return false, "watch timeout"
-}
-
 func makeTx(ctx serde.Context, form *etypes.Form, pubShares etypes.PubsharesUnit,
 	index int,
 	manager txn.Manager,
@@ -13,6 +13,7 @@ import (
 	"go.dedis.ch/dela/mino/minogrpc/session"
 	"go.dedis.ch/dela/serde/json"
 
+	"github.com/c4dt/d-voting/internal/confirm"
 	"github.com/c4dt/d-voting/internal/testing/fake"
 	"github.com/c4dt/d-voting/services/dkg"
 	"github.com/c4dt/d-voting/services/dkg/pedersen/types"
@@ -55,9 +56,10 @@ func TestHandler_Stream(t *testing.T) {
 	)
 
 	units := formTypes.PubsharesUnits{
-		Pubshares: make([]formTypes.PubsharesUnit, 0),
-		PubKeys:   make([][]byte, 0),
-		Indexes:   make([]int, 0),
+		IDs:     make([][]byte, 0),
+		Hashes:  make([][]byte, 0),
+		PubKeys: make([][]byte, 0),
+		Indexes: make([]int, 0),
 	}
 
 	form := formTypes.Form{
@@ -66,13 +68,18 @@ func TestHandler_Stream(t *testing.T) {
 		Status:           formTypes.ShuffledBallots,
 		Pubkey:           nil,
 		BallotSize:       0,
-		ShuffleInstances: make([]formTypes.ShuffleInstance, 1),
+		ShuffleInstances: make([]formTypes.ShuffleRef, 0),
 		ShuffleThreshold: 0,
 		PubsharesUnits:   units,
 		DecryptedBallots: nil,
 		Roster:           fake.Authority{},
 	}
 
+	ballotSnap := fake.NewSnapshot()
+
+	err = form.StoreShuffle(json.NewContext(), ballotSnap, formTypes.ShuffleInstance{}, true)
+	require.NoError(t, err)
+
 	Forms := make(map[string]formTypes.Form)
 	Forms[formIDHex] = form
 
@@ -85,12 +92,13 @@ func TestHandler_Stream(t *testing.T) {
 		Status:     false,
 		Channel:    nil,
 		Context:    json.NewContext(),
-		BallotSnap: fake.NewSnapshot(),
+		BallotSnap: ballotSnap,
 	}
 
 	h.context = json.NewContext()
 	h.pubSharesSigner = fake.NewSigner()
 	h.txmnger = fake.Manager{}
+	h.confirm = confirm.NewService(h.service, h.pool, h.txmnger)
 
 	err = h.Stream(fake.NewBadSender(), receiver)
 	require.NoError(t, err) // Threshold = 0 => no submission required
@@ -287,9 +295,10 @@ func TestHandler_HandlerDecryptRequest(t *testing.T) {
 	formIDHex := hex.EncodeToString([]byte("form"))
 
 	units := formTypes.PubsharesUnits{
-		Pubshares: make([]formTypes.PubsharesUnit, 0),
-		PubKeys:   make([][]byte, 0),
-		Indexes:   make([]int, 0),
+		IDs:     make([][]byte, 0),
+		Hashes:  make([][]byte, 0),
+		PubKeys: make([][]byte, 0),
+		Indexes: make([]int, 0),
 	}
 
 	form := formTypes.Form{
@@ -298,13 +307,18 @@ func TestHandler_HandlerDecryptRequest(t *testing.T) {
 		Status:           formTypes.ShuffledBallots,
 		Pubkey:           nil,
 		BallotSize:       0,
-		ShuffleInstances: make([]formTypes.ShuffleInstance, 1),
+		ShuffleInstances: make([]formTypes.ShuffleRef, 0),
 		ShuffleThreshold: 1,
 		PubsharesUnits:   units,
 		DecryptedBallots: nil,
 		Roster:           fake.Authority{},
 	}
 
+	ballotSnap := fake.NewSnapshot()
+
+	err := form.StoreShuffle(json.NewContext(), ballotSnap, formTypes.ShuffleInstance{}, true)
+	require.NoError(t, err)
+
 	Forms := make(map[string]formTypes.Form)
 	Forms[formIDHex] = form
 
@@ -321,7 +335,7 @@ func TestHandler_HandlerDecryptRequest(t *testing.T) {
 		Status:     false,
 		Channel:    nil,
 		Context:    json.NewContext(),
-		BallotSnap: fake.NewSnapshot(),
+		BallotSnap: ballotSnap,
 	}
 
 	h.context = json.NewContext()
@@ -339,11 +353,14 @@ func TestHandler_HandlerDecryptRequest(t *testing.T) {
 
 	// Bad manager:
 	h.txmnger = fake.Manager{}
+	h.confirm = confirm.NewService(h.service, h.pool, h.txmnger)
 
-	err := h.handleDecryptRequest(formIDHex)
-	require.EqualError(t, err, fake.Err("failed to make tx: failed to use manager"))
+	err = h.handleDecryptRequest(formIDHex)
+	require.EqualError(t, err, fake.Err("failed to submit tx: failed to submit: failed to make tx: "+
+		"failed to use manager"))
 
 	h.txmnger = signed.NewManager(fake.NewSigner(), fakeClient{})
+	h.confirm = confirm.NewService(h.service, h.pool, h.txmnger)
 
 	// All good:
 
@@ -371,7 +388,9 @@ func TestHandler_HandlerDecryptRequest(t *testing.T) {
 	shuffledBallots, err := form.Suffragia(service.Context, snap)
 	require.NoError(t, err)
 	shuffleInstance := formTypes.ShuffleInstance{ShuffledBallots: shuffledBallots.Ciphervotes}
-	form.ShuffleInstances = append(form.ShuffleInstances, shuffleInstance)
+
+	err = form.StoreShuffle(service.Context, ballotSnap, shuffleInstance, true)
+	require.NoError(t, err)
 
 	Forms[formIDHex] = form
 
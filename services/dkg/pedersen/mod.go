@@ -13,6 +13,7 @@ import (
 
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 
 	"github.com/c4dt/d-voting/contracts/evoting"
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
@@ -29,17 +30,25 @@ import (
 	jsonserde "go.dedis.ch/dela/serde/json"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/suites"
-	"go.dedis.ch/kyber/v3/util/random"
 	"golang.org/x/net/context"
 	"golang.org/x/xerrors"
 
-	// Register the JSON format for the form
+	// Register the JSON and protobuf formats for the form
 	_ "github.com/c4dt/d-voting/contracts/evoting/json"
+	_ "github.com/c4dt/d-voting/contracts/evoting/protobuf"
 )
 
 // BucketName is the name of the bucket in the database.
 const BucketName = "dkgmap"
 
+// CeremonyBucketName is the name of the bucket in the database that holds the
+// key ceremonies.
+const CeremonyBucketName = "dkgceremonies"
+
+// ceremonySegment prefixes the RPC segment of a key ceremony so that it can't
+// collide with the segment of a form.
+const ceremonySegment = "ceremony-"
+
 // suite is the Kyber suite for Pedersen.
 var suite = suites.MustFind("Ed25519")
 
@@ -74,6 +83,16 @@ type Pedersen struct {
 	signer  crypto.Signer
 	actors  map[string]dkg.Actor
 	db      kv.DB
+
+	// ceremonies are the key ceremonies, indexed by their hex-encoded ID.
+	ceremonies map[string]*Actor
+}
+
+// rosterProvider is implemented by the ordering services that can return the
+// current roster of the chain, such as cosipbft.Service. It is needed to set
+// up a key ceremony, which is not linked to the roster of a form.
+type rosterProvider interface {
+	GetRoster() (authority.Authority, error)
 }
 
 // NewPedersen returns a new DKG Pedersen factory
@@ -93,6 +112,8 @@ func NewPedersen(m mino.Mino, service ordering.Service,
 		signer:  signer,
 		formFac: formFac,
 		db:      db,
+
+		ceremonies: make(map[string]*Actor),
 	}
 }
 
@@ -118,72 +139,223 @@ func (s *Pedersen) Listen(formIDBuf []byte, txmngr txn.Manager) (dkg.Actor, erro
 	return s.NewActor(formIDBuf, s.pool, txmngr, NewHandlerData())
 }
 
+// ListenCeremony implements dkg.DKG. It must be called on each node that
+// participates in the key ceremony.
+func (s *Pedersen) ListenCeremony(ceremonyIDBuf []byte,
+	txmngr txn.Manager) (dkg.Actor, error) {
+
+	if len(ceremonyIDBuf) == 0 {
+		return nil, xerrors.Errorf("ceremonyID is empty")
+	}
+
+	actor, exists := s.GetCeremony(ceremonyIDBuf)
+	if exists {
+		return actor, xerrors.Errorf("key ceremony %x already exists", ceremonyIDBuf)
+	}
+
+	return s.NewCeremonyActor(ceremonyIDBuf, s.pool, txmngr, NewHandlerData())
+}
+
 // NewActor initializes a dkg.Actor with an RPC specific to the form with
 // the given keypair
 func (s *Pedersen) NewActor(formIDBuf []byte, pool pool.Pool, txmngr txn.Manager,
 	handlerData HandlerData) (dkg.Actor,
 	error) {
 
+	a := s.newActor(formIDBuf, false, pool, txmngr, handlerData)
+
+	s.Lock()
+	defer s.Unlock()
+	s.actors[a.formID] = a
+
+	return a, a.store()
+}
+
+// NewCeremonyActor initializes a dkg.Actor with an RPC specific to the key
+// ceremony with the given keypair
+func (s *Pedersen) NewCeremonyActor(ceremonyIDBuf []byte, pool pool.Pool,
+	txmngr txn.Manager, handlerData HandlerData) (dkg.Actor, error) {
+
+	a := s.newActor(ceremonyIDBuf, true, pool, txmngr, handlerData)
+
+	s.Lock()
+	defer s.Unlock()
+	s.ceremonies[a.formID] = a
+
+	return a, a.store()
+}
+
+// newActor creates an actor whose RPC is linked to the given ID, which is
+// either a form ID or a key ceremony ID.
+func (s *Pedersen) newActor(idBuf []byte, ceremony bool, pool pool.Pool,
+	txmngr txn.Manager, handlerData HandlerData) *Actor {
+
 	// hex-encoded string
-	formID := hex.EncodeToString(formIDBuf)
+	id := hex.EncodeToString(idBuf)
+
+	bucket := BucketName
+	segment := id
+
+	if ceremony {
+		bucket = CeremonyBucketName
+		segment = ceremonySegment + id
+	}
 
 	ctx := jsonserde.NewContext()
 
 	status := &dkg.Status{Status: dkg.Initialized}
 
-	// link the actor to an RPC by the form ID
+	// link the actor to an RPC by the form or ceremony ID
 	h := NewHandler(s.mino.GetAddress(), s.service, pool, txmngr, s.signer,
 		handlerData, ctx, s.formFac, status, func(h *Handler) {
-			err := storeHandler(formID, s.db, h)
+			err := storeHandler(bucket, id, s.db, h)
 			if err != nil {
 				dela.Logger.Err(err).Msg("While storing the dkg handler")
 			}
 		})
 
-	no := s.mino.WithSegment(formID)
+	no := s.mino.WithSegment(segment)
 	rpc := mino.MustCreateRPC(no, RPC, h, s.factory)
 
 	log := dela.Logger.With().Str("role", "DKG actor").Logger()
 
 	a := &Actor{
-		rpc:     rpc,
-		factory: s.factory,
-		service: s.service,
-		context: ctx,
-		formFac: s.formFac,
-		handler: h,
-		formID:  formID,
-		status:  status,
-		log:     log,
-		db:      s.db,
+		rpc:      rpc,
+		factory:  s.factory,
+		service:  s.service,
+		context:  ctx,
+		formFac:  s.formFac,
+		handler:  h,
+		formID:   id,
+		ceremony: ceremony,
+		status:   status,
+		log:      log,
+		db:       s.db,
 	}
 
-	evoting.PromFormDkgStatus.WithLabelValues(formID).Set(float64(dkg.Initialized))
+	evoting.PromFormDkgStatus.WithLabelValues(id).Set(float64(dkg.Initialized))
 
-	s.Lock()
-	defer s.Unlock()
-	s.actors[formID] = a
-
-	return a, a.store()
+	return a
 }
 
 // GetActor implements dkg.DKG
 func (s *Pedersen) GetActor(formIDBuf []byte) (dkg.Actor, bool) {
 	s.RLock()
-	defer s.RUnlock()
 	actor, exists := s.actors[hex.EncodeToString(formIDBuf)]
-	return actor, exists
+	s.RUnlock()
+
+	if exists {
+		return actor, true
+	}
+
+	return s.getBoundActor(formIDBuf)
+}
+
+// getBoundActor returns the actor of the key ceremony the form is bound to,
+// if any.
+func (s *Pedersen) getBoundActor(formIDBuf []byte) (dkg.Actor, bool) {
+	formID := hex.EncodeToString(formIDBuf)
+
+	form, err := etypes.FormFromStore(jsonserde.NewContext(), s.formFac, formID,
+		s.service.GetStore())
+	if err != nil || form.CeremonyID == "" {
+		return nil, false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	ceremony, exists := s.ceremonies[form.CeremonyID]
+	if !exists {
+		return nil, false
+	}
+
+	return boundActor{Actor: ceremony, formID: formID}, true
+}
+
+// GetCeremony implements dkg.DKG
+func (s *Pedersen) GetCeremony(ceremonyIDBuf []byte) (dkg.Actor, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	actor, exists := s.ceremonies[hex.EncodeToString(ceremonyIDBuf)]
+	if !exists {
+		return nil, false
+	}
+
+	return actor, true
+}
+
+// Ceremonies implements dkg.DKG
+func (s *Pedersen) Ceremonies() [][]byte {
+	s.RLock()
+	defer s.RUnlock()
+
+	ids := make([][]byte, 0, len(s.ceremonies))
+
+	for id := range s.ceremonies {
+		idBuf, err := hex.DecodeString(id)
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, idBuf)
+	}
+
+	return ids
+}
+
+// Forms implements dkg.DKG
+func (s *Pedersen) Forms() [][]byte {
+	s.RLock()
+	defer s.RUnlock()
+
+	ids := make([][]byte, 0, len(s.actors))
+
+	for id := range s.actors {
+		idBuf, err := hex.DecodeString(id)
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, idBuf)
+	}
+
+	return ids
 }
 
+// ReadActors fills the actors and key ceremonies from the database.
 func (s *Pedersen) ReadActors(txmngr txn.Manager) error {
 	// Use dkgMap to fill the actors map
 	return s.db.View(func(tx kv.ReadableTx) error {
 		bucket := tx.GetBucket([]byte(BucketName))
+		if bucket != nil {
+			err := bucket.ForEach(func(formIDBuf, handlerDataBuf []byte) error {
+
+				handlerData := HandlerData{}
+				err := json.Unmarshal(handlerDataBuf, &handlerData)
+				if err != nil {
+					return err
+				}
+
+				_, err = s.NewActor(formIDBuf, s.pool, txmngr, handlerData)
+				if err != nil {
+					return err
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		bucket = tx.GetBucket([]byte(CeremonyBucketName))
 		if bucket == nil {
 			return nil
 		}
 
-		return bucket.ForEach(func(formIDBuf, handlerDataBuf []byte) error {
+		return bucket.ForEach(func(ceremonyIDBuf, handlerDataBuf []byte) error {
 
 			handlerData := HandlerData{}
 			err := json.Unmarshal(handlerDataBuf, &handlerData)
@@ -191,7 +363,7 @@ func (s *Pedersen) ReadActors(txmngr txn.Manager) error {
 				return err
 			}
 
-			_, err = s.NewActor(formIDBuf, s.pool, txmngr, handlerData)
+			_, err = s.NewCeremonyActor(ceremonyIDBuf, s.pool, txmngr, handlerData)
 			if err != nil {
 				return err
 			}
@@ -211,10 +383,18 @@ type Actor struct {
 	context serde.Context
 	formFac serde.Factory
 	handler *Handler
-	formID  string
-	status  *dkg.Status
-	log     zerolog.Logger
-	db      kv.DB
+	// formID is the hex-encoded ID of the form, or of the key ceremony if
+	// ceremony is true.
+	formID   string
+	ceremony bool
+	status   *dkg.Status
+	log      zerolog.Logger
+	db       kv.DB
+
+	// encrypter encrypts with the key of the DKG. It is created on the first
+	// encryption, once the key is known, or by Precompute.
+	encrypterLock sync.Mutex
+	encrypter     *etypes.Encrypter
 }
 
 func (a *Actor) setErr(err error, args map[string]interface{}) {
@@ -239,32 +419,33 @@ func (a *Actor) Setup() (kyber.Point, error) {
 		return nil, err
 	}
 
-	form, err := etypes.FormFromStore(a.context, a.formFac, a.formID, a.service.GetStore())
+	roster, err := a.getRoster()
 	if err != nil {
-		err := xerrors.Errorf("failed to get form: %v", err)
 		a.setErr(err, nil)
 		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
 	defer cancel()
-	ctx = context.WithValue(ctx, tracing.ProtocolKey, protocolNameSetup)
 
-	sender, receiver, err := a.rpc.Stream(ctx, form.Roster)
+	ctx, span := tracing.StartStream(ctx, protocolNameSetup, a.formID)
+	defer span.End()
+
+	sender, receiver, err := a.rpc.Stream(ctx, roster)
 	if err != nil {
 		err := xerrors.Errorf("failed to stream: %v", err)
 		a.setErr(err, nil)
 		return nil, err
 	}
 
-	if form.Roster.Len() == 0 {
+	if roster.Len() == 0 {
 		err := xerrors.Errorf("the roster is empty")
 		a.setErr(err, nil)
 		return nil, err
 	}
 
-	addrs := make([]mino.Address, 0, form.Roster.Len())
-	addrIter := form.Roster.AddressIterator()
+	addrs := make([]mino.Address, 0, roster.Len())
+	addrIter := roster.AddressIterator()
 	for addrIter.HasNext() {
 		addrs = append(addrs, addrIter.GetNext())
 	}
@@ -371,17 +552,47 @@ func (a *Actor) Setup() (kyber.Point, error) {
 	return dkgPubKeys[0], a.store()
 }
 
+// getRoster returns the roster taking part in the DKG: the roster of the form,
+// or the current roster of the chain for a key ceremony.
+func (a *Actor) getRoster() (authority.Authority, error) {
+	if a.ceremony {
+		provider, ok := a.service.(rosterProvider)
+		if !ok {
+			return nil, xerrors.Errorf("the ordering service can't provide the roster")
+		}
+
+		roster, err := provider.GetRoster()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get roster: %v", err)
+		}
+
+		return roster, nil
+	}
+
+	form, err := etypes.FormFromStore(a.context, a.formFac, a.formID, a.service.GetStore())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get form: %v", err)
+	}
+
+	return form.Roster, nil
+}
+
 func (a *Actor) store() error {
-	return storeHandler(a.formID, a.db, a.handler)
+	bucket := BucketName
+	if a.ceremony {
+		bucket = CeremonyBucketName
+	}
+
+	return storeHandler(bucket, a.formID, a.db, a.handler)
 }
-func storeHandler(formID string, db kv.DB, h *Handler) error {
+func storeHandler(bucketName string, formID string, db kv.DB, h *Handler) error {
 	return db.Update(func(tx kv.WritableTx) error {
 		formIDBuf, err := hex.DecodeString(formID)
 		if err != nil {
 			return err
 		}
 
-		bucket, err := tx.GetBucketOrCreate([]byte(BucketName))
+		bucket, err := tx.GetBucketOrCreate([]byte(bucketName))
 		if err != nil {
 			return err
 		}
@@ -404,6 +615,15 @@ func (a *Actor) GetPublicKey() (kyber.Point, error) {
 	return a.handler.startRes.GetDistKey(), nil
 }
 
+// GetParticipants implements dkg.Actor
+func (a *Actor) GetParticipants() ([]mino.Address, error) {
+	if !a.handler.startRes.Done() {
+		return nil, xerrors.Errorf("dkg has not been initialized")
+	}
+
+	return a.handler.startRes.GetParticipants(), nil
+}
+
 // Encrypt implements dkg.Actor. It uses the DKG public key to encrypt a
 // message.
 func (a *Actor) Encrypt(message []byte) (K, C kyber.Point, remainder []byte,
@@ -413,25 +633,61 @@ func (a *Actor) Encrypt(message []byte) (K, C kyber.Point, remainder []byte,
 		return nil, nil, nil, xerrors.Errorf("setup() was not called")
 	}
 
-	// Embed the message (or as much of it as will fit) into a curve point.
-	M := suite.Point().Embed(message, random.New())
-	max := suite.Point().EmbedLen()
-	if max > len(message) {
-		max = len(message)
+	pair, _, remainder := a.getEncrypter().Encrypt(message)
+
+	return pair.K, pair.C, remainder, nil
+}
+
+// Precompute computes n ephemeral keys for the next encryptions, which then
+// take an embedding and an addition per chunk. It replaces the keys of a
+// previous call that are left. Nothing is computed ahead of time unless it is
+// called, as the actor may only encrypt a few messages.
+func (a *Actor) Precompute(n int) error {
+	if !a.handler.startRes.Done() {
+		return xerrors.Errorf("setup() was not called")
+	}
+
+	encrypter := etypes.NewEncrypter(a.handler.startRes.GetDistKey(), n)
+
+	err := encrypter.Precompute(n)
+	if err != nil {
+		return xerrors.Errorf("failed to precompute: %v", err)
 	}
-	remainder = message[max:]
-	// ElGamal-encrypt the point to produce ciphertext (K,C).
-	k := suite.Scalar().Pick(random.New())                     // ephemeral private key
-	K = suite.Point().Mul(k, nil)                              // ephemeral DH public key
-	S := suite.Point().Mul(k, a.handler.startRes.GetDistKey()) // ephemeral DH shared secret
-	C = S.Add(S, M)                                            // message blinded with secret
 
-	return K, C, remainder, nil
+	a.encrypterLock.Lock()
+	a.encrypter = encrypter
+	a.encrypterLock.Unlock()
+
+	return nil
+}
+
+// getEncrypter returns the encrypter of the key of the DKG. Unless Precompute
+// was called, it computes the ephemeral keys when it encrypts.
+func (a *Actor) getEncrypter() *etypes.Encrypter {
+	a.encrypterLock.Lock()
+	defer a.encrypterLock.Unlock()
+
+	if a.encrypter == nil {
+		a.encrypter = etypes.NewEncrypter(a.handler.startRes.GetDistKey(), 0)
+	}
+
+	return a.encrypter
 }
 
 // ComputePubshares implements dkg.Actor. It sends a decrypt request to all
 // the nodes taking part.
 func (a *Actor) ComputePubshares() error {
+	if a.ceremony {
+		return xerrors.Errorf("a key ceremony is not linked to a form, " +
+			"pubshares must be computed on a form bound to it")
+	}
+
+	return a.computePubshares(a.formID)
+}
+
+// computePubshares sends a request to compute the pubshares of the given form
+// to all the nodes taking part.
+func (a *Actor) computePubshares(formID string) error {
 
 	if !a.handler.startRes.Done() {
 		return xerrors.Errorf("setup() was not called")
@@ -444,7 +700,9 @@ func (a *Actor) ComputePubshares() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), decryptTimeout)
 	defer cancel()
-	ctx = context.WithValue(ctx, tracing.ProtocolKey, protocolNameDecrypt)
+
+	ctx, span := tracing.StartStream(ctx, protocolNameDecrypt, formID)
+	defer span.End()
 
 	sender, _, err := a.rpc.Stream(ctx, players)
 	if err != nil {
@@ -457,7 +715,7 @@ func (a *Actor) ComputePubshares() error {
 		addrs = append(addrs, iterator.GetNext())
 	}
 
-	message := types.NewDecryptRequest(a.formID)
+	message := types.NewDecryptRequest(formID)
 
 	err = <-sender.Send(message, addrs...)
 	if err != nil {
@@ -478,3 +736,25 @@ func (a *Actor) MarshalJSON() ([]byte, error) {
 func (a *Actor) Status() dkg.Status {
 	return *a.status
 }
+
+// boundActor is the actor of a key ceremony seen from a form that is bound to
+// it. The form keeps its own shuffle and decryption state on the chain.
+//
+// - implements dkg.Actor
+type boundActor struct {
+	*Actor
+	formID string
+}
+
+// Setup implements dkg.Actor. A form bound to a key ceremony can't be set up,
+// the setup must be done on the ceremony.
+func (b boundActor) Setup() (kyber.Point, error) {
+	return nil, xerrors.Errorf("form %s is bound to key ceremony %s, which "+
+		"must be set up directly", b.formID, b.Actor.formID)
+}
+
+// ComputePubshares implements dkg.Actor. It sends a decrypt request for the
+// form to all the nodes taking part in the key ceremony.
+func (b boundActor) ComputePubshares() error {
+	return b.Actor.computePubshares(b.formID)
+}
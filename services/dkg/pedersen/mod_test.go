@@ -309,10 +309,13 @@ func TestPedersen_Listen(t *testing.T) {
 	p := NewPedersen(fake.Mino{}, &service, fake.NewInMemoryDB(), &fake.Pool{},
 		fake.Factory{}, fake.Signer{})
 
+	require.Empty(t, p.Forms())
+
 	actor, err := p.Listen(formIDBuf, fake.Manager{})
 	require.NoError(t, err)
 
 	require.NotNil(t, actor)
+	require.Equal(t, [][]byte{formIDBuf}, p.Forms())
 }
 
 // If Listen is called twice for the same form, the actor data is unchanged
@@ -556,7 +559,9 @@ func TestPedersen_Scenario(t *testing.T) {
 	require.NoError(t, err)
 	shuffledBallots := suff.Ciphervotes
 	shuffleInstance := etypes.ShuffleInstance{ShuffledBallots: shuffledBallots}
-	form.ShuffleInstances = append(form.ShuffleInstances, shuffleInstance)
+
+	err = form.StoreShuffle(serdecontext, st, shuffleInstance, true)
+	require.NoError(t, err)
 
 	form.ShuffleThreshold = 1
 
@@ -584,6 +589,9 @@ func TestPedersen_Encrypt_NotStarted(t *testing.T) {
 
 	_, _, _, err := a.Encrypt(nil)
 	require.EqualError(t, err, "setup() was not called")
+
+	err = a.Precompute(1)
+	require.EqualError(t, err, "setup() was not called")
 }
 
 func TestPedersen_Encrypt_OK(t *testing.T) {
@@ -612,6 +620,19 @@ func TestPedersen_Encrypt_OK(t *testing.T) {
 	message, err := m.Data()
 	require.NoError(t, err)
 	require.Equal(t, msg[:29], message)
+
+	// the ephemeral keys are computed ahead
+	err = a.Precompute(2)
+	require.NoError(t, err)
+
+	k, c, _, err = a.Encrypt(msg)
+	require.NoError(t, err)
+
+	m = suite.Point().Sub(c, suite.Point().Mul(secret, k))
+
+	message, err = m.Data()
+	require.NoError(t, err)
+	require.Equal(t, msg[:29], message)
 }
 
 func TestPedersen_ComputePubshares_NotStarted(t *testing.T) {
@@ -684,6 +705,73 @@ func TestPedersen_ComputePubshares_OK(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// A key ceremony is not linked to a form, but forms bound to it get an actor
+// that uses the ceremony's key.
+func TestPedersen_Ceremony(t *testing.T) {
+	initMetrics()
+
+	formID := "d3adbeef"
+	ceremonyID := "c0ffee"
+
+	service := fake.NewService(formID, etypes.Form{
+		FormID:     formID,
+		CeremonyID: ceremonyID,
+		Roster:     fake.Authority{},
+	}, serdecontext)
+
+	db := fake.NewInMemoryDB()
+
+	p := NewPedersen(fake.Mino{}, &service, db, &fake.Pool{}, formFac, fake.Signer{})
+
+	_, err := p.ListenCeremony(nil, fake.Manager{})
+	require.EqualError(t, err, "ceremonyID is empty")
+
+	ceremonyIDBuf, err := hex.DecodeString(ceremonyID)
+	require.NoError(t, err)
+
+	ceremony, err := p.ListenCeremony(ceremonyIDBuf, fake.Manager{})
+	require.NoError(t, err)
+
+	_, err = p.ListenCeremony(ceremonyIDBuf, fake.Manager{})
+	require.EqualError(t, err, "key ceremony c0ffee already exists")
+
+	actor, exists := p.GetCeremony(ceremonyIDBuf)
+	require.True(t, exists)
+	require.Equal(t, ceremony, actor)
+	require.Equal(t, [][]byte{ceremonyIDBuf}, p.Ceremonies())
+
+	err = ceremony.ComputePubshares()
+	require.EqualError(t, err, "a key ceremony is not linked to a form, "+
+		"pubshares must be computed on a form bound to it")
+
+	// The ceremony is not an actor of a form
+	_, exists = p.actors[ceremonyID]
+	require.False(t, exists)
+
+	formIDBuf, err := hex.DecodeString(formID)
+	require.NoError(t, err)
+
+	bound, exists := p.GetActor(formIDBuf)
+	require.True(t, exists)
+
+	_, err = bound.Setup()
+	require.EqualError(t, err, "form d3adbeef is bound to key ceremony c0ffee, "+
+		"which must be set up directly")
+
+	err = bound.ComputePubshares()
+	require.EqualError(t, err, "setup() was not called")
+
+	// The ceremony is restored from the database
+	q := NewPedersen(fake.Mino{}, &service, db, &fake.Pool{}, formFac, fake.Signer{})
+
+	err = q.ReadActors(fake.Manager{})
+	require.NoError(t, err)
+
+	actor, exists = q.GetCeremony(ceremonyIDBuf)
+	require.True(t, exists)
+	requireActorsEqual(t, ceremony, actor)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
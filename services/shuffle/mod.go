@@ -1,9 +1,115 @@
 package shuffle
 
 import (
+	"time"
+
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+// ErrWrongStatus is wrapped by the error of StartShuffle when the form can't
+// be shuffled in its current status or its shuffle is already running.
+var ErrWrongStatus = xerrors.New("wrong status")
+
+// StatusCode is the type used to define the status of a shuffle
+type StatusCode uint16
+
+const (
+	// NotStarted indicates that the shuffle has not been started by this node
+	NotStarted StatusCode = 0
+	// Shuffling indicates that the shuffle is in progress
+	Shuffling StatusCode = 1
+	// Done indicates that the threshold of shuffles has been reached
+	Done StatusCode = 2
+	// Failed indicates that the shuffle failed
+	Failed StatusCode = 3
+)
+
+// TxStatusCode is the type used to define the status of a shuffle transaction
+type TxStatusCode uint16
+
+const (
+	// TxPending indicates that the transaction has not been included yet
+	TxPending TxStatusCode = 0
+	// TxAccepted indicates that the transaction has been accepted
+	TxAccepted TxStatusCode = 1
+	// TxRejected indicates that the transaction has been rejected
+	TxRejected TxStatusCode = 2
 )
 
+// Status defines the progress of the shuffle of a form, as seen by a node.
+type Status struct {
+	Status StatusCode
+	// Err is set when the status is Failed
+	Err error
+
+	// Round is the number of rounds already shuffled
+	Round int
+	// Threshold is the number of rounds needed
+	Threshold int
+	// Batches is the number of batches of the current round already accepted
+	// and BatchCount the total number of batches of the round. Both are 0 if
+	// the round is not shuffled in batches.
+	Batches    int
+	BatchCount int
+
+	// Contributors are the accepted shuffles, one per round
+	Contributors []Contribution
+	// Waiting contains the address of the roster members that have not
+	// contributed yet
+	Waiting []string
+	// Transactions are the shuffle transactions seen by the node
+	Transactions []Tx
+
+	// StartedAt is the time at which the node started the shuffle. It is zero
+	// if the shuffle was not started by this node.
+	StartedAt time.Time
+	// EstimatedEnd is the estimated completion of the shuffle. It is zero if it
+	// cannot be estimated yet.
+	EstimatedEnd time.Time
+}
+
+// Contribution defines the accepted shuffle of a round
+type Contribution struct {
+	Round int
+	// Node is the address of the roster member, empty if unknown
+	Node      string
+	PublicKey []byte
+}
+
+// Tx defines a shuffle transaction of a form
+type Tx struct {
+	ID         []byte
+	Round      int
+	BatchIndex int
+	PublicKey  []byte
+	Status     TxStatusCode
+	// Reason is the reason of the refusal when the transaction is rejected
+	Reason string
+}
+
+// Round defines the ballots to shuffle in the current round of a form. It is
+// used by mixers that are not part of the roster.
+type Round struct {
+	// Index is the index of the round, which is the number of rounds already
+	// shuffled
+	Index      int
+	Threshold  int
+	FormStatus etypes.Status
+	// Pubkey is the public key of the form
+	Pubkey      kyber.Point
+	Ciphervotes []etypes.Ciphervote
+	// Shufflers are the public keys of the nodes that already shuffled
+	Shufflers [][]byte
+	// Mixers are the public keys of the authorized mixers
+	Mixers [][]byte
+	// Batched is true if the round is being shuffled in batches, in which case
+	// a mixer must wait for the end of the round.
+	Batched bool
+}
+
 // Shuffle defines the primitive to start a shuffle protocol
 type Shuffle interface {
 	// Listen starts the RPC. This function should be called on each node that
@@ -14,6 +120,26 @@ type Shuffle interface {
 // Actor defines the primitives to use a shuffle protocol
 type Actor interface {
 	// Shuffle must be called by ONE of the actor to shuffle the list of ElGamal
-	// pairs. Each node represented by a player must first execute Listen().
+	// pairs. Each node represented by a player must first execute Listen(). It
+	// returns once the shuffle is over, whose progress can be followed with
+	// Status().
 	Shuffle(formID []byte, userID string) (err error)
+
+	// StartShuffle does the same as Shuffle but returns as soon as the
+	// shuffle is started. It returns an error if the form can't be shuffled,
+	// which wraps ErrWrongStatus if the shuffle can't start yet or anymore.
+	StartShuffle(formID []byte, userID string) error
+
+	// Status returns the progress of the shuffle of the form. The error wraps
+	// etypes.ErrFormNotFound if the form doesn't exist.
+	Status(formID []byte) (Status, error)
+
+	// GetRound returns the ballots to shuffle in the current round of the
+	// form. The error wraps etypes.ErrFormNotFound if the form doesn't exist.
+	GetRound(formID []byte) (Round, error)
+
+	// SubmitShuffle submits the shuffle made by a mixer that is not part of
+	// the roster. It returns the ID of the transaction, whose status is
+	// reported by Status().
+	SubmitShuffle(shuffleBallots etypes.ShuffleBallots) ([]byte, error)
 }
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"encoding/hex"
 	"net/http"
 
 	"github.com/c4dt/d-voting/services/shuffle"
@@ -13,14 +12,12 @@ import (
 	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation"
 	"go.dedis.ch/dela/mino/proxy"
-	"go.dedis.ch/kyber/v3/suites"
 	"golang.org/x/xerrors"
 
 	eproxy "github.com/c4dt/d-voting/proxy"
+	"github.com/c4dt/d-voting/proxy/openapi"
 )
 
-var suite = suites.MustFind("ed25519")
-
 // InitAction is an action to initialize the shuffle protocol
 //
 // - implements node.ActionTemplate
@@ -82,25 +79,32 @@ func (a *RegisterHandlersAction) Execute(ctx node.Context) error {
 		return xerrors.Errorf("failed to resolve dkg.DKG: %v", err)
 	}
 
-	proxykeyHex := ctx.Flags.String("proxykey")
-
-	proxykeyBuf, err := hex.DecodeString(proxykeyHex)
+	keys, err := eproxy.NewKeyRingFromFlags(ctx.Flags)
 	if err != nil {
-		return xerrors.Errorf("failed to decode proxykeyHex: %v", err)
+		return xerrors.Errorf("failed to get proxy keys: %v", err)
 	}
 
-	proxykey := suite.Point()
-
-	err = proxykey.UnmarshalBinary(proxykeyBuf)
+	limiter, err := eproxy.ResolveLimiter(ctx.Injector, ctx.Flags)
 	if err != nil {
-		return xerrors.Errorf("failed to unmarshal proxy key: %v", err)
+		return xerrors.Errorf("failed to get proxy limiter: %v", err)
 	}
 
 	router := mux.NewRouter()
+	router.Use(eproxy.MetricsMiddleware)
+	router.Use(eproxy.TracingMiddleware)
+	router.Use(eproxy.NewCORSFromFlags(ctx.Flags).Middleware)
+	router.Use(limiter.Middleware)
 
-	ep := eproxy.NewShuffle(actor, proxykey)
+	ep := eproxy.NewShuffle(actor, keys, limiter)
 
+	router.HandleFunc("/evoting/services/shuffle/{formID}", ep.Shuffle).Methods("GET")
 	router.HandleFunc("/evoting/services/shuffle/{formID}", ep.EditShuffle).Methods("PUT")
+	router.HandleFunc("/evoting/services/shuffle/{formID}", eproxy.AllowCORS).Methods("OPTIONS")
+	router.HandleFunc("/evoting/services/shuffle/{formID}/ballots", ep.Ballots).Methods("GET")
+	router.HandleFunc("/evoting/services/shuffle/{formID}/ballots", ep.SubmitBallots).Methods("POST")
+	router.HandleFunc("/evoting/services/shuffle/{formID}/ballots", eproxy.AllowCORS).Methods("OPTIONS")
+
+	openapi.WarnUndocumented(router)
 
 	router.NotFoundHandler = http.HandlerFunc(eproxy.NotFoundHandler)
 	router.MethodNotAllowedHandler = http.HandlerFunc(eproxy.NotAllowedHandler)
@@ -50,6 +50,16 @@ func (m controller) SetCommands(builder node.Builder) {
 	sub = cmd.SetSubCommand("registerHandlers")
 	sub.SetDescription("register the proxy handlers")
 	sub.SetAction(builder.MakeAction(&RegisterHandlersAction{}))
+
+	builder.SetStartFlags(
+		cli.IntFlag{
+			Name: "shufflebatchsize",
+			Usage: "the maximum number of ballots shuffled together, " +
+				"0 to shuffle all the ballots of a round at once",
+			Required: false,
+			Value:    0,
+		},
+	)
 }
 
 // OnStart implements node.Initializer. It creates and registers a neff
@@ -93,6 +103,14 @@ func (m controller) OnStart(ctx cli.Flags, inj node.Injector) error {
 	neffShuffle := neff.NewNeffShuffle(no, service, p, blocks,
 		etypes.NewFormFactory(etypes.CiphervoteFactory{}, rosterFac), signer)
 
+	batchSize := ctx.Int("shufflebatchsize")
+	if batchSize != 0 && batchSize < etypes.MinShuffleBatchSize {
+		return xerrors.Errorf("shuffle batch size must be at least %d: %d",
+			etypes.MinShuffleBatchSize, batchSize)
+	}
+
+	neffShuffle.SetBatchSize(batchSize)
+
 	inj.Inject(neffShuffle)
 
 	return nil
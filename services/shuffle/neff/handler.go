@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"fmt"
+	"runtime"
+	"sync"
 	"time"
 
 	"go.dedis.ch/kyber/v3"
 
 	"github.com/c4dt/d-voting/contracts/evoting"
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/confirm"
+	"github.com/c4dt/d-voting/services/shuffle"
 	"github.com/c4dt/d-voting/services/shuffle/neff/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/execution/native"
 	"go.dedis.ch/dela/core/ordering"
@@ -27,6 +33,14 @@ import (
 
 var suite = suites.MustFind("Ed25519")
 
+// pendingTimeout is the time after which a node takes over the round from
+// another node that does not make progress on the batches of the round.
+const pendingTimeout = time.Minute * 2
+
+// pendingCheckInterval is the time between two checks of the progress of a
+// round shuffled in batches by another node.
+const pendingCheckInterval = time.Second * 2
+
 // Handler represents the RPC executed on each node
 //
 // - implements mino.Handler
@@ -39,6 +53,16 @@ type Handler struct {
 	shuffleSigner crypto.Signer
 	context       serde.Context
 	formFac       serde.Factory
+
+	// confirm submits the transactions of the handler one after the other
+	confirm confirm.Service
+
+	// batchSize is the maximum number of ballots shuffled together. If it is
+	// 0, all the ballots of a round are shuffled at once.
+	batchSize int
+
+	// progress records the state of the transactions submitted by the node
+	progress *progress
 }
 
 // NewHandler creates a new handler
@@ -52,8 +76,10 @@ func NewHandler(me mino.Address, service ordering.Service, p pool.Pool,
 		p:             p,
 		txmngr:        txmngr,
 		shuffleSigner: shuffleSigner,
+		confirm:       confirm.NewService(service, p, txmngr),
 		context:       ctx,
 		formFac:       formFac,
+		progress:      newProgress(),
 	}
 }
 
@@ -84,11 +110,14 @@ func (h *Handler) Stream(out mino.Sender, in mino.Receiver) error {
 func (h *Handler) handleStartShuffle(formID string, userID string) error {
 	dela.Logger.Info().Msg("Starting the neff shuffle protocol ...")
 
-	err := h.txmngr.Sync()
+	err := h.confirm.Sync()
 	if err != nil {
 		return xerrors.Errorf("failed to sync manager: %v", err.Error())
 	}
 
+	var lastPending string
+	pendingSince := time.Now()
+
 	// loop until the threshold is reached or our transaction has been accepted
 	for {
 		form, err := etypes.FormFromStore(h.context, h.formFac, formID, h.service.GetStore())
@@ -99,7 +128,7 @@ func (h *Handler) handleStartShuffle(formID string, userID string) error {
 		round := len(form.ShuffleInstances)
 
 		// check if the threshold is reached
-		if round >= form.ShuffleThreshold {
+		if round >= form.ShuffleRounds() {
 			dela.Logger.Info().Msgf("shuffle done with round n°%d", round)
 			return nil
 		}
@@ -108,28 +137,77 @@ func (h *Handler) handleStartShuffle(formID string, userID string) error {
 			return xerrors.Errorf("the form must be closed: (%v)", form.Status)
 		}
 
-		tx, err := h.makeTx(&form, userID)
-		if err != nil {
-			return xerrors.Errorf("failed to make tx: %v", err)
+		if len(form.Mixers) != 0 {
+			mixer, err := h.isMixer(form.Mixers)
+			if err != nil {
+				return xerrors.Errorf("failed to check mixers: %v", err)
+			}
+
+			if !mixer {
+				dela.Logger.Info().Msgf("form %s is shuffled by its mixers", formID)
+				return nil
+			}
 		}
-		watchTimeout := 4 + form.ShuffleThreshold/2
-		watchCtx, cancel := context.WithTimeout(context.Background(), time.Duration(watchTimeout)*time.Second)
-		defer cancel()
 
-		events := h.service.Watch(watchCtx)
+		var accepted bool
+		var takeover bool
+		var msg string
 
-		err = h.p.Add(tx)
-		if err != nil {
-			// it is possible that an error is returned in case the nonce is not
-			// synced. In that case we sync and retry.
-			err = h.txmngr.Sync()
+		if form.PendingShuffle != nil || h.batchSize != 0 {
+			mine, err := h.isPendingMine(form.PendingShuffle)
 			if err != nil {
-				dela.Logger.Warn().Err(err).Msgf("failed to add tx, syncing nonce")
-				return xerrors.Errorf("failed to sync manager: %v", err.Error())
+				return xerrors.Errorf("failed to check pending shuffle: %v", err)
+			}
+
+			if !mine {
+				// another node is shuffling the round in batches, we wait as
+				// long as it makes progress.
+				pending := fmt.Sprintf("%d:%d", round, form.PendingShuffle.Batches)
+				if pending != lastPending {
+					lastPending = pending
+					pendingSince = time.Now()
+				}
+
+				if time.Since(pendingSince) <= pendingTimeout {
+					time.Sleep(pendingCheckInterval)
+					continue
+				}
+
+				// the smart contract refuses the takeover before, so there is
+				// no use in proving a batch.
+				stalled, err := h.isStalled(form.PendingShuffle)
+				if err != nil {
+					return xerrors.Errorf("failed to check pending shuffle: %v", err)
+				}
+
+				if !stalled {
+					time.Sleep(pendingCheckInterval)
+					continue
+				}
+
+				dela.Logger.Warn().Msgf("round %d shuffled in batches by "+
+					"another node is stalled, taking it over", round)
 			}
-		}
 
-		accepted, msg := watchTx(events, tx.GetID())
+			takeover = !mine
+
+			accepted, msg, err = h.submitBatches(&form, userID, takeover)
+			if err != nil {
+				return xerrors.Errorf("failed to submit batches: %v", err)
+			}
+		} else {
+			shuffleBallots, err := h.makeShuffle(&form, userID)
+			if err != nil {
+				return xerrors.Errorf("failed to make shuffle: %v", err)
+			}
+
+			info := shuffle.Tx{Round: round}
+
+			accepted, msg, err = h.submitTx(formID, shuffleBallots, info)
+			if err != nil {
+				return xerrors.Errorf("failed to submit tx: %v", err)
+			}
+		}
 
 		if accepted {
 			dela.Logger.Info().Msg("our shuffling contribution has " +
@@ -138,22 +216,206 @@ func (h *Handler) handleStartShuffle(formID string, userID string) error {
 			return nil
 		}
 
-		err = h.txmngr.Sync()
+		err = h.confirm.Sync()
 		if err != nil {
 			return xerrors.Errorf("failed to sync manager: %v", err.Error())
 		}
 
 		dela.Logger.Info().Msg("shuffling contribution denied : " + msg)
 
-		cancel()
+		if takeover {
+			time.Sleep(pendingCheckInterval)
+		}
 	}
 }
 
-func (h *Handler) makeTx(form *etypes.Form, userID string) (txn.Transaction, error) {
+// isMixer returns true if the node is one of the mixers.
+func (h *Handler) isMixer(mixers [][]byte) (bool, error) {
+	publicKey, err := h.shuffleSigner.GetPublicKey().MarshalBinary()
+	if err != nil {
+		return false, xerrors.Errorf("failed to marshal public key: %v", err)
+	}
+
+	for _, mixer := range mixers {
+		if bytes.Equal(mixer, publicKey) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isStalled returns true once the pending shuffle has no new batch for
+// evoting.ShuffleTakeoverBlocks blocks, see evoting.BlockCountId, so that the
+// smart contract lets another node start the round over.
+func (h *Handler) isStalled(pending *etypes.ShuffleRef) (bool, error) {
+	blocks, err := evoting.GetBlockCount(h.service.GetStore())
+	if err != nil {
+		return false, xerrors.Errorf("failed to get block count: %v", err)
+	}
+
+	return blocks >= pending.LastBlock+evoting.ShuffleTakeoverBlocks, nil
+}
+
+// isPendingMine returns true if there is no pending shuffle or if it is made
+// by this node.
+func (h *Handler) isPendingMine(pending *etypes.ShuffleRef) (bool, error) {
+	if pending == nil {
+		return true, nil
+	}
+
+	publicKey, err := h.shuffleSigner.GetPublicKey().MarshalBinary()
+	if err != nil {
+		return false, xerrors.Errorf("failed to marshal public key: %v", err)
+	}
+
+	return bytes.Equal(pending.ShufflerPublicKey, publicKey), nil
+}
+
+// submitTx submits the shuffle and waits for it to be included. It returns
+// true if the transaction has been accepted, or the reason of the refusal
+// otherwise. The state of the transaction, described by info, is recorded in
+// the progress of the form.
+func (h *Handler) submitTx(formID string, shuffleBallots etypes.ShuffleBallots,
+	info shuffle.Tx) (bool, string, error) {
+
+	receipt, info, err := h.submit(formID, shuffleBallots, info)
+	if err != nil {
+		return false, "", xerrors.Errorf("failed to submit shuffle: %v", err)
+	}
+
+	res := h.wait(formID, receipt, info)
+
+	return res.Accepted, res.Reason, nil
+}
+
+// submit adds the transaction of the shuffle to the pool and records it as
+// pending in the progress of the form. It returns the info of the transaction.
+func (h *Handler) submit(formID string, shuffleBallots etypes.ShuffleBallots,
+	info shuffle.Tx) (confirm.Receipt, shuffle.Tx, error) {
+
+	// the shuffle of a mixer is submitted on its behalf
+	if info.PublicKey == nil {
+		publicKey, err := h.shuffleSigner.GetPublicKey().MarshalBinary()
+		if err != nil {
+			return confirm.Receipt{}, info, xerrors.Errorf("failed to marshal "+
+				"public key: %v", err)
+		}
+
+		info.PublicKey = publicKey
+	}
+
+	makeTx := func() (txn.Transaction, error) {
+		return h.makeShuffleTx(shuffleBallots)
+	}
+
+	receipt, err := h.confirm.Submit(context.Background(), makeTx)
+	if err != nil {
+		return confirm.Receipt{}, info, xerrors.Errorf("failed to add tx: %v", err)
+	}
+
+	info.ID = receipt.GetTransaction().GetID()
+	info.Status = shuffle.TxPending
+
+	h.progress.setTx(formID, info)
+
+	return receipt, info, nil
+}
+
+// wait waits for the result of the transaction and records it in the progress
+// of the form. A transaction that has not been included is still pending.
+func (h *Handler) wait(formID string, receipt confirm.Receipt,
+	info shuffle.Tx) confirm.Result {
+
+	res := receipt.Wait()
+
+	if res.Included {
+		info.Status = shuffle.TxAccepted
+		if !res.Accepted {
+			info.Status = shuffle.TxRejected
+			info.Reason = res.Reason
+		}
+
+		h.progress.setTx(formID, info)
+	}
+
+	return res
+}
+
+// submitBatches shuffles the remaining batches of the current round and submits
+// them one after the other. It returns true once all the batches have been
+// accepted, or the reason of the refusal of a batch otherwise. If takeover is
+// true, the round is started over in place of the node whose pending shuffle
+// is stalled.
+func (h *Handler) submitBatches(form *etypes.Form, userID string,
+	takeover bool) (bool, string, error) {
+
+	batchSize := h.batchSize
+	start := 0
+
+	if form.PendingShuffle != nil {
+		batchSize = form.PendingShuffle.BatchSize
+
+		if !takeover {
+			start = form.PendingShuffle.Batches
+		}
+	}
+
+	// the smart contract refuses to start the round over until the pending
+	// shuffle has no new batch for evoting.ShuffleTakeoverBlocks blocks, so
+	// the first batch is made and submitted alone.
+	if takeover {
+		accepted, msg, err := h.submitBatchRange(form, userID, batchSize, 0, 1)
+		if err != nil || !accepted {
+			return accepted, msg, err
+		}
+
+		start = 1
+	}
+
+	return h.submitBatchRange(form, userID, batchSize, start, 0)
+}
+
+// submitBatchRange makes the batches from the given index, at most limit of
+// them if it is not 0, see makeBatches, and submits them one after the other.
+func (h *Handler) submitBatchRange(form *etypes.Form, userID string, batchSize,
+	start, limit int) (bool, string, error) {
+
+	batches, err := h.makeBatches(form, userID, batchSize, start, limit)
+	if err != nil {
+		return false, "", xerrors.Errorf("failed to make batches: %v", err)
+	}
+
+	for i, batch := range batches {
+		info := shuffle.Tx{
+			Round:      len(form.ShuffleInstances),
+			BatchIndex: start + i,
+		}
+
+		accepted, msg, err := h.submitTx(form.FormID, batch, info)
+		if err != nil {
+			return false, "", xerrors.Errorf("failed to submit batch %d: %v", start+i, err)
+		}
+
+		if !accepted {
+			return false, fmt.Sprintf("batch %d: %s", start+i, msg), nil
+		}
+
+		dela.Logger.Info().Msgf("shuffle of batch %d accepted", start+i+1)
+	}
+
+	return true, "", nil
+}
+
+// makeShuffle shuffles the ballots of the current round at once and proves the
+// shuffle.
+func (h *Handler) makeShuffle(form *etypes.Form, userID string) (etypes.ShuffleBallots,
+	error) {
 
 	shuffledBallots, getProver, err := h.getShuffledBallots(form)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to get shuffled ballots: %v", err)
+		return etypes.ShuffleBallots{}, xerrors.Errorf("failed to get shuffled "+
+			"ballots: %v", err)
 	}
 
 	shuffleBallots := etypes.ShuffleBallots{
@@ -163,11 +425,100 @@ func (h *Handler) makeTx(form *etypes.Form, userID string) (txn.Transaction, err
 		ShuffledBallots: shuffledBallots,
 	}
 
+	err = proveShuffle(h.shuffleSigner, h.context, &shuffleBallots,
+		form.ChunksPerBallot(), getProver)
+	if err != nil {
+		return etypes.ShuffleBallots{}, xerrors.Errorf("failed to prove shuffle: %v", err)
+	}
+
+	return shuffleBallots, nil
+}
+
+// makeBatches shuffles the ballots of the current round in batches of at most
+// batchSize ballots, starting from the given batch index, and makes at most
+// limit batches if it is not 0. The batches are shuffled and proven in
+// parallel.
+func (h *Handler) makeBatches(form *etypes.Form, userID string, batchSize,
+	start, limit int) ([]etypes.ShuffleBallots, error) {
+
+	ciphervotes, err := h.getRoundBallots(form)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get ballots of the round: %v", err)
+	}
+
+	round := len(form.ShuffleInstances)
+	count := etypes.ShuffleBatchCount(len(ciphervotes), batchSize)
+
+	// there is nothing left to make once the first batch of a round of a
+	// single batch is taken over
+	if start > count {
+		return nil, xerrors.Errorf("batch %d is out of range: %d batches", start, count)
+	}
+
+	if limit != 0 && start+limit < count {
+		count = start + limit
+	}
+
+	batches := make([]etypes.ShuffleBallots, count-start)
+	errs := make([]error, len(batches))
+
+	workers := make(chan struct{}, runtime.NumCPU())
+	wg := sync.WaitGroup{}
+
+	for i := range batches {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			indexes := etypes.ShuffleBatch(round, len(ciphervotes), batchSize, start+i)
+			batch := etypes.SelectCiphervotes(ciphervotes, indexes)
+
+			shuffled, getProver, err := shuffleCiphervotes(form.Pubkey, batch)
+			if err != nil {
+				errs[i] = xerrors.Errorf("failed to shuffle: %v", err)
+				return
+			}
+
+			batches[i] = etypes.ShuffleBallots{
+				FormID:          form.FormID,
+				UserID:          userID,
+				Round:           round,
+				ShuffledBallots: shuffled,
+				BatchSize:       batchSize,
+				BatchIndex:      start + i,
+			}
+
+			errs[i] = proveShuffle(h.shuffleSigner, h.context, &batches[i],
+				form.ChunksPerBallot(), getProver)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := range batches {
+		if errs[i] != nil {
+			return nil, xerrors.Errorf("failed to make batch %d: %v", start+i, errs[i])
+		}
+	}
+
+	return batches, nil
+}
+
+// proveShuffle fills the random vector, the proof and the signature of the
+// shuffled ballots.
+func proveShuffle(signer crypto.Signer, ctx serde.Context,
+	shuffleBallots *etypes.ShuffleBallots, chunks int,
+	getProver func(e []kyber.Scalar) (proof.Prover, error)) error {
+
 	hash := sha256.New()
 
-	err = shuffleBallots.Fingerprint(hash)
+	err := shuffleBallots.Fingerprint(hash)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to get fingerprint: %v", err)
+		return xerrors.Errorf("failed to get fingerprint: %v", err)
 	}
 
 	seed := hash.Sum(nil)
@@ -175,24 +526,28 @@ func (h *Handler) makeTx(form *etypes.Form, userID string) (txn.Transaction, err
 	// Generate random vector and proof
 	semiRandomStream, err := evoting.NewSemiRandomStream(seed)
 	if err != nil {
-		return nil, xerrors.Errorf("could not create semi-random stream: %v", err)
+		return xerrors.Errorf("could not create semi-random stream: %v", err)
 	}
 
-	e := make([]kyber.Scalar, form.ChunksPerBallot())
+	e := make([]kyber.Scalar, chunks)
 
-	for i := 0; i < form.ChunksPerBallot(); i++ {
+	for i := 0; i < chunks; i++ {
 		v := suite.Scalar().Pick(semiRandomStream)
 		e[i] = v
 	}
 
 	prover, err := getProver(e)
 	if err != nil {
-		return nil, xerrors.Errorf("could not get prover for shuffle : %v", err)
+		return xerrors.Errorf("could not get prover for shuffle : %v", err)
 	}
 
+	timer := prometheus.NewTimer(evoting.PromShuffleProofGeneration)
+
 	shuffleProof, err := proof.HashProve(suite, protocolName, prover)
+	timer.ObserveDuration()
+
 	if err != nil {
-		return nil, xerrors.Errorf("shuffle proof failed: %v", err)
+		return xerrors.Errorf("shuffle proof failed: %v", err)
 	}
 
 	shuffleBallots.Proof = shuffleProof
@@ -200,29 +555,34 @@ func (h *Handler) makeTx(form *etypes.Form, userID string) (txn.Transaction, err
 
 	err = shuffleBallots.RandomVector.LoadFromScalars(e)
 	if err != nil {
-		return nil, xerrors.Errorf("could not marshal shuffle random vector")
+		return xerrors.Errorf("could not marshal shuffle random vector")
 	}
 
 	// Sign the shuffle:
-	signature, err := h.shuffleSigner.Sign(seed)
+	signature, err := signer.Sign(seed)
 	if err != nil {
-		return nil, xerrors.Errorf("could not sign the shuffle : %v", err)
+		return xerrors.Errorf("could not sign the shuffle : %v", err)
 	}
 
-	encodedSignature, err := signature.Serialize(h.context)
+	encodedSignature, err := signature.Serialize(ctx)
 	if err != nil {
-		return nil, xerrors.Errorf("could not encode signature as []byte : %v ", err)
+		return xerrors.Errorf("could not encode signature as []byte : %v ", err)
 	}
 
-	publicKey, err := h.shuffleSigner.GetPublicKey().MarshalBinary()
+	publicKey, err := signer.GetPublicKey().MarshalBinary()
 	if err != nil {
-		return nil, xerrors.Errorf("could not unmarshal public key from nodeSigner: %v", err)
+		return xerrors.Errorf("could not unmarshal public key from nodeSigner: %v", err)
 	}
 
 	// Complete transaction:
 	shuffleBallots.PublicKey = publicKey
 	shuffleBallots.Signature = encodedSignature
 
+	return nil
+}
+
+// makeShuffleTx creates the transaction that submits the shuffled ballots.
+func (h *Handler) makeShuffleTx(shuffleBallots etypes.ShuffleBallots) (txn.Transaction, error) {
 	data, err := shuffleBallots.Serialize(h.context)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to serialize shuffle ballots: %v", err)
@@ -254,20 +614,40 @@ func (h *Handler) makeTx(form *etypes.Form, userID string) (txn.Transaction, err
 func (h *Handler) getShuffledBallots(form *etypes.Form) ([]etypes.Ciphervote,
 	func(e []kyber.Scalar) (proof.Prover, error), error) {
 
-	round := len(form.ShuffleInstances)
+	ciphervotes, err := h.getRoundBallots(form)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return shuffleCiphervotes(form.Pubkey, ciphervotes)
+}
 
-	var ciphervotes []etypes.Ciphervote
+// getRoundBallots returns the ballots to shuffle in the current round.
+func (h *Handler) getRoundBallots(form *etypes.Form) ([]etypes.Ciphervote, error) {
+	round := len(form.ShuffleInstances)
 
 	if round == 0 {
 		suff, err := form.Suffragia(h.context, h.service.GetStore())
 		if err != nil {
-			return nil, nil, xerrors.Errorf("couldn't get ballots: %v", err)
+			return nil, xerrors.Errorf("couldn't get ballots: %v", err)
 		}
-		ciphervotes = suff.Ciphervotes
-	} else {
-		ciphervotes = form.ShuffleInstances[round-1].ShuffledBallots
+
+		return suff.Ciphervotes, nil
 	}
 
+	lastShuffle, err := form.LastShuffle(h.context, h.service.GetStore())
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get last shuffle: %v", err)
+	}
+
+	return lastShuffle.ShuffledBallots, nil
+}
+
+// shuffleCiphervotes shuffles the ciphervotes and returns the function to get
+// the prover of the shuffle.
+func shuffleCiphervotes(pubkey kyber.Point, ciphervotes []etypes.Ciphervote) (
+	[]etypes.Ciphervote, func(e []kyber.Scalar) (proof.Prover, error), error) {
+
 	seqSize := len(ciphervotes[0])
 
 	X := make([][]kyber.Point, seqSize)
@@ -284,7 +664,7 @@ func (h *Handler) getShuffledBallots(form *etypes.Form) ([]etypes.Ciphervote,
 	}
 
 	// shuffle sequences
-	XX, YY, getProver := shuffleKyber.SequencesShuffle(suite, nil, form.Pubkey,
+	XX, YY, getProver := shuffleKyber.SequencesShuffle(suite, nil, pubkey,
 		X, Y, suite.RandomStream())
 
 	ciphervotes, err := etypes.CiphervotesFromPairs(XX, YY)
@@ -294,27 +674,3 @@ func (h *Handler) getShuffledBallots(form *etypes.Form) ([]etypes.Ciphervote,
 
 	return ciphervotes, getProver, nil
 }
-
-// watchTx checks the transaction to find one that match txID. Return if the
-// transaction has been accepted or not. Will also return false if/when the
-// events chan is closed, which is expected to happen.
-func watchTx(events <-chan ordering.Event, txID []byte) (bool, string) {
-	for event := range events {
-		for _, res := range event.Transactions {
-			if !bytes.Equal(res.GetTransaction().GetID(), txID) {
-				continue
-			}
-
-			dela.Logger.Info().Hex("id", txID).Msg("transaction included in the block")
-
-			accepted, msg := res.GetStatus()
-			if accepted {
-				return true, ""
-			}
-
-			return false, msg
-		}
-	}
-
-	return false, "watch timeout"
-}
@@ -1,6 +1,8 @@
 package neff
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"strconv"
 	"testing"
@@ -12,7 +14,9 @@ import (
 	"github.com/c4dt/d-voting/services/shuffle/neff/types"
 	"go.dedis.ch/kyber/v3"
 
+	"github.com/c4dt/d-voting/contracts/evoting"
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/confirm"
 	"github.com/c4dt/d-voting/internal/testing/fake"
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/access"
@@ -41,6 +45,7 @@ func TestHandler_Stream(t *testing.T) {
 
 	handler.txmngr = fake.Manager{}
 	handler.service = &fake.Service{Forms: make(map[string]etypes.Form), BallotSnap: fake.NewSnapshot()}
+	handler.confirm = confirm.NewService(handler.service, handler.p, handler.txmngr)
 
 	err = handler.Stream(fake.Sender{}, receiver)
 	require.EqualError(t, err, "failed to handle StartShuffle message: failed "+
@@ -64,7 +69,8 @@ func TestHandler_StartShuffle(t *testing.T) {
 	fakeErr := xerrors.Errorf("fake error")
 
 	handler := Handler{
-		me: fake.NewAddress(0),
+		me:       fake.NewAddress(0),
+		progress: newProgress(),
 	}
 	dummyID := hex.EncodeToString([]byte("dummyId"))
 
@@ -75,6 +81,7 @@ func TestHandler_StartShuffle(t *testing.T) {
 	}
 	handler.service = &badService
 	handler.txmngr = fake.Manager{}
+	handler.confirm = confirm.NewService(handler.service, handler.p, handler.txmngr)
 
 	err := handler.handleStartShuffle(dummyID, "123456")
 	require.EqualError(t, err, "failed to get form: while getting data for form: this key doesn't exist")
@@ -95,7 +102,7 @@ func TestHandler_StartShuffle(t *testing.T) {
 		FormID:           dummyID,
 		Status:           0,
 		Pubkey:           nil,
-		ShuffleInstances: []etypes.ShuffleInstance{},
+		ShuffleInstances: []etypes.ShuffleRef{},
 		DecryptedBallots: nil,
 		ShuffleThreshold: 1,
 		BallotSize:       1,
@@ -157,7 +164,7 @@ func TestHandler_StartShuffle(t *testing.T) {
 	handler.shuffleSigner = fake.NewBadSigner()
 
 	err = handler.handleStartShuffle(dummyID, "123456")
-	require.EqualError(t, err, fake.Err("failed to make tx: could not sign the shuffle "))
+	require.EqualError(t, err, fake.Err("failed to make shuffle: failed to prove shuffle: could not sign the shuffle "))
 
 	// Bad common signer :
 	service = updateService(form, dummyID)
@@ -168,9 +175,11 @@ func TestHandler_StartShuffle(t *testing.T) {
 	// Bad manager
 
 	handler.txmngr = fake.Manager{}
+	handler.confirm = confirm.NewService(handler.service, handler.p, handler.txmngr)
 
 	err = handler.handleStartShuffle(dummyID, "123456")
-	require.EqualError(t, err, fake.Err("failed to make tx: failed to use manager"))
+	require.EqualError(t, err, fake.Err("failed to submit tx: failed to submit shuffle: failed to add tx: "+
+		"failed to make tx: failed to use manager"))
 
 	manager := signed.NewManager(fake.NewSigner(), fakeClient{})
 
@@ -181,6 +190,7 @@ func TestHandler_StartShuffle(t *testing.T) {
 
 	handler.service = &service
 	handler.p = &fakePool
+	handler.confirm = confirm.NewService(handler.service, handler.p, handler.txmngr)
 
 	err = handler.handleStartShuffle(dummyID, "123456")
 	require.NoError(t, err)
@@ -210,12 +220,15 @@ func TestHandler_StartShuffle(t *testing.T) {
 	require.NoError(t, err)
 	shuffledBallots := append([]etypes.Ciphervote{}, ciphervotes.Ciphervotes...)
 
-	form.ShuffleInstances = append(form.ShuffleInstances,
-		etypes.ShuffleInstance{ShuffledBallots: shuffledBallots})
-
 	form.ShuffleThreshold = 2
 
 	service = updateService(form, dummyID)
+
+	err = form.StoreShuffle(service.Context, service.BallotSnap,
+		etypes.ShuffleInstance{ShuffledBallots: shuffledBallots}, true)
+	require.NoError(t, err)
+
+	service.Forms[dummyID] = form
 	fakePool = fake.Pool{Service: &service}
 	handler = *NewHandler(handler.me, &service, &fakePool, manager,
 		handler.shuffleSigner, serdecontext, formFac)
@@ -224,6 +237,35 @@ func TestHandler_StartShuffle(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHandler_IsStalled(t *testing.T) {
+	handler := initValidHandler("")
+	service := handler.service.(*fake.Service)
+
+	pending := &etypes.ShuffleRef{LastBlock: 5}
+
+	_, err := handler.isStalled(pending)
+	require.ErrorContains(t, err, "failed to get block count")
+
+	key := sha256.Sum256([]byte(evoting.BlockCountId))
+	buf := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(buf, 5+evoting.ShuffleTakeoverBlocks-1)
+	err = service.BallotSnap.Set(key[:], buf)
+	require.NoError(t, err)
+
+	stalled, err := handler.isStalled(pending)
+	require.NoError(t, err)
+	require.False(t, stalled)
+
+	binary.BigEndian.PutUint64(buf, 5+evoting.ShuffleTakeoverBlocks)
+	err = service.BallotSnap.Set(key[:], buf)
+	require.NoError(t, err)
+
+	stalled, err = handler.isStalled(pending)
+	require.NoError(t, err)
+	require.True(t, stalled)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 func updateService(form etypes.Form, dummyID string) fake.Service {
@@ -264,6 +306,7 @@ func initValidHandler(dummyID string) Handler {
 	handler.txmngr = signed.NewManager(fake.NewSigner(), fakeClient{})
 	handler.context = serdecontext
 	handler.formFac = formFac
+	handler.confirm = confirm.NewService(handler.service, handler.p, handler.txmngr)
 
 	return handler
 }
@@ -275,7 +318,7 @@ func initFakeForm(ctx serde.Context, snap store.Snapshot, formID string) etypes.
 		FormID:           formID,
 		Status:           etypes.Closed,
 		Pubkey:           pubKey,
-		ShuffleInstances: []etypes.ShuffleInstance{},
+		ShuffleInstances: []etypes.ShuffleRef{},
 		DecryptedBallots: nil,
 		ShuffleThreshold: 1,
 		BallotSize:       1,
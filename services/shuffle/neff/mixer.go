@@ -0,0 +1,307 @@
+package neff
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/confirm"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/c4dt/d-voting/services/shuffle"
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/serde"
+	jsonserde "go.dedis.ch/dela/serde/json"
+	"go.dedis.ch/kyber/v3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// mixerAttempts is the number of shuffles a mixer submits before giving
+	// up.
+	mixerAttempts = 10
+
+	// mixerCheckInterval is the time between two requests of a mixer to the
+	// proxy while it waits.
+	mixerCheckInterval = time.Second * 2
+
+	// mixerTxTimeout is the time a mixer waits for its transaction to be
+	// included.
+	mixerTxTimeout = time.Minute
+)
+
+// Mixer shuffles the ballots of forms without being part of the roster. It
+// runs standalone and uses the proxy of a node to fetch the ballots and to
+// submit its shuffles. Its public key must be one of the mixers of the form.
+type Mixer struct {
+	proxyAddr string
+	signer    crypto.Signer
+	client    *http.Client
+	context   serde.Context
+}
+
+// NewMixer returns a new mixer that uses the proxy at the given address.
+func NewMixer(proxyAddr string, signer crypto.Signer) Mixer {
+	return Mixer{
+		proxyAddr: proxyAddr,
+		signer:    signer,
+		client:    &http.Client{Timeout: time.Minute},
+		context:   jsonserde.NewContext(),
+	}
+}
+
+// Shuffle shuffles the ballots of the form once. It returns when the shuffle
+// has been accepted, or if the shuffle of the form is already over. formID is
+// hex-encoded.
+func (m Mixer) Shuffle(formID string) error {
+	publicKey, err := m.signer.GetPublicKey().MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("failed to marshal public key: %v", err)
+	}
+
+	publicKeyHex := hex.EncodeToString(publicKey)
+
+	for attempt := 0; attempt < mixerAttempts; {
+		round, err := m.getRound(formID)
+		if err != nil {
+			return xerrors.Errorf("failed to get round: %v", err)
+		}
+
+		if round.Round >= round.Threshold {
+			dela.Logger.Info().Msgf("shuffle done with round n°%d", round.Round)
+			return nil
+		}
+
+		if contains(round.Shufflers, publicKeyHex) {
+			dela.Logger.Info().Msg("our shuffle has already been accepted")
+			return nil
+		}
+
+		if !contains(round.Mixers, publicKeyHex) {
+			return xerrors.Errorf("not a mixer of the form: %s", publicKeyHex)
+		}
+
+		if round.FormStatus != int(etypes.Closed) {
+			return xerrors.Errorf("the form must be closed: (%d)", round.FormStatus)
+		}
+
+		if round.Batched {
+			time.Sleep(mixerCheckInterval)
+			continue
+		}
+
+		attempt++
+
+		txID, err := m.submitShuffle(formID, round)
+		if err != nil {
+			return xerrors.Errorf("failed to submit shuffle: %v", err)
+		}
+
+		accepted, reason, err := m.waitTx(formID, txID)
+		if err != nil {
+			return xerrors.Errorf("failed to wait for transaction: %v", err)
+		}
+
+		if accepted {
+			dela.Logger.Info().Msgf("shuffle of round %d accepted", round.Round)
+			return nil
+		}
+
+		dela.Logger.Info().Msgf("shuffle of round %d denied: %s", round.Round, reason)
+	}
+
+	return xerrors.Errorf("shuffle not accepted after %d attempts", mixerAttempts)
+}
+
+// submitShuffle shuffles the ballots of the round and submits the shuffle to
+// the proxy. It returns the hex-encoded ID of the transaction.
+func (m Mixer) submitShuffle(formID string, round ptypes.GetShuffleBallotsResponse) (string, error) {
+	pubkeyBuf, err := hex.DecodeString(round.Pubkey)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode pubkey: %v", err)
+	}
+
+	pubkey := suite.Point()
+
+	err = pubkey.UnmarshalBinary(pubkeyBuf)
+	if err != nil {
+		return "", xerrors.Errorf("failed to unmarshal pubkey: %v", err)
+	}
+
+	ciphervotes, err := decodeCiphervotes(round.Ballots)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode ballots: %v", err)
+	}
+
+	if len(ciphervotes) < 2 {
+		return "", xerrors.Errorf("not enough votes: %d < 2", len(ciphervotes))
+	}
+
+	shuffled, getProver, err := shuffleCiphervotes(pubkey, ciphervotes)
+	if err != nil {
+		return "", xerrors.Errorf("failed to shuffle: %v", err)
+	}
+
+	shuffleBallots := etypes.ShuffleBallots{
+		FormID:          formID,
+		Round:           round.Round,
+		ShuffledBallots: shuffled,
+	}
+
+	err = proveShuffle(m.signer, m.context, &shuffleBallots, len(ciphervotes[0]), getProver)
+	if err != nil {
+		return "", xerrors.Errorf("failed to prove shuffle: %v", err)
+	}
+
+	data, err := shuffleBallots.Serialize(m.context)
+	if err != nil {
+		return "", xerrors.Errorf("failed to serialize shuffle: %v", err)
+	}
+
+	req := ptypes.SubmitShuffleRequest{
+		ShuffleBallots: data,
+	}
+
+	var res ptypes.SubmitShuffleResponse
+
+	err = m.do(http.MethodPost, "/evoting/services/shuffle/"+formID+"/ballots", req, &res)
+	if err != nil {
+		return "", xerrors.Errorf("failed to post shuffle: %v", err)
+	}
+
+	return res.TxID, nil
+}
+
+// waitTx waits for the transaction to be accepted or rejected. It returns
+// false with a reason if the transaction is rejected or not included in time.
+func (m Mixer) waitTx(formID, txID string) (bool, string, error) {
+	deadline := time.Now().Add(mixerTxTimeout)
+
+	for time.Now().Before(deadline) {
+		var status ptypes.GetShuffleResponse
+
+		err := m.do(http.MethodGet, "/evoting/services/shuffle/"+formID, nil, &status)
+		if err != nil {
+			return false, "", xerrors.Errorf("failed to get shuffle status: %v", err)
+		}
+
+		for _, tx := range status.Transactions {
+			if tx.ID != txID {
+				continue
+			}
+
+			switch shuffle.TxStatusCode(tx.Status) {
+			case shuffle.TxAccepted:
+				return true, "", nil
+			case shuffle.TxRejected:
+				return false, tx.Reason, nil
+			}
+		}
+
+		time.Sleep(mixerCheckInterval)
+	}
+
+	return false, confirm.TimeoutReason, nil
+}
+
+// getRound returns the current round of the form.
+func (m Mixer) getRound(formID string) (ptypes.GetShuffleBallotsResponse, error) {
+	var round ptypes.GetShuffleBallotsResponse
+
+	err := m.do(http.MethodGet, "/evoting/services/shuffle/"+formID+"/ballots", nil, &round)
+	if err != nil {
+		return round, xerrors.Errorf("failed to get ballots: %v", err)
+	}
+
+	return round, nil
+}
+
+// do sends a request to the proxy and decodes the JSON response in res.
+func (m Mixer) do(method, path string, req, res interface{}) error {
+	var body io.Reader
+
+	if req != nil {
+		buf, err := json.Marshal(req)
+		if err != nil {
+			return xerrors.Errorf("failed to marshal request: %v", err)
+		}
+
+		body = bytes.NewReader(buf)
+	}
+
+	httpReq, err := http.NewRequest(method, m.proxyAddr+path, body)
+	if err != nil {
+		return xerrors.Errorf("failed to create request: %v", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return xerrors.Errorf("failed to send request: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(resp.Body)
+		return xerrors.Errorf("unexpected status: %s - %s", resp.Status, buf)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return xerrors.Errorf("failed to decode response: %v", err)
+	}
+
+	return nil
+}
+
+// decodeCiphervotes returns the ciphervotes of their JSON representation.
+func decodeCiphervotes(ballots []ptypes.CiphervoteJSON) ([]etypes.Ciphervote, error) {
+	ciphervotes := make([]etypes.Ciphervote, len(ballots))
+
+	for i, ballot := range ballots {
+		ciphervotes[i] = make(etypes.Ciphervote, len(ballot))
+
+		for j, egpair := range ballot {
+			k, err := unmarshalPoint(egpair.K)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to unmarshal K: %v", err)
+			}
+
+			c, err := unmarshalPoint(egpair.C)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to unmarshal C: %v", err)
+			}
+
+			ciphervotes[i][j] = etypes.EGPair{K: k, C: c}
+		}
+	}
+
+	return ciphervotes, nil
+}
+
+func unmarshalPoint(buf []byte) (kyber.Point, error) {
+	point := suite.Point()
+
+	err := point.UnmarshalBinary(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return point, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, elem := range list {
+		if elem == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package neff
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	ptypes "github.com/c4dt/d-voting/proxy/types"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/crypto/bls"
+)
+
+func TestMixer_Shuffle(t *testing.T) {
+	signer := bls.NewSigner()
+
+	publicKey, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	round := ptypes.GetShuffleBallotsResponse{
+		Round:      1,
+		Threshold:  1,
+		FormStatus: int(etypes.Closed),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/evoting/services/shuffle/deadbeef/ballots", r.URL.Path)
+
+		err := json.NewEncoder(w).Encode(round)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	mixer := NewMixer(srv.URL, signer)
+
+	// the shuffle is over
+	err = mixer.Shuffle("deadbeef")
+	require.NoError(t, err)
+
+	round.Round = 0
+
+	err = mixer.Shuffle("deadbeef")
+	require.EqualError(t, err, "not a mixer of the form: "+hex.EncodeToString(publicKey))
+
+	round.Mixers = []string{hex.EncodeToString(publicKey)}
+	round.FormStatus = int(etypes.Open)
+
+	err = mixer.Shuffle("deadbeef")
+	require.EqualError(t, err, "the form must be closed: (1)")
+
+	// our shuffle has already been accepted
+	round.Shufflers = []string{hex.EncodeToString(publicKey)}
+
+	err = mixer.Shuffle("deadbeef")
+	require.NoError(t, err)
+
+	mixer = NewMixer("http://127.0.0.1:0", signer)
+
+	err = mixer.Shuffle("deadbeef")
+	require.ErrorContains(t, err, "failed to get round: failed to get ballots")
+}
+
+func TestDecodeCiphervotes(t *testing.T) {
+	Ks, Cs, _ := fakeKCPoints(2)
+
+	k, err := Ks[0].MarshalBinary()
+	require.NoError(t, err)
+
+	c, err := Cs[0].MarshalBinary()
+	require.NoError(t, err)
+
+	ciphervotes, err := decodeCiphervotes([]ptypes.CiphervoteJSON{{{K: k, C: c}}})
+	require.NoError(t, err)
+	require.Len(t, ciphervotes, 1)
+	require.True(t, ciphervotes[0][0].K.Equal(Ks[0]))
+	require.True(t, ciphervotes[0][0].C.Equal(Cs[0]))
+
+	_, err = decodeCiphervotes([]ptypes.CiphervoteJSON{{{K: []byte("bad")}}})
+	require.ErrorContains(t, err, "failed to unmarshal K")
+}
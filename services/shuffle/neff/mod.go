@@ -1,19 +1,27 @@
 package neff
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/c4dt/d-voting/contracts/evoting"
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
+	"github.com/c4dt/d-voting/internal/tracing"
 	"github.com/c4dt/d-voting/services/shuffle"
 	"github.com/c4dt/d-voting/services/shuffle/neff/types"
 	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/core/execution/native"
 	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/pool"
 	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 	"golang.org/x/net/context"
@@ -25,6 +33,18 @@ import (
 const (
 	shuffleTimeout = time.Second * 30
 	protocolName   = "PairShuffle"
+
+	// protocolNameShuffle denotes the value of the protocol span tag
+	// associated with the `neff-shuffle` protocol.
+	protocolNameShuffle = "neff-shuffle"
+
+	// shuffleStallTimeout is the time after which a shuffle that doesn't make
+	// progress is considered as failed.
+	shuffleStallTimeout = time.Minute * 5
+
+	// shuffleCheckInterval is the time between two checks of the form when no
+	// block is committed.
+	shuffleCheckInterval = time.Second * 5
 )
 
 // NeffShuffle allows one to initialize a new SHUFFLE protocol.
@@ -39,6 +59,7 @@ type NeffShuffle struct {
 	context    serde.Context
 	nodeSigner crypto.Signer
 	formFac    serde.Factory
+	batchSize  int
 }
 
 // NewNeffShuffle returns a new NeffShuffle factory.
@@ -61,19 +82,33 @@ func NewNeffShuffle(m mino.Mino, s ordering.Service, p pool.Pool,
 	}
 }
 
+// SetBatchSize sets the maximum number of ballots this node shuffles together.
+// If it is 0, all the ballots of a round are shuffled at once, otherwise the
+// ballots are shuffled in batches submitted in separate transactions.
+func (n *NeffShuffle) SetBatchSize(size int) {
+	n.batchSize = size
+}
+
 // Listen implements shuffle.SHUFFLE. It must be called on each node that
 // participates in the SHUFFLE. Creates the RPC.
 func (n NeffShuffle) Listen(txmngr txn.Manager) (shuffle.Actor, error) {
+	progress := newProgress()
+
 	h := NewHandler(n.mino.GetAddress(), n.service, n.p, txmngr, n.nodeSigner,
 		n.context, n.formFac)
+	h.batchSize = n.batchSize
+	h.progress = progress
 
 	a := &Actor{
-		rpc:     mino.MustCreateRPC(n.mino, "shuffle", h, n.factory),
-		factory: n.factory,
-		mino:    n.mino,
-		service: n.service,
-		context: n.context,
-		formFac: n.formFac,
+		rpc:      mino.MustCreateRPC(n.mino, "shuffle", h, n.factory),
+		factory:  n.factory,
+		mino:     n.mino,
+		service:  n.service,
+		context:  n.context,
+		formFac:  n.formFac,
+		txFac:    etypes.NewTransactionFactory(etypes.CiphervoteFactory{}),
+		progress: progress,
+		handler:  h,
 	}
 
 	return a, nil
@@ -93,6 +128,11 @@ type Actor struct {
 
 	context serde.Context
 	formFac serde.Factory
+	txFac   serde.Factory
+
+	progress *progress
+	// handler is used to submit the shuffles of the mixers
+	handler *Handler
 }
 
 // Shuffle must be called by ONE of the actors to shuffle the list of ElGamal
@@ -102,20 +142,76 @@ func (a *Actor) Shuffle(formID []byte, userID string) error {
 	a.Lock()
 	defer a.Unlock()
 
+	form, err := a.prepare(formID)
+	if err != nil {
+		return xerrors.Errorf("failed to prepare shuffle: %w", err)
+	}
+
+	err = a.shuffle(form, userID)
+	a.progress.end(form.FormID, err)
+
+	return err
+}
+
+// StartShuffle implements shuffle.Actor. It checks that the form can be
+// shuffled and runs the shuffle in the background.
+func (a *Actor) StartShuffle(formID []byte, userID string) error {
+	form, err := a.prepare(formID)
+	if err != nil {
+		return xerrors.Errorf("failed to prepare shuffle: %w", err)
+	}
+
+	go func() {
+		a.Lock()
+		defer a.Unlock()
+
+		err := a.shuffle(form, userID)
+		a.progress.end(form.FormID, err)
+
+		if err != nil {
+			dela.Logger.Err(err).Msgf("failed to shuffle form %s", form.FormID)
+		}
+	}()
+
+	return nil
+}
+
+// prepare checks that the form can be shuffled and marks its shuffle as
+// started.
+func (a *Actor) prepare(formID []byte) (etypes.Form, error) {
 	formIDHex := hex.EncodeToString(formID)
 
 	form, err := etypes.FormFromStore(a.context, a.formFac, formIDHex, a.service.GetStore())
 	if err != nil {
-		return xerrors.Errorf("failed to get form: %v", err)
+		return form, xerrors.Errorf("failed to get form: %w", err)
 	}
 
 	if form.Roster.Len() == 0 {
-		return xerrors.Errorf("the roster is empty")
+		return form, xerrors.Errorf("the roster is empty")
+	}
+
+	round := len(form.ShuffleInstances)
+
+	if round < form.ShuffleRounds() && form.Status != etypes.Closed {
+		return form, xerrors.Errorf("%w: the form must be closed: (%v)",
+			shuffle.ErrWrongStatus, form.Status)
 	}
 
+	if !a.progress.start(formIDHex, round) {
+		return form, xerrors.Errorf("%w: shuffle of form %s is already running",
+			shuffle.ErrWrongStatus, formIDHex)
+	}
+
+	return form, nil
+}
+
+func (a *Actor) shuffle(form etypes.Form, userID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), shuffleTimeout)
 	defer cancel()
 
+	ctx, span := tracing.StartStream(ctx, protocolNameShuffle, form.FormID)
+	defer span.End()
+
 	sender, _, err := a.rpc.Stream(ctx, form.Roster)
 	if err != nil {
 		return xerrors.Errorf("failed to stream: %v", err)
@@ -131,9 +227,16 @@ func (a *Actor) Shuffle(formID []byte, userID string) error {
 		}
 	}
 
+	// we watch the blocks before starting the shuffle to not miss any
+	// transaction.
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+
+	events := a.service.Watch(watchCtx)
+
 	dela.Logger.Info().Msgf("sending start shuffle to: %v", addrs)
 
-	message := types.NewStartShuffle(formIDHex, userID, addrs)
+	message := types.NewStartShuffle(form.FormID, userID, addrs)
 
 	errs := sender.Send(message, addrs...)
 	err = <-errs
@@ -142,7 +245,7 @@ func (a *Actor) Shuffle(formID []byte, userID string) error {
 		//return xerrors.Errorf("failed to start shuffle: %v", err)
 	}
 
-	err = a.waitAndCheckShuffling(message.GetFormID(), form.Roster.Len())
+	err = a.waitAndCheckShuffling(message.GetFormID(), events)
 	if err != nil {
 		return xerrors.Errorf("failed to wait and check shuffling: %v", err)
 	}
@@ -150,37 +253,310 @@ func (a *Actor) Shuffle(formID []byte, userID string) error {
 	return nil
 }
 
-// waitAndCheckShuffling periodically checks the state of the form. It
-// returns an error if the shuffling is not done after a while. The retry and
-// waiting time depends on the rosterLen. formID is Hex-encoded.
-func (a *Actor) waitAndCheckShuffling(formID string, rosterLen int) error {
-	var form etypes.Form
-	var err error
+// waitAndCheckShuffling checks the state of the form each time a block is
+// committed, or periodically. It returns an error if the shuffle doesn't make
+// progress for a while. formID is Hex-encoded.
+func (a *Actor) waitAndCheckShuffling(formID string, events <-chan ordering.Event) error {
+	lastProgress := ""
+	progressAt := time.Now()
+
+	ticker := time.NewTicker(shuffleCheckInterval)
+	defer ticker.Stop()
 
-	for i := 0; ; i++ {
-		form, err = etypes.FormFromStore(a.context, a.formFac, formID, a.service.GetStore())
+	for {
+		form, err := etypes.FormFromStore(a.context, a.formFac, formID, a.service.GetStore())
 		if err != nil {
 			return xerrors.Errorf("failed to get form: %v", err)
 		}
 
 		round := len(form.ShuffleInstances)
-		dela.Logger.Info().Msgf("SHUFFLE / ROUND : %d", round)
 
 		// if the threshold is reached that means we have enough shuffling.
-		if round >= form.ShuffleThreshold {
+		if round >= form.ShuffleRounds() {
 			dela.Logger.Info().Msgf("shuffle done with round n°%d", round)
 			return nil
 		}
 
-		dela.Logger.Info().Msgf("waiting a while before checking form: %d", i)
-		sleepTime := rosterLen / 2
-		time.Sleep(time.Duration(sleepTime) * time.Second)
-		if i >= form.ShuffleThreshold*((int)(form.BallotCount)/16+1) {
-			break
+		current := fmt.Sprintf("%d", round)
+		if form.PendingShuffle != nil {
+			current = fmt.Sprintf("%d:%d", round, form.PendingShuffle.Batches)
+		}
+
+		if current != lastProgress {
+			dela.Logger.Info().Msgf("SHUFFLE / ROUND : %s", current)
+
+			lastProgress = current
+			progressAt = time.Now()
+		}
+
+		if time.Since(progressAt) > shuffleStallTimeout {
+			reason := a.progress.lastRejection(formID)
+			if reason == "" {
+				reason = "no shuffle transaction accepted"
+			}
+
+			return xerrors.Errorf("shuffle stalled at round %d/%d: %s", round,
+				form.ShuffleRounds(), reason)
+		}
+
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// the form is still checked periodically
+				events = nil
+				continue
+			}
+
+			a.recordEvent(formID, event)
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordEvent records the shuffle transactions of the form that are part of
+// the block.
+func (a *Actor) recordEvent(formID string, event ordering.Event) {
+	for _, res := range event.Transactions {
+		tx := res.GetTransaction()
+
+		if string(tx.GetArg(native.ContractArg)) != evoting.ContractName ||
+			string(tx.GetArg(evoting.CmdArg)) != string(evoting.CmdShuffleBallots) {
+			continue
+		}
+
+		msg, err := a.txFac.Deserialize(a.context, tx.GetArg(evoting.FormArg))
+		if err != nil {
+			dela.Logger.Warn().Err(err).Msg("failed to deserialize shuffle tx")
+			continue
+		}
+
+		shuffleBallots, ok := msg.(etypes.ShuffleBallots)
+		if !ok || shuffleBallots.FormID != formID {
+			continue
+		}
+
+		accepted, reason := res.GetStatus()
+
+		status := shuffle.TxAccepted
+		if !accepted {
+			status = shuffle.TxRejected
+		}
+
+		a.progress.setTx(formID, shuffle.Tx{
+			ID:         tx.GetID(),
+			Round:      shuffleBallots.Round,
+			BatchIndex: shuffleBallots.BatchIndex,
+			PublicKey:  shuffleBallots.PublicKey,
+			Status:     status,
+			Reason:     reason,
+		})
+	}
+}
+
+// Status implements shuffle.Actor. It returns the progress of the shuffle of
+// the form, which is read from the form and completed with what the node
+// witnessed.
+func (a *Actor) Status(formID []byte) (shuffle.Status, error) {
+	formIDHex := hex.EncodeToString(formID)
+
+	form, err := etypes.FormFromStore(a.context, a.formFac, formIDHex, a.service.GetStore())
+	if err != nil {
+		return shuffle.Status{}, xerrors.Errorf("failed to get form: %w", err)
+	}
+
+	status := shuffle.Status{
+		Round:     len(form.ShuffleInstances),
+		Threshold: form.ShuffleRounds(),
+	}
+
+	if form.PendingShuffle != nil {
+		size, err := a.roundSize(form)
+		if err != nil {
+			return shuffle.Status{}, xerrors.Errorf("failed to get size of round: %v", err)
+		}
+
+		status.Batches = form.PendingShuffle.Batches
+		status.BatchCount = etypes.ShuffleBatchCount(size, form.PendingShuffle.BatchSize)
+	}
+
+	nodes, err := rosterNodes(form.Roster)
+	if err != nil {
+		return shuffle.Status{}, xerrors.Errorf("failed to get roster: %v", err)
+	}
+
+	contributed := make(map[string]bool)
+
+	for i, instance := range form.ShuffleInstances {
+		key := hex.EncodeToString(instance.ShufflerPublicKey)
+		contributed[key] = true
+
+		status.Contributors = append(status.Contributors, shuffle.Contribution{
+			Round:     i,
+			Node:      nodes[key],
+			PublicKey: instance.ShufflerPublicKey,
+		})
+	}
+
+	// mixers are not part of the roster, they are identified by their key
+	for _, mixer := range form.Mixers {
+		keyHex := hex.EncodeToString(mixer)
+		if !contributed[keyHex] {
+			status.Waiting = append(status.Waiting, keyHex)
+		}
+	}
+
+	iter := form.Roster.PublicKeyIterator()
+	for len(form.Mixers) == 0 && iter.HasNext() {
+		key, err := iter.GetNext().MarshalBinary()
+		if err != nil {
+			return shuffle.Status{}, xerrors.Errorf("failed to marshal public key: %v", err)
+		}
+
+		keyHex := hex.EncodeToString(key)
+		if !contributed[keyHex] {
+			status.Waiting = append(status.Waiting, nodes[keyHex])
 		}
-		dela.Logger.Info().Msgf("WaitingRounds is : %d", form.ShuffleThreshold*((int)(form.BallotCount)/10+1))
 	}
 
-	return xerrors.Errorf("threshold of shuffling not reached: %d < %d",
-		len(form.ShuffleInstances), form.ShuffleThreshold)
+	a.progress.fill(formIDHex, &status)
+
+	return status, nil
+}
+
+// roundSize returns the number of ballots shuffled by the current round, which
+// are the ballots cast for the first round and the ballots of the previous
+// round otherwise. The pending shuffle only holds the batches done so far.
+func (a *Actor) roundSize(form etypes.Form) (int, error) {
+	round := len(form.ShuffleInstances)
+	if round > 0 {
+		return form.ShuffleInstances[round-1].BallotCount, nil
+	}
+
+	// the ballots of a voter who voted several times are only counted once
+	suff, err := form.Suffragia(a.context, a.service.GetStore())
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get ballots: %v", err)
+	}
+
+	return len(suff.Ciphervotes), nil
+}
+
+// GetRound implements shuffle.Actor. The ballots are only returned if the form
+// is closed and the shuffle is not over.
+func (a *Actor) GetRound(formID []byte) (shuffle.Round, error) {
+	formIDHex := hex.EncodeToString(formID)
+
+	form, err := etypes.FormFromStore(a.context, a.formFac, formIDHex, a.service.GetStore())
+	if err != nil {
+		return shuffle.Round{}, xerrors.Errorf("failed to get form: %w", err)
+	}
+
+	round := shuffle.Round{
+		Index:      len(form.ShuffleInstances),
+		Threshold:  form.ShuffleRounds(),
+		FormStatus: form.Status,
+		Pubkey:     form.Pubkey,
+		Shufflers:  make([][]byte, len(form.ShuffleInstances)),
+		Mixers:     form.Mixers,
+		Batched:    form.PendingShuffle != nil,
+	}
+
+	for i, instance := range form.ShuffleInstances {
+		round.Shufflers[i] = instance.ShufflerPublicKey
+	}
+
+	if form.Status != etypes.Closed || round.Index >= round.Threshold {
+		return round, nil
+	}
+
+	round.Ciphervotes, err = a.handler.getRoundBallots(&form)
+	if err != nil {
+		return shuffle.Round{}, xerrors.Errorf("failed to get ballots: %v", err)
+	}
+
+	return round, nil
+}
+
+// SubmitShuffle implements shuffle.Actor. It checks that the shuffle is signed
+// by a mixer of the form before submitting it, so that the node doesn't sign
+// transactions for anyone. The transaction is watched in the background.
+func (a *Actor) SubmitShuffle(shuffleBallots etypes.ShuffleBallots) ([]byte, error) {
+	form, err := etypes.FormFromStore(a.context, a.formFac, shuffleBallots.FormID,
+		a.service.GetStore())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get form: %v", err)
+	}
+
+	mixer := false
+	for _, key := range form.Mixers {
+		mixer = mixer || bytes.Equal(key, shuffleBallots.PublicKey)
+	}
+
+	if !mixer {
+		return nil, xerrors.Errorf("public key not associated to a mixer of the "+
+			"form: %x", shuffleBallots.PublicKey)
+	}
+
+	err = verifyShuffleSignature(a.context, shuffleBallots)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to verify signature: %v", err)
+	}
+
+	info := shuffle.Tx{
+		Round:     shuffleBallots.Round,
+		PublicKey: shuffleBallots.PublicKey,
+	}
+
+	receipt, info, err := a.handler.submit(form.FormID, shuffleBallots, info)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to submit: %v", err)
+	}
+
+	go a.handler.wait(form.FormID, receipt, info)
+
+	return info.ID, nil
+}
+
+// verifyShuffleSignature checks that the shuffle is signed by its public key.
+func verifyShuffleSignature(ctx serde.Context, shuffleBallots etypes.ShuffleBallots) error {
+	publicKey, err := bls.NewPublicKey(shuffleBallots.PublicKey)
+	if err != nil {
+		return xerrors.Errorf("failed to decode public key: %v", err)
+	}
+
+	signature, err := bls.NewSignatureFactory().SignatureOf(ctx, shuffleBallots.Signature)
+	if err != nil {
+		return xerrors.Errorf("failed to decode signature: %v", err)
+	}
+
+	h := sha256.New()
+
+	err = shuffleBallots.Fingerprint(h)
+	if err != nil {
+		return xerrors.Errorf("failed to get fingerprint: %v", err)
+	}
+
+	return publicKey.Verify(h.Sum(nil), signature)
+}
+
+// rosterNodes returns the address of the roster members indexed by their
+// hex-encoded public key.
+func rosterNodes(roster authority.Authority) (map[string]string, error) {
+	nodes := make(map[string]string, roster.Len())
+
+	addrIter := roster.AddressIterator()
+	pubkeyIter := roster.PublicKeyIterator()
+
+	for addrIter.HasNext() && pubkeyIter.HasNext() {
+		addr := addrIter.GetNext()
+
+		key, err := pubkeyIter.GetNext().MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to marshal public key: %v", err)
+		}
+
+		nodes[hex.EncodeToString(key)] = addr.String()
+	}
+
+	return nodes, nil
 }
@@ -9,12 +9,14 @@ import (
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/json"
 
 	etypes "github.com/c4dt/d-voting/contracts/evoting/types"
 	"github.com/c4dt/d-voting/internal/testing/fake"
+	"github.com/c4dt/d-voting/services/shuffle"
 	"github.com/c4dt/d-voting/services/shuffle/neff/types"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -60,18 +62,20 @@ func TestNeffShuffle_Shuffle(t *testing.T) {
 	suff, err := form.Suffragia(serdecontext, st)
 	require.NoError(t, err)
 	shuffledBallots := append([]etypes.Ciphervote{}, suff.Ciphervotes...)
-	form.ShuffleInstances = append(form.ShuffleInstances, etypes.ShuffleInstance{ShuffledBallots: shuffledBallots})
+	err = form.StoreShuffle(serdecontext, st, etypes.ShuffleInstance{ShuffledBallots: shuffledBallots}, true)
+	require.NoError(t, err)
 
 	form.ShuffleThreshold = 1
 
 	service := fake.NewService(formID, form, serdecontext)
 
 	actor := Actor{
-		rpc:     fake.NewBadRPC(),
-		mino:    fake.Mino{},
-		service: &service,
-		context: serdecontext,
-		formFac: etypes.NewFormFactory(etypes.CiphervoteFactory{}, fake.NewRosterFac(roster)),
+		rpc:      fake.NewBadRPC(),
+		mino:     fake.Mino{},
+		service:  &service,
+		context:  serdecontext,
+		formFac:  etypes.NewFormFactory(etypes.CiphervoteFactory{}, fake.NewRosterFac(roster)),
+		progress: newProgress(),
 	}
 
 	err = actor.Shuffle(formIDBuf, "123456")
@@ -107,6 +111,100 @@ func TestNeffShuffle_Shuffle(t *testing.T) {
 
 	err = actor.Shuffle(formIDBuf, "123456")
 	require.NoError(t, err)
+
+	status, err := actor.Status(formIDBuf)
+	require.NoError(t, err)
+	require.Equal(t, shuffle.Done, status.Status)
+	require.Equal(t, 1, status.Round)
+	require.Equal(t, 1, status.Threshold)
+	require.Len(t, status.Contributors, 1)
+}
+
+func TestActor_Status(t *testing.T) {
+	formID := "deadbeef"
+	formIDBuf, err := hex.DecodeString(formID)
+	require.NoError(t, err)
+
+	newSigner := func() crypto.Signer { return bls.NewSigner() }
+	roster := authority.FromAuthority(fake.NewAuthority(3, newSigner))
+
+	st := fake.NewSnapshot()
+	form, err := fake.NewForm(serdecontext, st, formID)
+	require.NoError(t, err)
+
+	form.Roster = roster
+	form.ShuffleThreshold = 2
+
+	pubkeyIter := roster.PublicKeyIterator()
+	pubkey, err := pubkeyIter.GetNext().MarshalBinary()
+	require.NoError(t, err)
+
+	form.ShuffleInstances = []etypes.ShuffleRef{{ShufflerPublicKey: pubkey, BallotCount: 10}}
+
+	// the first batch of the round is shuffled
+	form.PendingShuffle = &etypes.ShuffleRef{BallotCount: 4, BatchSize: 4, Batches: 1}
+
+	service := fake.NewService(formID, form, serdecontext)
+
+	actor := Actor{
+		service:  &service,
+		context:  serdecontext,
+		formFac:  etypes.NewFormFactory(etypes.CiphervoteFactory{}, fake.NewRosterFac(roster)),
+		progress: newProgress(),
+	}
+
+	_, err = actor.Status([]byte("unknown"))
+	require.Error(t, err)
+
+	form.Status = etypes.Open
+	service.Forms[formID] = form
+
+	err = actor.StartShuffle(formIDBuf, "123456")
+	require.EqualError(t, err, "failed to prepare shuffle: wrong status: the "+
+		"form must be closed: (1)")
+	require.ErrorIs(t, err, shuffle.ErrWrongStatus)
+
+	form.Status = etypes.Closed
+	service.Forms[formID] = form
+
+	status, err := actor.Status(formIDBuf)
+	require.NoError(t, err)
+	require.Equal(t, shuffle.NotStarted, status.Status)
+	require.Equal(t, 1, status.Round)
+	require.Equal(t, 2, status.Threshold)
+	require.Len(t, status.Contributors, 1)
+	require.Equal(t, fake.NewAddress(0).String(), status.Contributors[0].Node)
+	require.Len(t, status.Waiting, 2)
+	require.True(t, status.EstimatedEnd.IsZero())
+	require.Equal(t, 1, status.Batches)
+	require.Equal(t, 3, status.BatchCount)
+
+	actor.progress.start(formID, 0)
+	actor.progress.setTx(formID, shuffle.Tx{ID: []byte{1}, Round: 1})
+	actor.progress.setTx(formID, shuffle.Tx{ID: []byte{1}, Round: 1,
+		Status: shuffle.TxRejected, Reason: "fake reason"})
+	actor.progress.setTx(formID, shuffle.Tx{ID: []byte{1}, Round: 1})
+	actor.progress.setTx(formID, shuffle.Tx{ID: []byte{2}, Round: 1})
+
+	status, err = actor.Status(formIDBuf)
+	require.NoError(t, err)
+	require.Equal(t, shuffle.Shuffling, status.Status)
+	require.False(t, status.EstimatedEnd.IsZero())
+	require.Len(t, status.Transactions, 2)
+	require.Equal(t, shuffle.TxRejected, status.Transactions[0].Status)
+	require.Equal(t, shuffle.TxPending, status.Transactions[1].Status)
+	require.Equal(t, "fake reason", actor.progress.lastRejection(formID))
+
+	err = actor.StartShuffle(formIDBuf, "123456")
+	require.ErrorIs(t, err, shuffle.ErrWrongStatus)
+	require.ErrorContains(t, err, "is already running")
+
+	actor.progress.end(formID, fake.GetError())
+
+	status, err = actor.Status(formIDBuf)
+	require.NoError(t, err)
+	require.Equal(t, shuffle.Failed, status.Status)
+	require.EqualError(t, status.Err, fake.GetError().Error())
 }
 
 // -----------------------------------------------------------------------------
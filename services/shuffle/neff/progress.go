@@ -0,0 +1,155 @@
+package neff
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/c4dt/d-voting/services/shuffle"
+)
+
+// progress keeps track of the shuffles as seen by the node. It is shared by the
+// actor, which starts the shuffles, and the handler, which submits the
+// transactions.
+type progress struct {
+	sync.Mutex
+	forms map[string]*formProgress
+}
+
+// formProgress holds the progress of the shuffle of a form.
+type formProgress struct {
+	status     shuffle.StatusCode
+	err        error
+	startedAt  time.Time
+	startRound int
+
+	// txs are the shuffle transactions, in the order they were seen
+	txs     []shuffle.Tx
+	txIndex map[string]int
+}
+
+func newProgress() *progress {
+	return &progress{
+		forms: make(map[string]*formProgress),
+	}
+}
+
+// get returns the progress of the form, which is created if it doesn't exist.
+// The lock must be held.
+func (p *progress) get(formID string) *formProgress {
+	fp, found := p.forms[formID]
+	if !found {
+		fp = &formProgress{
+			txIndex: make(map[string]int),
+		}
+
+		p.forms[formID] = fp
+	}
+
+	return fp
+}
+
+// start marks the shuffle of the form as started at the given round. It
+// returns false if the shuffle is already running.
+func (p *progress) start(formID string, round int) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	fp := p.get(formID)
+
+	if fp.status == shuffle.Shuffling {
+		return false
+	}
+
+	fp.status = shuffle.Shuffling
+	fp.err = nil
+	fp.startedAt = time.Now()
+	fp.startRound = round
+
+	return true
+}
+
+// end marks the shuffle of the form as done, or failed if err is not nil.
+func (p *progress) end(formID string, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	fp := p.get(formID)
+
+	fp.status = shuffle.Done
+	fp.err = err
+
+	if err != nil {
+		fp.status = shuffle.Failed
+	}
+}
+
+// setTx records the state of a shuffle transaction. A transaction that has
+// already been included is not set back to pending.
+func (p *progress) setTx(formID string, tx shuffle.Tx) {
+	p.Lock()
+	defer p.Unlock()
+
+	fp := p.get(formID)
+	key := hex.EncodeToString(tx.ID)
+
+	i, found := fp.txIndex[key]
+	if !found {
+		fp.txIndex[key] = len(fp.txs)
+		fp.txs = append(fp.txs, tx)
+
+		return
+	}
+
+	if tx.Status == shuffle.TxPending {
+		return
+	}
+
+	fp.txs[i] = tx
+}
+
+// lastRejection returns the reason of the last rejected transaction of the
+// form, or an empty string.
+func (p *progress) lastRejection(formID string) string {
+	p.Lock()
+	defer p.Unlock()
+
+	fp := p.get(formID)
+
+	for i := len(fp.txs) - 1; i >= 0; i-- {
+		if fp.txs[i].Status == shuffle.TxRejected {
+			return fp.txs[i].Reason
+		}
+	}
+
+	return ""
+}
+
+// fill fills the status with the progress of the form. Round and Threshold
+// must already be set.
+func (p *progress) fill(formID string, status *shuffle.Status) {
+	p.Lock()
+	defer p.Unlock()
+
+	fp := p.get(formID)
+
+	status.Status = fp.status
+	status.Err = fp.err
+	status.StartedAt = fp.startedAt
+	status.Transactions = append([]shuffle.Tx{}, fp.txs...)
+
+	if status.Round >= status.Threshold && status.Status != shuffle.Failed {
+		status.Status = shuffle.Done
+	}
+
+	// the completion is estimated from the average duration of the rounds
+	// shuffled since the start.
+	done := status.Round - fp.startRound
+	if status.Status == shuffle.Shuffling && done > 0 {
+		elapsed := time.Since(fp.startedAt)
+		perRound := elapsed / time.Duration(done)
+		remaining := time.Duration(status.Threshold - status.Round)
+
+		status.EstimatedEnd = time.Now().Add(perRound * remaining)
+	}
+}
@@ -14,14 +14,22 @@ export const delaRouter = express.Router();
 
 initEnforcer().catch((e) => console.error(`Couldn't initialize enforcerer: ${e}`));
 
-// get payload creates a payload with a signature on it
-function getPayload(dataStr: string) {
+// get payload creates a payload with a signature on it. The signature also
+// covers the method and the path of the request, a timestamp and a nonce so
+// that the request cannot be replayed.
+function getPayload(dataStr: string, method: string, path: string) {
   let dataStrB64 = Buffer.from(dataStr).toString('base64url');
   while (dataStrB64.length % 4 !== 0) {
     dataStrB64 += '=';
   }
 
-  const hash: Buffer = crypto.createHash('sha256').update(dataStrB64).digest();
+  const timestamp = Math.floor(Date.now() / 1000);
+  const nonce = crypto.randomBytes(16).toString('hex');
+
+  const hash: Buffer = crypto
+    .createHash('sha256')
+    .update(`${dataStrB64}\n${method}\n${path}\n${timestamp}\n${nonce}`)
+    .digest();
 
   const edCurve = kyber.curve.newCurve('edwards25519');
 
@@ -38,6 +46,10 @@ function getPayload(dataStr: string) {
 
   return {
     Payload: dataStrB64,
+    Timestamp: timestamp,
+    Nonce: nonce,
+    Method: method,
+    Path: path,
     Signature: sign.toString('hex'),
   };
 }
@@ -45,10 +57,11 @@ function getPayload(dataStr: string) {
 // sendToDela signs the message and sends it to the dela proxy. It makes no
 // authentication check.
 function sendToDela(dataStr: string, req: express.Request, res: express.Response) {
-  let payload = getPayload(dataStr);
-
   // we strip the `/api` part: /api/form/xxx => /form/xxx
-  let uri = process.env.DELA_PROXY_URL + req.baseUrl.slice(4);
+  const path = req.baseUrl.slice(4);
+  let payload = getPayload(dataStr, req.method, path);
+
+  let uri = process.env.DELA_PROXY_URL + path;
   // boolean to check
   let redirectToDefaultProxy = true;
 
@@ -56,7 +69,7 @@ function sendToDela(dataStr: string, req: express.Request, res: express.Response
   const dkgInitRegex = /\/evoting\/services\/dkg\/actors$/;
   if (uri.match(dkgInitRegex)) {
     const dataStr2 = JSON.stringify({ FormID: req.body.FormID });
-    payload = getPayload(dataStr2);
+    payload = getPayload(dataStr2, req.method, path);
     redirectToDefaultProxy = false;
   }
 
@@ -64,7 +77,7 @@ function sendToDela(dataStr: string, req: express.Request, res: express.Response
   const dkgSetupRegex = /\/evoting\/services\/dkg\/actors\/.*$/;
   if (uri.match(dkgSetupRegex)) {
     const dataStr2 = JSON.stringify({ Action: req.body.Action });
-    payload = getPayload(dataStr2);
+    payload = getPayload(dataStr2, req.method, path);
 
     // If setup don't redirect to default proxy, if 'computePubshares' then keep
     // default proxy
@@ -81,7 +94,7 @@ function sendToDela(dataStr: string, req: express.Request, res: express.Response
       res.status(400).send('proxy undefined in body');
       return;
     }
-    uri = proxy + req.baseUrl.slice(4);
+    uri = proxy + path;
   }
 
   console.log('sending payload:', JSON.stringify(payload), 'to', uri);
@@ -131,28 +144,19 @@ delaRouter.delete('/forms/:formID', (req, res) => {
     res.status(401).send('Unauthenticated');
     return;
   }
-  const { formID } = req.params;
-
-  const edCurve = kyber.curve.newCurve('edwards25519');
-
-  const priv = Buffer.from(process.env.PRIVATE_KEY as string, 'hex');
-  const pub = Buffer.from(process.env.PUBLIC_KEY as string, 'hex');
-  const scalar = edCurve.scalar();
-  scalar.unmarshalBinary(priv);
-
-  const point = edCurve.point();
-  point.unmarshalBinary(pub);
-
-  const sign = kyber.sign.schnorr.sign(edCurve, scalar, Buffer.from(formID));
   // we only get the url as /forms/xxx , so we add the first part to get : /evoting/forms/xxx
-  const uri = process.env.DELA_PROXY_URL + xss(`/evoting${req.url}`);
+  const path = xss(`/evoting${req.url}`);
+  const uri = process.env.DELA_PROXY_URL + path;
+
+  const dataStr = JSON.stringify({ UserID: req.session.userId.toString() });
+  const payload = getPayload(dataStr, req.method, path);
 
   axios({
     method: req.method as Method,
     url: uri,
+    data: payload,
     headers: {
-      Authorization: sign.toString('hex'),
-      UserId: req.session.userId.toString(),
+      'Content-Type': 'application/json',
     },
   })
     .then((resp) => {